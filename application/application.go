@@ -0,0 +1,240 @@
+package application
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/application/change_feed"
+	"github.com/photoshelf/photoshelf-storage/application/event_bus"
+	"github.com/photoshelf/photoshelf-storage/application/health"
+	"github.com/photoshelf/photoshelf-storage/application/migration"
+	"github.com/photoshelf/photoshelf-storage/application/scrub"
+	"github.com/photoshelf/photoshelf-storage/application/service"
+	"github.com/photoshelf/photoshelf-storage/application/webhook"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/instrumented_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/traced_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/logging"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/tracing"
+	"github.com/photoshelf/photoshelf-storage/presentation/controller"
+	"github.com/photoshelf/photoshelf-storage/presentation/router"
+	"sync"
+)
+
+// Application owns the storage, services and background tasks built from a configuration.
+// Applications don't share any state, so several of them can run in one process.
+type Application struct {
+	Configuration   *Configuration
+	Repository      photo.Repository
+	AlbumRepository album.Repository
+	PhotoService    service.PhotoService
+	AlbumService    service.AlbumService
+	Health          *health.Checker
+	Metrics         *metrics.Metrics
+
+	components router.Components
+	close      func() error
+}
+
+// Configure loads the configuration from args, sets up the process wide logging and tracing with it,
+// then builds the application.
+func Configure(args ...string) (*Application, error) {
+	configuration, err := load(args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := logging.Setup(configuration.Log); err != nil {
+		return nil, err
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), configuration.Tracing)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := newApplication(configuration)
+	if err != nil {
+		shutdownTracing(context.Background())
+		return nil, err
+	}
+	closeApplication := app.close
+	app.close = func() error {
+		err := closeApplication()
+		if e := shutdownTracing(context.Background()); err == nil {
+			err = e
+		}
+		return err
+	}
+	return app, nil
+}
+
+// New validates the configuration, opens its storage and starts the background tasks.
+func New(configuration *Configuration) (*Application, error) {
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+	return newApplication(configuration)
+}
+
+func newApplication(configuration *Configuration) (*Application, error) {
+	storage, err := openStorage(configuration.Storage.StorageConfiguration)
+	if err != nil {
+		return nil, err
+	}
+	rawRepository, albumRepository := storage.photos, storage.albums
+	encrypted, err := newEncryptedStorage(configuration, rawRepository)
+	if err != nil {
+		closeBackends(storage)
+		return nil, err
+	}
+	repository := rawRepository
+	var checks []scrub.Check
+	if encrypted != nil {
+		repository = traced_storage.New("encrypted", encrypted)
+		checks = append(checks, encrypted.Check)
+	}
+	m := metrics.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	var migrating sync.WaitGroup
+	// stores are the backends whose stores are closed along with the repository.
+	stores := []*backend{storage}
+	closeStores := func() error {
+		var err error
+		for _, b := range stores {
+			if e := b.closeStores(); err == nil {
+				err = e
+			}
+		}
+		return err
+	}
+	fail := func(err error) (*Application, error) {
+		cancel()
+		migrating.Wait()
+		repository.Close()
+		closeStores()
+		return nil, err
+	}
+	// events is the backend holding the outbox and change log, and scrubbed the repository the scrubber verifies.
+	events, scrubbed := storage, rawRepository
+	serving := func() string { return configuration.Storage.Type }
+	if configuration.Storage.Migrate.Type != "" {
+		migrated, migratedAlbums, target, err := startMigration(ctx, &migrating, configuration, repository, albumRepository)
+		if err != nil {
+			return fail(err)
+		}
+		repository, albumRepository = migrated, migratedAlbums
+		stores = append(stores, target)
+		serving = func() string {
+			if migrated.IsCutOver() {
+				return configuration.Storage.Migrate.Type
+			}
+			return configuration.Storage.Type
+		}
+
+		// Events are kept by the target for the whole migration, as it is written along with the source
+		// before the cut-over and alone after it, and the scrubber verifies whichever serves reads.
+		if _, err := migration.MoveEvents(ctx, storage.outbox, target.outbox); err != nil {
+			return fail(err)
+		}
+		events, scrubbed = target, migrated.Under(rawRepository, target.photos)
+	}
+
+	instrumented := instrumented_storage.NewWithBackend(serving, repository, m)
+	if err := m.Register(append(storage.collectors, instrumented)...); err != nil {
+		return fail(err)
+	}
+	repository = instrumented
+
+	bus := event_bus.New(events.outbox)
+
+	feed := change_feed.New(events.changes)
+	feed.Subscribe(bus)
+
+	albumService := service.NewAlbumService(albumRepository, repository)
+	service.NewAlbumCleaner(albumService).Subscribe(bus)
+
+	notifier := webhook.New(m)
+	for _, subscription := range configuration.Webhooks {
+		if err := notifier.Subscribe(bus, subscription); err != nil {
+			return fail(err)
+		}
+	}
+
+	scrubber := &scrub.Scrubber{
+		Repository: scrubbed,
+		Checks:     checks,
+		Interval:   configuration.Scrub.Interval,
+		Rate:       configuration.Scrub.Rate,
+		Metrics:    m,
+	}
+	if configuration.Scrub.Interval > 0 {
+		if err := scrubber.Start(ctx); err != nil {
+			return fail(err)
+		}
+	}
+	if configuration.Metrics.StatsInterval > 0 {
+		instrumented.Start(ctx, configuration.Metrics.StatsInterval)
+	}
+
+	bus.Start(ctx)
+
+	photoService := service.New(repository, bus)
+	checker := health.New(repository)
+	app := &Application{
+		Configuration:   configuration,
+		Repository:      repository,
+		AlbumRepository: albumRepository,
+		PhotoService:    photoService,
+		AlbumService:    albumService,
+		Health:          checker,
+		Metrics:         m,
+		components: router.Components{
+			PhotoController:    controller.NewRestPhotoController(photoService, feed),
+			AlbumController:    controller.NewRestAlbumController(albumService),
+			AdminController:    controller.NewRestAdminController(scrubber, notifier),
+			HealthController:   controller.NewRestHealthController(checker),
+			PhotoServiceServer: controller.NewGrpcPhotoController(photoService, feed),
+			AlbumServiceServer: controller.NewGrpcAlbumController(albumService),
+			HealthServer:       controller.NewGrpcHealthController(checker),
+			Health:             checker,
+			Metrics:            m,
+		},
+	}
+	app.close = func() error {
+		cancel()
+		bus.Wait()
+		scrubber.Wait()
+		instrumented.Wait()
+		migrating.Wait()
+		err := repository.Close()
+		if e := closeStores(); err == nil {
+			err = e
+		}
+		return err
+	}
+	return app, nil
+}
+
+// NewServer listens on the ports of the server configuration, serving the application.
+func (app *Application) NewServer() (*router.Server, error) {
+	configuration := app.Configuration.Server
+	server, err := router.NewServer(app.components, configuration.Mode, configuration.Port, configuration.GrpcPort)
+	if err != nil {
+		return nil, err
+	}
+	server.ShutdownTimeout = configuration.ShutdownTimeout
+	return server, nil
+}
+
+// Components returns the controllers serving the application, to route them on servers of its own.
+func (app *Application) Components() router.Components {
+	return app.components
+}
+
+// Close stops the background tasks and closes the storage.
+func (app *Application) Close() error {
+	return app.close()
+}
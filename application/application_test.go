@@ -0,0 +1,60 @@
+package application
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("with two configurations, builds independent applications", func(t *testing.T) {
+		first, second := newMemoryApplication(t), newMemoryApplication(t)
+
+		id, err := first.PhotoService.Save(context.Background(), *photo.New([]byte("test")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = second.PhotoService.Find(context.Background(), *id)
+		assert.Error(t, err)
+		assert.NotSame(t, first.Metrics, second.Metrics)
+	})
+
+	t.Run("with invalid configuration, returns error", func(t *testing.T) {
+		_, err := New(&Configuration{})
+		assert.IsType(t, &ValidationError{}, err)
+	})
+}
+
+func TestApplication_NewServer(t *testing.T) {
+	app := newMemoryApplication(t)
+	server, err := app.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- server.Serve() }()
+
+	res, err := http.Get("http://" + server.Addrs()[0].String() + "/photos/unknown")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	}
+
+	server.Shutdown(context.Background())
+	assert.NoError(t, <-done)
+}
+
+func newMemoryApplication(t *testing.T) *Application {
+	t.Helper()
+	configuration, err := load("-t", "memory", "-p", "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := New(configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { app.Close() })
+	return app
+}
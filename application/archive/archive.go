@@ -0,0 +1,469 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	Version = 1
+
+	manifestName = "manifest.json"
+	photosDir    = "photos/"
+	albumsDir    = "albums/"
+	metaSuffix   = ".json"
+)
+
+var (
+	ErrUnknownCompression = errors.New("unknown compression")
+	ErrChecksumMismatch   = errors.New("checksum mismatch")
+	ErrUnexpectedEntry    = errors.New("unexpected archive entry")
+	ErrNoManifest         = errors.New("archive has no manifest")
+	ErrManifestMismatch   = errors.New("archive entries does not match manifest")
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+type Manifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Photos  []Entry   `json:"photos"`
+	Albums  []string  `json:"albums"`
+}
+
+type Entry struct {
+	Id     string   `json:"id"`
+	Tags   []string `json:"tags,omitempty"`
+	Size   int64    `json:"size"`
+	Sha256 string   `json:"sha256"`
+}
+
+type Result struct {
+	Imported  int
+	Unchanged int
+	Skipped   int
+	Albums    int
+}
+
+type albumRecord struct {
+	Id          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Cover       string   `json:"cover,omitempty"`
+	Photos      []string `json:"photos"`
+}
+
+func Export(ctx context.Context, w io.Writer, photos photo.Repository, albums album.Repository, compression string) error {
+	cw, err := compressor(w, compression)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(cw)
+
+	manifest := Manifest{Version: Version, Created: time.Now().UTC(), Photos: []Entry{}, Albums: []string{}}
+
+	ids, err := photos.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		photograph, err := photo.Peek(ctx, photos, id)
+		if isNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		entry := Entry{
+			Id:     id.Value(),
+			Tags:   photograph.Tags(),
+			Size:   int64(len(photograph.Image())),
+			Sha256: photo.Checksum(photograph.Image()),
+		}
+		if err := writeJSON(tw, photosDir+id.Value()+metaSuffix, entry); err != nil {
+			return err
+		}
+		if err := writeEntry(tw, photosDir+id.Value(), photograph.Image()); err != nil {
+			return err
+		}
+		manifest.Photos = append(manifest.Photos, entry)
+	}
+
+	all, err := albums.ReadAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range all {
+		record := albumRecord{Id: a.Id().Value(), Title: a.Title(), Description: a.Description(), Photos: []string{}}
+		if a.Cover() != nil {
+			record.Cover = a.Cover().Value()
+		}
+		for _, id := range a.Photos() {
+			record.Photos = append(record.Photos, id.Value())
+		}
+		if err := writeJSON(tw, albumsDir+record.Id+metaSuffix, record); err != nil {
+			return err
+		}
+		manifest.Albums = append(manifest.Albums, record.Id)
+	}
+
+	if err := writeJSON(tw, manifestName, manifest); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+// Import restores an archive written by Export. The whole archive is checked before anything is written,
+// so a damaged or crafted archive imports nothing. Archives which can't seek are spooled to a temporary file.
+func Import(ctx context.Context, r io.Reader, photos photo.Repository, albums album.Repository, overwrite bool) (*Result, error) {
+	rs, cleanup, err := rewindable(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verify(ctx, rs); err != nil {
+		return nil, err
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	err = walk(ctx, rs, visitor{
+		photo: func(entry Entry, r io.Reader) error {
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			return importPhoto(ctx, photos, entry, data, overwrite, result)
+		},
+		album: func(record albumRecord) error {
+			return importAlbum(ctx, albums, record, overwrite, result)
+		},
+	})
+	return result, err
+}
+
+// verify checks the entries, identifiers and checksums of an archive against its manifest without writing anything.
+func verify(ctx context.Context, r io.Reader) error {
+	var manifest *Manifest
+	found := make(map[string]Entry)
+	err := walk(ctx, r, visitor{
+		manifest: func(m Manifest) error {
+			manifest = &m
+			return nil
+		},
+		photo: func(entry Entry, r io.Reader) error {
+			hash := sha256.New()
+			size, err := io.Copy(hash, r)
+			if err != nil {
+				return err
+			}
+			if size != entry.Size || hex.EncodeToString(hash.Sum(nil)) != entry.Sha256 {
+				return fmt.Errorf("%s: %s", photosDir+entry.Id, ErrChecksumMismatch)
+			}
+			found[entry.Id] = entry
+			return nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if manifest == nil {
+		return ErrNoManifest
+	}
+	if len(manifest.Photos) != len(found) {
+		return ErrManifestMismatch
+	}
+	for _, entry := range manifest.Photos {
+		if actual, ok := found[entry.Id]; !ok || actual.Sha256 != entry.Sha256 {
+			return ErrManifestMismatch
+		}
+	}
+	return nil
+}
+
+// visitor receives the entries of an archive from walk. A nil function skips its entries.
+type visitor struct {
+	manifest func(manifest Manifest) error
+	photo    func(entry Entry, data io.Reader) error
+	album    func(record albumRecord) error
+}
+
+func walk(ctx context.Context, r io.Reader, v visitor) error {
+	dr, err := decompressor(r)
+	if err != nil {
+		return err
+	}
+	defer dr.Close()
+	tr := tar.NewReader(dr)
+
+	var pending *Entry
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name := header.Name
+		switch {
+		case name == manifestName:
+			manifest := Manifest{}
+			if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return err
+			}
+			if v.manifest != nil {
+				if err := v.manifest(manifest); err != nil {
+					return err
+				}
+			}
+
+		case strings.HasPrefix(name, photosDir) && strings.HasSuffix(name, metaSuffix):
+			pending = &Entry{}
+			if err := json.NewDecoder(tr).Decode(pending); err != nil {
+				return err
+			}
+			if err := photo.ValidateIdentifier(*photo.IdentifierOf(pending.Id)); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+
+		case strings.HasPrefix(name, photosDir):
+			if pending == nil || photosDir+pending.Id != name {
+				return fmt.Errorf("%s: %s", name, ErrUnexpectedEntry)
+			}
+			if v.photo != nil {
+				if err := v.photo(*pending, tr); err != nil {
+					return err
+				}
+			}
+			pending = nil
+
+		case strings.HasPrefix(name, albumsDir):
+			record := albumRecord{}
+			if err := json.NewDecoder(tr).Decode(&record); err != nil {
+				return err
+			}
+			if err := album.ValidateIdentifier(*album.IdentifierOf(record.Id)); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+			if v.album != nil {
+				if err := v.album(record); err != nil {
+					return err
+				}
+			}
+
+		default:
+			return fmt.Errorf("%s: %s", name, ErrUnexpectedEntry)
+		}
+	}
+}
+
+func importPhoto(ctx context.Context, photos photo.Repository, entry Entry, data []byte, overwrite bool, result *Result) error {
+	id := photo.IdentifierOf(entry.Id)
+	exists, equal, err := compare(ctx, photos, *id, entry)
+	if err != nil {
+		return err
+	}
+	if equal {
+		result.Unchanged++
+		return nil
+	}
+	if exists && !overwrite {
+		result.Skipped++
+		return nil
+	}
+
+	if _, err := photos.Save(ctx, *photo.Of(*id, data, entry.Tags...)); err != nil {
+		return err
+	}
+	result.Imported++
+	return nil
+}
+
+// compare reports whether the photo exists and whether it equals the entry.
+// A recorded checksum which differs from the entry settles it without reading the image.
+func compare(ctx context.Context, photos photo.Repository, id photo.Identifier, entry Entry) (exists bool, equal bool, err error) {
+	if checksums, ok := photos.(photo.ChecksumRepository); ok {
+		checksum, err := checksums.ChecksumOf(ctx, id)
+		if err == nil && checksum != entry.Sha256 {
+			return true, false, nil
+		} else if err != nil && err != photo.ErrNoChecksums && !isNotFound(err) {
+			return false, false, err
+		}
+	}
+
+	existing, err := photo.Peek(ctx, photos, id)
+	if isNotFound(err) {
+		return false, false, nil
+	} else if err != nil {
+		return false, false, err
+	}
+	equal = photo.Checksum(existing.Image()) == entry.Sha256 && reflect.DeepEqual(existing.Tags(), photo.Of(id, nil, entry.Tags...).Tags())
+	return true, equal, nil
+}
+
+func importAlbum(ctx context.Context, albums album.Repository, record albumRecord, overwrite bool, result *Result) error {
+	id := album.IdentifierOf(record.Id)
+	if _, err := albums.Read(ctx, *id); err == nil && !overwrite {
+		return nil
+	} else if err != nil {
+		if e, ok := err.(*album.ResourceError); !ok || e.Err != album.ErrNotFound {
+			return err
+		}
+	}
+
+	var cover *photo.Identifier
+	if record.Cover != "" {
+		cover = photo.IdentifierOf(record.Cover)
+	}
+	var ids []photo.Identifier
+	for _, value := range record.Photos {
+		ids = append(ids, *photo.IdentifierOf(value))
+	}
+	if _, err := albums.Save(ctx, *album.Of(*id, record.Title, record.Description, cover, ids)); err != nil {
+		return err
+	}
+	result.Albums++
+	return nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func compressor(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case "", "none":
+		return nopWriteCloser{w}, nil
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "zstd":
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("%s: %s", compression, ErrUnknownCompression)
+	}
+}
+
+func decompressor(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		decoder, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return ioutil.NopCloser(br), nil
+	}
+}
+
+// rewindable returns r when it can seek, otherwise a temporary copy of it which is removed by cleanup.
+func rewindable(ctx context.Context, r io.Reader) (rs io.ReadSeeker, cleanup func(), err error) {
+	if rs, ok := r.(io.ReadSeeker); ok {
+		if _, err := rs.Seek(0, io.SeekCurrent); err == nil {
+			return rs, func() {}, nil
+		}
+	}
+
+	file, err := ioutil.TempFile("", "photoshelf-import")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+	if _, err := io.Copy(file, contextReader{ctx, r}); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return file, cleanup, nil
+}
+
+// contextReader stops reading once the context is done, so spooling a slow input can be interrupted.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+func writeJSON(tw *tar.Writer, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeEntry(tw, name, data)
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func isNotFound(err error) bool {
+	if e, ok := err.(*photo.ResourceError); ok {
+		return e.Err == photo.ErrNotFound
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	for _, compression := range []string{"none", "gzip", "zstd"} {
+		t.Run(compression+", can restore same photos and albums", func(t *testing.T) {
+			photos, albums := createStorage(t)
+			fillStorage(t, photos, albums)
+
+			buf := &bytes.Buffer{}
+			if err := Export(context.Background(), buf, photos, albums, compression); err != nil {
+				t.Fatal(err)
+			}
+
+			restoredPhotos, restoredAlbums := createStorage(t)
+			result, err := Import(context.Background(), buf, restoredPhotos, restoredAlbums, false)
+			if assert.NoError(t, err) {
+				assert.Equal(t, &Result{Imported: 2, Albums: 1}, result)
+
+				actual, err := restoredPhotos.Read(context.Background(), *photo.IdentifierOf("a"))
+				if assert.NoError(t, err) {
+					assert.Equal(t, []byte("image a"), actual.Image())
+					assert.Equal(t, []string{"cat", "pet"}, actual.Tags())
+				}
+
+				restored, err := restoredAlbums.Read(context.Background(), *album.IdentifierOf("holiday"))
+				if assert.NoError(t, err) {
+					assert.Equal(t, "title", restored.Title())
+					assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("b"), *photo.IdentifierOf("a")}, restored.Photos())
+				}
+			}
+		})
+	}
+
+	t.Run("with unknown compression, returns error", func(t *testing.T) {
+		photos, albums := createStorage(t)
+		err := Export(context.Background(), &bytes.Buffer{}, photos, albums, "lzma")
+		assert.Error(t, err)
+	})
+}
+
+func TestImport(t *testing.T) {
+	t.Run("import twice, second reports unchanged", func(t *testing.T) {
+		data := exportFixture(t)
+		photos, albums := createStorage(t)
+		if _, err := Import(context.Background(), bytes.NewReader(data), photos, albums, false); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Import(context.Background(), bytes.NewReader(data), photos, albums, false)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, result.Imported)
+			assert.Equal(t, 2, result.Unchanged)
+		}
+	})
+
+	t.Run("with existing different photo and skip, keeps existing", func(t *testing.T) {
+		data := exportFixture(t)
+		photos, albums := createStorage(t)
+		if _, err := photos.Save(context.Background(), *photo.Of(*photo.IdentifierOf("a"), []byte("local"))); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Import(context.Background(), bytes.NewReader(data), photos, albums, false)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, result.Skipped)
+			actual, _ := photos.Read(context.Background(), *photo.IdentifierOf("a"))
+			assert.Equal(t, []byte("local"), actual.Image())
+		}
+	})
+
+	t.Run("with existing different photo and overwrite, replaces existing", func(t *testing.T) {
+		data := exportFixture(t)
+		photos, albums := createStorage(t)
+		if _, err := photos.Save(context.Background(), *photo.Of(*photo.IdentifierOf("a"), []byte("local"))); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Import(context.Background(), bytes.NewReader(data), photos, albums, true)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, result.Imported)
+			actual, _ := photos.Read(context.Background(), *photo.IdentifierOf("a"))
+			assert.Equal(t, []byte("image a"), actual.Image())
+		}
+	})
+
+	t.Run("with broken checksum, imports nothing", func(t *testing.T) {
+		data := exportFixture(t)
+		broken := bytes.Replace(data, []byte("image b"), []byte("image x"), 1)
+		photos, albums := createStorage(t)
+
+		_, err := Import(context.Background(), bytes.NewReader(broken), photos, albums, false)
+		if assert.Error(t, err) {
+			assertEmpty(t, photos, albums)
+		}
+	})
+
+	t.Run("with truncated archive, returns error and imports nothing", func(t *testing.T) {
+		data := exportFixture(t)
+		photos, albums := createStorage(t)
+
+		truncated := &bytes.Buffer{}
+		tr := tar.NewReader(bytes.NewReader(data))
+		tw := tar.NewWriter(truncated)
+		for i := 0; i < 2; i++ {
+			header, err := tr.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := ioutil.ReadAll(tr)
+			tw.WriteHeader(header)
+			tw.Write(content)
+		}
+		tw.Close()
+
+		_, err := Import(context.Background(), truncated, photos, albums, false)
+		assert.Equal(t, ErrNoManifest, err)
+		assertEmpty(t, photos, albums)
+
+		result, err := Import(context.Background(), bytes.NewReader(data), photos, albums, false)
+		if assert.NoError(t, err) {
+			assert.Equal(t, &Result{Imported: 2, Albums: 1}, result)
+		}
+	})
+
+	for _, id := range []string{"../../x", ".."} {
+		t.Run("with photo id "+id+", imports nothing", func(t *testing.T) {
+			entries := []Entry{checksummed("a", "image a"), checksummed(id, "image x")}
+			data := craftArchive(t, entries, nil)
+			photos, albums := createStorage(t)
+
+			_, err := Import(context.Background(), bytes.NewReader(data), photos, albums, false)
+			assert.Error(t, err)
+			assertEmpty(t, photos, albums)
+		})
+
+		t.Run("with album id "+id+", imports nothing", func(t *testing.T) {
+			data := craftArchive(t, []Entry{checksummed("a", "image a")}, []string{id})
+			photos, albums := createStorage(t)
+
+			_, err := Import(context.Background(), bytes.NewReader(data), photos, albums, false)
+			assert.Error(t, err)
+			assertEmpty(t, photos, albums)
+		})
+	}
+
+	t.Run("with recorded checksum, does not read differing photos", func(t *testing.T) {
+		data := exportFixture(t)
+		storage, albums := createStorage(t)
+		photos := &countingRepository{FileStorage: storage.(*file_storage.FileStorage)}
+		if _, err := photos.Save(context.Background(), *photo.Of(*photo.IdentifierOf("a"), []byte("local"))); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Import(context.Background(), bytes.NewReader(data), photos, albums, false)
+		if assert.NoError(t, err) {
+			assert.Equal(t, &Result{Imported: 1, Skipped: 1, Albums: 1}, result)
+			assert.Equal(t, []string{"b"}, photos.reads)
+		}
+	})
+}
+
+type countingRepository struct {
+	*file_storage.FileStorage
+	reads []string
+}
+
+func (repository *countingRepository) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	repository.reads = append(repository.reads, id.Value())
+	return repository.FileStorage.Read(ctx, id)
+}
+
+func checksummed(id string, image string) Entry {
+	return Entry{Id: id, Size: int64(len(image)), Sha256: photo.Checksum([]byte(image))}
+}
+
+// craftArchive writes an archive by hand, with the image of each entry taken from "image "+id.
+func craftArchive(tb testing.TB, entries []Entry, albums []string) []byte {
+	tb.Helper()
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	manifest := Manifest{Version: Version, Photos: entries, Albums: albums}
+	for _, entry := range entries {
+		if err := writeJSON(tw, photosDir+entry.Id+metaSuffix, entry); err != nil {
+			tb.Fatal(err)
+		}
+		if err := writeEntry(tw, photosDir+entry.Id, []byte("image "+entry.Id)); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	for _, id := range albums {
+		if err := writeJSON(tw, albumsDir+id+metaSuffix, albumRecord{Id: id, Title: "title", Photos: []string{}}); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	if err := writeJSON(tw, manifestName, manifest); err != nil {
+		tb.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func assertEmpty(t *testing.T, photos photo.Repository, albums album.Repository) {
+	t.Helper()
+	ids, err := photos.FindAll(context.Background())
+	if assert.NoError(t, err) {
+		assert.Empty(t, ids)
+	}
+	all, err := albums.ReadAll(context.Background())
+	if assert.NoError(t, err) {
+		assert.Empty(t, all)
+	}
+}
+
+func exportFixture(tb testing.TB) []byte {
+	tb.Helper()
+	photos, albums := createStorage(tb)
+	fillStorage(tb, photos, albums)
+
+	buf := &bytes.Buffer{}
+	if err := Export(context.Background(), buf, photos, albums, "none"); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func fillStorage(tb testing.TB, photos photo.Repository, albums album.Repository) {
+	tb.Helper()
+	if _, err := photos.Save(context.Background(), *photo.Of(*photo.IdentifierOf("a"), []byte("image a"), "pet", "cat")); err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := photos.Save(context.Background(), *photo.Of(*photo.IdentifierOf("b"), []byte("image b"))); err != nil {
+		tb.Fatal(err)
+	}
+	ids := []photo.Identifier{*photo.IdentifierOf("b"), *photo.IdentifierOf("a")}
+	if _, err := albums.Save(context.Background(), *album.Of(*album.IdentifierOf("holiday"), "title", "description", nil, ids)); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func createStorage(tb testing.TB) (photo.Repository, album.Repository) {
+	tb.Helper()
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+	storage := file_storage.New(dir)
+	return storage, file_storage.NewAlbumStorage(storage)
+}
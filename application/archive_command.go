@@ -0,0 +1,104 @@
+package application
+
+import (
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/application/archive"
+	"io"
+	"os"
+)
+
+func Export(args ...string) error {
+	configuration := &Configuration{}
+
+	flg := newFlagSet("export", configuration)
+	output := flg.String("o", "-", "output archive path, - for stdout")
+	compression := flg.String("z", "none", "compression [none|gzip|zstd]")
+	encryptionFlags(flg, configuration)
+	if err := parse(flg, configuration, args); err != nil {
+		return err
+	}
+	if err := configuration.validateStorage(); err != nil {
+		return err
+	}
+
+	storage, err := openStorage(configuration.Storage.StorageConfiguration)
+	if err != nil {
+		return err
+	}
+	repository, err := encryptStorage(configuration, storage.photos)
+	if err != nil {
+		storage.photos.Close()
+		return err
+	}
+	defer repository.Close()
+	albumRepository := storage.albums
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+	return archive.Export(ctx, w, repository, albumRepository, *compression)
+}
+
+func Import(args ...string) error {
+	configuration := &Configuration{}
+
+	flg := newFlagSet("import", configuration)
+	input := flg.String("i", "-", "input archive path, - for stdin")
+	onConflict := flg.String("on-conflict", "skip", "action for existing ids [skip|overwrite]")
+	encryptionFlags(flg, configuration)
+	if err := parse(flg, configuration, args); err != nil {
+		return err
+	}
+	if err := configuration.validateStorage(); err != nil {
+		return err
+	}
+
+	var overwrite bool
+	switch *onConflict {
+	case "skip":
+	case "overwrite":
+		overwrite = true
+	default:
+		return fmt.Errorf("unknown conflict action : %s", *onConflict)
+	}
+
+	storage, err := openStorage(configuration.Storage.StorageConfiguration)
+	if err != nil {
+		return err
+	}
+	repository, err := encryptStorage(configuration, storage.photos)
+	if err != nil {
+		storage.photos.Close()
+		return err
+	}
+	defer repository.Close()
+	albumRepository := storage.albums
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+	result, err := archive.Import(ctx, r, repository, albumRepository, overwrite)
+	if result != nil {
+		fmt.Fprintf(os.Stderr, "imported: %d, unchanged: %d, skipped: %d, albums: %d\n",
+			result.Imported, result.Unchanged, result.Skipped, result.Albums)
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	t.Run("with key file, exports plaintext and imports ciphertext", func(t *testing.T) {
+		source, target := createTempDir(t), createTempDir(t)
+		keyFile := path.Join(createTempDir(t), "keys")
+		if err := ioutil.WriteFile(keyFile, []byte("key MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		archive := path.Join(createTempDir(t), "photos.tar")
+
+		id := photo.IdentifierOf("secret")
+		storage := newEncryptedFileStorage(t, source, keyFile)
+		if _, err := storage.Save(context.Background(), *photo.Of(*id, []byte("plain image"))); err != nil {
+			t.Fatal(err)
+		}
+
+		if !assert.NoError(t, Export("-t", "file", "-s", source, "-key-file", keyFile, "-o", archive)) {
+			return
+		}
+		data, err := ioutil.ReadFile(archive)
+		if assert.NoError(t, err) {
+			assert.True(t, bytes.Contains(data, []byte("plain image")))
+		}
+
+		if !assert.NoError(t, Import("-t", "file", "-s", target, "-key-file", keyFile, "-i", archive)) {
+			return
+		}
+		stored, err := file_storage.New(target).Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.False(t, bytes.Contains(stored.Image(), []byte("plain image")))
+		}
+		imported, err := newEncryptedFileStorage(t, target, keyFile).Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("plain image"), imported.Image())
+		}
+	})
+}
+
+func newEncryptedFileStorage(t *testing.T, dir string, keyFile string) photo.Repository {
+	t.Helper()
+	configuration := &Configuration{}
+	configuration.Storage.Encryption.KeyFile = keyFile
+	storage, err := encryptStorage(configuration, file_storage.New(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return storage
+}
+
+func createTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "archive_command")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
@@ -0,0 +1,95 @@
+package change_feed
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/application/event_bus"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"sync"
+)
+
+const (
+	defaultRetention = 10000
+	trimInterval     = 100
+	batchSize        = 100
+)
+
+// Feed records published events in a change log and streams them to watchers.
+// Changes are identified by the outbox sequence, so a watcher can resume after the last sequence it received.
+type Feed struct {
+	Retention uint64
+
+	changes event.ChangeLog
+
+	mu     sync.Mutex
+	notify chan struct{}
+}
+
+func New(changes event.ChangeLog) *Feed {
+	return &Feed{
+		Retention: defaultRetention,
+		changes:   changes,
+		notify:    make(chan struct{}),
+	}
+}
+
+func (feed *Feed) Subscribe(bus *event_bus.Bus) {
+	bus.Subscribe("change feed", feed.handle)
+}
+
+func (feed *Feed) handle(ctx context.Context, record event.Record) error {
+	if err := feed.changes.Put(ctx, record); err != nil {
+		return err
+	}
+	if record.Sequence%trimInterval == 0 && record.Sequence > feed.Retention {
+		if err := feed.changes.Trim(ctx, record.Sequence-feed.Retention); err != nil {
+			return err
+		}
+	}
+
+	feed.mu.Lock()
+	close(feed.notify)
+	feed.notify = make(chan struct{})
+	feed.mu.Unlock()
+	return nil
+}
+
+// Watch sends changes after the given sequence until ctx is done or send fails.
+// With a nil after, only changes recorded from now on are sent.
+func (feed *Feed) Watch(ctx context.Context, after *uint64, send func(event.Record) error) error {
+	var cursor uint64
+	if after != nil {
+		cursor = *after
+	} else {
+		latest, err := feed.changes.Latest(ctx)
+		if err != nil {
+			return err
+		}
+		cursor = latest
+	}
+
+	for {
+		feed.mu.Lock()
+		notify := feed.notify
+		feed.mu.Unlock()
+
+		records, err := feed.changes.Since(ctx, cursor, batchSize)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := send(record); err != nil {
+				return err
+			}
+			cursor = record.Sequence
+		}
+		if len(records) == batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+	}
+}
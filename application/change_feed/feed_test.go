@@ -0,0 +1,110 @@
+package change_feed
+
+import (
+	"context"
+	"errors"
+	"github.com/photoshelf/photoshelf-storage/application/event_bus"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var errStop = errors.New("stop")
+
+func TestFeed_Watch(t *testing.T) {
+	t.Run("with cursor, sends recorded changes after it", func(t *testing.T) {
+		feed, bus := createFeed(t)
+		publish(t, bus, "a", "b", "c")
+
+		after := uint64(1)
+		var received []uint64
+		err := feed.Watch(context.Background(), &after, func(record event.Record) error {
+			received = append(received, record.Sequence)
+			if len(received) == 2 {
+				return errStop
+			}
+			return nil
+		})
+		assert.Equal(t, errStop, err)
+		assert.Equal(t, []uint64{2, 3}, received)
+	})
+
+	t.Run("without cursor, sends only new changes", func(t *testing.T) {
+		feed, bus := createFeed(t)
+		publish(t, bus, "a")
+
+		received := make(chan event.Record)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			done <- feed.Watch(ctx, nil, func(record event.Record) error {
+				received <- record
+				return nil
+			})
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		publish(t, bus, "b")
+		select {
+		case record := <-received:
+			assert.Equal(t, uint64(2), record.Sequence)
+			id := record.Event.PhotoId()
+			assert.Equal(t, "b", id.Value())
+		case <-time.After(time.Second):
+			t.Fatal("change was not sent")
+		}
+
+		cancel()
+		assert.Equal(t, context.Canceled, <-done)
+	})
+}
+
+func TestFeed_Subscribe(t *testing.T) {
+	t.Run("trims changes beyond retention", func(t *testing.T) {
+		changes := memory_storage.NewChangeLog()
+		feed := New(changes)
+		feed.Retention = 50
+		bus := event_bus.New(memory_storage.NewOutbox())
+		feed.Subscribe(bus)
+
+		for i := 0; i < 100; i++ {
+			if err := bus.Publish(context.Background(), event.PhotoDeleted{Id: *photo.IdentifierOf("a")}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := bus.Dispatch(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		records, err := changes.Since(context.Background(), 0, 1000)
+		if assert.NoError(t, err) {
+			assert.Len(t, records, 51)
+			assert.Equal(t, uint64(50), records[0].Sequence)
+		}
+	})
+}
+
+func createFeed(tb testing.TB) (*Feed, *event_bus.Bus) {
+	tb.Helper()
+	feed := New(memory_storage.NewChangeLog())
+	bus := event_bus.New(memory_storage.NewOutbox())
+	feed.Subscribe(bus)
+	return feed, bus
+}
+
+func publish(tb testing.TB, bus *event_bus.Bus, ids ...string) {
+	tb.Helper()
+	for _, id := range ids {
+		e := event.PhotoCreated{Id: *photo.IdentifierOf(id), OccurredAt: time.Now()}
+		if err := bus.Publish(context.Background(), e); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	if err := bus.Dispatch(context.Background()); err != nil {
+		tb.Fatal(err)
+	}
+}
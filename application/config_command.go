@@ -0,0 +1,42 @@
+package application
+
+import (
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/application/webhook"
+	"gopkg.in/yaml.v2"
+	"os"
+)
+
+const redacted = "REDACTED"
+
+// Config prints the effective configuration, loaded like the server does, with secrets redacted.
+func Config(args ...string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("unknown config command, usage : config print [flags]")
+	}
+
+	configuration, err := load(args[1:]...)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(configuration.redacted())
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stdout.Write(data); err != nil {
+		return err
+	}
+	return configuration.Validate()
+}
+
+func (configuration *Configuration) redacted() *Configuration {
+	copied := *configuration
+	copied.Webhooks = make([]webhook.Subscription, len(configuration.Webhooks))
+	for i, subscription := range configuration.Webhooks {
+		if subscription.Secret != "" {
+			subscription.Secret = redacted
+		}
+		copied.Webhooks[i] = subscription
+	}
+	return &copied
+}
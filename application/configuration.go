@@ -1,34 +1,81 @@
 package application
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"github.com/facebookgo/inject"
-	"github.com/photoshelf/photoshelf-storage/application/service"
+	"github.com/photoshelf/photoshelf-storage/application/webhook"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
-	"github.com/photoshelf/photoshelf-storage/infrastructure/container"
 	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/boltdb_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/encrypted_storage"
 	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
 	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/leveldb_storage"
-	"github.com/photoshelf/photoshelf-storage/presentation/controller"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/replicated_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/tiered_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/traced_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/logging"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/tracing"
+	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/yaml.v2"
+	"io"
 	"io/ioutil"
 	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
 )
 
+type StorageConfiguration struct {
+	Type        string
+	Path        string
+	Quorum      int
+	Replicas    []StorageConfiguration `yaml:",omitempty"`
+	Hot         *StorageConfiguration  `yaml:",omitempty"`
+	Cold        *StorageConfiguration  `yaml:",omitempty"`
+	MaxBytes    int64                  `yaml:"max_bytes"`
+	MaxItems    int                    `yaml:"max_items"`
+	WriteBehind bool                   `yaml:"write_behind"`
+}
+
 type Configuration struct {
 	Server struct {
-		Port int
-		Mode string
+		Port            int
+		GrpcPort        int `yaml:"grpc_port"`
+		Mode            string
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
 	}
 	Storage struct {
-		Type string
-		Path string
+		StorageConfiguration `yaml:",inline"`
+		Migrate              struct {
+			StorageConfiguration `yaml:",inline"`
+			Workers              int
+			Checkpoint           string
+		}
+		Encryption struct {
+			KeyFile        string `yaml:"key_file"`
+			AllowPlaintext bool   `yaml:"allow_plaintext"`
+		}
+	}
+	Scrub struct {
+		Interval time.Duration
+		Rate     int
 	}
+	Metrics struct {
+		StatsInterval time.Duration `yaml:"stats_interval"`
+	}
+	Webhooks []webhook.Subscription
+	Tracing  tracing.Options
+	Log      logging.Options
+
+	file string
 }
 
 func (configuration *Configuration) String() string {
-	if (Configuration{}) == *configuration {
+	if reflect.DeepEqual(Configuration{}, *configuration) {
 		return ""
 	}
 	return fmt.Sprint(*configuration)
@@ -42,22 +89,14 @@ func (configuration *Configuration) Set(path string) error {
 	return yaml.Unmarshal(configurationFile, configuration)
 }
 
-func load(args ...string) *Configuration {
-	configuration := &Configuration{}
-
-	flg := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-	flg.Var(configuration, "c", "configuration file path")
-	flg.IntVar(
-		&configuration.Server.Port,
-		"p",
-		1323,
-		"port number",
-	)
+func newFlagSet(name string, configuration *Configuration) *flag.FlagSet {
+	flg := flag.NewFlagSet(name, flag.ExitOnError)
+	flg.StringVar(&configuration.file, "c", "", "configuration file path, PHOTOSHELF_CONFIG when empty")
 	flg.StringVar(
 		&configuration.Storage.Type,
 		"t",
 		"boltdb",
-		"storage type [file|leveldb|boltdb]",
+		"storage type [file|leveldb|boltdb|memory|replicated|tiered]",
 	)
 	flg.StringVar(
 		&configuration.Storage.Path,
@@ -65,50 +104,383 @@ func load(args ...string) *Configuration {
 		"./photos",
 		"storage path",
 	)
+	return flg
+}
+
+// parse fills the configuration from, in increasing precedence, the flag defaults, the configuration file,
+// PHOTOSHELF_* environment variables and the flags.
+func parse(flg *flag.FlagSet, configuration *Configuration, args []string) error {
+	if err := flg.Parse(args); err != nil {
+		return err
+	}
+	set := make(map[string]string)
+	flg.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+
+	if configuration.file == "" {
+		configuration.file = os.Getenv(environmentPrefix + "_CONFIG")
+	}
+	if configuration.file != "" {
+		if err := configuration.Set(configuration.file); err != nil {
+			return err
+		}
+	}
+	if err := applyEnvironment(configuration, os.LookupEnv); err != nil {
+		return err
+	}
+	for name, value := range set {
+		if err := flg.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+func load(args ...string) (*Configuration, error) {
+	configuration := &Configuration{}
+	if err := parse(newServerFlagSet(configuration), configuration, args); err != nil {
+		return nil, err
+	}
+	return configuration, nil
+}
+
+// DefaultConfiguration returns the configuration the server starts with when no flag, file or environment is given.
+func DefaultConfiguration() *Configuration {
+	configuration := &Configuration{}
+	newServerFlagSet(configuration).Parse(nil)
+	return configuration
+}
+
+func newServerFlagSet(configuration *Configuration) *flag.FlagSet {
+	flg := newFlagSet(os.Args[0], configuration)
+	flg.IntVar(
+		&configuration.Server.Port,
+		"p",
+		1323,
+		"port number",
+	)
 	flg.StringVar(
 		&configuration.Server.Mode,
 		"m",
 		"rest",
-		"server mode [rest|grpc]",
+		"server mode [rest|grpc|all]",
+	)
+	flg.IntVar(
+		&configuration.Server.GrpcPort,
+		"grpc-port",
+		0,
+		"gRPC port number in all mode, 0 to serve gRPC on the REST port",
+	)
+	flg.DurationVar(
+		&configuration.Server.ShutdownTimeout,
+		"shutdown-timeout",
+		30*time.Second,
+		"time to wait for in-flight requests on shutdown",
+	)
+	flg.Int64Var(
+		&configuration.Storage.MaxBytes,
+		"max-bytes",
+		0,
+		"memory storage capacity in bytes, 0 for unlimited",
+	)
+	flg.StringVar(
+		&configuration.Storage.Migrate.Type,
+		"migrate-t",
+		"",
+		"migrate to storage type [file|leveldb|boltdb]",
+	)
+	flg.StringVar(
+		&configuration.Storage.Migrate.Path,
+		"migrate-s",
+		"",
+		"migrate to storage path",
+	)
+	flg.IntVar(
+		&configuration.Storage.Migrate.Workers,
+		"migrate-workers",
+		4,
+		"number of parallel migration workers",
+	)
+	flg.StringVar(
+		&configuration.Storage.Migrate.Checkpoint,
+		"migrate-checkpoint",
+		"",
+		"migration checkpoint file path",
+	)
+	encryptionFlags(flg, configuration)
+	flg.DurationVar(
+		&configuration.Scrub.Interval,
+		"scrub-interval",
+		0,
+		"interval between integrity scrubs, 0 to disable",
+	)
+	flg.IntVar(
+		&configuration.Scrub.Rate,
+		"scrub-rate",
+		10,
+		"photos per second to scrub, 0 for unlimited",
+	)
+	flg.DurationVar(
+		&configuration.Metrics.StatsInterval,
+		"stats-interval",
+		time.Minute,
+		"interval between refreshes of the stored photos and bytes metrics, 0 to disable",
+	)
+	flg.StringVar(
+		&configuration.Tracing.Exporter,
+		"trace-exporter",
+		"",
+		"trace exporter [otlp|stdout|file], none when empty",
 	)
-	flg.Parse(args)
+	flg.StringVar(
+		&configuration.Tracing.Endpoint,
+		"trace-endpoint",
+		"",
+		"OTLP/HTTP collector host:port, OTEL_EXPORTER_OTLP_ENDPOINT when empty",
+	)
+	flg.StringVar(
+		&configuration.Tracing.File,
+		"trace-file",
+		"traces.json",
+		"file to append spans to with the file exporter",
+	)
+	flg.StringVar(
+		&configuration.Log.Level,
+		"log-level",
+		"info",
+		"log level [debug|info|warn|error]",
+	)
+	flg.StringVar(
+		&configuration.Log.Format,
+		"log-format",
+		"json",
+		"log format [json|text]",
+	)
+	return flg
+}
 
-	return configuration
+type backend struct {
+	photos     photo.Repository
+	albums     album.Repository
+	outbox     event.Outbox
+	changes    event.ChangeLog
+	versions   photo.VersionLog
+	collectors []prometheus.Collector
+
+	// parts are the backends a replicated or tiered storage is composed of.
+	parts []*backend
 }
 
-func Configure(args ...string) (*Configuration, error) {
-	configuration := load(args...)
+func openStorage(configuration StorageConfiguration) (*backend, error) {
+	opened, err := openBackend(configuration)
+	if err != nil {
+		return nil, err
+	}
+	opened.photos = traced_storage.New(configuration.Type, opened.photos)
+	return opened, nil
+}
 
-	var repository photo.Repository
-	var err error
-	switch configuration.Storage.Type {
+func openBackend(configuration StorageConfiguration) (*backend, error) {
+	path := configuration.Path
+	switch configuration.Type {
 	case "file":
-		repository = file_storage.New(configuration.Storage.Path)
+		storage := file_storage.New(path)
+		return &backend{
+			photos:   storage,
+			albums:   file_storage.NewAlbumStorage(storage),
+			outbox:   file_storage.NewOutbox(storage),
+			changes:  file_storage.NewChangeLog(storage),
+			versions: file_storage.NewVersionLog(storage),
+		}, nil
 	case "leveldb":
-		repository, err = leveldb_storage.New(configuration.Storage.Path)
+		storage, err := leveldb_storage.New(path)
 		if err != nil {
 			return nil, err
 		}
+		return &backend{
+			photos:     storage,
+			albums:     leveldb_storage.NewAlbumStorage(storage),
+			outbox:     leveldb_storage.NewOutbox(storage),
+			changes:    leveldb_storage.NewChangeLog(storage),
+			versions:   leveldb_storage.NewVersionLog(storage),
+			collectors: []prometheus.Collector{leveldb_storage.NewCollector(storage)},
+		}, nil
 	case "boltdb":
-		repository, err = boltdb_storage.New(configuration.Storage.Path)
+		storage, err := boltdb_storage.New(path)
 		if err != nil {
 			return nil, err
 		}
+		return &backend{
+			photos:     storage,
+			albums:     boltdb_storage.NewAlbumStorage(storage),
+			outbox:     boltdb_storage.NewOutbox(storage),
+			changes:    boltdb_storage.NewChangeLog(storage),
+			versions:   boltdb_storage.NewVersionLog(storage),
+			collectors: []prometheus.Collector{boltdb_storage.NewCollector(storage)},
+		}, nil
+	case "memory":
+		return &backend{
+			photos:   memory_storage.New(configuration.MaxBytes),
+			albums:   memory_storage.NewAlbumStorage(),
+			outbox:   memory_storage.NewOutbox(),
+			changes:  memory_storage.NewChangeLog(),
+			versions: memory_storage.NewVersionLog(),
+		}, nil
+	case "replicated":
+		return openReplicatedStorage(configuration)
+	case "tiered":
+		return openTieredStorage(configuration)
 	default:
-		return nil, fmt.Errorf("unknown storage type : %s", configuration.Storage.Type)
+		return nil, fmt.Errorf("unknown storage type : %s", configuration.Type)
+	}
+}
+
+func openReplicatedStorage(configuration StorageConfiguration) (*backend, error) {
+	var replicas []replicated_storage.Replica
+	var albumReplicas []album.Repository
+	var backends []*backend
+	var collectors []prometheus.Collector
+	for _, replica := range configuration.Replicas {
+		b, err := openStorage(replica)
+		if err != nil {
+			closeBackends(backends...)
+			return nil, err
+		}
+		if b.versions == nil {
+			closeBackends(append(backends, b)...)
+			return nil, fmt.Errorf("can't replicate to %s storage", replica.Type)
+		}
+		replicas = append(replicas, replicated_storage.Replica{Photos: b.photos, Versions: b.versions})
+		albumReplicas = append(albumReplicas, b.albums)
+		backends = append(backends, b)
+		collectors = append(collectors, b.collectors...)
+	}
+
+	quorum := configuration.Quorum
+	if quorum == 0 {
+		quorum = len(replicas)/2 + 1
+	}
+	storage, err := replicated_storage.New(quorum, replicas...)
+	if err != nil {
+		closeBackends(backends...)
+		return nil, err
+	}
+	albumStorage, err := replicated_storage.NewAlbumStorage(quorum, albumReplicas...)
+	if err != nil {
+		closeBackends(backends...)
+		return nil, err
+	}
+	// Events are kept by the first replica only, the outboxes and change logs of the others are left unused.
+	return &backend{
+		photos:     storage,
+		albums:     albumStorage,
+		outbox:     backends[0].outbox,
+		changes:    backends[0].changes,
+		collectors: collectors,
+		parts:      backends,
+	}, nil
+}
+
+func openTieredStorage(configuration StorageConfiguration) (*backend, error) {
+	if configuration.Hot == nil || configuration.Cold == nil {
+		return nil, fmt.Errorf("tiered storage needs hot and cold storage")
+	}
+	hot, err := openStorage(*configuration.Hot)
+	if err != nil {
+		return nil, err
+	}
+	cold, err := openStorage(*configuration.Cold)
+	if err != nil {
+		closeBackends(hot)
+		return nil, err
 	}
 
-	restPhotoController := controller.NewRestPhotoController()
-	if err := inject.Populate(restPhotoController, service.New(), repository); err != nil {
+	storage, err := tiered_storage.New(hot.photos, cold.photos, tiered_storage.Options{
+		MaxBytes:    configuration.MaxBytes,
+		MaxItems:    configuration.MaxItems,
+		WriteBehind: configuration.WriteBehind,
+	})
+	if err != nil {
+		closeBackends(hot, cold)
 		return nil, err
 	}
-	container.Set(restPhotoController)
+	return &backend{
+		photos:     storage,
+		albums:     cold.albums,
+		outbox:     cold.outbox,
+		changes:    cold.changes,
+		versions:   cold.versions,
+		collectors: append(hot.collectors, cold.collectors...),
+		parts:      []*backend{hot, cold},
+	}, nil
+}
+
+// closeBackends closes the backends opened before a storage composed of them failed to open.
+func closeBackends(backends ...*backend) {
+	for _, b := range backends {
+		b.photos.Close()
+		b.closeStores()
+	}
+}
+
+// closeStores closes the stores of the backend and of its parts which hold resources of their own.
+// The photos are closed apart, as closing the photos of a composed storage closes those of its parts.
+func (b *backend) closeStores() error {
+	var err error
+	for _, store := range []interface{}{b.albums, b.outbox, b.changes, b.versions} {
+		if closer, ok := store.(io.Closer); ok {
+			if e := closer.Close(); err == nil {
+				err = e
+			}
+		}
+	}
+	for _, part := range b.parts {
+		if e := part.closeStores(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func encryptionFlags(flg *flag.FlagSet, configuration *Configuration) {
+	flg.StringVar(
+		&configuration.Storage.Encryption.KeyFile,
+		"key-file",
+		"",
+		"encryption key file path",
+	)
+	flg.BoolVar(
+		&configuration.Storage.Encryption.AllowPlaintext,
+		"allow-plaintext",
+		false,
+		"read photos stored before encryption was enabled, until they are re-encrypted",
+	)
+}
 
-	grpcPhotoController := controller.NewGrpcPhotoController()
-	if err := inject.Populate(grpcPhotoController, service.New(), repository); err != nil {
+func encryptStorage(configuration *Configuration, repository photo.Repository) (photo.Repository, error) {
+	encrypted, err := newEncryptedStorage(configuration, repository)
+	if err != nil {
 		return nil, err
+	} else if encrypted == nil {
+		return repository, nil
 	}
-	container.Set(grpcPhotoController)
+	return traced_storage.New("encrypted", encrypted), nil
+}
 
-	return configuration, nil
+// newEncryptedStorage returns nil without a key file.
+func newEncryptedStorage(configuration *Configuration, repository photo.Repository) (*encrypted_storage.EncryptedStorage, error) {
+	encryption := configuration.Storage.Encryption
+	if encryption.KeyFile == "" {
+		return nil, nil
+	}
+	keyring, err := encrypted_storage.LoadKeyring(encryption.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return encrypted_storage.New(repository, keyring, encrypted_storage.Options{AllowPlaintext: encryption.AllowPlaintext}), nil
 }
@@ -1,22 +1,36 @@
 package application
 
 import (
-	"github.com/photoshelf/photoshelf-storage/infrastructure/container"
+	"context"
+	"github.com/photoshelf/photoshelf-storage/application/migration"
+	"github.com/photoshelf/photoshelf-storage/application/webhook"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
 	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/boltdb_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/encrypted_storage"
 	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
 	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/leveldb_storage"
-	"github.com/photoshelf/photoshelf-storage/presentation/controller"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/replicated_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/tiered_storage"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
-	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
 	t.Run("with no args, can load default", func(t *testing.T) {
-		configuration := load()
+		configuration, err := load()
+		if err != nil {
+			t.Fatal(err)
+		}
 		assert.EqualValues(t, 1323, configuration.Server.Port)
 		assert.EqualValues(t, "boltdb", configuration.Storage.Type)
 		assert.EqualValues(t, "./photos", configuration.Storage.Path)
@@ -24,18 +38,173 @@ func TestLoad(t *testing.T) {
 
 	t.Run("with specify c flag, can load from file", func(t *testing.T) {
 		configurationPath := path.Join(os.Getenv("GOPATH"), "src/github.com/photoshelf/photoshelf-storage", "testdata", "test.yml")
-		configuration := load("-c", configurationPath)
+		configuration, err := load("-c", configurationPath)
+		if err != nil {
+			t.Fatal(err)
+		}
 		assert.EqualValues(t, configuration.Server.Port, 12345)
 		assert.EqualValues(t, configuration.Storage.Type, "hoge")
 		assert.EqualValues(t, configuration.Storage.Path, "fuga")
 	})
 
 	t.Run("with flags, can parse from flags", func(t *testing.T) {
-		configuration := load("-p", "54321", "-t", "foo", "-s", "bar")
+		configuration, err := load("-p", "54321", "-t", "foo", "-s", "bar")
+		if err != nil {
+			t.Fatal(err)
+		}
 		assert.EqualValues(t, configuration.Server.Port, 54321)
 		assert.EqualValues(t, configuration.Storage.Type, "foo")
 		assert.EqualValues(t, configuration.Storage.Path, "bar")
 	})
+
+	t.Run("with file, environment and flags, prefers flags to environment to file", func(t *testing.T) {
+		configurationPath := path.Join(os.TempDir(), "layered.yml")
+		configurationFile := []byte("server:\n  port: 1000\n  grpc_port: 1001\nstorage:\n  type: file\n  path: from_file\nscrub:\n  rate: 5\n")
+		if err := ioutil.WriteFile(configurationPath, configurationFile, 0600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("PHOTOSHELF_SERVER_PORT", "2000")
+		t.Setenv("PHOTOSHELF_STORAGE_PATH", "from_env")
+		t.Setenv("PHOTOSHELF_SCRUB_INTERVAL", "1h")
+		t.Setenv("PHOTOSHELF_TRACING_SAMPLE_RATIO", "0.5")
+
+		configuration, err := load("-p", "3000", "-c", configurationPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 3000, configuration.Server.Port)
+		assert.Equal(t, 1001, configuration.Server.GrpcPort)
+		assert.Equal(t, "file", configuration.Storage.Type)
+		assert.Equal(t, "from_env", configuration.Storage.Path)
+		assert.Equal(t, 5, configuration.Scrub.Rate)
+		assert.Equal(t, time.Hour, configuration.Scrub.Interval)
+		assert.Equal(t, 0.5, configuration.Tracing.SampleRatio)
+		assert.Equal(t, "rest", configuration.Server.Mode)
+	})
+
+	t.Run("with PHOTOSHELF_CONFIG, loads the file", func(t *testing.T) {
+		configurationPath := path.Join(os.Getenv("GOPATH"), "src/github.com/photoshelf/photoshelf-storage", "testdata", "test.yml")
+		t.Setenv("PHOTOSHELF_CONFIG", configurationPath)
+
+		configuration, err := load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 12345, configuration.Server.Port)
+	})
+
+	t.Run("with malformed environment variable, returns error", func(t *testing.T) {
+		t.Setenv("PHOTOSHELF_SERVER_PORT", "http")
+
+		_, err := load()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "PHOTOSHELF_SERVER_PORT")
+		}
+	})
+}
+
+func TestConfiguration_Validate(t *testing.T) {
+	t.Run("with defaults, returns no error", func(t *testing.T) {
+		configuration, err := load("-s", path.Join(os.TempDir(), "validate.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(t, configuration.Validate())
+	})
+
+	t.Run("with boltdb type and directory path, returns friendly error", func(t *testing.T) {
+		configuration, err := load("-t", "boltdb", "-s", os.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = configuration.Validate()
+		if assert.IsType(t, &ValidationError{}, err) {
+			assert.Equal(t, []string{"storage.path : " + os.TempDir() + " is a directory, boltdb storage needs a file"}, err.(*ValidationError).Problems)
+		}
+	})
+
+	t.Run("with several invalid values, reports all of them", func(t *testing.T) {
+		configuration, err := load("-t", "memory", "-p", "70000", "-m", "soap", "-log-level", "verbose")
+		if err != nil {
+			t.Fatal(err)
+		}
+		configuration.Tracing.SampleRatio = 2
+		err = configuration.Validate()
+		if assert.IsType(t, &ValidationError{}, err) {
+			assert.Equal(t, []string{
+				"server.port : 70000 is not between 0 and 65535",
+				`server.mode : "soap" is not one of rest, grpc or all`,
+				"tracing.sample_ratio : 2 is not between 0 and 1",
+				`log.level : "verbose" is not one of debug, info, warn or error`,
+			}, err.(*ValidationError).Problems)
+		}
+	})
+
+	t.Run("with replicated type, validates every replica", func(t *testing.T) {
+		configuration, err := load("-t", "replicated")
+		if err != nil {
+			t.Fatal(err)
+		}
+		configuration.Storage.Quorum = 4
+		configuration.Storage.Replicas = []StorageConfiguration{{Type: "memory"}, {Type: "tape"}, {Type: "replicated"}}
+		err = configuration.Validate()
+		if assert.IsType(t, &ValidationError{}, err) {
+			assert.Equal(t, []string{
+				"storage.quorum : 4 is not between 1 and 3 replicas, or 0 for a majority",
+				`storage.replicas[1].type : "tape" is not one of file, leveldb, boltdb, memory, replicated or tiered`,
+				"storage.replicas[2].type : replicated storage can't be a replica",
+			}, err.(*ValidationError).Problems)
+		}
+	})
+}
+
+func TestConfig(t *testing.T) {
+	t.Run("with print, writes effective configuration with secrets redacted", func(t *testing.T) {
+		configurationPath := path.Join(os.TempDir(), "print.yml")
+		configurationFile := []byte("storage:\n  type: memory\nwebhooks:\n  - url: http://localhost/hooks\n    secret: secret\n")
+		if err := ioutil.WriteFile(configurationPath, configurationFile, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		out := captureStdout(t, func() {
+			assert.NoError(t, Config("print", "-c", configurationPath, "-p", "8080"))
+		})
+		assert.Contains(t, out, "port: 8080")
+		assert.Contains(t, out, "type: memory")
+		assert.Contains(t, out, "secret: REDACTED")
+		assert.NotContains(t, out, "secret: secret")
+	})
+
+	t.Run("with invalid configuration, prints it and returns error", func(t *testing.T) {
+		var err error
+		out := captureStdout(t, func() {
+			err = Config("print", "-t", "tape")
+		})
+		assert.Contains(t, out, "type: tape")
+		assert.Error(t, err)
+	})
+
+	t.Run("without print, returns error", func(t *testing.T) {
+		assert.Error(t, Config())
+	})
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
 }
 
 func TestConfiguration_Set(t *testing.T) {
@@ -64,7 +233,10 @@ func TestConfiguration_Set(t *testing.T) {
 
 func TestConfiguration_String(t *testing.T) {
 	t.Run("when not empty, returns value", func(t *testing.T) {
-		conf := load()
+		conf, err := load()
+		if err != nil {
+			t.Fatal(err)
+		}
 		assert.NotEmpty(t, conf.String())
 	})
 }
@@ -74,9 +246,9 @@ func TestConfigure(t *testing.T) {
 		dbPath := path.Join(os.TempDir(), "leveldb")
 		os.RemoveAll(dbPath)
 
-		_, err := Configure("-t", "leveldb", "-s", dbPath)
+		app, err := Configure("-t", "leveldb", "-s", dbPath)
 		if assert.NoError(t, err) {
-			assert.IsType(t, new(leveldb_storage.LeveldbStorage), actualRepository())
+			assert.IsType(t, new(leveldb_storage.LeveldbStorage), actualRepository(app))
 		}
 	})
 
@@ -92,9 +264,9 @@ func TestConfigure(t *testing.T) {
 	})
 
 	t.Run("with file type, returns instance specify", func(t *testing.T) {
-		_, err := Configure("-t", "file")
+		app, err := Configure("-t", "file")
 		if assert.NoError(t, err) {
-			assert.IsType(t, new(file_storage.FileStorage), actualRepository())
+			assert.IsType(t, new(file_storage.FileStorage), actualRepository(app))
 		}
 	})
 
@@ -102,12 +274,33 @@ func TestConfigure(t *testing.T) {
 		dbPath := path.Join(os.TempDir(), "boltdb")
 		os.RemoveAll(dbPath)
 
-		_, err := Configure("-t", "boltdb", "-s", dbPath)
+		app, err := Configure("-t", "boltdb", "-s", dbPath)
 		if assert.NoError(t, err) {
-			assert.IsType(t, new(boltdb_storage.BoltdbStorage), actualRepository())
+			assert.IsType(t, new(boltdb_storage.BoltdbStorage), actualRepository(app))
 		}
 	})
 
+	t.Run("with boltdb type, exposes repository and bolt metrics", func(t *testing.T) {
+		dbPath := path.Join(os.TempDir(), "boltdb_metrics")
+		os.RemoveAll(dbPath)
+
+		app, err := Configure("-t", "boltdb", "-s", dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer app.Close()
+
+		scrape := func() string {
+			recorder := httptest.NewRecorder()
+			app.Metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			return recorder.Body.String()
+		}
+		assert.Eventually(t, func() bool {
+			return strings.Contains(scrape(), `photoshelf_repository_objects{backend="boltdb"} 0`)
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Contains(t, scrape(), `photoshelf_boltdb_read_tx_total{path="`+dbPath+`"}`)
+	})
+
 	t.Run("when fail to load boltdb, returns error", func(t *testing.T) {
 		dbPath := path.Join(os.TempDir(), "err_boltdb")
 		os.RemoveAll(dbPath)
@@ -117,19 +310,343 @@ func TestConfigure(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("with memory type, returns instance specify", func(t *testing.T) {
+		app, err := Configure("-t", "memory", "-max-bytes", "1024")
+		if assert.NoError(t, err) {
+			assert.IsType(t, new(memory_storage.MemoryStorage), actualRepository(app))
+		}
+	})
+
 	t.Run("with unknown type, returns error", func(t *testing.T) {
 		_, err := Configure("-t", "unknown")
 		assert.Error(t, err)
 	})
+
+	t.Run("with unknown trace exporter, returns error", func(t *testing.T) {
+		_, err := Configure("-t", "memory", "-trace-exporter", "zipkin")
+		assert.Error(t, err)
+	})
+
+	t.Run("with unknown log format, returns error", func(t *testing.T) {
+		_, err := Configure("-t", "memory", "-log-format", "xml")
+		assert.Error(t, err)
+	})
+
+	t.Run("with replicated type, returns replicated storage", func(t *testing.T) {
+		dir := path.Join(os.TempDir(), "replicated")
+		os.RemoveAll(dir)
+		os.MkdirAll(path.Join(dir, "file"), 0700)
+		configurationPath := path.Join(dir, "replicated.yml")
+		configurationFile := []byte(`
+storage:
+  type: replicated
+  quorum: 1
+  replicas:
+    - type: boltdb
+      path: ` + path.Join(dir, "photos.db") + `
+    - type: file
+      path: ` + path.Join(dir, "file") + `
+`)
+		if err := ioutil.WriteFile(configurationPath, configurationFile, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		app, err := Configure("-c", configurationPath)
+		if assert.NoError(t, err) {
+			assert.IsType(t, new(replicated_storage.ReplicatedStorage), actualRepository(app))
+		}
+	})
+
+	t.Run("with replicated type and no replicas, returns error", func(t *testing.T) {
+		_, err := Configure("-t", "replicated")
+		assert.Error(t, err)
+	})
+
+	t.Run("with replicated type and failing replica, closes opened replicas", func(t *testing.T) {
+		dir := path.Join(os.TempDir(), "replicated_failing")
+		os.RemoveAll(dir)
+		os.MkdirAll(dir, 0700)
+		unusable := unusablePath(t, dir)
+
+		_, err := openStorage(StorageConfiguration{Type: "replicated", Replicas: []StorageConfiguration{
+			{Type: "leveldb", Path: path.Join(dir, "photos")},
+			{Type: "leveldb", Path: unusable},
+		}})
+		if assert.Error(t, err) {
+			assertReopens(t, path.Join(dir, "photos"))
+		}
+	})
+
+	t.Run("with tiered type, returns tiered storage", func(t *testing.T) {
+		dir := path.Join(os.TempDir(), "tiered")
+		os.RemoveAll(dir)
+		os.MkdirAll(path.Join(dir, "cold"), 0700)
+		configurationPath := path.Join(dir, "tiered.yml")
+		configurationFile := []byte(`
+storage:
+  type: tiered
+  max_items: 100
+  hot:
+    type: leveldb
+    path: ` + path.Join(dir, "hot") + `
+  cold:
+    type: file
+    path: ` + path.Join(dir, "cold") + `
+`)
+		if err := ioutil.WriteFile(configurationPath, configurationFile, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		app, err := Configure("-c", configurationPath)
+		if assert.NoError(t, err) {
+			assert.IsType(t, new(tiered_storage.TieredStorage), actualRepository(app))
+		}
+	})
+
+	t.Run("with tiered type and no tiers, returns error", func(t *testing.T) {
+		_, err := Configure("-t", "tiered")
+		assert.Error(t, err)
+	})
+
+	t.Run("with tiered type and failing cold storage, closes hot storage", func(t *testing.T) {
+		dir := path.Join(os.TempDir(), "tiered_failing")
+		os.RemoveAll(dir)
+		os.MkdirAll(dir, 0700)
+		unusable := unusablePath(t, dir)
+
+		_, err := openStorage(StorageConfiguration{
+			Type:     "tiered",
+			MaxItems: 100,
+			Hot:      &StorageConfiguration{Type: "leveldb", Path: path.Join(dir, "hot")},
+			Cold:     &StorageConfiguration{Type: "leveldb", Path: unusable},
+		})
+		if assert.Error(t, err) {
+			assertReopens(t, path.Join(dir, "hot"))
+		}
+	})
+
+	t.Run("with key file, returns encrypted storage", func(t *testing.T) {
+		keyFile := path.Join(os.TempDir(), "photoshelf_keys")
+		if err := ioutil.WriteFile(keyFile, []byte("key MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		app, err := Configure("-t", "file", "-key-file", keyFile)
+		if assert.NoError(t, err) {
+			assert.IsType(t, new(encrypted_storage.EncryptedStorage), actualRepository(app))
+		}
+	})
+
+	t.Run("with wrong key file, returns error", func(t *testing.T) {
+		_, err := Configure("-t", "file", "-key-file", path.Join(os.TempDir(), "no_key_file"))
+		assert.Error(t, err)
+	})
+
+	t.Run("with webhooks, subscribes them", func(t *testing.T) {
+		dir := path.Join(os.TempDir(), "webhooks")
+		os.RemoveAll(dir)
+		os.MkdirAll(dir, 0700)
+		configurationPath := path.Join(dir, "webhooks.yml")
+		configurationFile := []byte(`
+storage:
+  type: memory
+webhooks:
+  - name: indexer
+    url: http://localhost:8080/hooks
+    events: [photo.created, photo.deleted]
+    secret: secret
+`)
+		if err := ioutil.WriteFile(configurationPath, configurationFile, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		app, err := Configure("-c", configurationPath)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []webhook.Subscription{{
+				Name:   "indexer",
+				URL:    "http://localhost:8080/hooks",
+				Events: []string{"photo.created", "photo.deleted"},
+				Secret: "secret",
+			}}, app.Configuration.Webhooks)
+		}
+	})
+
+	t.Run("with invalid webhook url, returns error", func(t *testing.T) {
+		dir := path.Join(os.TempDir(), "webhooks")
+		os.MkdirAll(dir, 0700)
+		configurationPath := path.Join(dir, "invalid.yml")
+		if err := ioutil.WriteFile(configurationPath, []byte("storage:\n  type: memory\nwebhooks:\n  - url: localhost\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := Configure("-c", configurationPath)
+		assert.Error(t, err)
+	})
+
+	t.Run("with migrate type, returns migration repository", func(t *testing.T) {
+		targetPath := path.Join(os.TempDir(), "migrate_target")
+		os.RemoveAll(targetPath)
+		os.MkdirAll(targetPath, 0700)
+
+		app, err := Configure("-t", "file", "-migrate-t", "file", "-migrate-s", targetPath)
+		if assert.NoError(t, err) {
+			assert.IsType(t, new(migration.Repository), actualRepository(app))
+		}
+	})
+
+	t.Run("with migrate type, labels metrics with the backend serving after cut over", func(t *testing.T) {
+		sourcePath := path.Join(os.TempDir(), "migrate_source")
+		os.RemoveAll(sourcePath)
+		os.MkdirAll(sourcePath, 0700)
+
+		app, err := Configure("-t", "file", "-s", sourcePath, "-migrate-t", "memory")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer app.Close()
+		migrated := actualRepository(app).(*migration.Repository)
+		assert.Eventually(t, migrated.IsCutOver, 5*time.Second, 10*time.Millisecond)
+
+		if _, err := app.Repository.FindAll(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		app.Metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Contains(t, recorder.Body.String(), `photoshelf_repository_operation_duration_seconds_count{backend="memory",operation="find_all"} 1`)
+	})
+
+	t.Run("with migrate type, keeps events in the target", func(t *testing.T) {
+		sourcePath := path.Join(os.TempDir(), "migrate_events_source")
+		targetPath := path.Join(os.TempDir(), "migrate_events_target")
+		for _, dir := range []string{sourcePath, targetPath} {
+			os.RemoveAll(dir)
+			os.MkdirAll(dir, 0700)
+			defer os.RemoveAll(dir)
+		}
+		ctx := context.Background()
+		pending := event.PhotoDeleted{Id: *photo.IdentifierOf("gone"), OccurredAt: time.Now()}
+		if err := file_storage.NewOutbox(file_storage.New(sourcePath)).Append(ctx, pending); err != nil {
+			t.Fatal(err)
+		}
+
+		app, err := Configure("-t", "file", "-s", sourcePath, "-migrate-t", "file", "-migrate-s", targetPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer app.Close()
+		if _, err := app.PhotoService.Save(ctx, *photo.New([]byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+
+		changes := file_storage.NewChangeLog(file_storage.New(targetPath))
+		assert.Eventually(t, func() bool {
+			records, err := changes.Since(ctx, 0, 10)
+			return err == nil && len(records) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		records, err := file_storage.NewOutbox(file_storage.New(sourcePath)).Pending(ctx)
+		if assert.NoError(t, err) {
+			assert.Empty(t, records)
+		}
+	})
+
+	t.Run("with migrate target same as source, returns error", func(t *testing.T) {
+		_, err := Configure("-t", "file", "-migrate-t", "file", "-migrate-s", "./photos")
+		assert.Error(t, err)
+	})
+}
+
+func TestApplication_Close(t *testing.T) {
+	t.Run("after close, boltdb can be opened again", func(t *testing.T) {
+		dbPath := path.Join(os.TempDir(), "close_boltdb")
+		os.RemoveAll(dbPath)
+		defer os.RemoveAll(dbPath)
+
+		app, err := Configure("-t", "boltdb", "-s", dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.NoError(t, app.Close()) {
+			storage, err := boltdb_storage.New(dbPath)
+			if assert.NoError(t, err) {
+				storage.Close()
+			}
+		}
+	})
+
+	t.Run("after close, every replica can be opened again", func(t *testing.T) {
+		dir := path.Join(os.TempDir(), "close_replicated")
+		os.RemoveAll(dir)
+		defer os.RemoveAll(dir)
+		configuration, err := load("-t", "replicated")
+		if err != nil {
+			t.Fatal(err)
+		}
+		configuration.Storage.Replicas = []StorageConfiguration{
+			{Type: "leveldb", Path: path.Join(dir, "first")},
+			{Type: "leveldb", Path: path.Join(dir, "second")},
+		}
+
+		app, err := New(configuration)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.NoError(t, app.Close()) {
+			assertReopens(t, path.Join(dir, "first"))
+			assertReopens(t, path.Join(dir, "second"))
+		}
+	})
+
+	t.Run("with composed storage, closes stores of every part", func(t *testing.T) {
+		first := &closingAlbums{Repository: memory_storage.NewAlbumStorage()}
+		second := &closingAlbums{Repository: memory_storage.NewAlbumStorage()}
+		composed := &backend{
+			photos: memory_storage.New(0),
+			albums: memory_storage.NewAlbumStorage(),
+			parts:  []*backend{{albums: first}, {albums: second}},
+		}
+
+		closeBackends(composed)
+		assert.True(t, first.closed)
+		assert.True(t, second.closed)
+	})
 }
 
-func actualRepository() interface{} {
-	photoController := controller.NewRestPhotoController()
-	container.Get(photoController)
+type closingAlbums struct {
+	album.Repository
+	closed bool
+}
+
+func (albums *closingAlbums) Close() error {
+	albums.closed = true
+	return nil
+}
 
-	pcv := reflect.Indirect(reflect.ValueOf(photoController))
-	ps := pcv.Field(0).Interface()
-	psv := reflect.ValueOf(ps)
-	rv := reflect.Indirect(psv).Field(0)
-	return rv.Interface()
+// unusablePath returns a path under a regular file, where no storage can be opened.
+func unusablePath(tb testing.TB, dir string) string {
+	tb.Helper()
+	file := path.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		tb.Fatal(err)
+	}
+	return path.Join(file, "photos")
+}
+
+// assertReopens asserts that the leveldb storage at path was closed, as it is locked while open.
+func assertReopens(tb testing.TB, path string) {
+	tb.Helper()
+	storage, err := leveldb_storage.New(path)
+	if assert.NoError(tb, err) {
+		storage.Close()
+	}
+}
+
+func actualRepository(app *Application) interface{} {
+	var repository interface{} = app.Repository
+	for {
+		wrapper, ok := repository.(interface{ Repository() photo.Repository })
+		if !ok {
+			return repository
+		}
+		repository = wrapper.Repository()
+	}
 }
@@ -0,0 +1,96 @@
+package application
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const environmentPrefix = "PHOTOSHELF"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnvironment sets every scalar field of the configuration from its PHOTOSHELF_* variable,
+// named after its path in the configuration file, like PHOTOSHELF_SERVER_GRPC_PORT for server.grpc_port.
+func applyEnvironment(configuration *Configuration, lookup func(string) (string, bool)) error {
+	return applyEnvironmentTo(reflect.ValueOf(configuration).Elem(), environmentPrefix, lookup)
+}
+
+func applyEnvironmentTo(value reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		key := prefix
+		if name, inline := yamlName(field); !inline {
+			key += "_" + strings.ToUpper(name)
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			if err := applyEnvironmentTo(value.Field(i), key, lookup); err != nil {
+				return err
+			}
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+			s, ok := lookup(key)
+			if !ok {
+				continue
+			}
+			if err := setString(value.Field(i), s); err != nil {
+				return fmt.Errorf("%s : %s", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func yamlName(field reflect.StructField) (string, bool) {
+	options := strings.Split(field.Tag.Get("yaml"), ",")
+	for _, option := range options[1:] {
+		if option == "inline" {
+			return "", true
+		}
+	}
+	if options[0] != "" {
+		return options[0], false
+	}
+	return strings.ToLower(field.Name), false
+}
+
+func setString(value reflect.Value, s string) error {
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s", s)
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", s)
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", s)
+		}
+		value.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		value.SetFloat(f)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package event_bus
+
+import (
+	"context"
+	"errors"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+const defaultRetryInterval = time.Second
+
+// ErrRetryLater can be returned by a handler to have an event redelivered without logging a failure.
+var ErrRetryLater = errors.New("retry later")
+
+type Handler func(ctx context.Context, record event.Record) error
+
+// subscriber receives events in order, from its own goroutine, so that a slow one doesn't delay the others.
+type subscriber struct {
+	name    string
+	handler Handler
+	wake    chan struct{}
+
+	delivering sync.Mutex
+	// cursor is the sequence of the last event delivered to the subscriber, guarded by the bus mutex.
+	cursor uint64
+}
+
+// Bus delivers events appended to an outbox to its subscribers.
+// An event stays in the outbox until every subscriber accepted it, so a subscriber may receive it more than once.
+type Bus struct {
+	RetryInterval time.Duration
+
+	outbox event.Outbox
+
+	mu          sync.Mutex
+	subscribers []*subscriber
+
+	running sync.WaitGroup
+}
+
+func New(outbox event.Outbox) *Bus {
+	return &Bus{
+		RetryInterval: defaultRetryInterval,
+		outbox:        outbox,
+	}
+}
+
+// Subscribe adds a subscriber, which must be done before Start.
+func (bus *Bus) Subscribe(name string, handler Handler) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.subscribers = append(bus.subscribers, &subscriber{name: name, handler: handler, wake: make(chan struct{}, 1)})
+}
+
+func (bus *Bus) Publish(ctx context.Context, e event.Event) error {
+	if err := bus.outbox.Append(ctx, e); err != nil {
+		return err
+	}
+	bus.notify()
+	return nil
+}
+
+func (bus *Bus) Stage(ctx context.Context, build func(id photo.Identifier) event.Event) (context.Context, *event.Staged) {
+	return event.Stage(ctx, bus.outbox, build)
+}
+
+func (bus *Bus) Commit(ctx context.Context, staged *event.Staged, id photo.Identifier) error {
+	if !staged.Appended() {
+		if err := bus.outbox.Append(ctx, staged.Event(id)); err != nil {
+			return err
+		}
+	}
+	bus.notify()
+	return nil
+}
+
+func (bus *Bus) notify() {
+	for _, s := range bus.snapshot() {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start delivers events to every subscriber from its own goroutine, until ctx is done.
+func (bus *Bus) Start(ctx context.Context) {
+	for _, s := range bus.snapshot() {
+		bus.running.Add(1)
+		go func(s *subscriber) {
+			defer bus.running.Done()
+			ticker := time.NewTicker(bus.RetryInterval)
+			defer ticker.Stop()
+			for {
+				if err := bus.deliver(ctx, s); err != nil && ctx.Err() == nil {
+					slog.ErrorContext(ctx, "event dispatch failed", "subscriber", s.name, "error", err)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-s.wake:
+				case <-ticker.C:
+				}
+			}
+		}(s)
+	}
+}
+
+// Wait blocks until the dispatching started by Start stopped.
+func (bus *Bus) Wait() {
+	bus.running.Wait()
+}
+
+// Dispatch delivers pending events to every subscriber and waits until they handled them.
+func (bus *Bus) Dispatch(ctx context.Context) error {
+	subscribers := bus.snapshot()
+	if len(subscribers) == 0 {
+		records, err := bus.outbox.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		return bus.collect(ctx, records)
+	}
+	errs := make(chan error, len(subscribers))
+	for _, s := range subscribers {
+		go func(s *subscriber) {
+			errs <- bus.deliver(ctx, s)
+		}(s)
+	}
+
+	var firstErr error
+	for range subscribers {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// deliver delivers the pending events after its cursor to a subscriber, in order.
+// When the subscriber fails, the following events are retried on its next delivery.
+func (bus *Bus) deliver(ctx context.Context, s *subscriber) error {
+	s.delivering.Lock()
+	defer s.delivering.Unlock()
+
+	records, err := bus.outbox.Pending(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if record.Sequence <= bus.cursorOf(s) {
+			continue
+		}
+		if err := s.handler(ctx, record); err != nil {
+			if err != ErrRetryLater {
+				slog.WarnContext(ctx, "event delivery failed", "sequence", record.Sequence, "subscriber", s.name, "error", err)
+			}
+			break
+		}
+		bus.mu.Lock()
+		s.cursor = record.Sequence
+		bus.mu.Unlock()
+	}
+	return bus.collect(ctx, records)
+}
+
+// collect removes the events which every subscriber accepted from the outbox.
+func (bus *Bus) collect(ctx context.Context, records []event.Record) error {
+	bus.mu.Lock()
+	var delivered uint64 = math.MaxUint64
+	for _, s := range bus.subscribers {
+		if s.cursor < delivered {
+			delivered = s.cursor
+		}
+	}
+	bus.mu.Unlock()
+
+	for _, record := range records {
+		if record.Sequence > delivered {
+			break
+		}
+		if err := bus.outbox.Remove(ctx, record.Sequence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bus *Bus) cursorOf(s *subscriber) uint64 {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	return s.cursor
+}
+
+func (bus *Bus) snapshot() []*subscriber {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	return append([]*subscriber{}, bus.subscribers...)
+}
@@ -0,0 +1,214 @@
+package event_bus
+
+import (
+	"context"
+	"errors"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBus_Dispatch(t *testing.T) {
+	t.Run("delivers events to every subscriber, then removes them", func(t *testing.T) {
+		outbox := memory_storage.NewOutbox()
+		bus := New(outbox)
+		first, second := &recorder{}, &recorder{}
+		bus.Subscribe("first", first.handle)
+		bus.Subscribe("second", second.handle)
+
+		publish(t, bus, created("a"), deleted("a"))
+		if assert.NoError(t, bus.Dispatch(context.Background())) {
+			assert.Equal(t, []string{"photo.created a", "photo.deleted a"}, first.received)
+			assert.Equal(t, []string{"photo.created a", "photo.deleted a"}, second.received)
+			assertPending(t, outbox, 0)
+		}
+	})
+
+	t.Run("when subscriber fails, keeps event and retries only that subscriber", func(t *testing.T) {
+		outbox := memory_storage.NewOutbox()
+		bus := New(outbox)
+		healthy, failing := &recorder{}, &recorder{err: errors.New("unavailable")}
+		bus.Subscribe("healthy", healthy.handle)
+		bus.Subscribe("failing", failing.handle)
+
+		publish(t, bus, created("a"), created("b"))
+		if assert.NoError(t, bus.Dispatch(context.Background())) {
+			assert.Equal(t, []string{"photo.created a", "photo.created b"}, healthy.received)
+			assert.Empty(t, failing.received)
+			assertPending(t, outbox, 2)
+		}
+
+		failing.err = nil
+		if assert.NoError(t, bus.Dispatch(context.Background())) {
+			assert.Equal(t, []string{"photo.created a", "photo.created b"}, healthy.received)
+			assert.Equal(t, []string{"photo.created a", "photo.created b"}, failing.received)
+			assertPending(t, outbox, 0)
+		}
+	})
+
+	t.Run("without subscribers, removes events", func(t *testing.T) {
+		outbox := memory_storage.NewOutbox()
+		bus := New(outbox)
+
+		publish(t, bus, created("a"))
+		if assert.NoError(t, bus.Dispatch(context.Background())) {
+			assertPending(t, outbox, 0)
+		}
+	})
+
+	t.Run("after restart, redelivers pending events", func(t *testing.T) {
+		outbox := memory_storage.NewOutbox()
+		publish(t, New(outbox), updated("a"))
+
+		subscriber := &recorder{}
+		bus := New(outbox)
+		bus.Subscribe("subscriber", subscriber.handle)
+		if assert.NoError(t, bus.Dispatch(context.Background())) {
+			assert.Equal(t, []string{"photo.updated a"}, subscriber.received)
+		}
+	})
+}
+
+func TestBus_Start(t *testing.T) {
+	bus := New(memory_storage.NewOutbox())
+	received := make(chan event.Event, 1)
+	bus.Subscribe("subscriber", func(ctx context.Context, record event.Record) error {
+		received <- record.Event
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus.Start(ctx)
+
+	publish(t, bus, created("a"))
+	select {
+	case e := <-received:
+		assert.Equal(t, created("a"), e)
+	case <-time.After(time.Second):
+		t.Fatal("event was not delivered")
+	}
+
+	cancel()
+	bus.Wait()
+}
+
+func TestBus_Start_slowSubscriber(t *testing.T) {
+	outbox := memory_storage.NewOutbox()
+	bus := New(outbox)
+	release := make(chan struct{})
+	bus.Subscribe("slow", func(ctx context.Context, record event.Record) error {
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	received := make(chan event.Event, 2)
+	bus.Subscribe("fast", func(ctx context.Context, record event.Record) error {
+		received <- record.Event
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus.Start(ctx)
+
+	publish(t, bus, created("a"), deleted("a"))
+	for _, expected := range []event.Event{created("a"), deleted("a")} {
+		select {
+		case e := <-received:
+			assert.Equal(t, expected, e)
+		case <-time.After(time.Second):
+			t.Fatal("event was not delivered while another subscriber was blocked")
+		}
+	}
+	assertPending(t, outbox, 2)
+
+	close(release)
+	for i := 0; i < 100 && pending(t, outbox) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assertPending(t, outbox, 0)
+
+	cancel()
+	bus.Wait()
+}
+
+type recorder struct {
+	err      error
+	received []string
+}
+
+func (r *recorder) handle(ctx context.Context, record event.Record) error {
+	if r.err != nil {
+		return r.err
+	}
+	id := record.Event.PhotoId()
+	r.received = append(r.received, record.Event.Type()+" "+id.Value())
+	return nil
+}
+
+func created(id string) event.Event {
+	return event.PhotoCreated{Id: *photo.IdentifierOf(id)}
+}
+
+func TestBus_Commit(t *testing.T) {
+	build := func(id photo.Identifier) event.Event {
+		return event.PhotoDeleted{Id: id}
+	}
+
+	t.Run("when write did not append staged event, appends it", func(t *testing.T) {
+		outbox := memory_storage.NewOutbox()
+		bus := New(outbox)
+
+		_, staged := bus.Stage(context.Background(), build)
+		assert.NoError(t, bus.Commit(context.Background(), staged, *photo.IdentifierOf("a")))
+		assertPending(t, outbox, 1)
+	})
+
+	t.Run("when write appended staged event, does not append it again", func(t *testing.T) {
+		outbox := memory_storage.NewOutbox()
+		bus := New(outbox)
+
+		ctx, staged := bus.Stage(context.Background(), build)
+		event.StagedIn(ctx).MarkAppended()
+		assert.NoError(t, bus.Commit(context.Background(), staged, *photo.IdentifierOf("a")))
+		assertPending(t, outbox, 0)
+	})
+}
+
+func updated(id string) event.Event {
+	return event.PhotoUpdated{Id: *photo.IdentifierOf(id)}
+}
+
+func deleted(id string) event.Event {
+	return event.PhotoDeleted{Id: *photo.IdentifierOf(id)}
+}
+
+func publish(t *testing.T, bus *Bus, events ...event.Event) {
+	t.Helper()
+	for _, e := range events {
+		if err := bus.Publish(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertPending(t *testing.T, outbox event.Outbox, expected int) {
+	t.Helper()
+	assert.Equal(t, expected, pending(t, outbox))
+}
+
+func pending(t *testing.T, outbox event.Outbox) int {
+	t.Helper()
+	records, err := outbox.Pending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(records)
+}
@@ -0,0 +1,36 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"sync/atomic"
+)
+
+var ErrNotServing = errors.New("server is not serving")
+
+// Checker tells whether the server can take requests.
+// It is ready while the servers are serving and the storage is writable.
+type Checker struct {
+	repository photo.Repository
+	serving    int32
+}
+
+func New(repository photo.Repository) *Checker {
+	return &Checker{repository: repository}
+}
+
+func (checker *Checker) SetServing(serving bool) {
+	var value int32
+	if serving {
+		value = 1
+	}
+	atomic.StoreInt32(&checker.serving, value)
+}
+
+func (checker *Checker) Ready(ctx context.Context) error {
+	if atomic.LoadInt32(&checker.serving) == 0 {
+		return ErrNotServing
+	}
+	return photo.Ping(ctx, checker.repository)
+}
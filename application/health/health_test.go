@@ -0,0 +1,45 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/photoshelf/photoshelf-storage/domain/model/mock_photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	t.Run("before serving, returns not serving", func(t *testing.T) {
+		checker := New(memory_storage.New(0))
+		assert.Equal(t, ErrNotServing, checker.Ready(context.Background()))
+	})
+
+	t.Run("while serving, checks storage", func(t *testing.T) {
+		checker := New(memory_storage.New(0))
+		checker.SetServing(true)
+		assert.NoError(t, checker.Ready(context.Background()))
+	})
+
+	t.Run("after serving, returns not serving", func(t *testing.T) {
+		checker := New(memory_storage.New(0))
+		checker.SetServing(true)
+		checker.SetServing(false)
+		assert.Equal(t, ErrNotServing, checker.Ready(context.Background()))
+	})
+
+	t.Run("when storage fails, returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repository := mock_photo.NewMockRepository(ctrl)
+		repository.EXPECT().
+			FindAll(gomock.Any()).
+			Return(nil, errors.New("disk failure"))
+
+		checker := New(repository)
+		checker.SetServing(true)
+		assert.EqualError(t, checker.Ready(context.Background()), "disk failure")
+	})
+}
@@ -0,0 +1,224 @@
+package migration
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"os"
+	"sync"
+)
+
+// cutOverMarker is the checkpoint line recording the cut-over, which no photo id can be as ids can't contain ':'.
+const cutOverMarker = ":cut-over"
+
+var ErrVerificationFailed = errors.New("target does not match source")
+
+type Progress struct {
+	Total   int
+	Copied  int
+	Skipped int
+}
+
+type Migrator struct {
+	Photos     *Repository
+	Albums     *AlbumRepository
+	Workers    int
+	Checkpoint string
+	OnProgress func(Progress)
+
+	mu       sync.Mutex
+	progress Progress
+}
+
+// Run copies the photos and albums, verifies them and cuts over to the target.
+// A migration recorded as cut over in its checkpoint isn't run again.
+func (migrator *Migrator) Run(ctx context.Context) error {
+	if cutOver, err := migrator.Restore(); err != nil || cutOver {
+		return err
+	}
+	if err := migrator.copyPhotos(ctx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if migrator.Albums != nil {
+		if _, err := migrator.Albums.copyAll(ctx); err != nil {
+			return err
+		}
+	}
+	if err := migrator.Verify(ctx); err != nil {
+		return err
+	}
+	if err := recordCutOver(migrator.Checkpoint); err != nil {
+		return err
+	}
+	migrator.Photos.CutOver()
+	return nil
+}
+
+// Restore cuts over to the target when the checkpoint records that the migration already did,
+// so that a restarted server doesn't serve the stale source again.
+func (migrator *Migrator) Restore() (bool, error) {
+	done, err := readCheckpoint(migrator.Checkpoint)
+	if err != nil {
+		return false, err
+	}
+	if !done[cutOverMarker] {
+		return false, nil
+	}
+	migrator.Photos.CutOver()
+	return true, nil
+}
+
+func (migrator *Migrator) Verify(ctx context.Context) error {
+	ids, err := migrator.Photos.source.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	var mismatched int
+	for _, id := range ids {
+		ok, err := migrator.Photos.verify(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			mismatched++
+		}
+	}
+	if mismatched > 0 {
+		return fmt.Errorf("%d photos: %s", mismatched, ErrVerificationFailed)
+	}
+	return nil
+}
+
+func (migrator *Migrator) copyPhotos(ctx context.Context) error {
+	ids, err := migrator.Photos.source.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	done, err := readCheckpoint(migrator.Checkpoint)
+	if err != nil {
+		return err
+	}
+	var checkpoint *os.File
+	if migrator.Checkpoint != "" {
+		checkpoint, err = os.OpenFile(migrator.Checkpoint, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer checkpoint.Close()
+	}
+
+	migrator.progress = Progress{Total: len(ids)}
+	workers := migrator.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	queue := make(chan photo.Identifier)
+	errs := make(chan error, workers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				copied, err := migrator.Photos.copy(ctx, id)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if err := migrator.report(id, copied, checkpoint); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	var firstErr error
+enqueue:
+	for _, id := range ids {
+		if done[id.Value()] {
+			migrator.report(id, false, nil)
+			continue
+		}
+		select {
+		case queue <- id:
+		case firstErr = <-errs:
+			break enqueue
+		case <-ctx.Done():
+			firstErr = ctx.Err()
+			break enqueue
+		}
+	}
+	close(queue)
+	wg.Wait()
+	close(errs)
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return <-errs
+}
+
+func (migrator *Migrator) report(id photo.Identifier, copied bool, checkpoint *os.File) error {
+	migrator.mu.Lock()
+	defer migrator.mu.Unlock()
+
+	if copied {
+		migrator.progress.Copied++
+	} else {
+		migrator.progress.Skipped++
+	}
+	if checkpoint != nil {
+		if _, err := fmt.Fprintln(checkpoint, id.Value()); err != nil {
+			return err
+		}
+	}
+	if migrator.OnProgress != nil {
+		migrator.OnProgress(migrator.progress)
+	}
+	return nil
+}
+
+func recordCutOver(path string) error {
+	if path == "" {
+		return nil
+	}
+	checkpoint, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(checkpoint, cutOverMarker); err != nil {
+		checkpoint.Close()
+		return err
+	}
+	return checkpoint.Close()
+}
+
+func readCheckpoint(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+	if path == "" {
+		return done, nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		done[scanner.Text()] = true
+	}
+	return done, scanner.Err()
+}
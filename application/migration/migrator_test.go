@@ -0,0 +1,144 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestMigrator_Run(t *testing.T) {
+	t.Run("copies all photos and albums, then cuts over", func(t *testing.T) {
+		photos, albums := createMigration(t)
+		fillSource(t, photos, albums, 10)
+
+		var last Progress
+		migrator := &Migrator{Photos: photos, Albums: albums, Workers: 3, OnProgress: func(p Progress) { last = p }}
+		if assert.NoError(t, migrator.Run(context.Background())) {
+			assert.Equal(t, Progress{Total: 10, Copied: 10}, last)
+			assert.True(t, photos.IsCutOver())
+
+			ids, err := photos.target.FindAll(context.Background())
+			if assert.NoError(t, err) {
+				assert.Len(t, ids, 10)
+			}
+			all, err := albums.target.ReadAll(context.Background())
+			if assert.NoError(t, err) {
+				assert.Len(t, all, 1)
+			}
+		}
+	})
+
+	t.Run("with checkpoint, skips copied photos", func(t *testing.T) {
+		photos, albums := createMigration(t)
+		fillSource(t, photos, albums, 3)
+		checkpoint := path.Join(tempDir(t), "checkpoint")
+		if err := ioutil.WriteFile(checkpoint, []byte("photo0\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := photos.target.Save(context.Background(), *photo.Of(*photo.IdentifierOf("photo0"), []byte("photo0"), "tag")); err != nil {
+			t.Fatal(err)
+		}
+
+		var last Progress
+		migrator := &Migrator{Photos: photos, Checkpoint: checkpoint, OnProgress: func(p Progress) { last = p }}
+		if assert.NoError(t, migrator.Run(context.Background())) {
+			assert.Equal(t, Progress{Total: 3, Copied: 2, Skipped: 1}, last)
+
+			data, _ := ioutil.ReadFile(checkpoint)
+			assert.Equal(t, "photo0\nphoto1\nphoto2\n"+cutOverMarker+"\n", string(data))
+		}
+	})
+
+	t.Run("with checkpoint, records cut over for a restarted migration", func(t *testing.T) {
+		photos, albums := createMigration(t)
+		fillSource(t, photos, albums, 2)
+		checkpoint := path.Join(tempDir(t), "checkpoint")
+
+		if err := (&Migrator{Photos: photos, Albums: albums, Checkpoint: checkpoint}).Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := photos.Save(context.Background(), *photo.Of(*photo.IdentifierOf("photo0"), []byte("updated"))); err != nil {
+			t.Fatal(err)
+		}
+
+		restarted := NewRepository(photos.source, photos.target)
+		var progressed bool
+		migrator := &Migrator{Photos: restarted, Checkpoint: checkpoint, OnProgress: func(Progress) { progressed = true }}
+		if assert.NoError(t, migrator.Run(context.Background())) {
+			assert.True(t, restarted.IsCutOver())
+			assert.False(t, progressed)
+
+			stored, err := restarted.Read(context.Background(), *photo.IdentifierOf("photo0"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, []byte("updated"), stored.Image())
+			}
+		}
+	})
+
+	t.Run("with stale checkpoint, fails verification and keeps reading source", func(t *testing.T) {
+		photos, albums := createMigration(t)
+		fillSource(t, photos, albums, 2)
+		checkpoint := path.Join(tempDir(t), "checkpoint")
+		if err := ioutil.WriteFile(checkpoint, []byte("photo0\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		migrator := &Migrator{Photos: photos, Checkpoint: checkpoint}
+		err := migrator.Run(context.Background())
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), ErrVerificationFailed.Error())
+			assert.False(t, photos.IsCutOver())
+		}
+	})
+
+	t.Run("with cancelled context, stops without cutting over", func(t *testing.T) {
+		photos, albums := createMigration(t)
+		fillSource(t, photos, albums, 3)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		migrator := &Migrator{Photos: photos, Albums: albums}
+		assert.Equal(t, context.Canceled, migrator.Run(ctx))
+		assert.False(t, photos.IsCutOver())
+	})
+}
+
+func fillSource(tb testing.TB, photos *Repository, albums *AlbumRepository, n int) {
+	tb.Helper()
+	var ids []photo.Identifier
+	for i := 0; i < n; i++ {
+		id := photo.IdentifierOf(fmt.Sprintf("photo%d", i))
+		if _, err := photos.source.Save(context.Background(), *photo.Of(*id, []byte(id.Value()), "tag")); err != nil {
+			tb.Fatal(err)
+		}
+		ids = append(ids, *id)
+	}
+	if _, err := albums.source.Save(context.Background(), *album.Of(*album.IdentifierOf("album"), "title", "", nil, ids)); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func createMigration(tb testing.TB) (*Repository, *AlbumRepository) {
+	tb.Helper()
+	source := file_storage.New(tempDir(tb))
+	target := file_storage.New(tempDir(tb))
+	photos := NewRepository(source, target)
+	return photos, NewAlbumRepository(file_storage.NewAlbumStorage(source), file_storage.NewAlbumStorage(target), photos)
+}
+
+func tempDir(tb testing.TB) string {
+	tb.Helper()
+	dir, err := ioutil.TempDir("", "migration")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
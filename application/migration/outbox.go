@@ -0,0 +1,25 @@
+package migration
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+)
+
+// MoveEvents appends the events pending in the source outbox to the target outbox and removes them from
+// the source, so that they are delivered from the target. An event moved but not removed is moved again,
+// which is fine as events may be delivered more than once.
+func MoveEvents(ctx context.Context, source event.Outbox, target event.Outbox) (int, error) {
+	records, err := source.Pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, record := range records {
+		if err := target.Append(ctx, record.Event); err != nil {
+			return i, err
+		}
+		if err := source.Remove(ctx, record.Sequence); err != nil {
+			return i + 1, err
+		}
+	}
+	return len(records), nil
+}
@@ -0,0 +1,48 @@
+package migration
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMoveEvents(t *testing.T) {
+	t.Run("moves pending events in order", func(t *testing.T) {
+		source, target := memory_storage.NewOutbox(), memory_storage.NewOutbox()
+		occurredAt := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+		events := []event.Event{
+			event.PhotoCreated{Id: *photo.IdentifierOf("a"), Tags: []string{"cat"}, OccurredAt: occurredAt},
+			event.PhotoDeleted{Id: *photo.IdentifierOf("b"), OccurredAt: occurredAt},
+		}
+		for _, e := range events {
+			if err := source.Append(context.Background(), e); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		moved, err := MoveEvents(context.Background(), source, target)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, moved)
+		}
+
+		pending, _ := source.Pending(context.Background())
+		assert.Empty(t, pending)
+		pending, _ = target.Pending(context.Background())
+		var actual []event.Event
+		for _, record := range pending {
+			actual = append(actual, record.Event)
+		}
+		assert.Equal(t, events, actual)
+	})
+
+	t.Run("with nothing pending, moves nothing", func(t *testing.T) {
+		moved, err := MoveEvents(context.Background(), memory_storage.NewOutbox(), memory_storage.NewOutbox())
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, moved)
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"hash/fnv"
+	"io"
+	"reflect"
+	"sync"
+)
+
+const lockStripes = 64
+
+type Repository struct {
+	source photo.Repository
+	target photo.Repository
+
+	// follows is the migration whose cut-over the repository follows, nil for the migration itself.
+	follows *Repository
+
+	cutOver bool
+	stateMu sync.RWMutex
+	locks   [lockStripes]sync.Mutex
+}
+
+func NewRepository(source photo.Repository, target photo.Repository) *Repository {
+	return &Repository{source: source, target: target}
+}
+
+// Under returns a repository over source and target which cuts over along with this one, to read the
+// repositories under the migrated ones, such as the storages under encryption which the scrubber verifies.
+func (repository *Repository) Under(source photo.Repository, target photo.Repository) *Repository {
+	return &Repository{source: source, target: target, follows: repository}
+}
+
+func (repository *Repository) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	id := photograph.Id()
+	if photograph.IsNew() {
+		id = photo.NewIdentifier(photograph.Image())
+	}
+	photograph = *photo.Of(*id, photograph.Image(), photograph.Tags()...)
+
+	unlock := repository.lock(*id)
+	defer unlock()
+
+	if repository.IsCutOver() {
+		return repository.target.Save(ctx, photograph)
+	}
+	if _, err := repository.source.Save(ctx, photograph); err != nil {
+		return nil, err
+	}
+	if _, err := repository.target.Save(ctx, photograph); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (repository *Repository) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	return repository.reader().Read(ctx, id)
+}
+
+func (repository *Repository) Open(ctx context.Context, id photo.Identifier) (io.ReadCloser, error) {
+	return photo.Open(ctx, repository.reader(), id)
+}
+
+func (repository *Repository) Peek(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	return photo.Peek(ctx, repository.reader(), id)
+}
+
+func (repository *Repository) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	return photo.TagsOf(ctx, repository.reader(), id)
+}
+
+func (repository *Repository) Delete(ctx context.Context, id photo.Identifier) error {
+	unlock := repository.lock(id)
+	defer unlock()
+
+	if repository.IsCutOver() {
+		return repository.target.Delete(ctx, id)
+	}
+	if err := repository.source.Delete(ctx, id); err != nil {
+		return err
+	}
+	return repository.target.Delete(ctx, id)
+}
+
+func (repository *Repository) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	return repository.reader().FindAll(ctx)
+}
+
+func (repository *Repository) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	return repository.reader().FindByTag(ctx, tag)
+}
+
+func (repository *Repository) Close() error {
+	err := repository.source.Close()
+	if e := repository.target.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (repository *Repository) Ping(ctx context.Context) error {
+	if err := photo.Ping(ctx, repository.source); err != nil {
+		return err
+	}
+	return photo.Ping(ctx, repository.target)
+}
+
+func (repository *Repository) Checksums(ctx context.Context) (map[photo.Identifier]string, error) {
+	checksums, ok := repository.reader().(photo.ChecksumRepository)
+	if !ok {
+		return nil, photo.ErrNoChecksums
+	}
+	return checksums.Checksums(ctx)
+}
+
+func (repository *Repository) ChecksumOf(ctx context.Context, id photo.Identifier) (string, error) {
+	checksums, ok := repository.reader().(photo.ChecksumRepository)
+	if !ok {
+		return "", photo.ErrNoChecksums
+	}
+	return checksums.ChecksumOf(ctx, id)
+}
+
+func (repository *Repository) Stats(ctx context.Context) (*photo.Stats, error) {
+	return photo.StatsOf(ctx, repository.reader())
+}
+
+func (repository *Repository) CutOver() {
+	repository.stateMu.Lock()
+	defer repository.stateMu.Unlock()
+	repository.cutOver = true
+}
+
+func (repository *Repository) copy(ctx context.Context, id photo.Identifier) (bool, error) {
+	unlock := repository.lock(id)
+	defer unlock()
+
+	photograph, err := photo.Peek(ctx, repository.source, id)
+	if isNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if _, err := repository.target.Save(ctx, *photograph); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (repository *Repository) verify(ctx context.Context, id photo.Identifier) (bool, error) {
+	unlock := repository.lock(id)
+	defer unlock()
+
+	expected, err := photo.Peek(ctx, repository.source, id)
+	if isNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	actual, err := photo.Peek(ctx, repository.target, id)
+	if isNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return bytes.Equal(expected.Image(), actual.Image()) && reflect.DeepEqual(expected.Tags(), actual.Tags()), nil
+}
+
+func (repository *Repository) reader() photo.Repository {
+	if repository.IsCutOver() {
+		return repository.target
+	}
+	return repository.source
+}
+
+func (repository *Repository) IsCutOver() bool {
+	if repository.follows != nil {
+		return repository.follows.IsCutOver()
+	}
+	repository.stateMu.RLock()
+	defer repository.stateMu.RUnlock()
+	return repository.cutOver
+}
+
+func (repository *Repository) lock(id photo.Identifier) func() {
+	h := fnv.New32a()
+	h.Write([]byte(id.Value()))
+	mu := &repository.locks[h.Sum32()%lockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+type AlbumRepository struct {
+	source album.Repository
+	target album.Repository
+
+	photos *Repository
+	mu     sync.Mutex
+}
+
+func NewAlbumRepository(source album.Repository, target album.Repository, photos *Repository) *AlbumRepository {
+	return &AlbumRepository{source: source, target: target, photos: photos}
+}
+
+func (repository *AlbumRepository) Save(ctx context.Context, a album.Album) (*album.Identifier, error) {
+	id := a.Id()
+	if a.IsNew() {
+		id = album.NewIdentifier()
+	}
+	a = *album.Of(*id, a.Title(), a.Description(), a.Cover(), a.Photos())
+
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+
+	if repository.photos.IsCutOver() {
+		return repository.target.Save(ctx, a)
+	}
+	if _, err := repository.source.Save(ctx, a); err != nil {
+		return nil, err
+	}
+	if _, err := repository.target.Save(ctx, a); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (repository *AlbumRepository) Read(ctx context.Context, id album.Identifier) (*album.Album, error) {
+	return repository.reader().Read(ctx, id)
+}
+
+func (repository *AlbumRepository) ReadAll(ctx context.Context) ([]album.Album, error) {
+	return repository.reader().ReadAll(ctx)
+}
+
+func (repository *AlbumRepository) Delete(ctx context.Context, id album.Identifier) error {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+
+	if repository.photos.IsCutOver() {
+		return repository.target.Delete(ctx, id)
+	}
+	if err := repository.source.Delete(ctx, id); err != nil {
+		return err
+	}
+	return repository.target.Delete(ctx, id)
+}
+
+func (repository *AlbumRepository) copyAll(ctx context.Context) (int, error) {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+
+	albums, err := repository.source.ReadAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, a := range albums {
+		if _, err := repository.target.Save(ctx, a); err != nil {
+			return 0, err
+		}
+	}
+	return len(albums), nil
+}
+
+func (repository *AlbumRepository) reader() album.Repository {
+	if repository.photos.IsCutOver() {
+		return repository.target
+	}
+	return repository.source
+}
+
+func isNotFound(err error) bool {
+	if e, ok := err.(*photo.ResourceError); ok {
+		return e.Err == photo.ErrNotFound
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRepository(t *testing.T) {
+	t.Run("before cut over, writes to both and reads from source", func(t *testing.T) {
+		photos, _ := createMigration(t)
+		id, err := photos.Save(context.Background(), *photo.New([]byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, repository := range []photo.Repository{photos.source, photos.target, photos} {
+			actual, err := repository.Read(context.Background(), *id)
+			if assert.NoError(t, err) {
+				assert.Equal(t, []byte("data"), actual.Image())
+			}
+		}
+
+		if assert.NoError(t, photos.Delete(context.Background(), *id)) {
+			_, err := photos.target.Read(context.Background(), *id)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("delete missing in target, succeeds", func(t *testing.T) {
+		photos, _ := createMigration(t)
+		id := photo.IdentifierOf("only_source")
+		if _, err := photos.source.Save(context.Background(), *photo.Of(*id, []byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NoError(t, photos.Delete(context.Background(), *id))
+	})
+
+	t.Run("after cut over, reads and writes target only", func(t *testing.T) {
+		photos, _ := createMigration(t)
+		photos.CutOver()
+		id, err := photos.Save(context.Background(), *photo.New([]byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = photos.source.Read(context.Background(), *id)
+		assert.Error(t, err)
+		actual, err := photos.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("data"), actual.Image())
+		}
+	})
+}
+
+func TestRepository_Under(t *testing.T) {
+	photos, _ := createMigration(t)
+	rawSource, rawTarget := file_storage.New(tempDir(t)), file_storage.New(tempDir(t))
+	under := photos.Under(rawSource, rawTarget)
+	if _, err := rawSource.Save(context.Background(), *photo.Of(*photo.IdentifierOf("source"), []byte("data"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rawTarget.Save(context.Background(), *photo.Of(*photo.IdentifierOf("target"), []byte("data"))); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := under.FindAll(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("source")}, ids)
+	}
+
+	photos.CutOver()
+	ids, err = under.FindAll(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("target")}, ids)
+	}
+}
+
+func TestRepository_Conformance(t *testing.T) {
+	for _, cutOver := range []bool{false, true} {
+		t.Run(fmt.Sprintf("cut over %v", cutOver), func(t *testing.T) {
+			phototest.RunRepositoryTests(t, func(t *testing.T) photo.Repository {
+				photos, _ := createMigration(t)
+				if cutOver {
+					photos.CutOver()
+				}
+				return photos
+			})
+		})
+	}
+}
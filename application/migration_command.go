@@ -0,0 +1,71 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/application/migration"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"log/slog"
+	"sync"
+)
+
+const progressInterval = 1000
+
+// startMigration migrates source to the storage to migrate to, and returns the migrated repositories
+// along with the target storage, whose stores the caller closes.
+func startMigration(ctx context.Context, running *sync.WaitGroup, configuration *Configuration, source photo.Repository, albumSource album.Repository) (*migration.Repository, album.Repository, *backend, error) {
+	migrate := configuration.Storage.Migrate
+	if migrate.Type == configuration.Storage.Type && migrate.Path == configuration.Storage.Path {
+		return nil, nil, nil, fmt.Errorf("migration target is same as source : %s", migrate.Path)
+	}
+
+	targetStorage, err := openStorage(migrate.StorageConfiguration)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	target, err := encryptStorage(configuration, targetStorage.photos)
+	if err != nil {
+		closeBackends(targetStorage)
+		return nil, nil, nil, err
+	}
+
+	photos := migration.NewRepository(source, target)
+	albums := migration.NewAlbumRepository(albumSource, targetStorage.albums, photos)
+	migrator := &migration.Migrator{
+		Photos:     photos,
+		Albums:     albums,
+		Workers:    migrate.Workers,
+		Checkpoint: migrate.Checkpoint,
+		OnProgress: logProgress,
+	}
+	cutOver, err := migrator.Restore()
+	if err != nil {
+		target.Close()
+		targetStorage.closeStores()
+		return nil, nil, nil, err
+	}
+	if cutOver {
+		slog.Info("migration already cut over", "type", migrate.Type, "path", migrate.Path)
+		return photos, albums, targetStorage, nil
+	}
+
+	running.Add(1)
+	go func() {
+		defer running.Done()
+		if err := migrator.Run(ctx); err != nil {
+			slog.Error("migration failed", "error", err)
+			return
+		}
+		slog.Info("migration finished", "type", migrate.Type, "path", migrate.Path)
+	}()
+
+	return photos, albums, targetStorage, nil
+}
+
+func logProgress(progress migration.Progress) {
+	processed := progress.Copied + progress.Skipped
+	if processed%progressInterval == 0 || processed == progress.Total {
+		slog.Info("migration progress", "processed", processed, "total", progress.Total, "copied", progress.Copied, "skipped", progress.Skipped)
+	}
+}
@@ -5,8 +5,10 @@
 package mock_service
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	photo "github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	io "io"
 	reflect "reflect"
 )
 
@@ -34,39 +36,102 @@ func (m *MockPhotoService) EXPECT() *MockPhotoServiceMockRecorder {
 }
 
 // Save mocks base method
-func (m *MockPhotoService) Save(photograph photo.Photo) (*photo.Identifier, error) {
-	ret := m.ctrl.Call(m, "Save", photograph)
+func (m *MockPhotoService) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	ret := m.ctrl.Call(m, "Save", ctx, photograph)
 	ret0, _ := ret[0].(*photo.Identifier)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Save indicates an expected call of Save
-func (mr *MockPhotoServiceMockRecorder) Save(photo interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockPhotoService)(nil).Save), photo)
+func (mr *MockPhotoServiceMockRecorder) Save(ctx, photo interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockPhotoService)(nil).Save), ctx, photo)
 }
 
 // Find mocks base method
-func (m *MockPhotoService) Find(id photo.Identifier) (*photo.Photo, error) {
-	ret := m.ctrl.Call(m, "Find", id)
+func (m *MockPhotoService) Find(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	ret := m.ctrl.Call(m, "Find", ctx, id)
 	ret0, _ := ret[0].(*photo.Photo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Find indicates an expected call of Find
-func (mr *MockPhotoServiceMockRecorder) Find(id interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockPhotoService)(nil).Find), id)
+func (mr *MockPhotoServiceMockRecorder) Find(ctx, id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockPhotoService)(nil).Find), ctx, id)
+}
+
+// Open mocks base method
+func (m *MockPhotoService) Open(ctx context.Context, id photo.Identifier) (io.ReadCloser, error) {
+	ret := m.ctrl.Call(m, "Open", ctx, id)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open
+func (mr *MockPhotoServiceMockRecorder) Open(ctx, id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockPhotoService)(nil).Open), ctx, id)
 }
 
 // Delete mocks base method
-func (m *MockPhotoService) Delete(id photo.Identifier) error {
-	ret := m.ctrl.Call(m, "Delete", id)
+func (m *MockPhotoService) Delete(ctx context.Context, id photo.Identifier) error {
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete
-func (mr *MockPhotoServiceMockRecorder) Delete(id interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPhotoService)(nil).Delete), id)
+func (mr *MockPhotoServiceMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPhotoService)(nil).Delete), ctx, id)
+}
+
+// AddTag mocks base method
+func (m *MockPhotoService) AddTag(ctx context.Context, id photo.Identifier, tag string) error {
+	ret := m.ctrl.Call(m, "AddTag", ctx, id, tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddTag indicates an expected call of AddTag
+func (mr *MockPhotoServiceMockRecorder) AddTag(ctx, id, tag interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTag", reflect.TypeOf((*MockPhotoService)(nil).AddTag), ctx, id, tag)
+}
+
+// Tags mocks base method
+func (m *MockPhotoService) Tags(ctx context.Context, id photo.Identifier) ([]string, error) {
+	ret := m.ctrl.Call(m, "Tags", ctx, id)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tags indicates an expected call of Tags
+func (mr *MockPhotoServiceMockRecorder) Tags(ctx, id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tags", reflect.TypeOf((*MockPhotoService)(nil).Tags), ctx, id)
+}
+
+// RemoveTag mocks base method
+func (m *MockPhotoService) RemoveTag(ctx context.Context, id photo.Identifier, tag string) error {
+	ret := m.ctrl.Call(m, "RemoveTag", ctx, id, tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveTag indicates an expected call of RemoveTag
+func (mr *MockPhotoServiceMockRecorder) RemoveTag(ctx, id, tag interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveTag", reflect.TypeOf((*MockPhotoService)(nil).RemoveTag), ctx, id, tag)
+}
+
+// FindByTags mocks base method
+func (m *MockPhotoService) FindByTags(ctx context.Context, tags []string, matchAll bool) ([]photo.Identifier, error) {
+	ret := m.ctrl.Call(m, "FindByTags", ctx, tags, matchAll)
+	ret0, _ := ret[0].([]photo.Identifier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByTags indicates an expected call of FindByTags
+func (mr *MockPhotoServiceMockRecorder) FindByTags(ctx, tags, matchAll interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByTags", reflect.TypeOf((*MockPhotoService)(nil).FindByTags), ctx, tags, matchAll)
 }
@@ -0,0 +1,57 @@
+package application
+
+import (
+	"fmt"
+	"os"
+)
+
+func Reencrypt(args ...string) error {
+	configuration := &Configuration{}
+
+	flg := newFlagSet("reencrypt", configuration)
+	flg.StringVar(
+		&configuration.Storage.Encryption.KeyFile,
+		"key-file",
+		"",
+		"encryption key file path",
+	)
+	if err := parse(flg, configuration, args); err != nil {
+		return err
+	}
+	if err := configuration.validateStorage(); err != nil {
+		return err
+	}
+
+	if configuration.Storage.Encryption.KeyFile == "" {
+		return fmt.Errorf("key file is required")
+	}
+	opened, err := openStorage(configuration.Storage.StorageConfiguration)
+	if err != nil {
+		return err
+	}
+	defer opened.photos.Close()
+	storage, err := newEncryptedStorage(configuration, opened.photos)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+	ids, err := storage.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	var reencrypted int
+	for _, id := range ids {
+		changed, err := storage.Reencrypt(ctx, id)
+		if err != nil {
+			return err
+		}
+		if changed {
+			reencrypted++
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "reencrypted: %d, unchanged: %d\n", reencrypted, len(ids)-reencrypted)
+	return nil
+}
@@ -0,0 +1,44 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestReencrypt(t *testing.T) {
+	t.Run("with plaintext photos, encrypts them with current key", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "reencrypt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		storage := file_storage.New(dir)
+		id := photo.IdentifierOf("legacy")
+		if _, err := storage.Save(context.Background(), *photo.Of(*id, []byte("plain image"))); err != nil {
+			t.Fatal(err)
+		}
+		keyFile := path.Join(dir, ".keys")
+		if err := ioutil.WriteFile(keyFile, []byte("key MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if assert.NoError(t, Reencrypt("-t", "file", "-s", dir, "-key-file", keyFile)) {
+			stored, err := storage.Read(context.Background(), *id)
+			if assert.NoError(t, err) {
+				assert.False(t, bytes.Contains(stored.Image(), []byte("plain image")))
+			}
+		}
+	})
+
+	t.Run("without key file, returns error", func(t *testing.T) {
+		assert.Error(t, Reencrypt("-t", "file"))
+	})
+}
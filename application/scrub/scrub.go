@@ -0,0 +1,241 @@
+package scrub
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Report struct {
+	Started  time.Time    `json:"started"`
+	Finished time.Time    `json:"finished"`
+	Checked  int          `json:"checked"`
+	Corrupt  []Corruption `json:"corrupt"`
+	Missing  []string     `json:"missing"`
+	Orphaned []string     `json:"orphaned"`
+}
+
+type Corruption struct {
+	Id       string `json:"id"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (report *Report) Healthy() bool {
+	return len(report.Corrupt) == 0 && len(report.Missing) == 0 && len(report.Orphaned) == 0
+}
+
+// Check verifies a stored photo beyond its checksum, like whether it can be decrypted.
+type Check func(stored photo.Photo) error
+
+func Verify(ctx context.Context, repository photo.Repository, rate int, checks ...Check) (*Report, error) {
+	checksums, ok := repository.(photo.ChecksumRepository)
+	if !ok {
+		return nil, photo.ErrNoChecksums
+	}
+
+	report := &Report{Started: time.Now(), Corrupt: []Corruption{}, Missing: []string{}, Orphaned: []string{}}
+
+	expected, err := checksums.Checksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := repository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var throttle <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(rate))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	found := make(map[photo.Identifier]bool)
+	for _, id := range ids {
+		found[id] = true
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if throttle != nil {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-throttle:
+			}
+		}
+		report.Checked++
+
+		if err := inspect(ctx, report, repository, checksums, id, checks); err != nil {
+			return nil, err
+		}
+	}
+	for id := range expected {
+		if found[id] {
+			continue
+		}
+		if err := inspect(ctx, report, repository, checksums, id, checks); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(report.Missing)
+
+	report.Finished = time.Now()
+	return report, nil
+}
+
+// finding is what is wrong with a photo, one of its fields being set.
+type finding struct {
+	missing    bool
+	orphaned   bool
+	corruption *Corruption
+}
+
+// inspect compares a photo with the checksum recorded for it, both read at check time, so that photos written
+// or deleted since they were listed aren't reported. A photo found unhealthy is examined once more,
+// in case it was being written meanwhile.
+func inspect(ctx context.Context, report *Report, repository photo.Repository, checksums photo.ChecksumRepository, id photo.Identifier, checks []Check) error {
+	f, err := examine(ctx, repository, checksums, id, checks)
+	if err != nil || f == nil {
+		return err
+	}
+	f, err = examine(ctx, repository, checksums, id, checks)
+	if err != nil || f == nil {
+		return err
+	}
+
+	switch {
+	case f.missing:
+		report.Missing = append(report.Missing, id.Value())
+	case f.orphaned:
+		report.Orphaned = append(report.Orphaned, id.Value())
+	default:
+		report.Corrupt = append(report.Corrupt, *f.corruption)
+	}
+	return nil
+}
+
+// examine returns what is wrong with a photo, nil when it is healthy or deleted.
+func examine(ctx context.Context, repository photo.Repository, checksums photo.ChecksumRepository, id photo.Identifier, checks []Check) (*finding, error) {
+	photograph, readErr := photo.Peek(ctx, repository, id)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	checksum, err := checksums.ChecksumOf(ctx, id)
+	recorded := err == nil
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	switch {
+	case isNotFound(readErr) && !recorded:
+		return nil, nil
+	case isNotFound(readErr):
+		return &finding{missing: true}, nil
+	case readErr != nil:
+		return &finding{corruption: &Corruption{Id: id.Value(), Expected: checksum, Error: readErr.Error()}}, nil
+	case !recorded:
+		return &finding{orphaned: true}, nil
+	}
+	actual := photo.Checksum(photograph.Image())
+	if actual != checksum {
+		return &finding{corruption: &Corruption{Id: id.Value(), Expected: checksum, Actual: actual}}, nil
+	}
+	if err := runChecks(*photograph, checks); err != nil {
+		return &finding{corruption: &Corruption{Id: id.Value(), Expected: checksum, Actual: actual, Error: err.Error()}}, nil
+	}
+	return nil, nil
+}
+
+func isNotFound(err error) bool {
+	if e, ok := err.(*photo.ResourceError); ok {
+		return e.Err == photo.ErrNotFound
+	}
+	return false
+}
+
+func runChecks(stored photo.Photo, checks []Check) error {
+	for _, check := range checks {
+		if err := check(stored); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Scrubber struct {
+	Repository photo.Repository
+	Checks     []Check
+	Interval   time.Duration
+	Rate       int
+	Metrics    *metrics.Metrics
+
+	mu   sync.RWMutex
+	last *Report
+
+	running sync.WaitGroup
+}
+
+func (scrubber *Scrubber) Start(ctx context.Context) error {
+	if _, ok := scrubber.Repository.(photo.ChecksumRepository); !ok {
+		return photo.ErrNoChecksums
+	}
+
+	scrubber.running.Add(1)
+	go func() {
+		defer scrubber.running.Done()
+		for {
+			report, err := Verify(ctx, scrubber.Repository, scrubber.Rate, scrubber.Checks...)
+			if err == context.Canceled {
+				return
+			} else if err != nil {
+				slog.ErrorContext(ctx, "scrub failed", "error", err)
+			} else {
+				scrubber.record(report)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(scrubber.Interval):
+			}
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until the scrubbing started by Start stopped.
+func (scrubber *Scrubber) Wait() {
+	scrubber.running.Wait()
+}
+
+func (scrubber *Scrubber) LastReport() *Report {
+	scrubber.mu.RLock()
+	defer scrubber.mu.RUnlock()
+	return scrubber.last
+}
+
+func (scrubber *Scrubber) record(report *Report) {
+	if m := scrubber.Metrics; m != nil {
+		m.ScrubRuns.Inc()
+		m.ScrubPhotos.WithLabelValues("checked").Set(float64(report.Checked))
+		m.ScrubPhotos.WithLabelValues("corrupt").Set(float64(len(report.Corrupt)))
+		m.ScrubPhotos.WithLabelValues("missing").Set(float64(len(report.Missing)))
+		m.ScrubPhotos.WithLabelValues("orphaned").Set(float64(len(report.Orphaned)))
+	}
+	scrubber.mu.Lock()
+	scrubber.last = report
+	scrubber.mu.Unlock()
+
+	if !report.Healthy() {
+		slog.Warn("scrub found unhealthy photos",
+			"corrupt", len(report.Corrupt), "missing", len(report.Missing), "orphaned", len(report.Orphaned))
+	}
+}
@@ -0,0 +1,182 @@
+package scrub
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/photoshelf/photoshelf-storage/domain/model/mock_photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	t.Run("with healthy storage, returns healthy report", func(t *testing.T) {
+		repository, _ := createStorage(t, "first", "second")
+
+		report, err := Verify(context.Background(), repository, 0)
+		if assert.NoError(t, err) {
+			assert.True(t, report.Healthy())
+			assert.Equal(t, 2, report.Checked)
+		}
+	})
+
+	t.Run("with broken storage, reports corrupt, missing and orphaned", func(t *testing.T) {
+		repository, dir := createStorage(t, "corrupt", "missing", "healthy")
+		if err := ioutil.WriteFile(path.Join(dir, "corrupt"), []byte("bit rot"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Remove(path.Join(dir, "missing")); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(dir, "orphaned"), []byte("data"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := Verify(context.Background(), repository, 0)
+		if assert.NoError(t, err) {
+			assert.False(t, report.Healthy())
+			assert.Equal(t, []Corruption{{
+				Id:       "corrupt",
+				Expected: photo.Checksum([]byte("corrupt")),
+				Actual:   photo.Checksum([]byte("bit rot")),
+			}}, report.Corrupt)
+			assert.Equal(t, []string{"missing"}, report.Missing)
+			assert.Equal(t, []string{"orphaned"}, report.Orphaned)
+		}
+	})
+
+	t.Run("with photos written while verifying, does not report them", func(t *testing.T) {
+		storage, _ := createStorage(t, "changed", "deleted", "healthy")
+		repository := &changingRepository{FileStorage: storage, whileListing: func() {
+			save(t, storage, "created", []byte("created"))
+		}, afterListing: func() {
+			save(t, storage, "changed", []byte("new data"))
+			if err := storage.Delete(context.Background(), *photo.IdentifierOf("deleted")); err != nil {
+				t.Fatal(err)
+			}
+		}}
+
+		report, err := Verify(context.Background(), repository, 0)
+		if assert.NoError(t, err) {
+			assert.True(t, report.Healthy(), "%+v", report)
+		}
+	})
+
+	t.Run("with failing check, reports corrupt", func(t *testing.T) {
+		repository, _ := createStorage(t, "unreadable", "healthy")
+		check := func(stored photo.Photo) error {
+			if stored.Id().Value() == "unreadable" {
+				return photo.ErrNotFound
+			}
+			return nil
+		}
+
+		report, err := Verify(context.Background(), repository, 0, check)
+		if assert.NoError(t, err) {
+			checksum := photo.Checksum([]byte("unreadable"))
+			assert.Equal(t, []Corruption{{
+				Id:       "unreadable",
+				Expected: checksum,
+				Actual:   checksum,
+				Error:    photo.ErrNotFound.Error(),
+			}}, report.Corrupt)
+		}
+	})
+
+	t.Run("with rate, throttles reads", func(t *testing.T) {
+		repository, _ := createStorage(t, "first", "second", "third")
+
+		started := time.Now()
+		if _, err := Verify(context.Background(), repository, 20); err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, time.Since(started) >= 100*time.Millisecond)
+	})
+
+	t.Run("with cancelled context, stops", func(t *testing.T) {
+		repository, _ := createStorage(t, "first", "second")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := Verify(ctx, repository, 0)
+		assert.Equal(t, context.Canceled, err)
+	})
+
+	t.Run("with repository without checksums, returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		_, err := Verify(context.Background(), mock_photo.NewMockRepository(ctrl), 0)
+		assert.Equal(t, photo.ErrNoChecksums, err)
+	})
+}
+
+func TestScrubber_Start(t *testing.T) {
+	repository, _ := createStorage(t, "first")
+	m := metrics.New()
+	scrubber := &Scrubber{Repository: repository, Interval: time.Hour, Metrics: m}
+	assert.Nil(t, scrubber.LastReport())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := scrubber.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100 && scrubber.LastReport() == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if assert.NotNil(t, scrubber.LastReport()) {
+		assert.Equal(t, 1, scrubber.LastReport().Checked)
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.ScrubPhotos.WithLabelValues("checked")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.ScrubRuns))
+	}
+
+	cancel()
+	scrubber.Wait()
+}
+
+// changingRepository changes the storage while and after its photos are listed, once the checksums were read.
+type changingRepository struct {
+	*file_storage.FileStorage
+	whileListing func()
+	afterListing func()
+}
+
+func (repository *changingRepository) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	repository.whileListing()
+	ids, err := repository.FileStorage.FindAll(ctx)
+	repository.afterListing()
+	return ids, err
+}
+
+func save(tb testing.TB, repository photo.Repository, id string, data []byte) {
+	tb.Helper()
+	if _, err := repository.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), data)); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func createStorage(tb testing.TB, ids ...string) (*file_storage.FileStorage, string) {
+	tb.Helper()
+	dir, err := ioutil.TempDir("", "scrub")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+
+	storage := file_storage.New(dir)
+	for _, id := range ids {
+		if _, err := storage.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), []byte(id))); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return storage, dir
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/application/event_bus"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+)
+
+// AlbumCleaner removes deleted photos from the albums containing them, through the album service
+// so that it doesn't overwrite albums saved meanwhile.
+type AlbumCleaner struct {
+	albums AlbumService
+}
+
+func NewAlbumCleaner(albums AlbumService) *AlbumCleaner {
+	return &AlbumCleaner{albums}
+}
+
+func (cleaner *AlbumCleaner) Subscribe(bus *event_bus.Bus) {
+	bus.Subscribe("album cleaner", cleaner.handle)
+}
+
+func (cleaner *AlbumCleaner) handle(ctx context.Context, record event.Record) error {
+	deleted, ok := record.Event.(event.PhotoDeleted)
+	if !ok {
+		return nil
+	}
+	return cleaner.albums.RemovePhoto(ctx, deleted.Id)
+}
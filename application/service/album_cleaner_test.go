@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/photoshelf/photoshelf-storage/application/mock_service"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAlbumCleaner_handle(t *testing.T) {
+	t.Run("when photo deleted, removes photo from albums", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mock_album_service := mock_service.NewMockAlbumService(ctrl)
+		mock_album_service.EXPECT().
+			RemovePhoto(gomock.Any(), *id).
+			Return(nil)
+
+		cleaner := NewAlbumCleaner(mock_album_service)
+
+		assert.NoError(t, cleaner.handle(context.Background(), event.Record{Sequence: 1, Event: event.PhotoDeleted{Id: *id}}))
+	})
+
+	t.Run("when photo updated, does nothing", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cleaner := NewAlbumCleaner(mock_service.NewMockAlbumService(ctrl))
+
+		assert.NoError(t, cleaner.handle(context.Background(), event.Record{Sequence: 1, Event: event.PhotoUpdated{Id: *photo.IdentifierOf("id")}}))
+	})
+}
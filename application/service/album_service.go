@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"log/slog"
+	"sync"
+)
+
+type AlbumService interface {
+	// Save stores an album. Photos added to it must exist, or it returns ErrPhotoNotFound.
+	// An id given by a client must be valid, or it returns ErrInvalidIdentifier, as Find and Delete do.
+	Save(ctx context.Context, album album.Album) (*album.Identifier, error)
+	Find(ctx context.Context, id album.Identifier) (*album.Album, error)
+	FindAll(ctx context.Context) ([]album.Album, error)
+	Delete(ctx context.Context, id album.Identifier) error
+	// RemovePhoto removes a deleted photo from every album containing it.
+	RemovePhoto(ctx context.Context, id photo.Identifier) error
+}
+
+type albumServiceImpl struct {
+	Repository album.Repository
+	Photos     photo.Repository
+
+	// mu serializes the writes of albums, which read them before saving them.
+	mu sync.Mutex
+}
+
+func NewAlbumService(repository album.Repository, photos photo.Repository) AlbumService {
+	return &albumServiceImpl{Repository: repository, Photos: photos}
+}
+
+func (service *albumServiceImpl) Save(ctx context.Context, a album.Album) (*album.Identifier, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	var current *album.Album
+	if !a.IsNew() {
+		if err := album.ValidateIdentifier(*a.Id()); err != nil {
+			return nil, err
+		}
+		found, err := service.Repository.Read(ctx, *a.Id())
+		if err != nil && !isAlbumNotFound(err) {
+			return nil, err
+		}
+		current = found
+	}
+	for _, id := range a.Photos() {
+		if current != nil && current.Contains(id) {
+			continue
+		}
+		if err := service.checkPhoto(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return service.Repository.Save(ctx, a)
+}
+
+func (service *albumServiceImpl) Find(ctx context.Context, id album.Identifier) (*album.Album, error) {
+	if err := album.ValidateIdentifier(id); err != nil {
+		return nil, err
+	}
+	return service.Repository.Read(ctx, id)
+}
+
+func (service *albumServiceImpl) FindAll(ctx context.Context) ([]album.Album, error) {
+	return service.Repository.ReadAll(ctx)
+}
+
+func (service *albumServiceImpl) Delete(ctx context.Context, id album.Identifier) error {
+	if err := album.ValidateIdentifier(id); err != nil {
+		return err
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	return service.Repository.Delete(ctx, id)
+}
+
+func (service *albumServiceImpl) RemovePhoto(ctx context.Context, id photo.Identifier) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	albums, err := service.Repository.ReadAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range albums {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !a.Contains(id) {
+			continue
+		}
+		a.RemovePhoto(id)
+		if _, err := service.Repository.Save(ctx, a); err != nil {
+			return err
+		}
+		slog.DebugContext(ctx, "photo removed from album", "photo_id", id.Value(), "album_id", a.Id().Value())
+	}
+	return nil
+}
+
+// checkPhoto tells whether a photo added to an album exists, without reading its image whole when the storage can stream it.
+func (service *albumServiceImpl) checkPhoto(ctx context.Context, id photo.Identifier) error {
+	reader, err := photo.Open(ctx, service.Photos, id)
+	if isNotFound(err) {
+		return &photo.ResourceError{Id: id, Err: album.ErrPhotoNotFound}
+	} else if err != nil {
+		return err
+	}
+	return reader.Close()
+}
+
+func isAlbumNotFound(err error) bool {
+	if e, ok := err.(*album.ResourceError); ok {
+		return e.Err == album.ErrNotFound
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/mock_album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/mock_photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAlbumServiceImpl_Save(t *testing.T) {
+	t.Run("when repository returns object, it returns object", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := album.IdentifierOf("id")
+		mock_repository := mock_album.NewMockRepository(ctrl)
+		mock_repository.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(id, nil)
+
+		album_service := NewAlbumService(mock_repository, mock_photo.NewMockRepository(ctrl))
+
+		actual, err := album_service.Save(context.Background(), *album.New("title", ""))
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, id, actual)
+		}
+	})
+
+	t.Run("when repository returns error, it returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mock_repository := mock_album.NewMockRepository(ctrl)
+		mock_repository.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("expected error"))
+
+		album_service := NewAlbumService(mock_repository, mock_photo.NewMockRepository(ctrl))
+
+		_, err := album_service.Save(context.Background(), *album.New("title", ""))
+		assert.Error(t, err)
+	})
+
+	t.Run("with photo added, checks that it exists", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := album.IdentifierOf("id")
+		current := album.Of(*id, "title", "", nil, []photo.Identifier{*photo.IdentifierOf("kept")})
+		saved := album.Of(*id, "title", "", nil, []photo.Identifier{*photo.IdentifierOf("kept"), *photo.IdentifierOf("added")})
+		mock_repository := mock_album.NewMockRepository(ctrl)
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *id).
+			Return(current, nil)
+		mock_repository.EXPECT().
+			Save(gomock.Any(), *saved).
+			Return(id, nil)
+		mock_photo_repository := mock_photo.NewMockRepository(ctrl)
+		mock_photo_repository.EXPECT().
+			Read(gomock.Any(), *photo.IdentifierOf("added")).
+			Return(photo.Of(*photo.IdentifierOf("added"), []byte("image")), nil)
+
+		album_service := NewAlbumService(mock_repository, mock_photo_repository)
+
+		_, err := album_service.Save(context.Background(), *saved)
+		assert.NoError(t, err)
+	})
+
+	t.Run("with photo which does not exist, returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		missing := photo.IdentifierOf("missing")
+		mock_photo_repository := mock_photo.NewMockRepository(ctrl)
+		mock_photo_repository.EXPECT().
+			Read(gomock.Any(), *missing).
+			Return(nil, &photo.ResourceError{Id: *missing, Err: photo.ErrNotFound})
+
+		album_service := NewAlbumService(mock_album.NewMockRepository(ctrl), mock_photo_repository)
+
+		a := album.New("title", "")
+		a.AddPhoto(*missing)
+		_, err := album_service.Save(context.Background(), *a)
+		if assert.Error(t, err) {
+			assert.Equal(t, album.ErrPhotoNotFound, err.(*photo.ResourceError).Err)
+		}
+	})
+
+	t.Run("with invalid identifier, returns ErrInvalidIdentifier", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		album_service := NewAlbumService(mock_album.NewMockRepository(ctrl), mock_photo.NewMockRepository(ctrl))
+
+		_, err := album_service.Save(context.Background(), *album.Of(*album.IdentifierOf("../../x"), "title", "", nil, nil))
+		assert.Equal(t, album.ErrInvalidIdentifier, err)
+	})
+}
+
+func TestAlbumServiceImpl_Find(t *testing.T) {
+	t.Run("when repository returns object, it returns object", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := album.Of(*album.IdentifierOf("id"), "title", "", nil, nil)
+		mock_repository := mock_album.NewMockRepository(ctrl)
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *album.IdentifierOf("id")).
+			Return(expected, nil)
+
+		album_service := NewAlbumService(mock_repository, mock_photo.NewMockRepository(ctrl))
+
+		actual, err := album_service.Find(context.Background(), *album.IdentifierOf("id"))
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, expected, actual)
+		}
+	})
+
+	t.Run("with invalid identifier, returns ErrInvalidIdentifier", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		album_service := NewAlbumService(mock_album.NewMockRepository(ctrl), mock_photo.NewMockRepository(ctrl))
+
+		_, err := album_service.Find(context.Background(), *album.IdentifierOf("../../x"))
+		assert.Equal(t, album.ErrInvalidIdentifier, err)
+	})
+}
+
+func TestAlbumServiceImpl_FindAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expected := []album.Album{*album.Of(*album.IdentifierOf("id"), "title", "", nil, nil)}
+	mock_repository := mock_album.NewMockRepository(ctrl)
+	mock_repository.EXPECT().
+		ReadAll(gomock.Any()).
+		Return(expected, nil)
+
+	album_service := NewAlbumService(mock_repository, mock_photo.NewMockRepository(ctrl))
+
+	actual, err := album_service.FindAll(context.Background())
+	if assert.NoError(t, err) {
+		assert.EqualValues(t, expected, actual)
+	}
+}
+
+func TestAlbumServiceImpl_Delete(t *testing.T) {
+	t.Run("when repository returns error, it returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mock_repository := mock_album.NewMockRepository(ctrl)
+		mock_repository.EXPECT().
+			Delete(gomock.Any(), *album.IdentifierOf("id")).
+			Return(errors.New("expected error"))
+
+		album_service := NewAlbumService(mock_repository, mock_photo.NewMockRepository(ctrl))
+
+		assert.Error(t, album_service.Delete(context.Background(), *album.IdentifierOf("id")))
+	})
+
+	t.Run("with invalid identifier, returns ErrInvalidIdentifier", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		album_service := NewAlbumService(mock_album.NewMockRepository(ctrl), mock_photo.NewMockRepository(ctrl))
+
+		assert.Equal(t, album.ErrInvalidIdentifier, album_service.Delete(context.Background(), *album.IdentifierOf("../../x")))
+	})
+}
+
+func TestAlbumServiceImpl_RemovePhoto(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := photo.IdentifierOf("id")
+	containing := album.Of(*album.IdentifierOf("containing"), "", "", id, []photo.Identifier{*photo.IdentifierOf("other"), *id})
+	other := album.Of(*album.IdentifierOf("other"), "", "", nil, []photo.Identifier{*photo.IdentifierOf("other")})
+	mock_repository := mock_album.NewMockRepository(ctrl)
+	mock_repository.EXPECT().
+		ReadAll(gomock.Any()).
+		Return([]album.Album{*containing, *other}, nil)
+	mock_repository.EXPECT().
+		Save(gomock.Any(), *album.Of(*album.IdentifierOf("containing"), "", "", nil, []photo.Identifier{*photo.IdentifierOf("other")})).
+		Return(album.IdentifierOf("containing"), nil)
+
+	album_service := NewAlbumService(mock_repository, mock_photo.NewMockRepository(ctrl))
+
+	assert.NoError(t, album_service.RemovePhoto(context.Background(), *id))
+}
+
+func TestAlbumServiceImpl_RemovePhoto_concurrentSave(t *testing.T) {
+	photos := memory_storage.New(0)
+	for _, id := range []string{"kept", "added"} {
+		if _, err := photos.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), []byte(id))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	albums := &blockingAlbumRepository{MemoryAlbumStorage: memory_storage.NewAlbumStorage(), read: make(chan struct{}), release: make(chan struct{})}
+	id := album.IdentifierOf("id")
+	if _, err := albums.Save(context.Background(), *album.Of(*id, "", "", nil, []photo.Identifier{*photo.IdentifierOf("deleted"), *photo.IdentifierOf("kept")})); err != nil {
+		t.Fatal(err)
+	}
+	album_service := NewAlbumService(albums, photos)
+
+	removed := make(chan error)
+	go func() { removed <- album_service.RemovePhoto(context.Background(), *photo.IdentifierOf("deleted")) }()
+	<-albums.read
+	saved := make(chan error)
+	go func() {
+		_, err := album_service.Save(context.Background(), *album.Of(*id, "", "", nil, []photo.Identifier{*photo.IdentifierOf("kept"), *photo.IdentifierOf("added")}))
+		saved <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(albums.release)
+	assert.NoError(t, <-removed)
+	assert.NoError(t, <-saved)
+
+	actual, err := albums.Read(context.Background(), *id)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("kept"), *photo.IdentifierOf("added")}, actual.Photos())
+	}
+}
+
+// blockingAlbumRepository holds reading every album until released, once it has read them.
+type blockingAlbumRepository struct {
+	*memory_storage.MemoryAlbumStorage
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (repository *blockingAlbumRepository) ReadAll(ctx context.Context) ([]album.Album, error) {
+	albums, err := repository.MemoryAlbumStorage.ReadAll(ctx)
+	close(repository.read)
+	<-repository.release
+	return albums, err
+}
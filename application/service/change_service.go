@@ -0,0 +1,10 @@
+package service
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+)
+
+type ChangeService interface {
+	Watch(ctx context.Context, after *uint64, send func(event.Record) error) error
+}
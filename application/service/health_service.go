@@ -0,0 +1,8 @@
+package service
+
+import "context"
+
+type HealthService interface {
+	Ready(ctx context.Context) error
+	SetServing(serving bool)
+}
@@ -1,31 +1,266 @@
 package service
 
 import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
 )
 
+const instrumentationName = "github.com/photoshelf/photoshelf-storage/application/service"
+
 type PhotoService interface {
-	Save(photo photo.Photo) (*photo.Identifier, error)
-	Find(id photo.Identifier) (*photo.Photo, error)
-	Delete(id photo.Identifier) error
+	// Save stores a new photo, or replaces the image of an existing one. An existing photo keeps its tags,
+	// and the tags of the saved photo are added to them; AddTag and RemoveTag change them otherwise.
+	Save(ctx context.Context, photo photo.Photo) (*photo.Identifier, error)
+	Find(ctx context.Context, id photo.Identifier) (*photo.Photo, error)
+	// Open streams the image of a photo, from storages which can stream it.
+	Open(ctx context.Context, id photo.Identifier) (io.ReadCloser, error)
+	// Tags returns the tags of a photo, without reading its image from storages which keep them apart.
+	Tags(ctx context.Context, id photo.Identifier) ([]string, error)
+	Delete(ctx context.Context, id photo.Identifier) error
+	AddTag(ctx context.Context, id photo.Identifier, tag string) error
+	RemoveTag(ctx context.Context, id photo.Identifier, tag string) error
+	FindByTags(ctx context.Context, tags []string, matchAll bool) ([]photo.Identifier, error)
 }
 
+// lockStripes is how many locks the writes of photos are spread over.
+const lockStripes = 64
+
 type photoServiceImpl struct {
-	Repository photo.Repository `inject:""`
+	Repository photo.Repository
+	Publisher  event.Publisher
+
+	// locks serialize the writes of a photo, which read it to merge tags before saving it.
+	locks [lockStripes]sync.Mutex
+}
+
+func New(repository photo.Repository, publisher event.Publisher) PhotoService {
+	return &photoServiceImpl{Repository: repository, Publisher: publisher}
+}
+
+func (service *photoServiceImpl) Save(ctx context.Context, photograph photo.Photo) (id *photo.Identifier, err error) {
+	ctx, span := startSpan(ctx, "Save")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateTags(photograph.Tags()); err != nil {
+		return nil, err
+	}
+	isNew := photograph.IsNew()
+	if !isNew {
+		if err := photo.ValidateIdentifier(*photograph.Id()); err != nil {
+			return nil, err
+		}
+		unlock := service.lock(*photograph.Id())
+		defer unlock()
+
+		current, err := service.Repository.Read(ctx, *photograph.Id())
+		if isNotFound(err) {
+			isNew = true
+		} else if err != nil {
+			return nil, err
+		} else {
+			photograph = *photo.Of(*photograph.Id(), photograph.Image(), append(current.Tags(), photograph.Tags()...)...)
+		}
+	}
+	tags := photograph.Tags()
+	writeCtx, staged := service.Publisher.Stage(ctx, func(id photo.Identifier) event.Event {
+		if isNew {
+			return event.PhotoCreated{Id: id, Tags: tags, OccurredAt: time.Now()}
+		}
+		return event.PhotoUpdated{Id: id, Tags: tags, OccurredAt: time.Now()}
+	})
+	id, err = service.Repository.Save(writeCtx, photograph)
+	if err != nil {
+		return nil, err
+	}
+	service.publish(ctx, staged, *id)
+	slog.DebugContext(ctx, "photo saved", "photo_id", id.Value())
+	return id, nil
+}
+
+func (service *photoServiceImpl) Find(ctx context.Context, id photo.Identifier) (photograph *photo.Photo, err error) {
+	ctx, span := startSpan(ctx, "Find", attribute.String("photo.id", id.Value()))
+	defer func() { tracing.End(span, err) }()
+
+	return service.Repository.Read(ctx, id)
+}
+
+func (service *photoServiceImpl) Open(ctx context.Context, id photo.Identifier) (reader io.ReadCloser, err error) {
+	ctx, span := startSpan(ctx, "Open", attribute.String("photo.id", id.Value()))
+	defer func() { tracing.End(span, err) }()
+
+	return photo.Open(ctx, service.Repository, id)
+}
+
+func (service *photoServiceImpl) Tags(ctx context.Context, id photo.Identifier) (tags []string, err error) {
+	ctx, span := startSpan(ctx, "Tags", attribute.String("photo.id", id.Value()))
+	defer func() { tracing.End(span, err) }()
+
+	return photo.TagsOf(ctx, service.Repository, id)
+}
+
+func (service *photoServiceImpl) Delete(ctx context.Context, id photo.Identifier) (err error) {
+	ctx, span := startSpan(ctx, "Delete", attribute.String("photo.id", id.Value()))
+	defer func() { tracing.End(span, err) }()
+
+	unlock := service.lock(id)
+	defer unlock()
+
+	writeCtx, staged := service.Publisher.Stage(ctx, func(id photo.Identifier) event.Event {
+		return event.PhotoDeleted{Id: id, OccurredAt: time.Now()}
+	})
+	if err := service.Repository.Delete(writeCtx, id); err != nil {
+		return err
+	}
+	service.publish(ctx, staged, id)
+	slog.DebugContext(ctx, "photo deleted", "photo_id", id.Value())
+	return nil
+}
+
+func (service *photoServiceImpl) AddTag(ctx context.Context, id photo.Identifier, tag string) (err error) {
+	ctx, span := startSpan(ctx, "AddTag", attribute.String("photo.id", id.Value()), attribute.String("photo.tag", tag))
+	defer func() { tracing.End(span, err) }()
+
+	if err := photo.ValidateTag(tag); err != nil {
+		return err
+	}
+	unlock := service.lock(id)
+	defer unlock()
+
+	photograph, err := service.Repository.Read(ctx, id)
+	if err != nil {
+		return err
+	}
+	if photograph.HasTag(tag) {
+		return nil
+	}
+
+	photograph.AddTag(tag)
+	return service.update(ctx, *photograph)
+}
+
+func (service *photoServiceImpl) RemoveTag(ctx context.Context, id photo.Identifier, tag string) (err error) {
+	ctx, span := startSpan(ctx, "RemoveTag", attribute.String("photo.id", id.Value()), attribute.String("photo.tag", tag))
+	defer func() { tracing.End(span, err) }()
+
+	if err := photo.ValidateTag(tag); err != nil {
+		return err
+	}
+	unlock := service.lock(id)
+	defer unlock()
+
+	photograph, err := service.Repository.Read(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !photograph.HasTag(tag) {
+		return nil
+	}
+
+	photograph.RemoveTag(tag)
+	return service.update(ctx, *photograph)
+}
+
+func (service *photoServiceImpl) FindByTags(ctx context.Context, tags []string, matchAll bool) (ids []photo.Identifier, err error) {
+	ctx, span := startSpan(ctx, "FindByTags", attribute.StringSlice("photo.tags", tags), attribute.Bool("match_all", matchAll))
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateTags(tags); err != nil {
+		return nil, err
+	}
+	unique := make(map[string]bool)
+	var result []photo.Identifier
+	counts := make(map[photo.Identifier]int)
+	for _, tag := range tags {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if unique[tag] {
+			continue
+		}
+		unique[tag] = true
+
+		ids, err := service.Repository.FindByTag(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if counts[id] == 0 {
+				result = append(result, id)
+			}
+			counts[id]++
+		}
+	}
+
+	if !matchAll {
+		return result, nil
+	}
+
+	var matched []photo.Identifier
+	for _, id := range result {
+		if counts[id] == len(unique) {
+			matched = append(matched, id)
+		}
+	}
+	return matched, nil
+}
+
+func (service *photoServiceImpl) update(ctx context.Context, photograph photo.Photo) error {
+	tags := photograph.Tags()
+	writeCtx, staged := service.Publisher.Stage(ctx, func(id photo.Identifier) event.Event {
+		return event.PhotoUpdated{Id: id, Tags: tags, OccurredAt: time.Now()}
+	})
+	id, err := service.Repository.Save(writeCtx, photograph)
+	if err != nil {
+		return err
+	}
+	service.publish(ctx, staged, *id)
+	slog.DebugContext(ctx, "photo tags updated", "photo_id", id.Value(), "tags", photograph.Tags())
+	return nil
+}
+
+// lock serializes the writes of the photo, and returns the function releasing it.
+func (service *photoServiceImpl) lock(id photo.Identifier) func() {
+	h := fnv.New32a()
+	h.Write([]byte(id.Value()))
+	mu := &service.locks[h.Sum32()%lockStripes]
+	mu.Lock()
+	return mu.Unlock
 }
 
-func New() PhotoService {
-	return &photoServiceImpl{}
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if err := photo.ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (service *photoServiceImpl) Save(photo photo.Photo) (*photo.Identifier, error) {
-	return service.Repository.Save(photo)
+func startSpan(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, "PhotoService."+operation, trace.WithAttributes(attributes...))
 }
 
-func (service *photoServiceImpl) Find(id photo.Identifier) (*photo.Photo, error) {
-	return service.Repository.Read(id)
+// publish announces the event of a write which succeeded. Storages holding the outbox appended it along
+// with the write already; for the others, failing to append it doesn't fail the write which is persisted.
+func (service *photoServiceImpl) publish(ctx context.Context, staged *event.Staged, id photo.Identifier) {
+	if err := service.Publisher.Commit(ctx, staged, id); err != nil {
+		slog.ErrorContext(ctx, "event publish failed", "photo_id", id.Value(), "error", err)
+	}
 }
 
-func (service *photoServiceImpl) Delete(id photo.Identifier) error {
-	return service.Repository.Delete(id)
+func isNotFound(err error) bool {
+	if e, ok := err.(*photo.ResourceError); ok {
+		return e.Err == photo.ErrNotFound
+	}
+	return false
 }
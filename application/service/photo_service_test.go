@@ -1,13 +1,24 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"github.com/facebookgo/inject"
+	"fmt"
 	"github.com/golang/mock/gomock"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
 	"github.com/photoshelf/photoshelf-storage/domain/model/mock_photo"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"io/ioutil"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestPhotoServiceImpl_Find(t *testing.T) {
@@ -17,16 +28,14 @@ func TestPhotoServiceImpl_Find(t *testing.T) {
 
 		photograph := photo.Of(*photo.IdentifierOf("id"), []byte("test"))
 		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
 		mock_repository.EXPECT().
-			Read(gomock.Any()).
+			Read(gomock.Any(), gomock.Any()).
 			Return(photograph, nil)
 
-		photo_service := New()
-		if err := inject.Populate(photo_service, mock_repository); err != nil {
-			t.Fatal(err)
-		}
+		photo_service := New(mock_repository, publisher)
 
-		actual, err := photo_service.Find(*photo.IdentifierOf("any"))
+		actual, err := photo_service.Find(context.Background(), *photo.IdentifierOf("any"))
 		if assert.NoError(t, err) {
 			assert.EqualValues(t, photograph, actual)
 		}
@@ -37,39 +46,272 @@ func TestPhotoServiceImpl_Find(t *testing.T) {
 		defer ctrl.Finish()
 
 		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
 		mock_repository.EXPECT().
-			Read(gomock.Any()).
+			Read(gomock.Any(), gomock.Any()).
 			Return(nil, errors.New("expected error"))
 
-		photo_service := New()
-		if err := inject.Populate(photo_service, mock_repository); err != nil {
-			t.Fatal(err)
-		}
+		photo_service := New(mock_repository, publisher)
 
-		actual, err := photo_service.Find(*photo.IdentifierOf("any"))
+		actual, err := photo_service.Find(context.Background(), *photo.IdentifierOf("any"))
 		if assert.Error(t, err) {
 			assert.Nil(t, actual)
 		}
 	})
 }
 
+func TestPhotoServiceImpl_Open(t *testing.T) {
+	t.Run("when repository can't stream, it reads the photo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *photo.IdentifierOf("id")).
+			Return(photo.Of(*photo.IdentifierOf("id"), []byte("test")), nil)
+
+		photo_service := New(mock_repository, &fakePublisher{})
+
+		reader, err := photo_service.Open(context.Background(), *photo.IdentifierOf("id"))
+		if assert.NoError(t, err) {
+			defer reader.Close()
+			actual, err := ioutil.ReadAll(reader)
+			if assert.NoError(t, err) {
+				assert.Equal(t, []byte("test"), actual)
+			}
+		}
+	})
+
+	t.Run("when repository returns error, it returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		mock_repository.EXPECT().
+			Read(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("expected error"))
+
+		photo_service := New(mock_repository, &fakePublisher{})
+
+		_, err := photo_service.Open(context.Background(), *photo.IdentifierOf("any"))
+		assert.Error(t, err)
+	})
+}
+
+func TestPhotoServiceImpl_Tags(t *testing.T) {
+	t.Run("when repository reads tags alone, it doesn't read the photo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repository := &tagRepository{MockRepository: mock_photo.NewMockRepository(ctrl), tags: []string{"cat"}}
+		photo_service := New(repository, &fakePublisher{})
+
+		actual, err := photo_service.Tags(context.Background(), *photo.IdentifierOf("id"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"cat"}, actual)
+		}
+	})
+
+	t.Run("when repository can't read tags alone, it reads the photo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *photo.IdentifierOf("id")).
+			Return(photo.Of(*photo.IdentifierOf("id"), []byte("test"), "cat"), nil)
+
+		photo_service := New(mock_repository, &fakePublisher{})
+
+		actual, err := photo_service.Tags(context.Background(), *photo.IdentifierOf("id"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"cat"}, actual)
+		}
+	})
+}
+
+type tagRepository struct {
+	*mock_photo.MockRepository
+	tags []string
+}
+
+func (repository *tagRepository) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	return repository.tags, nil
+}
+
+func TestPhotoServiceImpl_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock_repository := mock_photo.NewMockRepository(ctrl)
+	mock_repository.EXPECT().
+		Read(gomock.Any(), gomock.Any()).
+		Do(func(ctx context.Context, id photo.Identifier) {
+			assert.True(t, trace.SpanContextFromContext(ctx).IsValid())
+		}).
+		Return(nil, errors.New("expected error"))
+
+	photo_service := New(mock_repository, &fakePublisher{})
+
+	photo_service.Find(context.Background(), *photo.IdentifierOf("id"))
+	if assert.Len(t, recorder.Ended(), 1) {
+		span := recorder.Ended()[0]
+		assert.Equal(t, "PhotoService.Find", span.Name())
+		assert.Equal(t, codes.Error, span.Status().Code)
+	}
+}
+
 func TestPhotoServiceImpl_Save(t *testing.T) {
+	t.Run("with invalid tag, returns ErrInvalidTag without saving", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		photo_service := New(mock_photo.NewMockRepository(ctrl), &fakePublisher{})
+
+		_, err := photo_service.Save(context.Background(), *photo.Of(*photo.IdentifierOf("id"), []byte("test"), ".."))
+		assert.Equal(t, photo.ErrInvalidTag, err)
+	})
+
+	t.Run("with invalid identifier, returns ErrInvalidIdentifier without saving", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		photo_service := New(mock_photo.NewMockRepository(ctrl), &fakePublisher{})
+
+		_, err := photo_service.Save(context.Background(), *photo.Of(*photo.IdentifierOf("checksums:id"), []byte("test")))
+		assert.Equal(t, photo.ErrInvalidIdentifier, err)
+	})
+
 	t.Run("when repository returns object, it returns object", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		id := photo.IdentifierOf("id")
 		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *id).
+			Return(photo.Of(*id, []byte("old")), nil)
 		mock_repository.EXPECT().
-			Save(gomock.Any()).
+			Save(gomock.Any(), gomock.Any()).
 			Return(id, nil)
 
-		photo_service := New()
-		if err := inject.Populate(photo_service, mock_repository); err != nil {
-			t.Fatal(err)
+		photo_service := New(mock_repository, publisher)
+
+		actual, err := photo_service.Save(context.Background(), *photo.Of(*id, nil))
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, id, actual)
+			assert.Equal(t, []string{"photo.updated id"}, publisher.committed())
 		}
+	})
 
-		actual, err := photo_service.Save(*photo.Of(*id, nil))
+	t.Run("when photo is new, publishes created event", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(id, nil)
+
+		photo_service := New(mock_repository, publisher)
+
+		actual, err := photo_service.Save(context.Background(), *photo.New([]byte("data")))
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, id, actual)
+			assert.Equal(t, []string{"photo.created id"}, publisher.committed())
+		}
+	})
+
+	t.Run("when photo exists, keeps its tags and adds the saved ones", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *id).
+			Return(photo.Of(*id, []byte("old"), "kept"), nil)
+		mock_repository.EXPECT().
+			Save(gomock.Any(), *photo.Of(*id, []byte("new"), "added", "kept")).
+			Return(id, nil)
+
+		photo_service := New(mock_repository, &fakePublisher{})
+
+		_, err := photo_service.Save(context.Background(), *photo.Of(*id, []byte("new"), "added"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("when photo with identifier doesn't exist, publishes created event", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *id).
+			Return(nil, &photo.ResourceError{Id: *id, Err: photo.ErrNotFound})
+		mock_repository.EXPECT().
+			Save(gomock.Any(), *photo.Of(*id, []byte("data"), "tag")).
+			Return(id, nil)
+
+		photo_service := New(mock_repository, publisher)
+
+		_, err := photo_service.Save(context.Background(), *photo.Of(*id, []byte("data"), "tag"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"photo.created id"}, publisher.committed())
+		}
+	})
+
+	t.Run("writes under the context staging the event", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *id).
+			Return(photo.Of(*id, []byte("old")), nil)
+		mock_repository.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Do(func(ctx context.Context, photograph photo.Photo) {
+				if staged := event.StagedIn(ctx); assert.NotNil(t, staged) {
+					assert.Equal(t, event.TypePhotoUpdated, staged.Event(*id).Type())
+				}
+			}).
+			Return(id, nil)
+
+		photo_service := New(mock_repository, &fakePublisher{})
+
+		_, err := photo_service.Save(context.Background(), *photo.Of(*id, nil))
+		assert.NoError(t, err)
+	})
+
+	t.Run("when publisher returns error, returns the saved photo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{err: errors.New("expected error")}
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *id).
+			Return(photo.Of(*id, []byte("old")), nil)
+		mock_repository.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(id, nil)
+
+		photo_service := New(mock_repository, publisher)
+
+		actual, err := photo_service.Save(context.Background(), *photo.Of(*id, nil))
 		if assert.NoError(t, err) {
 			assert.EqualValues(t, id, actual)
 		}
@@ -80,37 +322,285 @@ func TestPhotoServiceImpl_Save(t *testing.T) {
 		defer ctrl.Finish()
 
 		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			Read(gomock.Any(), gomock.Any()).
+			Return(photo.Of(*photo.IdentifierOf("any"), []byte("old")), nil)
 		mock_repository.EXPECT().
-			Save(gomock.Any()).
+			Save(gomock.Any(), gomock.Any()).
 			Return(nil, errors.New("expected error"))
 
-		photo_service := New()
-		if err := inject.Populate(photo_service, mock_repository); err != nil {
-			t.Fatal(err)
-		}
+		photo_service := New(mock_repository, publisher)
 
-		actual, err := photo_service.Save(*photo.Of(*photo.IdentifierOf("any"), nil))
+		actual, err := photo_service.Save(context.Background(), *photo.Of(*photo.IdentifierOf("any"), nil))
 		if assert.Error(t, err) {
 			assert.Nil(t, actual)
+			assert.Empty(t, publisher.committed())
 		}
 	})
 }
 
 func TestPhotoServiceImpl_Delete(t *testing.T) {
+	t.Run("when photo deleted, publishes deleted event", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			Delete(gomock.Any(), *id).
+			Return(nil)
+
+		photo_service := New(mock_repository, publisher)
+
+		assert.NoError(t, photo_service.Delete(context.Background(), *id))
+		assert.Equal(t, []string{"photo.deleted id"}, publisher.committed())
+	})
+
 	t.Run("when repository returns error, it returns error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
 		mock_repository.EXPECT().
-			Delete(gomock.Any()).
+			Delete(gomock.Any(), gomock.Any()).
 			Return(errors.New("expected error"))
 
-		photo_service := New()
-		if err := inject.Populate(photo_service, mock_repository); err != nil {
+		photo_service := New(mock_repository, publisher)
+
+		assert.Error(t, photo_service.Delete(context.Background(), *photo.IdentifierOf("any")))
+	})
+}
+
+func TestPhotoServiceImpl_AddTag(t *testing.T) {
+	t.Run("when photo exists, saves photo with tag", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *id).
+			Return(photo.Of(*id, []byte("test"), "a"), nil)
+		mock_repository.EXPECT().
+			Save(gomock.Any(), *photo.Of(*id, []byte("test"), "a", "b")).
+			Return(id, nil)
+
+		photo_service := New(mock_repository, publisher)
+
+		assert.NoError(t, photo_service.AddTag(context.Background(), *id, "b"))
+		assert.Equal(t, []string{"photo.updated id"}, publisher.committed())
+	})
+
+	t.Run("when photo already has tag, does not save", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *id).
+			Return(photo.Of(*id, []byte("test"), "a"), nil)
+		mock_repository.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Times(0)
+
+		photo_service := New(mock_repository, publisher)
+
+		assert.NoError(t, photo_service.AddTag(context.Background(), *id, "a"))
+	})
+
+	t.Run("when repository returns error, it returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			Read(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("expected error"))
+
+		photo_service := New(mock_repository, publisher)
+
+		assert.Error(t, photo_service.AddTag(context.Background(), *photo.IdentifierOf("any"), "a"))
+	})
+
+	t.Run("with invalid tag, returns ErrInvalidTag", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		photo_service := New(mock_photo.NewMockRepository(ctrl), &fakePublisher{})
+
+		for _, tag := range []string{"", ".", "..", "a/b"} {
+			assert.Equal(t, photo.ErrInvalidTag, photo_service.AddTag(context.Background(), *photo.IdentifierOf("id"), tag))
+		}
+	})
+
+	t.Run("when tags are added concurrently, keeps every tag", func(t *testing.T) {
+		repository := memory_storage.New(0)
+		id := photo.IdentifierOf("id")
+		if _, err := repository.Save(context.Background(), *photo.Of(*id, []byte("test"))); err != nil {
 			t.Fatal(err)
 		}
+		photo_service := New(&slowRepository{repository}, &fakePublisher{})
+
+		var tags []string
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			tag := fmt.Sprintf("tag%02d", i)
+			tags = append(tags, tag)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, photo_service.AddTag(context.Background(), *id, tag))
+			}()
+		}
+		wg.Wait()
+
+		found, err := repository.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, tags, found.Tags())
+		}
+	})
+}
+
+func TestPhotoServiceImpl_RemoveTag(t *testing.T) {
+	t.Run("when photo has tag, saves photo without tag", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			Read(gomock.Any(), *id).
+			Return(photo.Of(*id, []byte("test"), "a", "b"), nil)
+		mock_repository.EXPECT().
+			Save(gomock.Any(), *photo.Of(*id, []byte("test"), "b")).
+			Return(id, nil)
+
+		photo_service := New(mock_repository, publisher)
+
+		assert.NoError(t, photo_service.RemoveTag(context.Background(), *id, "a"))
+		assert.Equal(t, []string{"photo.updated id"}, publisher.committed())
+	})
+
+	t.Run("when repository returns error, it returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			Read(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("expected error"))
+
+		photo_service := New(mock_repository, publisher)
+
+		assert.Error(t, photo_service.RemoveTag(context.Background(), *photo.IdentifierOf("any"), "a"))
+	})
+}
+
+func TestPhotoServiceImpl_FindByTags(t *testing.T) {
+	setup := func(t *testing.T, ctrl *gomock.Controller) PhotoService {
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			FindByTag(gomock.Any(), "a").
+			Return([]photo.Identifier{*photo.IdentifierOf("1"), *photo.IdentifierOf("2")}, nil)
+		mock_repository.EXPECT().
+			FindByTag(gomock.Any(), "b").
+			Return([]photo.Identifier{*photo.IdentifierOf("2"), *photo.IdentifierOf("3")}, nil)
+
+		photo_service := New(mock_repository, publisher)
+		return photo_service
+	}
+
+	t.Run("with match all, returns intersection", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		actual, err := setup(t, ctrl).FindByTags(context.Background(), []string{"a", "b", "a"}, true)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("2")}, actual)
+		}
+	})
 
-		assert.Error(t, photo_service.Delete(*photo.IdentifierOf("any")))
+	t.Run("with match any, returns union", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		actual, err := setup(t, ctrl).FindByTags(context.Background(), []string{"a", "b"}, false)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{
+				*photo.IdentifierOf("1"),
+				*photo.IdentifierOf("2"),
+				*photo.IdentifierOf("3"),
+			}, actual)
+		}
 	})
+
+	t.Run("when repository returns error, it returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mock_repository := mock_photo.NewMockRepository(ctrl)
+		publisher := &fakePublisher{}
+		mock_repository.EXPECT().
+			FindByTag(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("expected error"))
+
+		photo_service := New(mock_repository, publisher)
+
+		_, err := photo_service.FindByTags(context.Background(), []string{"a"}, true)
+		assert.Error(t, err)
+	})
+}
+
+// fakePublisher records the events committed after writes, or fails with err.
+// slowRepository saves slowly, so that concurrent writes of a photo overlap.
+type slowRepository struct {
+	*memory_storage.MemoryStorage
+}
+
+func (repository *slowRepository) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	time.Sleep(time.Millisecond)
+	return repository.MemoryStorage.Save(ctx, photograph)
+}
+
+type fakePublisher struct {
+	mu     sync.Mutex
+	events []event.Event
+	err    error
+}
+
+func (publisher *fakePublisher) Publish(ctx context.Context, e event.Event) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	if publisher.err != nil {
+		return publisher.err
+	}
+	publisher.events = append(publisher.events, e)
+	return nil
+}
+
+func (publisher *fakePublisher) Stage(ctx context.Context, build func(id photo.Identifier) event.Event) (context.Context, *event.Staged) {
+	return event.Stage(ctx, nil, build)
+}
+
+func (publisher *fakePublisher) Commit(ctx context.Context, staged *event.Staged, id photo.Identifier) error {
+	return publisher.Publish(ctx, staged.Event(id))
+}
+
+func (publisher *fakePublisher) committed() []string {
+	var committed []string
+	for _, e := range publisher.events {
+		id := e.PhotoId()
+		committed = append(committed, e.Type()+" "+id.Value())
+	}
+	return committed
 }
@@ -0,0 +1,9 @@
+package service
+
+import (
+	"github.com/photoshelf/photoshelf-storage/application/scrub"
+)
+
+type ScrubService interface {
+	LastReport() *scrub.Report
+}
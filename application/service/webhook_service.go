@@ -0,0 +1,9 @@
+package service
+
+import (
+	"github.com/photoshelf/photoshelf-storage/application/webhook"
+)
+
+type WebhookService interface {
+	Deliveries() *webhook.Report
+}
@@ -0,0 +1,192 @@
+package application
+
+import (
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/logging"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/tracing"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration :\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(field string, format string, args ...interface{}) {
+	v.problems = append(v.problems, field+" : "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+// Validate reports every invalid value of the configuration at once, before anything is opened.
+func (configuration *Configuration) Validate() error {
+	v := &validator{}
+
+	server := configuration.Server
+	v.port("server.port", server.Port)
+	v.port("server.grpc_port", server.GrpcPort)
+	switch server.Mode {
+	case "rest", "grpc", "all":
+	default:
+		v.add("server.mode", "%q is not one of rest, grpc or all", server.Mode)
+	}
+	if server.ShutdownTimeout < 0 {
+		v.add("server.shutdown_timeout", "must not be negative")
+	}
+
+	v.storageSection(configuration)
+
+	if configuration.Scrub.Interval < 0 {
+		v.add("scrub.interval", "must not be negative")
+	}
+	if configuration.Scrub.Rate < 0 {
+		v.add("scrub.rate", "must not be negative")
+	}
+
+	for i, subscription := range configuration.Webhooks {
+		if err := subscription.Validate(); err != nil {
+			v.add(fmt.Sprintf("webhooks[%d].url", i), "%s", err)
+		}
+	}
+
+	switch configuration.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterOtlp, tracing.ExporterStdout, tracing.ExporterFile:
+	default:
+		v.add("tracing.exporter", "%q is not one of otlp, stdout or file", configuration.Tracing.Exporter)
+	}
+	if ratio := configuration.Tracing.SampleRatio; ratio < 0 || ratio > 1 {
+		v.add("tracing.sample_ratio", "%v is not between 0 and 1", ratio)
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(configuration.Log.Level)); configuration.Log.Level != "" && err != nil {
+		v.add("log.level", "%q is not one of debug, info, warn or error", configuration.Log.Level)
+	}
+	switch strings.ToLower(configuration.Log.Format) {
+	case "", logging.FormatJson, logging.FormatText:
+	default:
+		v.add("log.format", "%q is not one of json or text", configuration.Log.Format)
+	}
+
+	return v.err()
+}
+
+// validateStorage validates only the storage, for the commands which open nothing else.
+func (configuration *Configuration) validateStorage() error {
+	v := &validator{}
+	v.storageSection(configuration)
+	return v.err()
+}
+
+func (v *validator) storageSection(configuration *Configuration) {
+	storage := configuration.Storage
+	v.storage("storage", storage.StorageConfiguration)
+	if storage.Migrate.Type != "" {
+		v.storage("storage.migrate", storage.Migrate.StorageConfiguration)
+		if storage.Migrate.Workers < 1 {
+			v.add("storage.migrate.workers", "must be at least 1")
+		}
+	}
+	if keyFile := storage.Encryption.KeyFile; keyFile != "" {
+		if info, err := os.Stat(keyFile); err != nil {
+			v.add("storage.encryption.key_file", "%s can't be read", keyFile)
+		} else if info.IsDir() {
+			v.add("storage.encryption.key_file", "%s is a directory", keyFile)
+		}
+	}
+}
+
+func (v *validator) storage(field string, configuration StorageConfiguration) {
+	switch configuration.Type {
+	case "file", "leveldb":
+		v.path(field+".path", configuration, true)
+	case "boltdb":
+		v.path(field+".path", configuration, false)
+	case "memory":
+		if configuration.MaxBytes < 0 {
+			v.add(field+".max_bytes", "must not be negative")
+		}
+	case "replicated":
+		if len(configuration.Replicas) == 0 {
+			v.add(field+".replicas", "replicated storage needs at least one replica")
+		}
+		if configuration.Quorum < 0 || configuration.Quorum > len(configuration.Replicas) {
+			v.add(field+".quorum", "%d is not between 1 and %d replicas, or 0 for a majority", configuration.Quorum, len(configuration.Replicas))
+		}
+		for i, replica := range configuration.Replicas {
+			if replica.Type == "replicated" {
+				v.add(fmt.Sprintf("%s.replicas[%d].type", field, i), "replicated storage can't be a replica")
+				continue
+			}
+			v.storage(fmt.Sprintf("%s.replicas[%d]", field, i), replica)
+		}
+	case "tiered":
+		if configuration.Hot == nil {
+			v.add(field+".hot", "tiered storage needs a hot storage")
+		} else {
+			v.storage(field+".hot", *configuration.Hot)
+		}
+		if configuration.Cold == nil {
+			v.add(field+".cold", "tiered storage needs a cold storage")
+		} else {
+			v.storage(field+".cold", *configuration.Cold)
+		}
+		if configuration.MaxBytes < 0 {
+			v.add(field+".max_bytes", "must not be negative")
+		}
+		if configuration.MaxItems < 0 {
+			v.add(field+".max_items", "must not be negative")
+		}
+	case "":
+		v.add(field+".type", "is required")
+	default:
+		v.add(field+".type", "%q is not one of file, leveldb, boltdb, memory, replicated or tiered", configuration.Type)
+	}
+}
+
+// path checks that the path of a file or leveldb storage is a directory, and that of a boltdb storage is a file.
+func (v *validator) path(field string, configuration StorageConfiguration, directory bool) {
+	path := configuration.Path
+	if path == "" {
+		v.add(field, "is required for %s storage", configuration.Type)
+		return
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		if directory {
+			return
+		}
+		if parent, err := os.Stat(filepath.Dir(path)); err != nil || !parent.IsDir() {
+			v.add(field, "directory of %s does not exist", path)
+		}
+	case err != nil:
+		v.add(field, "%s can't be accessed", path)
+	case directory && !info.IsDir():
+		v.add(field, "%s is a file, %s storage needs a directory", path, configuration.Type)
+	case !directory && info.IsDir():
+		v.add(field, "%s is a directory, %s storage needs a file", path, configuration.Type)
+	}
+}
+
+func (v *validator) port(field string, port int) {
+	if port < 0 || port > 65535 {
+		v.add(field, "%d is not between 0 and 65535", port)
+	}
+}
@@ -0,0 +1,55 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/application/scrub"
+	"os"
+)
+
+func Verify(args ...string) error {
+	configuration := &Configuration{}
+
+	flg := newFlagSet("verify", configuration)
+	rate := flg.Int("rate", 0, "photos per second to verify, 0 for unlimited")
+	encryptionFlags(flg, configuration)
+	if err := parse(flg, configuration, args); err != nil {
+		return err
+	}
+	if err := configuration.validateStorage(); err != nil {
+		return err
+	}
+
+	storage, err := openStorage(configuration.Storage.StorageConfiguration)
+	if err != nil {
+		return err
+	}
+	defer storage.photos.Close()
+	encrypted, err := newEncryptedStorage(configuration, storage.photos)
+	if err != nil {
+		return err
+	}
+	var checks []scrub.Check
+	if encrypted != nil {
+		checks = append(checks, encrypted.Check)
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+	report, err := scrub.Verify(ctx, storage.photos, *rate, checks...)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	if !report.Healthy() {
+		return fmt.Errorf("found %d corrupt, %d missing and %d orphaned photos",
+			len(report.Corrupt), len(report.Missing), len(report.Orphaned))
+	}
+	return nil
+}
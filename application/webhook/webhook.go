@@ -0,0 +1,248 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/application/event_bus"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	EventHeader     = "X-Photoshelf-Event"
+	DeliveryHeader  = "X-Photoshelf-Delivery"
+	SignatureHeader = "X-Photoshelf-Signature"
+
+	maxDeadLetters = 1000
+)
+
+var ErrInvalidURL = errors.New("webhook url must be an absolute http or https url")
+
+type Subscription struct {
+	Name   string
+	URL    string
+	Events []string
+	Secret string
+}
+
+func (subscription *Subscription) accepts(eventType string) bool {
+	if len(subscription.Events) == 0 {
+		return true
+	}
+	for _, e := range subscription.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type Delivery struct {
+	Webhook     string     `json:"webhook"`
+	URL         string     `json:"url"`
+	Sequence    uint64     `json:"sequence"`
+	Event       string     `json:"event"`
+	PhotoId     string     `json:"photo_id"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error"`
+	LastAttempt time.Time  `json:"last_attempt"`
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+}
+
+type Report struct {
+	Failing     []Delivery `json:"failing"`
+	DeadLetters []Delivery `json:"dead_letters"`
+}
+
+type deliveryKey struct {
+	webhook  string
+	sequence uint64
+}
+
+// Notifier posts events to webhooks. A failed delivery is retried with exponential backoff,
+// and moved to the dead letters after MaxAttempts.
+type Notifier struct {
+	Client         *http.Client
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	metrics     *metrics.Metrics
+	mu          sync.Mutex
+	failing     map[deliveryKey]*Delivery
+	deadLetters []Delivery
+}
+
+func New(m *metrics.Metrics) *Notifier {
+	return &Notifier{
+		Client:         &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts:    8,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Minute,
+		metrics:        m,
+		failing:        make(map[deliveryKey]*Delivery),
+	}
+}
+
+func (subscription *Subscription) Validate() error {
+	u, err := url.Parse(subscription.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s : %s", ErrInvalidURL, subscription.URL)
+	}
+	return nil
+}
+
+func (notifier *Notifier) Subscribe(bus *event_bus.Bus, subscription Subscription) error {
+	if err := subscription.Validate(); err != nil {
+		return err
+	}
+	if subscription.Name == "" {
+		subscription.Name = subscription.URL
+	}
+	bus.Subscribe("webhook "+subscription.Name, notifier.handler(subscription))
+	return nil
+}
+
+func (notifier *Notifier) Deliveries() *Report {
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+
+	report := &Report{Failing: []Delivery{}, DeadLetters: append([]Delivery{}, notifier.deadLetters...)}
+	for _, delivery := range notifier.failing {
+		report.Failing = append(report.Failing, *delivery)
+	}
+	sort.Slice(report.Failing, func(i, j int) bool {
+		if report.Failing[i].Webhook != report.Failing[j].Webhook {
+			return report.Failing[i].Webhook < report.Failing[j].Webhook
+		}
+		return report.Failing[i].Sequence < report.Failing[j].Sequence
+	})
+	return report
+}
+
+func (notifier *Notifier) handler(subscription Subscription) event_bus.Handler {
+	return func(ctx context.Context, record event.Record) error {
+		if !subscription.accepts(record.Event.Type()) {
+			return nil
+		}
+
+		key := deliveryKey{subscription.Name, record.Sequence}
+		notifier.mu.Lock()
+		delivery, retrying := notifier.failing[key]
+		if retrying && time.Now().Before(*delivery.NextAttempt) {
+			notifier.mu.Unlock()
+			return event_bus.ErrRetryLater
+		}
+		notifier.mu.Unlock()
+
+		err := notifier.post(ctx, subscription, record)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		notifier.mu.Lock()
+		defer notifier.mu.Unlock()
+
+		if err == nil {
+			delete(notifier.failing, key)
+			notifier.metrics.WebhookDeliveries.WithLabelValues("delivered").Inc()
+			return nil
+		}
+		notifier.metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+
+		if !retrying {
+			id := record.Event.PhotoId()
+			delivery = &Delivery{
+				Webhook:  subscription.Name,
+				URL:      subscription.URL,
+				Sequence: record.Sequence,
+				Event:    record.Event.Type(),
+				PhotoId:  id.Value(),
+			}
+			notifier.failing[key] = delivery
+		}
+		delivery.Attempts++
+		delivery.LastError = err.Error()
+		delivery.LastAttempt = time.Now()
+
+		if delivery.Attempts >= notifier.MaxAttempts {
+			slog.ErrorContext(ctx, "webhook gave up event", "webhook", subscription.Name, "sequence", record.Sequence, "attempts", delivery.Attempts, "error", err)
+			delete(notifier.failing, key)
+			delivery.NextAttempt = nil
+			notifier.deadLetters = append(notifier.deadLetters, *delivery)
+			if len(notifier.deadLetters) > maxDeadLetters {
+				notifier.deadLetters = notifier.deadLetters[len(notifier.deadLetters)-maxDeadLetters:]
+			}
+			notifier.metrics.WebhookDeliveries.WithLabelValues("dead_lettered").Inc()
+			return nil
+		}
+
+		next := delivery.LastAttempt.Add(notifier.backoff(delivery.Attempts))
+		delivery.NextAttempt = &next
+		slog.WarnContext(ctx, "webhook failed event", "webhook", subscription.Name, "sequence", record.Sequence, "attempt", delivery.Attempts, "error", err)
+		return event_bus.ErrRetryLater
+	}
+}
+
+func (notifier *Notifier) backoff(attempts int) time.Duration {
+	backoff := notifier.InitialBackoff
+	for i := 1; i < attempts && backoff < notifier.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > notifier.MaxBackoff {
+		return notifier.MaxBackoff
+	}
+	return backoff
+}
+
+func (notifier *Notifier) post(ctx context.Context, subscription Subscription, record event.Record) error {
+	body, err := event.Marshal(record.Event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, record.Event.Type())
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(record.Sequence, 10))
+	if subscription.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(subscription.Secret, body))
+	}
+
+	res, err := notifier.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status : %s", res.Status)
+	}
+	return nil
+}
+
+// Sign returns the value of the signature header for body, the hex encoded HMAC-SHA256 with the secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/application/event_bus"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNotifier_Subscribe(t *testing.T) {
+	t.Run("delivers signed event", func(t *testing.T) {
+		receiver := newReceiver(t)
+		bus, outbox := createBus(t, New(metrics.New()), Subscription{Name: "hook", URL: receiver.URL, Secret: "secret"})
+
+		e := event.PhotoCreated{Id: *photo.IdentifierOf("id"), Tags: []string{"tag"}}
+		if err := bus.Publish(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+		dispatch(t, bus)
+
+		requests := receiver.received()
+		if assert.Len(t, requests, 1) {
+			expected, _ := event.Marshal(e)
+			assert.JSONEq(t, string(expected), string(requests[0].body))
+			assert.Equal(t, Sign("secret", requests[0].body), requests[0].header.Get(SignatureHeader))
+			assert.Equal(t, "photo.created", requests[0].header.Get(EventHeader))
+			assert.Equal(t, "1", requests[0].header.Get(DeliveryHeader))
+		}
+		assertPending(t, outbox, 0)
+	})
+
+	t.Run("with event filter, delivers only matching events", func(t *testing.T) {
+		receiver := newReceiver(t)
+		bus, _ := createBus(t, New(metrics.New()), Subscription{URL: receiver.URL, Events: []string{event.TypePhotoDeleted}})
+
+		publish(t, bus, event.PhotoCreated{Id: *photo.IdentifierOf("id")}, event.PhotoDeleted{Id: *photo.IdentifierOf("id")})
+		dispatch(t, bus)
+
+		requests := receiver.received()
+		if assert.Len(t, requests, 1) {
+			assert.Equal(t, "photo.deleted", requests[0].header.Get(EventHeader))
+			assert.Empty(t, requests[0].header.Get(SignatureHeader))
+		}
+	})
+
+	t.Run("when receiver fails, retries with backoff", func(t *testing.T) {
+		receiver := newReceiver(t)
+		receiver.failures = 2
+		notifier := New(metrics.New())
+		notifier.InitialBackoff = 20 * time.Millisecond
+		bus, outbox := createBus(t, notifier, Subscription{Name: "hook", URL: receiver.URL})
+
+		publish(t, bus, event.PhotoDeleted{Id: *photo.IdentifierOf("id")})
+		dispatch(t, bus)
+		report := notifier.Deliveries()
+		if assert.Len(t, report.Failing, 1) {
+			assert.Equal(t, 1, report.Failing[0].Attempts)
+			assert.Equal(t, "hook", report.Failing[0].Webhook)
+			assert.Contains(t, report.Failing[0].LastError, "500")
+		}
+
+		dispatch(t, bus)
+		assert.Len(t, receiver.received(), 1, "should wait for backoff")
+
+		for i := 0; i < 20 && len(receiver.received()) < 3; i++ {
+			time.Sleep(10 * time.Millisecond)
+			dispatch(t, bus)
+		}
+		assert.Len(t, receiver.received(), 3)
+		assert.Empty(t, notifier.Deliveries().Failing)
+		assertPending(t, outbox, 0)
+	})
+
+	t.Run("after max attempts, moves delivery to dead letters", func(t *testing.T) {
+		receiver := newReceiver(t)
+		receiver.failures = -1
+		m := metrics.New()
+		notifier := New(m)
+		notifier.MaxAttempts = 2
+		notifier.InitialBackoff = time.Millisecond
+		bus, outbox := createBus(t, notifier, Subscription{Name: "hook", URL: receiver.URL})
+
+		publish(t, bus, event.PhotoDeleted{Id: *photo.IdentifierOf("id")})
+		for i := 0; i < 20 && len(notifier.Deliveries().DeadLetters) == 0; i++ {
+			time.Sleep(5 * time.Millisecond)
+			dispatch(t, bus)
+		}
+
+		report := notifier.Deliveries()
+		assert.Empty(t, report.Failing)
+		if assert.Len(t, report.DeadLetters, 1) {
+			assert.Equal(t, 2, report.DeadLetters[0].Attempts)
+			assert.Equal(t, "id", report.DeadLetters[0].PhotoId)
+		}
+		assert.Equal(t, float64(2), testutil.ToFloat64(m.WebhookDeliveries.WithLabelValues("failed")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.WebhookDeliveries.WithLabelValues("dead_lettered")))
+		assertPending(t, outbox, 0)
+	})
+
+	t.Run("with invalid url, returns error", func(t *testing.T) {
+		bus := event_bus.New(memory_storage.NewOutbox())
+		assert.Error(t, New(metrics.New()).Subscribe(bus, Subscription{URL: "ftp://example.com"}))
+		assert.Error(t, New(metrics.New()).Subscribe(bus, Subscription{URL: "/relative"}))
+	})
+}
+
+func TestNotifier_backoff(t *testing.T) {
+	notifier := New(metrics.New())
+	notifier.InitialBackoff = time.Second
+	notifier.MaxBackoff = 5 * time.Second
+
+	assert.Equal(t, time.Second, notifier.backoff(1))
+	assert.Equal(t, 2*time.Second, notifier.backoff(2))
+	assert.Equal(t, 4*time.Second, notifier.backoff(3))
+	assert.Equal(t, 5*time.Second, notifier.backoff(4))
+}
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+type receiver struct {
+	*httptest.Server
+	failures int
+
+	mu       sync.Mutex
+	requests []request
+}
+
+func newReceiver(t *testing.T) *receiver {
+	r := &receiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, request{req.Header, body})
+		if r.failures != 0 {
+			r.failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) received() []request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]request{}, r.requests...)
+}
+
+func createBus(t *testing.T, notifier *Notifier, subscription Subscription) (*event_bus.Bus, event.Outbox) {
+	t.Helper()
+	outbox := memory_storage.NewOutbox()
+	bus := event_bus.New(outbox)
+	if err := notifier.Subscribe(bus, subscription); err != nil {
+		t.Fatal(err)
+	}
+	return bus, outbox
+}
+
+func publish(t *testing.T, bus *event_bus.Bus, events ...event.Event) {
+	t.Helper()
+	for _, e := range events {
+		if err := bus.Publish(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func dispatch(t *testing.T, bus *event_bus.Bus) {
+	t.Helper()
+	if err := bus.Dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertPending(t *testing.T, outbox event.Outbox, expected int) {
+	t.Helper()
+	records, err := outbox.Pending(context.Background())
+	if assert.NoError(t, err) {
+		assert.Len(t, records, expected)
+	}
+}
@@ -0,0 +1,108 @@
+// Package client stores photos on a photoshelf storage server over REST or gRPC.
+package client
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"google.golang.org/grpc"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Client is the photo API both transports serve, with the same behavior over either.
+// A photo that does not exist is reported as a *photo.ResourceError with photo.ErrNotFound, as the server does.
+type Client interface {
+	// Save stores a new photo, or replaces the image of an existing one. An existing photo keeps its tags,
+	// and the tags of the saved photo are added to them.
+	Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error)
+	// Find returns the photo with its image and tags.
+	Find(ctx context.Context, id photo.Identifier) (*photo.Photo, error)
+	// Delete deletes the photo. Deleting a photo which does not exist succeeds, so a retried delete does too.
+	Delete(ctx context.Context, id photo.Identifier) error
+	Upload(ctx context.Context, image io.Reader, tags ...string) (*photo.Identifier, error)
+	Download(ctx context.Context, id photo.Identifier) (io.ReadCloser, error)
+	Close() error
+}
+
+// Retry retries idempotent calls failing with a transient error, waiting InitialBackoff before the first retry
+// and twice as long before each next one, up to MaxBackoff.
+type Retry struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func DefaultRetry() Retry {
+	return Retry{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+	}
+}
+
+func (retry Retry) do(ctx context.Context, retryable func(err error) bool, call func() error) error {
+	backoff := retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= retry.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > retry.MaxBackoff {
+			backoff = retry.MaxBackoff
+		}
+	}
+}
+
+// Option changes how a client calls the server.
+type Option func(options *options)
+
+type options struct {
+	retry       Retry
+	httpClient  *http.Client
+	dialOptions []grpc.DialOption
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		retry:      DefaultRetry(),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithRetry replaces the default retry of idempotent calls. Retry{MaxAttempts: 1} disables retries.
+func WithRetry(retry Retry) Option {
+	return func(options *options) {
+		options.retry = retry
+	}
+}
+
+// WithHTTPClient sends REST requests with client instead of http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(options *options) {
+		options.httpClient = client
+	}
+}
+
+// WithDialOptions dials the gRPC server with opts instead of an insecure connection.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(options *options) {
+		options.dialOptions = opts
+	}
+}
+
+func notFound(id photo.Identifier) error {
+	return &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/photoshelf/photoshelf-storage/storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var quickRetry = Retry{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+func TestRetry_do(t *testing.T) {
+	transient := errors.New("transient")
+	retryable := func(err error) bool { return err == transient }
+
+	t.Run("when transient error, retries until success", func(t *testing.T) {
+		var calls int
+		err := quickRetry.do(context.Background(), retryable, func() error {
+			if calls++; calls < 3 {
+				return transient
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("when transient error persists, returns it after max attempts", func(t *testing.T) {
+		var calls int
+		err := quickRetry.do(context.Background(), retryable, func() error {
+			calls++
+			return transient
+		})
+		assert.Equal(t, transient, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("when permanent error, does not retry", func(t *testing.T) {
+		var calls int
+		permanent := errors.New("permanent")
+		err := quickRetry.do(context.Background(), retryable, func() error {
+			calls++
+			return permanent
+		})
+		assert.Equal(t, permanent, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("when context is done, stops retrying", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls int
+		err := Retry{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour}.do(ctx, retryable, func() error {
+			calls++
+			cancel()
+			return transient
+		})
+		assert.Equal(t, transient, err)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func newMemoryStorage(t *testing.T) *storage.Storage {
+	t.Helper()
+	s, err := storage.New(storage.WithStorage("memory", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
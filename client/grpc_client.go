@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+)
+
+// chunkSize is how many bytes of an image each message of an upload carries.
+const chunkSize = 64 << 10
+
+// GrpcClient calls the PhotoService of a gRPC server.
+// Save and Find send a photo in a single message, while Upload and Download stream its image in chunks.
+type GrpcClient struct {
+	conn    *grpc.ClientConn
+	photos  protobuf.PhotoServiceClient
+	options *options
+}
+
+// NewGrpc dials the server at target, without TLS unless WithDialOptions says otherwise.
+func NewGrpc(target string, opts ...Option) (*GrpcClient, error) {
+	o := newOptions(opts)
+	dialOptions := o.dialOptions
+	if len(dialOptions) == 0 {
+		dialOptions = []grpc.DialOption{grpc.WithInsecure()}
+	}
+
+	conn, err := grpc.Dial(target, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return &GrpcClient{conn, protobuf.NewPhotoServiceClient(conn), o}, nil
+}
+
+// Save stores the photo, replacing it when it has an id, along with its tags.
+func (client *GrpcClient) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	req := &protobuf.Photo{Image: photograph.Image(), Tags: photograph.Tags()}
+	if !photograph.IsNew() {
+		req.Id = &protobuf.Id{Value: photograph.Id().Value()}
+	}
+
+	var res *protobuf.Id
+	call := func() (err error) {
+		res, err = client.photos.Save(ctx, req)
+		return err
+	}
+	var err error
+	if photograph.IsNew() {
+		err = call()
+	} else {
+		err = client.options.retry.do(ctx, retryableCode, call)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return photo.IdentifierOf(res.Value), nil
+}
+
+func (client *GrpcClient) Find(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	var res *protobuf.Photo
+	err := client.options.retry.do(ctx, retryableCode, func() (err error) {
+		res, err = client.photos.Find(ctx, &protobuf.Id{Value: id.Value()})
+		return err
+	})
+	if err != nil {
+		return nil, photoError(id, err)
+	}
+	return photo.Of(id, res.Image, res.Tags...), nil
+}
+
+func (client *GrpcClient) Delete(ctx context.Context, id photo.Identifier) error {
+	err := client.options.retry.do(ctx, retryableCode, func() error {
+		_, err := client.photos.Delete(ctx, &protobuf.Id{Value: id.Value()})
+		return err
+	})
+	return photoError(id, err)
+}
+
+// Upload streams image to a new photo with tags, in chunks. It isn't retried, since image can't be read twice.
+func (client *GrpcClient) Upload(ctx context.Context, image io.Reader, tags ...string) (*photo.Identifier, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.photos.Upload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, chunkSize)
+	req := &protobuf.UploadRequest{Tags: tags}
+	for {
+		n, err := io.ReadFull(image, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		if n > 0 || req.Tags != nil {
+			req.Chunk = buf[:n]
+			if err := stream.Send(req); err == io.EOF {
+				// The server ended the upload, CloseAndRecv returns why.
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			req = &protobuf.UploadRequest{}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	return photo.IdentifierOf(res.Value), nil
+}
+
+// Download streams the image of the photo in chunks. The caller closes it.
+func (client *GrpcClient) Download(ctx context.Context, id photo.Identifier) (io.ReadCloser, error) {
+	var image *chunkReader
+	err := client.options.retry.do(ctx, retryableCode, func() error {
+		ctx, cancel := context.WithCancel(ctx)
+		stream, err := client.photos.Download(ctx, &protobuf.Id{Value: id.Value()})
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		// The error of a stream comes with its first message, which tells whether the photo exists.
+		image = &chunkReader{stream: stream, cancel: cancel}
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			image.err = err
+			return nil
+		} else if err != nil {
+			cancel()
+			return err
+		}
+		image.chunk = chunk.Data
+		return nil
+	})
+	if err != nil {
+		return nil, photoError(id, err)
+	}
+	return image, nil
+}
+
+func (client *GrpcClient) Close() error {
+	return client.conn.Close()
+}
+
+// retryableCode tells whether the call may succeed when made again: the server was unreachable.
+func retryableCode(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+func photoError(id photo.Identifier, err error) error {
+	if status.Code(err) == codes.NotFound {
+		return notFound(id)
+	}
+	return err
+}
+
+// chunkReader reads the image of a download from its stream of chunks.
+type chunkReader struct {
+	stream protobuf.PhotoService_DownloadClient
+	cancel context.CancelFunc
+	chunk  []byte
+	err    error
+}
+
+func (reader *chunkReader) Read(p []byte) (int, error) {
+	for len(reader.chunk) == 0 {
+		if reader.err != nil {
+			return 0, reader.err
+		}
+		chunk, err := reader.stream.Recv()
+		if err != nil {
+			reader.err = err
+			continue
+		}
+		reader.chunk = chunk.Data
+	}
+	n := copy(p, reader.chunk)
+	reader.chunk = reader.chunk[n:]
+	return n, nil
+}
+
+// Close cancels the download, unless it was read to the end.
+func (reader *chunkReader) Close() error {
+	reader.cancel()
+	return nil
+}
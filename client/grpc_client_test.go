@@ -0,0 +1,205 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func TestGrpcClient(t *testing.T) {
+	s := newMemoryStorage(t)
+	client := newGrpcClient(t, s.RegisterGrpc)
+	ctx := context.Background()
+
+	t.Run("saves, finds and deletes photo", func(t *testing.T) {
+		saved := photo.New([]byte("test"))
+		saved.AddTag("cat")
+		id, err := client.Save(ctx, *saved)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := client.Find(ctx, *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("test"), found.Image())
+			assert.Equal(t, []string{"cat"}, found.Tags())
+		}
+
+		if _, err := client.Save(ctx, *photo.Of(*id, []byte("replaced"), "dog")); err != nil {
+			t.Fatal(err)
+		}
+		found, err = client.Find(ctx, *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("replaced"), found.Image())
+			assert.Equal(t, []string{"cat", "dog"}, found.Tags())
+		}
+
+		assert.NoError(t, client.Delete(ctx, *id))
+		_, err = client.Find(ctx, *id)
+		assert.Equal(t, &photo.ResourceError{Id: *id, Err: photo.ErrNotFound}, err)
+		assert.NoError(t, client.Delete(ctx, *id), "deleting it again succeeds")
+	})
+
+	t.Run("uploads and downloads photo", func(t *testing.T) {
+		id, err := client.Upload(ctx, bytes.NewReader([]byte("streamed")), "dog")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		image, err := client.Download(ctx, *id)
+		if assert.NoError(t, err) {
+			data, _ := ioutil.ReadAll(image)
+			image.Close()
+			assert.Equal(t, []byte("streamed"), data)
+		}
+
+		found, err := s.Photos().Find(ctx, *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"dog"}, found.Tags())
+		}
+	})
+
+	t.Run("uploads and downloads photo larger than a message", func(t *testing.T) {
+		large := bytes.Repeat([]byte("0123456789"), 5<<20/10)
+		id, err := client.Upload(ctx, bytes.NewReader(large), "cat", "dog")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		image, err := client.Download(ctx, *id)
+		if assert.NoError(t, err) {
+			data, _ := ioutil.ReadAll(image)
+			image.Close()
+			assert.Equal(t, large, data)
+		}
+
+		found, err := s.Photos().Find(ctx, *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"cat", "dog"}, found.Tags())
+		}
+	})
+
+	t.Run("when photo does not exist, returns not found error on download", func(t *testing.T) {
+		_, err := client.Download(ctx, *photo.IdentifierOf("missing"))
+		assert.Equal(t, &photo.ResourceError{Id: *photo.IdentifierOf("missing"), Err: photo.ErrNotFound}, err)
+	})
+}
+
+func TestGrpcClient_retry(t *testing.T) {
+	service := &unavailablePhotoService{failures: 2}
+	client := newGrpcClient(t, func(s *grpc.Server) {
+		protobuf.RegisterPhotoServiceServer(s, service)
+	})
+
+	t.Run("when server is unavailable, retries idempotent call", func(t *testing.T) {
+		found, err := client.Find(context.Background(), *photo.IdentifierOf("test"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("test"), found.Image())
+		}
+		assert.Equal(t, 3, service.calls)
+	})
+
+	t.Run("when server is unavailable, retries download", func(t *testing.T) {
+		service.calls, service.failures = 0, 2
+		image, err := client.Download(context.Background(), *photo.IdentifierOf("test"))
+		if assert.NoError(t, err) {
+			data, _ := ioutil.ReadAll(image)
+			image.Close()
+			assert.Equal(t, []byte("test"), data)
+		}
+		assert.Equal(t, 3, service.calls)
+	})
+
+	t.Run("when server is unavailable, does not retry upload", func(t *testing.T) {
+		service.calls, service.failures = 0, 1
+		_, err := client.Upload(context.Background(), bytes.NewReader([]byte("test")))
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, 1, service.calls)
+	})
+
+	t.Run("when server is unavailable, does not retry new photo", func(t *testing.T) {
+		service.calls, service.failures = 0, 1
+		_, err := client.Save(context.Background(), *photo.New([]byte("test")))
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, 1, service.calls)
+	})
+}
+
+func newGrpcClient(t *testing.T, register func(s *grpc.Server)) *GrpcClient {
+	t.Helper()
+	server := grpc.NewServer()
+	register(server)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	client, err := NewGrpc(listener.Addr().String(), WithRetry(quickRetry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+type unavailablePhotoService struct {
+	calls    int
+	failures int
+}
+
+func (service *unavailablePhotoService) fail() error {
+	service.calls++
+	if service.calls <= service.failures {
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	return nil
+}
+
+func (service *unavailablePhotoService) Save(ctx context.Context, req *protobuf.Photo) (*protobuf.Id, error) {
+	if err := service.fail(); err != nil {
+		return nil, err
+	}
+	return &protobuf.Id{Value: "test"}, nil
+}
+
+func (service *unavailablePhotoService) Find(ctx context.Context, req *protobuf.Id) (*protobuf.Photo, error) {
+	if err := service.fail(); err != nil {
+		return nil, err
+	}
+	return &protobuf.Photo{Id: req, Image: []byte("test")}, nil
+}
+
+func (service *unavailablePhotoService) Delete(ctx context.Context, req *protobuf.Id) (*protobuf.Empty, error) {
+	if err := service.fail(); err != nil {
+		return nil, err
+	}
+	return &protobuf.Empty{}, nil
+}
+
+func (service *unavailablePhotoService) Watch(req *protobuf.WatchRequest, stream protobuf.PhotoService_WatchServer) error {
+	return nil
+}
+
+func (service *unavailablePhotoService) Upload(stream protobuf.PhotoService_UploadServer) error {
+	if err := service.fail(); err != nil {
+		return err
+	}
+	return stream.SendAndClose(&protobuf.Id{Value: "test"})
+}
+
+func (service *unavailablePhotoService) Download(req *protobuf.Id, stream protobuf.PhotoService_DownloadServer) error {
+	if err := service.fail(); err != nil {
+		return err
+	}
+	return stream.Send(&protobuf.Chunk{Data: []byte("test")})
+}
@@ -0,0 +1,293 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/presentation/view"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// StatusError is a REST response with an unexpected status.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", err.Code, err.Message)
+}
+
+// RestClient calls the REST API under a base url, such as http://localhost:1323 or the prefix storage is embedded at.
+// Uploads are streamed as multipart forms, and downloads are streamed from the response.
+type RestClient struct {
+	baseURL string
+	options *options
+}
+
+func NewRest(baseURL string, opts ...Option) *RestClient {
+	return &RestClient{strings.TrimSuffix(baseURL, "/"), newOptions(opts)}
+}
+
+// Save uploads the photo with its tags, replacing it when it has an id.
+// Tags the stored photo already has are kept.
+func (client *RestClient) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	if photograph.IsNew() {
+		return client.post(ctx, bytes.NewReader(photograph.Image()), photograph.Tags())
+	}
+
+	id := photograph.Id()
+	err := client.options.retry.do(ctx, retryableResponse, func() error {
+		res, err := client.upload(ctx, http.MethodPut, "/photos/"+url.PathEscape(id.Value()), bytes.NewReader(photograph.Image()), photograph.Tags())
+		if err != nil {
+			return err
+		}
+		return drain(res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// Find downloads the photo, then its tags, which the server reads without reading the image again.
+func (client *RestClient) Find(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	image, err := client.Download(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer image.Close()
+
+	data, err := ioutil.ReadAll(image)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := client.Tags(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return photo.Of(id, data, tags...), nil
+}
+
+func (client *RestClient) Delete(ctx context.Context, id photo.Identifier) error {
+	return client.options.retry.do(ctx, retryableResponse, func() error {
+		res, err := client.do(ctx, http.MethodDelete, "/photos/"+url.PathEscape(id.Value()), nil, "")
+		if err != nil {
+			return err
+		}
+		return drain(res)
+	})
+}
+
+// Upload streams image to a new photo with tags. It isn't retried, since image can't be read twice.
+func (client *RestClient) Upload(ctx context.Context, image io.Reader, tags ...string) (*photo.Identifier, error) {
+	return client.post(ctx, image, tags)
+}
+
+// Download streams the image of the photo. The caller closes it.
+func (client *RestClient) Download(ctx context.Context, id photo.Identifier) (io.ReadCloser, error) {
+	var image io.ReadCloser
+	err := client.options.retry.do(ctx, retryableResponse, func() error {
+		res, err := client.do(ctx, http.MethodGet, "/photos/"+url.PathEscape(id.Value()), nil, "")
+		if err != nil {
+			return err
+		}
+		if res.StatusCode == http.StatusNotFound {
+			drain(res)
+			return notFound(id)
+		}
+		if err := checkStatus(res); err != nil {
+			res.Body.Close()
+			return err
+		}
+		image = res.Body
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// FindByTags returns the photos having all of tags, or any of them unless matchAll.
+func (client *RestClient) FindByTags(ctx context.Context, tags []string, matchAll bool) ([]photo.Identifier, error) {
+	query := url.Values{"tag": tags}
+	if matchAll {
+		query.Set("match", "all")
+	} else {
+		query.Set("match", "any")
+	}
+
+	var found view.Found
+	err := client.options.retry.do(ctx, retryableResponse, func() error {
+		res, err := client.do(ctx, http.MethodGet, "/photos?"+query.Encode(), nil, "")
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if err := checkStatus(res); err != nil {
+			return err
+		}
+		return json.NewDecoder(res.Body).Decode(&found)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]photo.Identifier, 0, len(found.Ids))
+	for _, id := range found.Ids {
+		ids = append(ids, *photo.IdentifierOf(id))
+	}
+	return ids, nil
+}
+
+func (client *RestClient) Tags(ctx context.Context, id photo.Identifier) ([]string, error) {
+	var tags view.Tags
+	err := client.options.retry.do(ctx, retryableResponse, func() error {
+		res, err := client.do(ctx, http.MethodGet, "/photos/"+url.PathEscape(id.Value())+"/tags", nil, "")
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode == http.StatusNotFound {
+			return notFound(id)
+		}
+		if err := checkStatus(res); err != nil {
+			return err
+		}
+		return json.NewDecoder(res.Body).Decode(&tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags.Tags, nil
+}
+
+func (client *RestClient) AddTag(ctx context.Context, id photo.Identifier, tag string) error {
+	return client.retryNotFound(ctx, id, http.MethodPut, tagPath(id, tag))
+}
+
+func (client *RestClient) RemoveTag(ctx context.Context, id photo.Identifier, tag string) error {
+	return client.retryNotFound(ctx, id, http.MethodDelete, tagPath(id, tag))
+}
+
+// Close releases nothing, the http client is left to its owner.
+func (client *RestClient) Close() error {
+	return nil
+}
+
+func (client *RestClient) post(ctx context.Context, image io.Reader, tags []string) (*photo.Identifier, error) {
+	res, err := client.upload(ctx, http.MethodPost, "/photos/", image, tags)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if err := checkStatus(res); err != nil {
+		return nil, err
+	}
+
+	var created view.Created
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		return nil, err
+	}
+	return photo.IdentifierOf(created.Id), nil
+}
+
+// upload sends the image in a multipart form along with the tags, which the server saves with it at once.
+func (client *RestClient) upload(ctx context.Context, method string, path string, image io.Reader, tags []string) (*http.Response, error) {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		var err error
+		for _, tag := range tags {
+			if err = form.WriteField("tag", tag); err != nil {
+				break
+			}
+		}
+		var part io.Writer
+		if err == nil {
+			part, err = form.CreateFormFile("photo", "photo")
+		}
+		if err == nil {
+			_, err = io.Copy(part, image)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	res, err := client.do(ctx, method, path, body, form.FormDataContentType())
+	body.Close()
+	return res, err
+}
+
+func (client *RestClient) retryNotFound(ctx context.Context, id photo.Identifier, method string, path string) error {
+	return client.options.retry.do(ctx, retryableResponse, func() error {
+		res, err := client.do(ctx, method, path, nil, "")
+		if err != nil {
+			return err
+		}
+		if res.StatusCode == http.StatusNotFound {
+			drain(res)
+			return notFound(id)
+		}
+		return drain(res)
+	})
+}
+
+func (client *RestClient) do(ctx context.Context, method string, path string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest(method, client.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return client.options.httpClient.Do(req.WithContext(ctx))
+}
+
+// retryableResponse tells whether the request may succeed when sent again: the server was unreachable or overloaded.
+func retryableResponse(err error) bool {
+	switch e := err.(type) {
+	case *url.Error:
+		return true
+	case *StatusError:
+		return e.Code >= http.StatusInternalServerError || e.Code == http.StatusTooManyRequests
+	}
+	return false
+}
+
+func tagPath(id photo.Identifier, tag string) string {
+	return "/photos/" + url.PathEscape(id.Value()) + "/tags/" + url.PathEscape(tag)
+}
+
+func checkStatus(res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	data, _ := ioutil.ReadAll(res.Body)
+	var message struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &message) != nil || message.Message == "" {
+		message.Message = strings.TrimSpace(string(data))
+	}
+	return &StatusError{Code: res.StatusCode, Message: message.Message}
+}
+
+func drain(res *http.Response) error {
+	defer res.Body.Close()
+	if err := checkStatus(res); err != nil {
+		return err
+	}
+	_, err := io.Copy(ioutil.Discard, res.Body)
+	return err
+}
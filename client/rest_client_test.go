@@ -0,0 +1,175 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRestClient(t *testing.T) {
+	server := httptest.NewServer(newMemoryStorage(t).Handler())
+	defer server.Close()
+	client := NewRest(server.URL+"/", WithRetry(quickRetry))
+	ctx := context.Background()
+
+	t.Run("saves, finds and deletes photo", func(t *testing.T) {
+		saved := photo.New([]byte("test"))
+		saved.AddTag("cat")
+		id, err := client.Save(ctx, *saved)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := client.Find(ctx, *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("test"), found.Image())
+			assert.Equal(t, []string{"cat"}, found.Tags())
+		}
+
+		if _, err := client.Save(ctx, *photo.Of(*id, []byte("replaced"), "dog")); err != nil {
+			t.Fatal(err)
+		}
+		found, err = client.Find(ctx, *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("replaced"), found.Image())
+			assert.Equal(t, []string{"cat", "dog"}, found.Tags())
+		}
+
+		assert.NoError(t, client.Delete(ctx, *id))
+		_, err = client.Find(ctx, *id)
+		assert.Equal(t, &photo.ResourceError{Id: *id, Err: photo.ErrNotFound}, err)
+	})
+
+	t.Run("uploads and downloads tagged photo", func(t *testing.T) {
+		id, err := client.Upload(ctx, bytes.NewReader([]byte("streamed")), "cat", "dog")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		image, err := client.Download(ctx, *id)
+		if assert.NoError(t, err) {
+			data, _ := ioutil.ReadAll(image)
+			image.Close()
+			assert.Equal(t, []byte("streamed"), data)
+		}
+
+		ids, err := client.FindByTags(ctx, []string{"cat", "dog"}, true)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*id}, ids)
+		}
+
+		assert.NoError(t, client.RemoveTag(ctx, *id, "dog"))
+		ids, err = client.FindByTags(ctx, []string{"dog"}, false)
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+
+	t.Run("when photo does not exist, returns not found error", func(t *testing.T) {
+		id := photo.IdentifierOf("unknown")
+		err := client.AddTag(ctx, *id, "cat")
+		assert.Equal(t, &photo.ResourceError{Id: *id, Err: photo.ErrNotFound}, err)
+	})
+
+	t.Run("when request is invalid, returns status error", func(t *testing.T) {
+		_, err := client.FindByTags(ctx, nil, true)
+		assert.Equal(t, &StatusError{Code: http.StatusBadRequest, Message: "tag is required"}, err)
+	})
+}
+
+func TestRestClient_retry(t *testing.T) {
+	handler := newMemoryStorage(t).Handler()
+	var calls, failures int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := NewRest(server.URL, WithRetry(quickRetry))
+	ctx := context.Background()
+
+	t.Run("when server is unavailable, retries idempotent call", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		atomic.StoreInt32(&failures, 2)
+		_, err := client.Find(ctx, *photo.IdentifierOf("unknown"))
+		assert.Equal(t, &photo.ResourceError{Id: *photo.IdentifierOf("unknown"), Err: photo.ErrNotFound}, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("when server stays unavailable, returns status error", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		atomic.StoreInt32(&failures, 3)
+		err := client.Delete(ctx, *photo.IdentifierOf("unknown"))
+		if assert.IsType(t, &StatusError{}, err) {
+			assert.Equal(t, http.StatusServiceUnavailable, err.(*StatusError).Code)
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("when server is unavailable, does not retry upload", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		atomic.StoreInt32(&failures, 1)
+		_, err := client.Save(ctx, *photo.New([]byte("test")))
+		assert.IsType(t, &StatusError{}, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("uploads photo with its tags in one request", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		atomic.StoreInt32(&failures, 0)
+		id, err := client.Upload(ctx, bytes.NewReader([]byte("test")), "cat", "dog")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		tags, err := client.Tags(ctx, *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"cat", "dog"}, tags)
+		}
+	})
+}
+
+func TestRestClient_Delete(t *testing.T) {
+	handler := newMemoryStorage(t).Handler()
+	var lost int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&lost, -1) >= 0 {
+			// the request is served, but its response is lost on the way back
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := NewRest(server.URL, WithRetry(quickRetry))
+	ctx := context.Background()
+
+	t.Run("when retried delete finds photo gone, succeeds", func(t *testing.T) {
+		id, err := client.Save(ctx, *photo.New([]byte("test")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		atomic.StoreInt32(&lost, 1)
+		assert.NoError(t, client.Delete(ctx, *id))
+		_, err = client.Find(ctx, *id)
+		assert.Equal(t, &photo.ResourceError{Id: *id, Err: photo.ErrNotFound}, err)
+	})
+
+	t.Run("when photo does not exist, succeeds", func(t *testing.T) {
+		atomic.StoreInt32(&lost, 0)
+		assert.NoError(t, client.Delete(ctx, *photo.IdentifierOf("unknown")))
+	})
+}
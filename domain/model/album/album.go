@@ -0,0 +1,97 @@
+package album
+
+import "github.com/photoshelf/photoshelf-storage/domain/model/photo"
+
+type Album struct {
+	id          *Identifier
+	title       string
+	description string
+	cover       *photo.Identifier
+	photos      []photo.Identifier
+}
+
+func New(title string, description string) *Album {
+	return &Album{id: &Identifier{}, title: title, description: description}
+}
+
+func Of(id Identifier, title string, description string, cover *photo.Identifier, photos []photo.Identifier) *Album {
+	return &Album{id: &id, title: title, description: description, cover: cover, photos: photos}
+}
+
+func (album *Album) Id() *Identifier {
+	return album.id
+}
+
+func (album *Album) IsNew() bool {
+	return len(album.id.value) == 0
+}
+
+func (album *Album) Title() string {
+	return album.title
+}
+
+func (album *Album) Description() string {
+	return album.description
+}
+
+func (album *Album) Cover() *photo.Identifier {
+	return album.cover
+}
+
+func (album *Album) Photos() []photo.Identifier {
+	return album.photos
+}
+
+func (album *Album) Contains(id photo.Identifier) bool {
+	return album.indexOf(id) >= 0
+}
+
+func (album *Album) AddPhoto(id photo.Identifier) {
+	if album.Contains(id) {
+		return
+	}
+	album.photos = append(album.photos, id)
+}
+
+func (album *Album) RemovePhoto(id photo.Identifier) {
+	i := album.indexOf(id)
+	if i < 0 {
+		return
+	}
+	album.photos = append(album.photos[:i], album.photos[i+1:]...)
+	if album.cover != nil && *album.cover == id {
+		album.cover = nil
+	}
+}
+
+func (album *Album) SetCover(id *photo.Identifier) error {
+	if id != nil && !album.Contains(*id) {
+		return ErrCoverNotInAlbum
+	}
+	album.cover = id
+	return nil
+}
+
+func (album *Album) Reorder(photos []photo.Identifier) error {
+	if len(photos) != len(album.photos) {
+		return ErrInvalidPhotoList
+	}
+	seen := make(map[photo.Identifier]bool)
+	for _, id := range photos {
+		if seen[id] || !album.Contains(id) {
+			return ErrInvalidPhotoList
+		}
+		seen[id] = true
+	}
+	album.photos = append([]photo.Identifier{}, photos...)
+	return nil
+}
+
+func (album *Album) indexOf(id photo.Identifier) int {
+	for i, photoId := range album.photos {
+		if photoId == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,90 @@
+package album
+
+import (
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func photos(values ...string) []photo.Identifier {
+	var ids []photo.Identifier
+	for _, value := range values {
+		ids = append(ids, *photo.IdentifierOf(value))
+	}
+	return ids
+}
+
+func TestNew(t *testing.T) {
+	instance := New("title", "description")
+	assert.Equal(t, "title", instance.Title())
+	assert.Equal(t, "description", instance.Description())
+	assert.True(t, instance.IsNew())
+	assert.Nil(t, instance.Cover())
+	assert.Empty(t, instance.Photos())
+}
+
+func TestOf(t *testing.T) {
+	instance := Of(*IdentifierOf("id"), "title", "description", photo.IdentifierOf("a"), photos("a", "b"))
+	assert.Equal(t, "id", instance.Id().Value())
+	assert.False(t, instance.IsNew())
+	assert.Equal(t, "a", instance.Cover().Value())
+	assert.Equal(t, photos("a", "b"), instance.Photos())
+}
+
+func TestAlbum_AddPhoto(t *testing.T) {
+	t.Run("appends photo to last", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), "", "", nil, photos("a"))
+		instance.AddPhoto(*photo.IdentifierOf("b"))
+		assert.Equal(t, photos("a", "b"), instance.Photos())
+	})
+
+	t.Run("with existing photo, does nothing", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), "", "", nil, photos("a", "b"))
+		instance.AddPhoto(*photo.IdentifierOf("a"))
+		assert.Equal(t, photos("a", "b"), instance.Photos())
+	})
+}
+
+func TestAlbum_RemovePhoto(t *testing.T) {
+	t.Run("removes photo", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), "", "", nil, photos("a", "b", "c"))
+		instance.RemovePhoto(*photo.IdentifierOf("b"))
+		assert.Equal(t, photos("a", "c"), instance.Photos())
+	})
+
+	t.Run("when photo is cover, clears cover", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), "", "", photo.IdentifierOf("a"), photos("a", "b"))
+		instance.RemovePhoto(*photo.IdentifierOf("a"))
+		assert.Nil(t, instance.Cover())
+	})
+}
+
+func TestAlbum_SetCover(t *testing.T) {
+	t.Run("with photo in album, sets cover", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), "", "", nil, photos("a", "b"))
+		if assert.NoError(t, instance.SetCover(photo.IdentifierOf("b"))) {
+			assert.Equal(t, "b", instance.Cover().Value())
+		}
+	})
+
+	t.Run("with photo not in album, returns error", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), "", "", nil, photos("a"))
+		assert.Equal(t, ErrCoverNotInAlbum, instance.SetCover(photo.IdentifierOf("b")))
+	})
+}
+
+func TestAlbum_Reorder(t *testing.T) {
+	t.Run("with same photos, reorders", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), "", "", nil, photos("a", "b", "c"))
+		if assert.NoError(t, instance.Reorder(photos("c", "a", "b"))) {
+			assert.Equal(t, photos("c", "a", "b"), instance.Photos())
+		}
+	})
+
+	t.Run("with different photos, returns error", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), "", "", nil, photos("a", "b"))
+		assert.Equal(t, ErrInvalidPhotoList, instance.Reorder(photos("a", "c")))
+		assert.Equal(t, ErrInvalidPhotoList, instance.Reorder(photos("a", "a")))
+		assert.Equal(t, ErrInvalidPhotoList, instance.Reorder(photos("a")))
+	})
+}
@@ -0,0 +1,150 @@
+package albumtest
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// RunRepositoryTests checks that the repository returned by factory satisfies the semantics
+// every album.Repository is expected to have. factory must return an empty repository on each call.
+func RunRepositoryTests(t *testing.T, factory func(t *testing.T) album.Repository) {
+	t.Run("save without identifier, generates new identifier", func(t *testing.T) {
+		repository := factory(t)
+		id, err := repository.Save(context.Background(), *album.New("title", "description"))
+		if assert.NoError(t, err) {
+			assert.NoError(t, album.ValidateIdentifier(*id))
+			assertAlbum(t, repository, *album.Of(*id, "title", "description", nil, nil))
+		}
+	})
+
+	t.Run("read, returns saved album", func(t *testing.T) {
+		repository := factory(t)
+		saved := album.Of(*album.IdentifierOf("id"), "title", "description", photo.IdentifierOf("a"), photos("b", "a"))
+		id, err := repository.Save(context.Background(), *saved)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "id", id.Value())
+			assertAlbum(t, repository, *saved)
+		}
+	})
+
+	t.Run("read missing, returns not found", func(t *testing.T) {
+		repository := factory(t)
+		_, err := repository.Read(context.Background(), *album.IdentifierOf("missing"))
+		assertError(t, album.ErrNotFound, err)
+	})
+
+	t.Run("read all, returns every album", func(t *testing.T) {
+		repository := factory(t)
+		assertIds(t, repository)
+
+		save(t, repository, "first")
+		save(t, repository, "second")
+		assertIds(t, repository, "first", "second")
+	})
+
+	t.Run("delete, removes album", func(t *testing.T) {
+		repository := factory(t)
+		id := save(t, repository, "id")
+
+		if assert.NoError(t, repository.Delete(context.Background(), *id)) {
+			_, err := repository.Read(context.Background(), *id)
+			assertError(t, album.ErrNotFound, err)
+			assertIds(t, repository)
+		}
+	})
+
+	t.Run("delete missing, returns no error", func(t *testing.T) {
+		repository := factory(t)
+		assert.NoError(t, repository.Delete(context.Background(), *album.IdentifierOf("missing")))
+	})
+
+	t.Run("invalid identifier, returns error without touching albums", func(t *testing.T) {
+		repository := factory(t)
+		save(t, repository, "x")
+
+		for _, value := range []string{"../../x", "../x", "..", "a/b"} {
+			id := album.IdentifierOf(value)
+			_, err := repository.Save(context.Background(), *album.Of(*id, "title", "", nil, nil))
+			assertError(t, album.ErrInvalidIdentifier, err)
+			_, err = repository.Read(context.Background(), *id)
+			assertError(t, album.ErrInvalidIdentifier, err)
+			assertError(t, album.ErrInvalidIdentifier, repository.Delete(context.Background(), *id))
+		}
+		assertIds(t, repository, "x")
+	})
+
+	t.Run("cancelled context, returns error", func(t *testing.T) {
+		repository := factory(t)
+		id := save(t, repository, "id")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repository.Save(ctx, *album.Of(*album.IdentifierOf("other"), "", "", nil, nil))
+		assert.Error(t, err)
+		_, err = repository.Read(ctx, *id)
+		assert.Error(t, err)
+		_, err = repository.ReadAll(ctx)
+		assert.Error(t, err)
+		assert.Error(t, repository.Delete(ctx, *id))
+
+		assertIds(t, repository, "id")
+	})
+}
+
+func save(t *testing.T, repository album.Repository, id string) *album.Identifier {
+	t.Helper()
+	identifier, err := repository.Save(context.Background(), *album.Of(*album.IdentifierOf(id), id, "", nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identifier
+}
+
+func photos(values ...string) []photo.Identifier {
+	var ids []photo.Identifier
+	for _, value := range values {
+		ids = append(ids, *photo.IdentifierOf(value))
+	}
+	return ids
+}
+
+func assertAlbum(t *testing.T, repository album.Repository, expected album.Album) {
+	t.Helper()
+	actual, err := repository.Read(context.Background(), *expected.Id())
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected.Id(), actual.Id())
+		assert.Equal(t, expected.Title(), actual.Title())
+		assert.Equal(t, expected.Description(), actual.Description())
+		assert.Equal(t, expected.Cover(), actual.Cover())
+		assert.Equal(t, expected.Photos(), actual.Photos())
+	}
+}
+
+func assertIds(t *testing.T, repository album.Repository, expected ...string) {
+	t.Helper()
+	albums, err := repository.ReadAll(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	var actual []string
+	for _, a := range albums {
+		actual = append(actual, a.Id().Value())
+	}
+	if len(expected) == 0 {
+		assert.Empty(t, actual)
+		return
+	}
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func assertError(t *testing.T, expected error, err error) {
+	t.Helper()
+	if assert.Error(t, err) {
+		if e, ok := err.(*album.ResourceError); assert.True(t, ok, "error should be *album.ResourceError : %v", err) {
+			assert.Equal(t, expected, e.Err)
+		}
+	}
+}
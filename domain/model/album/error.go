@@ -0,0 +1,24 @@
+package album
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound         = errors.New("id does not exists")
+	ErrCoverNotInAlbum  = errors.New("cover must be a photo in album")
+	ErrInvalidPhotoList = errors.New("photos must be same as album has")
+	ErrPhotoNotFound    = errors.New("photo in album does not exist")
+
+	ErrInvalidIdentifier = errors.New("id must not be empty nor start with ., nor contain /, : or control characters")
+)
+
+type ResourceError struct {
+	Id  Identifier
+	Err error
+}
+
+func (err *ResourceError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Id.value, err.Err.Error())
+}
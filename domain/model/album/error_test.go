@@ -0,0 +1,11 @@
+package album
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResourceError_Error(t *testing.T) {
+	e := &ResourceError{*IdentifierOf("id"), ErrNotFound}
+	assert.Equal(t, "id: id does not exists", e.Error())
+}
@@ -0,0 +1,29 @@
+package album
+
+import "github.com/photoshelf/photoshelf-storage/domain/model/photo"
+
+type Identifier struct {
+	value string
+}
+
+// NewIdentifier generates an id the same way as photo ids.
+func NewIdentifier() *Identifier {
+	return &Identifier{photo.NewIdentifier(nil).Value()}
+}
+
+func IdentifierOf(value string) *Identifier {
+	return &Identifier{value}
+}
+
+func (id *Identifier) Value() string {
+	return id.value
+}
+
+// ValidateIdentifier tells whether an id given by a client can name an album, under the same rules as photo ids,
+// so that storages can use it as a file name or a key.
+func ValidateIdentifier(id Identifier) error {
+	if photo.ValidateIdentifier(*photo.IdentifierOf(id.value)) != nil {
+		return ErrInvalidIdentifier
+	}
+	return nil
+}
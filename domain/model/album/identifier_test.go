@@ -0,0 +1,35 @@
+package album
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewIdentifier(t *testing.T) {
+	t.Run("it is unique", func(t *testing.T) {
+		ids := make(map[string]bool)
+		for i := 0; i < 1000; i++ {
+			id := NewIdentifier()
+			assert.False(t, ids[id.Value()])
+			ids[id.Value()] = true
+		}
+	})
+}
+
+func TestValidateIdentifier(t *testing.T) {
+	assert.NoError(t, ValidateIdentifier(*NewIdentifier()))
+	for _, id := range []string{"e3158990bdee63f8594c260cd51a011d", "a.b", "サクラ", "a b"} {
+		assert.NoError(t, ValidateIdentifier(*IdentifierOf(id)), id)
+	}
+	for _, id := range []string{"", ".", "..", "../../x", ".albums", "a/b", "albums:a", "a\x00b"} {
+		assert.Equal(t, ErrInvalidIdentifier, ValidateIdentifier(*IdentifierOf(id)), id)
+	}
+}
+
+func ExampleIdentifier_Value() {
+	id := IdentifierOf("example_id")
+	fmt.Println(id.Value())
+	// Output:
+	// example_id
+}
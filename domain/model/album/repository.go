@@ -0,0 +1,13 @@
+package album
+
+import "context"
+
+type Repository interface {
+	Save(ctx context.Context, album Album) (*Identifier, error)
+
+	Read(ctx context.Context, id Identifier) (*Album, error)
+
+	ReadAll(ctx context.Context) ([]Album, error)
+
+	Delete(ctx context.Context, id Identifier) error
+}
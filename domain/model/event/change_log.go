@@ -0,0 +1,15 @@
+package event
+
+import "context"
+
+// ChangeLog keeps published events by their outbox sequence, so that watchers can resume from a sequence.
+// Putting a record with a sequence already kept replaces it.
+type ChangeLog interface {
+	Put(ctx context.Context, record Record) error
+
+	Since(ctx context.Context, sequence uint64, limit int) ([]Record, error)
+
+	Latest(ctx context.Context) (uint64, error)
+
+	Trim(ctx context.Context, before uint64) error
+}
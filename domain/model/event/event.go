@@ -0,0 +1,99 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"time"
+)
+
+const (
+	TypePhotoCreated = "photo.created"
+	TypePhotoUpdated = "photo.updated"
+	TypePhotoDeleted = "photo.deleted"
+)
+
+type Event interface {
+	Type() string
+	PhotoId() photo.Identifier
+	Time() time.Time
+}
+
+type PhotoCreated struct {
+	Id         photo.Identifier
+	Tags       []string
+	OccurredAt time.Time
+}
+
+func (e PhotoCreated) Type() string              { return TypePhotoCreated }
+func (e PhotoCreated) PhotoId() photo.Identifier { return e.Id }
+func (e PhotoCreated) Time() time.Time           { return e.OccurredAt }
+
+type PhotoUpdated struct {
+	Id         photo.Identifier
+	Tags       []string
+	OccurredAt time.Time
+}
+
+func (e PhotoUpdated) Type() string              { return TypePhotoUpdated }
+func (e PhotoUpdated) PhotoId() photo.Identifier { return e.Id }
+func (e PhotoUpdated) Time() time.Time           { return e.OccurredAt }
+
+type PhotoDeleted struct {
+	Id         photo.Identifier
+	OccurredAt time.Time
+}
+
+func (e PhotoDeleted) Type() string              { return TypePhotoDeleted }
+func (e PhotoDeleted) PhotoId() photo.Identifier { return e.Id }
+func (e PhotoDeleted) Time() time.Time           { return e.OccurredAt }
+
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+
+	// Stage returns a context under which the write of a photo appends its event to the outbox,
+	// when the storage holding the outbox supports it.
+	Stage(ctx context.Context, build func(id photo.Identifier) Event) (context.Context, *Staged)
+
+	// Commit publishes the staged event of a write which succeeded, appending it unless the write did.
+	Commit(ctx context.Context, staged *Staged, id photo.Identifier) error
+}
+
+type envelope struct {
+	Type       string    `json:"type"`
+	Id         string    `json:"id"`
+	Tags       []string  `json:"tags,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func Marshal(e Event) ([]byte, error) {
+	id := e.PhotoId()
+	value := envelope{Type: e.Type(), Id: id.Value(), OccurredAt: e.Time()}
+	switch e := e.(type) {
+	case PhotoCreated:
+		value.Tags = e.Tags
+	case PhotoUpdated:
+		value.Tags = e.Tags
+	}
+	return json.Marshal(value)
+}
+
+func Unmarshal(data []byte) (Event, error) {
+	value := envelope{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	id := *photo.IdentifierOf(value.Id)
+	switch value.Type {
+	case TypePhotoCreated:
+		return PhotoCreated{Id: id, Tags: value.Tags, OccurredAt: value.OccurredAt}, nil
+	case TypePhotoUpdated:
+		return PhotoUpdated{Id: id, Tags: value.Tags, OccurredAt: value.OccurredAt}, nil
+	case TypePhotoDeleted:
+		return PhotoDeleted{Id: id, OccurredAt: value.OccurredAt}, nil
+	default:
+		return nil, fmt.Errorf("unknown event type : %s", value.Type)
+	}
+}
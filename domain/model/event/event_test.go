@@ -0,0 +1,33 @@
+package event
+
+import (
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMarshal(t *testing.T) {
+	occurredAt := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, e := range []Event{
+		PhotoCreated{Id: *photo.IdentifierOf("id"), Tags: []string{"a", "b"}, OccurredAt: occurredAt},
+		PhotoUpdated{Id: *photo.IdentifierOf("id"), Tags: []string{"a"}, OccurredAt: occurredAt},
+		PhotoDeleted{Id: *photo.IdentifierOf("id"), OccurredAt: occurredAt},
+	} {
+		t.Run(e.Type()+", round trips", func(t *testing.T) {
+			data, err := Marshal(e)
+			if !assert.NoError(t, err) {
+				return
+			}
+			actual, err := Unmarshal(data)
+			if assert.NoError(t, err) {
+				assert.Equal(t, e, actual)
+			}
+		})
+	}
+
+	t.Run("with unknown type, returns error", func(t *testing.T) {
+		_, err := Unmarshal([]byte(`{"type": "album.created", "id": "id"}`))
+		assert.Error(t, err)
+	})
+}
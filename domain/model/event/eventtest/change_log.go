@@ -0,0 +1,96 @@
+package eventtest
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// RunChangeLogTests checks that the change log returned by factory keeps records ordered by sequence.
+// factory must return an empty change log on each call.
+func RunChangeLogTests(t *testing.T, factory func(t *testing.T) event.ChangeLog) {
+	t.Run("empty, returns no records and zero latest", func(t *testing.T) {
+		changes := factory(t)
+		assertSince(t, changes, 0, 10)
+		assertLatest(t, changes, 0)
+	})
+
+	t.Run("since, returns records after sequence in order", func(t *testing.T) {
+		changes := factory(t)
+		put(t, changes, 1, 2, 3, 10)
+
+		assertSince(t, changes, 0, 10, 1, 2, 3, 10)
+		assertSince(t, changes, 2, 10, 3, 10)
+		assertSince(t, changes, 10, 10)
+		assertLatest(t, changes, 10)
+	})
+
+	t.Run("since with limit, returns first records", func(t *testing.T) {
+		changes := factory(t)
+		put(t, changes, 1, 2, 3)
+
+		assertSince(t, changes, 0, 2, 1, 2)
+	})
+
+	t.Run("put same sequence, keeps one record", func(t *testing.T) {
+		changes := factory(t)
+		put(t, changes, 1, 1)
+
+		assertSince(t, changes, 0, 10, 1)
+	})
+
+	t.Run("trim, removes records before sequence", func(t *testing.T) {
+		changes := factory(t)
+		put(t, changes, 1, 2, 3)
+
+		if assert.NoError(t, changes.Trim(context.Background(), 3)) {
+			assertSince(t, changes, 0, 10, 3)
+			assertLatest(t, changes, 3)
+		}
+	})
+
+	t.Run("cancelled context, returns error", func(t *testing.T) {
+		changes := factory(t)
+		put(t, changes, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.Error(t, changes.Put(ctx, event.Record{Sequence: 2, Event: created("2")}))
+		_, err := changes.Since(ctx, 0, 10)
+		assert.Error(t, err)
+
+		assertSince(t, changes, 0, 10, 1)
+	})
+}
+
+func put(t *testing.T, changes event.ChangeLog, sequences ...uint64) {
+	t.Helper()
+	for _, sequence := range sequences {
+		if err := changes.Put(context.Background(), event.Record{Sequence: sequence, Event: created("id")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertSince(t *testing.T, changes event.ChangeLog, sequence uint64, limit int, expected ...uint64) {
+	t.Helper()
+	records, err := changes.Since(context.Background(), sequence, limit)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var actual []uint64
+	for _, record := range records {
+		actual = append(actual, record.Sequence)
+		assert.Equal(t, created("id"), record.Event)
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func assertLatest(t *testing.T, changes event.ChangeLog, expected uint64) {
+	t.Helper()
+	latest, err := changes.Latest(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, latest)
+	}
+}
@@ -0,0 +1,117 @@
+package eventtest
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// RunOutboxTests checks that the outbox returned by factory keeps events in order until they are removed.
+// factory must return an empty outbox on each call.
+func RunOutboxTests(t *testing.T, factory func(t *testing.T) event.Outbox) {
+	t.Run("empty, returns no records", func(t *testing.T) {
+		outbox := factory(t)
+		assertPending(t, outbox)
+	})
+
+	t.Run("append, returns records in order", func(t *testing.T) {
+		outbox := factory(t)
+		first := created("first")
+		second := deleted("second")
+		appendEvents(t, outbox, first, second)
+
+		records := assertPending(t, outbox, first, second)
+		if len(records) == 2 {
+			assert.True(t, records[0].Sequence < records[1].Sequence)
+		}
+	})
+
+	t.Run("remove, drops only that record", func(t *testing.T) {
+		outbox := factory(t)
+		first := created("first")
+		second := updated("second")
+		appendEvents(t, outbox, first, second)
+
+		records := assertPending(t, outbox, first, second)
+		if assert.NoError(t, outbox.Remove(context.Background(), records[0].Sequence)) {
+			assertPending(t, outbox, second)
+		}
+	})
+
+	t.Run("remove missing, returns no error", func(t *testing.T) {
+		outbox := factory(t)
+		assert.NoError(t, outbox.Remove(context.Background(), 42))
+	})
+
+	t.Run("append after remove, does not reuse sequence", func(t *testing.T) {
+		outbox := factory(t)
+		appendEvents(t, outbox, created("first"))
+		records := assertPending(t, outbox, created("first"))
+		if err := outbox.Remove(context.Background(), records[0].Sequence); err != nil {
+			t.Fatal(err)
+		}
+
+		appendEvents(t, outbox, created("second"))
+		actual := assertPending(t, outbox, created("second"))
+		if len(actual) == 1 {
+			assert.True(t, records[0].Sequence < actual[0].Sequence)
+		}
+	})
+
+	t.Run("cancelled context, returns error", func(t *testing.T) {
+		outbox := factory(t)
+		appendEvents(t, outbox, created("first"))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.Error(t, outbox.Append(ctx, created("second")))
+		_, err := outbox.Pending(ctx)
+		assert.Error(t, err)
+
+		assertPending(t, outbox, created("first"))
+	})
+}
+
+var occurredAt = time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func created(id string) event.Event {
+	return event.PhotoCreated{Id: *photo.IdentifierOf(id), Tags: []string{"tag"}, OccurredAt: occurredAt}
+}
+
+func updated(id string) event.Event {
+	return event.PhotoUpdated{Id: *photo.IdentifierOf(id), Tags: []string{"tag"}, OccurredAt: occurredAt}
+}
+
+func deleted(id string) event.Event {
+	return event.PhotoDeleted{Id: *photo.IdentifierOf(id), OccurredAt: occurredAt}
+}
+
+func appendEvents(t *testing.T, outbox event.Outbox, events ...event.Event) {
+	t.Helper()
+	for _, e := range events {
+		if err := outbox.Append(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertPending(t *testing.T, outbox event.Outbox, expected ...event.Event) []event.Record {
+	t.Helper()
+	records, err := outbox.Pending(context.Background())
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	var actual []event.Event
+	for _, record := range records {
+		actual = append(actual, record.Event)
+	}
+	if len(expected) == 0 {
+		assert.Empty(t, actual)
+	} else {
+		assert.Equal(t, expected, actual)
+	}
+	return records
+}
@@ -0,0 +1,18 @@
+package event
+
+import "context"
+
+type Record struct {
+	Sequence uint64
+	Event    Event
+}
+
+// Outbox keeps published events until every subscriber received them.
+// Pending returns records in the order they were appended.
+type Outbox interface {
+	Append(ctx context.Context, e Event) error
+
+	Pending(ctx context.Context) ([]Record, error)
+
+	Remove(ctx context.Context, sequence uint64) error
+}
@@ -0,0 +1,48 @@
+package event
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"sync"
+)
+
+type stagedKey struct{}
+
+// Staged is the event of a photo write, to append to an outbox along with the write.
+// The storage holding the outbox appends it in the same transaction as the photo, and marks it appended.
+type Staged struct {
+	Outbox Outbox
+
+	build func(id photo.Identifier) Event
+
+	mu       sync.Mutex
+	appended bool
+}
+
+// Stage returns a context under which the write of a photo appends the event built for its id to outbox.
+func Stage(ctx context.Context, outbox Outbox, build func(id photo.Identifier) Event) (context.Context, *Staged) {
+	staged := &Staged{Outbox: outbox, build: build}
+	return context.WithValue(ctx, stagedKey{}, staged), staged
+}
+
+// StagedIn returns the event staged for the write of ctx, nil without one.
+func StagedIn(ctx context.Context) *Staged {
+	staged, _ := ctx.Value(stagedKey{}).(*Staged)
+	return staged
+}
+
+func (staged *Staged) Event(id photo.Identifier) Event {
+	return staged.build(id)
+}
+
+func (staged *Staged) MarkAppended() {
+	staged.mu.Lock()
+	defer staged.mu.Unlock()
+	staged.appended = true
+}
+
+func (staged *Staged) Appended() bool {
+	staged.mu.Lock()
+	defer staged.mu.Unlock()
+	return staged.appended
+}
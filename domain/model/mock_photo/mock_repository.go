@@ -5,6 +5,7 @@
 package mock_photo
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
 	reflect "reflect"
@@ -34,39 +35,77 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 }
 
 // Save mocks base method
-func (m *MockRepository) Save(photograph photo.Photo) (*photo.Identifier, error) {
-	ret := m.ctrl.Call(m, "Save", photograph)
+func (m *MockRepository) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	ret := m.ctrl.Call(m, "Save", ctx, photograph)
 	ret0, _ := ret[0].(*photo.Identifier)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Save indicates an expected call of Save
-func (mr *MockRepositoryMockRecorder) Save(photo interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), photo)
+func (mr *MockRepositoryMockRecorder) Save(ctx, photo interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, photo)
 }
 
 // Read mocks base method
-func (m *MockRepository) Read(id photo.Identifier) (*photo.Photo, error) {
-	ret := m.ctrl.Call(m, "Read", id)
+func (m *MockRepository) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	ret := m.ctrl.Call(m, "Read", ctx, id)
 	ret0, _ := ret[0].(*photo.Photo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Read indicates an expected call of Read
-func (mr *MockRepositoryMockRecorder) Read(id interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockRepository)(nil).Read), id)
+func (mr *MockRepositoryMockRecorder) Read(ctx, id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockRepository)(nil).Read), ctx, id)
 }
 
 // Delete mocks base method
-func (m *MockRepository) Delete(id photo.Identifier) error {
-	ret := m.ctrl.Call(m, "Delete", id)
+func (m *MockRepository) Delete(ctx context.Context, id photo.Identifier) error {
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete
-func (mr *MockRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), id)
+func (mr *MockRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), ctx, id)
+}
+
+// FindAll mocks base method
+func (m *MockRepository) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	ret := m.ctrl.Call(m, "FindAll", ctx)
+	ret0, _ := ret[0].([]photo.Identifier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAll indicates an expected call of FindAll
+func (mr *MockRepositoryMockRecorder) FindAll(ctx interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockRepository)(nil).FindAll), ctx)
+}
+
+// FindByTag mocks base method
+func (m *MockRepository) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	ret := m.ctrl.Call(m, "FindByTag", ctx, tag)
+	ret0, _ := ret[0].([]photo.Identifier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByTag indicates an expected call of FindByTag
+func (mr *MockRepositoryMockRecorder) FindByTag(ctx, tag interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByTag", reflect.TypeOf((*MockRepository)(nil).FindByTag), ctx, tag)
+}
+
+// Close mocks base method
+func (m *MockRepository) Close() error {
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close
+func (mr *MockRepositoryMockRecorder) Close() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockRepository)(nil).Close))
 }
@@ -0,0 +1,22 @@
+package photo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+var ErrNoChecksums = errors.New("repository does not record checksums")
+
+type ChecksumRepository interface {
+	Checksums(ctx context.Context) (map[Identifier]string, error)
+
+	// ChecksumOf returns the checksum recorded for a photo, or ErrNotFound when there is none.
+	ChecksumOf(ctx context.Context, id Identifier) (string, error)
+}
+
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,20 @@
+package photo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	t.Run("with same data, returns same checksum", func(t *testing.T) {
+		assert.Equal(t, Checksum([]byte("data")), Checksum([]byte("data")))
+	})
+
+	t.Run("with different data, returns different checksum", func(t *testing.T) {
+		assert.NotEqual(t, Checksum([]byte("data")), Checksum([]byte("date")))
+	})
+
+	t.Run("returns sha256 hex", func(t *testing.T) {
+		assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Checksum(nil))
+	})
+}
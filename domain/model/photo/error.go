@@ -8,6 +8,9 @@ import (
 var (
 	ErrNotFound   = errors.New("id does not exists")
 	ErrCannotRead = errors.New("photo can't read")
+	ErrInvalidTag = errors.New("tag must not be empty, . or .., nor contain / or control characters")
+
+	ErrInvalidIdentifier = errors.New("id must not be empty nor start with ., nor contain /, : or control characters")
 )
 
 type ResourceError struct {
@@ -0,0 +1,28 @@
+package photo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrSentinelMismatch = errors.New("sentinel read back differs from the written one")
+
+// HealthRepository is implemented by repositories which can check that their storage is writable,
+// by writing a sentinel apart from photos and reading it back.
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks the repository with its own Ping, or by listing photos when it has none.
+func Ping(ctx context.Context, repository Repository) error {
+	if r, ok := repository.(HealthRepository); ok {
+		return r.Ping(ctx)
+	}
+	_, err := repository.FindAll(ctx)
+	return err
+}
+
+func Sentinel() []byte {
+	return []byte(time.Now().UTC().Format(time.RFC3339Nano))
+}
@@ -3,7 +3,9 @@ package photo
 import (
 	"crypto/md5"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 )
 
 type Identifier struct {
@@ -23,3 +25,12 @@ func IdentifierOf(value string) *Identifier {
 func (id *Identifier) Value() string {
 	return id.value
 }
+
+// ValidateIdentifier tells whether an id given by a client can name a photo,
+// so that storages can use it as a file name or a key beside their own keys.
+func ValidateIdentifier(id Identifier) error {
+	if id.value == "" || strings.HasPrefix(id.value, ".") || strings.ContainsAny(id.value, "/:") || strings.IndexFunc(id.value, unicode.IsControl) >= 0 {
+		return ErrInvalidIdentifier
+	}
+	return nil
+}
@@ -31,6 +31,15 @@ func TestIdentifier_Value(t *testing.T) {
 	})
 }
 
+func TestValidateIdentifier(t *testing.T) {
+	for _, id := range []string{"e3158990bdee63f8594c260cd51a011d", "a.b", "サクラ", "a b"} {
+		assert.NoError(t, ValidateIdentifier(*IdentifierOf(id)), id)
+	}
+	for _, id := range []string{"", ".", "..", ".tags", "a/b", "checksums:a", "a\x00b"} {
+		assert.Equal(t, ErrInvalidIdentifier, ValidateIdentifier(*IdentifierOf(id)), id)
+	}
+}
+
 func ExampleIdentifier_Value() {
 	id := IdentifierOf("example_id")
 	fmt.Println(id.Value())
@@ -1,16 +1,35 @@
 package photo
 
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
 type Photo struct {
 	id    *Identifier
 	image []byte
+	tags  []string
 }
 
 func New(data []byte) *Photo {
-	return &Photo{&Identifier{}, data}
+	return &Photo{&Identifier{}, data, nil}
+}
+
+func Of(id Identifier, data []byte, tags ...string) *Photo {
+	photo := &Photo{&id, data, nil}
+	for _, tag := range tags {
+		photo.AddTag(tag)
+	}
+	return photo
 }
 
-func Of(id Identifier, data []byte) *Photo {
-	return &Photo{&id, data}
+// ValidateTag tells whether tag can name a tag, so that storages can use it as a path segment.
+func ValidateTag(tag string) error {
+	if tag == "" || tag == "." || tag == ".." || strings.ContainsRune(tag, '/') || strings.IndexFunc(tag, unicode.IsControl) >= 0 {
+		return ErrInvalidTag
+	}
+	return nil
 }
 
 func (photo *Photo) Image() []byte {
@@ -24,3 +43,33 @@ func (photo *Photo) Id() *Identifier {
 func (photo *Photo) IsNew() bool {
 	return len(photo.id.value) == 0
 }
+
+func (photo *Photo) Tags() []string {
+	return photo.tags
+}
+
+func (photo *Photo) HasTag(tag string) bool {
+	i := sort.SearchStrings(photo.tags, tag)
+	return i < len(photo.tags) && photo.tags[i] == tag
+}
+
+func (photo *Photo) AddTag(tag string) {
+	if photo.HasTag(tag) {
+		return
+	}
+	i := sort.SearchStrings(photo.tags, tag)
+	photo.tags = append(photo.tags, "")
+	copy(photo.tags[i+1:], photo.tags[i:])
+	photo.tags[i] = tag
+}
+
+func (photo *Photo) RemoveTag(tag string) {
+	if !photo.HasTag(tag) {
+		return
+	}
+	i := sort.SearchStrings(photo.tags, tag)
+	photo.tags = append(photo.tags[:i], photo.tags[i+1:]...)
+	if len(photo.tags) == 0 {
+		photo.tags = nil
+	}
+}
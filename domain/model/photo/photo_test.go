@@ -40,3 +40,64 @@ func TestPhoto_IsNew(t *testing.T) {
 		assert.False(t, instance.IsNew())
 	})
 }
+
+func TestOf_WithTags(t *testing.T) {
+	t.Run("tags are sorted and unique", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), []byte("image"), "b", "a", "b")
+		assert.Equal(t, []string{"a", "b"}, instance.Tags())
+	})
+
+	t.Run("without tags, returns nil", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), []byte("image"))
+		assert.Nil(t, instance.Tags())
+	})
+}
+
+func TestPhoto_AddTag(t *testing.T) {
+	t.Run("adds tag in order", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), []byte("image"), "a", "c")
+		instance.AddTag("b")
+		assert.Equal(t, []string{"a", "b", "c"}, instance.Tags())
+	})
+
+	t.Run("with existing tag, does nothing", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), []byte("image"), "a")
+		instance.AddTag("a")
+		assert.Equal(t, []string{"a"}, instance.Tags())
+	})
+}
+
+func TestPhoto_RemoveTag(t *testing.T) {
+	t.Run("removes tag", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), []byte("image"), "a", "b")
+		instance.RemoveTag("a")
+		assert.Equal(t, []string{"b"}, instance.Tags())
+	})
+
+	t.Run("removes last tag, returns nil", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), []byte("image"), "a")
+		instance.RemoveTag("a")
+		assert.Nil(t, instance.Tags())
+	})
+
+	t.Run("with no such tag, does nothing", func(t *testing.T) {
+		instance := Of(*IdentifierOf("id"), []byte("image"), "a")
+		instance.RemoveTag("b")
+		assert.Equal(t, []string{"a"}, instance.Tags())
+	})
+}
+
+func TestPhoto_HasTag(t *testing.T) {
+	instance := Of(*IdentifierOf("id"), []byte("image"), "a")
+	assert.True(t, instance.HasTag("a"))
+	assert.False(t, instance.HasTag("b"))
+}
+
+func TestValidateTag(t *testing.T) {
+	for _, tag := range []string{"cat", "サクラ", "a.b", "...", "a b"} {
+		assert.NoError(t, ValidateTag(tag), tag)
+	}
+	for _, tag := range []string{"", ".", "..", "a/b", "/", "a\x00b", "a\nb"} {
+		assert.Equal(t, ErrInvalidTag, ValidateTag(tag), tag)
+	}
+}
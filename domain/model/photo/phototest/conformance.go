@@ -0,0 +1,342 @@
+package phototest
+
+import (
+	"context"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+const largePayloadSize = 4 * 1024 * 1024
+
+// RunRepositoryTests checks that the repository returned by factory satisfies the semantics
+// every photo.Repository is expected to have. factory must return an empty repository on each call.
+func RunRepositoryTests(t *testing.T, factory func(t *testing.T) photo.Repository) {
+	t.Run("save without identifier, generates new identifier", func(t *testing.T) {
+		repository := factory(t)
+		id, err := repository.Save(context.Background(), *photo.New([]byte("data")))
+		if assert.NoError(t, err) {
+			assert.NotEmpty(t, id.Value())
+			assertPhoto(t, repository, *id, []byte("data"))
+		}
+	})
+
+	t.Run("save with identifier, returns same identifier", func(t *testing.T) {
+		repository := factory(t)
+		id, err := repository.Save(context.Background(), *photo.Of(*photo.IdentifierOf("id"), []byte("data")))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "id", id.Value())
+		}
+	})
+
+	t.Run("read, returns saved data and tags", func(t *testing.T) {
+		repository := factory(t)
+		id := save(t, repository, "id", []byte("data"), "b", "a")
+
+		assertPhoto(t, repository, *id, []byte("data"), "a", "b")
+	})
+
+	t.Run("read missing, returns not found", func(t *testing.T) {
+		repository := factory(t)
+		_, err := repository.Read(context.Background(), *photo.IdentifierOf("missing"))
+		assertNotFound(t, err)
+	})
+
+	t.Run("overwrite, replaces data and tags", func(t *testing.T) {
+		repository := factory(t)
+		save(t, repository, "id", []byte("before"), "old", "kept")
+		id := save(t, repository, "id", []byte("after"), "kept", "new")
+
+		assertPhoto(t, repository, *id, []byte("after"), "kept", "new")
+		assertIds(t, findAll(repository), "id")
+		assertIds(t, findByTag(repository, "old"))
+		assertIds(t, findByTag(repository, "new"), "id")
+	})
+
+	t.Run("delete, removes photo and its tags", func(t *testing.T) {
+		repository := factory(t)
+		id := save(t, repository, "id", []byte("data"), "tag")
+
+		if assert.NoError(t, repository.Delete(context.Background(), *id)) {
+			_, err := repository.Read(context.Background(), *id)
+			assertNotFound(t, err)
+			assertIds(t, findAll(repository))
+			assertIds(t, findByTag(repository, "tag"))
+		}
+	})
+
+	t.Run("delete missing, returns no error", func(t *testing.T) {
+		repository := factory(t)
+		assert.NoError(t, repository.Delete(context.Background(), *photo.IdentifierOf("missing")))
+	})
+
+	t.Run("find all, returns every photo", func(t *testing.T) {
+		repository := factory(t)
+		assertIds(t, findAll(repository))
+
+		save(t, repository, "first", []byte("first"))
+		save(t, repository, "second", []byte("second"))
+		assertIds(t, findAll(repository), "first", "second")
+	})
+
+	t.Run("find by tag, returns photos having tag", func(t *testing.T) {
+		repository := factory(t)
+		save(t, repository, "first", []byte("first"), "a", "b")
+		save(t, repository, "second", []byte("second"), "b")
+
+		assertIds(t, findByTag(repository, "a"), "first")
+		assertIds(t, findByTag(repository, "b"), "first", "second")
+		assertIds(t, findByTag(repository, "c"))
+	})
+
+	t.Run("large payload, round trips", func(t *testing.T) {
+		repository := factory(t)
+		data := make([]byte, largePayloadSize)
+		rand.Read(data)
+
+		id := save(t, repository, "large", data)
+		assertPhoto(t, repository, *id, data)
+
+		if stream, ok := repository.(photo.StreamRepository); ok {
+			reader, err := stream.Open(context.Background(), *id)
+			if assert.NoError(t, err) {
+				actual, err := ioutil.ReadAll(reader)
+				if assert.NoError(t, err) {
+					assert.Equal(t, data, actual)
+				}
+				assert.NoError(t, reader.Close())
+			}
+		}
+
+		save(t, repository, "large", []byte("small"))
+		assertPhoto(t, repository, *id, []byte("small"))
+	})
+
+	t.Run("concurrent saves and reads, keep every photo", func(t *testing.T) {
+		repository := factory(t)
+		const n = 16
+
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				id := *photo.IdentifierOf(fmt.Sprintf("photo-%02d", i))
+				data := []byte(id.Value())
+				if _, err := repository.Save(context.Background(), *photo.Of(id, data, "tag")); err != nil {
+					t.Error(err)
+					return
+				}
+				actual, err := repository.Read(context.Background(), id)
+				if assert.NoError(t, err) {
+					assert.Equal(t, data, actual.Image())
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		ids, err := repository.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.Len(t, ids, n)
+		}
+		ids, err = repository.FindByTag(context.Background(), "tag")
+		if assert.NoError(t, err) {
+			assert.Len(t, ids, n)
+		}
+	})
+
+	t.Run("cancelled context, returns error", func(t *testing.T) {
+		repository := factory(t)
+		id := save(t, repository, "id", []byte("data"), "tag")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repository.Save(ctx, *photo.Of(*photo.IdentifierOf("other"), []byte("data")))
+		assert.Error(t, err)
+		_, err = repository.Read(ctx, *id)
+		assert.Error(t, err)
+		_, err = repository.FindAll(ctx)
+		assert.Error(t, err)
+		_, err = repository.FindByTag(ctx, "tag")
+		assert.Error(t, err)
+		assert.Error(t, repository.Delete(ctx, *id))
+
+		assertPhoto(t, repository, *id, []byte("data"), "tag")
+		assertIds(t, findAll(repository), "id")
+	})
+
+	t.Run("ping, doesn't add photos", func(t *testing.T) {
+		repository := factory(t)
+		if assert.NoError(t, photo.Ping(context.Background(), repository)) {
+			assertIds(t, findAll(repository))
+		}
+	})
+
+	t.Run("close, returns no error", func(t *testing.T) {
+		repository := factory(t)
+		save(t, repository, "id", []byte("data"))
+		assert.NoError(t, repository.Close())
+	})
+
+	t.Run("stats, count photos and stored bytes", func(t *testing.T) {
+		repository := factory(t)
+		save(t, repository, "first", []byte("before"))
+		save(t, repository, "first", []byte("after"))
+		save(t, repository, "large", make([]byte, largePayloadSize))
+		second := save(t, repository, "second", []byte("second"))
+		if err := repository.Delete(context.Background(), *second); err != nil {
+			t.Fatal(err)
+		}
+
+		stats, err := photo.StatsOf(context.Background(), repository)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, stats.Count)
+			assert.True(t, stats.Bytes >= int64(len("after")+largePayloadSize), "stored bytes %d", stats.Bytes)
+		}
+	})
+
+	t.Run("sizes, follow saves and deletes", func(t *testing.T) {
+		repository := factory(t)
+		save(t, repository, "first", []byte("before"))
+		save(t, repository, "first", []byte("after"))
+		save(t, repository, "large", make([]byte, largePayloadSize))
+		second := save(t, repository, "second", []byte("second"))
+		if err := repository.Delete(context.Background(), *second); err != nil {
+			t.Fatal(err)
+		}
+
+		sizes, err := photo.SizesOf(context.Background(), repository)
+		if assert.NoError(t, err) {
+			assert.Equal(t, map[photo.Identifier]int64{
+				*photo.IdentifierOf("first"): int64(len("after")),
+				*photo.IdentifierOf("large"): largePayloadSize,
+			}, sizes)
+		}
+	})
+
+	t.Run("tags of photo, follow saves and deletes", func(t *testing.T) {
+		repository := factory(t)
+		id := save(t, repository, "first", []byte("before"), "cat")
+		save(t, repository, "first", []byte("after"), "dog", "cat")
+		save(t, repository, "untagged", []byte("untagged"))
+
+		tags, err := photo.TagsOf(context.Background(), repository, *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, photo.Of(*id, nil, "dog", "cat").Tags(), tags)
+		}
+		tags, err = photo.TagsOf(context.Background(), repository, *photo.IdentifierOf("untagged"))
+		if assert.NoError(t, err) {
+			assert.Empty(t, tags)
+		}
+
+		if err := repository.Delete(context.Background(), *id); err != nil {
+			t.Fatal(err)
+		}
+		_, err = photo.TagsOf(context.Background(), repository, *id)
+		assertNotFound(t, err)
+		_, err = photo.TagsOf(context.Background(), repository, *photo.IdentifierOf("missing"))
+		assertNotFound(t, err)
+	})
+
+	t.Run("checksums, follow saves and deletes", func(t *testing.T) {
+		repository := factory(t)
+		checksums, ok := repository.(photo.ChecksumRepository)
+		if !ok {
+			t.Skip("repository does not record checksums")
+		}
+
+		save(t, repository, "first", []byte("before"))
+		save(t, repository, "first", []byte("after"))
+		second := save(t, repository, "second", []byte("second"))
+		if err := repository.Delete(context.Background(), *second); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := checksums.Checksums(context.Background())
+		if assert.NoError(t, err) {
+			assert.Equal(t, map[photo.Identifier]string{*photo.IdentifierOf("first"): photo.Checksum([]byte("after"))}, actual)
+		}
+	})
+
+	t.Run("checksum of photo, follows saves and deletes", func(t *testing.T) {
+		repository := factory(t)
+		checksums, ok := repository.(photo.ChecksumRepository)
+		if !ok {
+			t.Skip("repository does not record checksums")
+		}
+
+		id := save(t, repository, "first", []byte("before"))
+		save(t, repository, "first", []byte("after"))
+		actual, err := checksums.ChecksumOf(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, photo.Checksum([]byte("after")), actual)
+		}
+
+		if err := repository.Delete(context.Background(), *id); err != nil {
+			t.Fatal(err)
+		}
+		_, err = checksums.ChecksumOf(context.Background(), *id)
+		assert.Equal(t, &photo.ResourceError{Id: *id, Err: photo.ErrNotFound}, err)
+	})
+}
+
+func save(t *testing.T, repository photo.Repository, id string, data []byte, tags ...string) *photo.Identifier {
+	t.Helper()
+	identifier, err := repository.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), data, tags...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identifier
+}
+
+func findAll(repository photo.Repository) func() ([]photo.Identifier, error) {
+	return func() ([]photo.Identifier, error) {
+		return repository.FindAll(context.Background())
+	}
+}
+
+func findByTag(repository photo.Repository, tag string) func() ([]photo.Identifier, error) {
+	return func() ([]photo.Identifier, error) {
+		return repository.FindByTag(context.Background(), tag)
+	}
+}
+
+func assertPhoto(t *testing.T, repository photo.Repository, id photo.Identifier, data []byte, tags ...string) {
+	t.Helper()
+	actual, err := repository.Read(context.Background(), id)
+	if assert.NoError(t, err) {
+		assert.Equal(t, id, *actual.Id())
+		assert.Equal(t, data, actual.Image())
+		assert.Equal(t, photo.Of(id, nil, tags...).Tags(), actual.Tags())
+	}
+}
+
+func assertIds(t *testing.T, find func() ([]photo.Identifier, error), expected ...string) {
+	t.Helper()
+	ids, err := find()
+	if !assert.NoError(t, err) {
+		return
+	}
+	var actual []string
+	for _, id := range ids {
+		actual = append(actual, id.Value())
+	}
+	if len(expected) == 0 {
+		assert.Empty(t, actual)
+		return
+	}
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func assertNotFound(t *testing.T, err error) {
+	t.Helper()
+	if assert.Error(t, err) {
+		if e, ok := err.(*photo.ResourceError); assert.True(t, ok, "error should be *photo.ResourceError : %v", err) {
+			assert.Equal(t, photo.ErrNotFound, e.Err)
+		}
+	}
+}
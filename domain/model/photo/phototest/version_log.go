@@ -0,0 +1,121 @@
+package phototest
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// RunVersionLogTests checks that the version log returned by factory keeps the last version put for each photo.
+// factory must return an empty version log on each call.
+func RunVersionLogTests(t *testing.T, factory func(t *testing.T) photo.VersionLog) {
+	t.Run("get missing, returns not found", func(t *testing.T) {
+		versions := factory(t)
+		_, err := versions.Get(context.Background(), *photo.IdentifierOf("missing"))
+		assertNotFound(t, err)
+	})
+
+	t.Run("put, replaces version", func(t *testing.T) {
+		versions := factory(t)
+		putVersion(t, versions, "id", photo.Version{Stamp: 1})
+		assertVersion(t, versions, "id", photo.Version{Stamp: 1})
+
+		putVersion(t, versions, "id", photo.Version{Stamp: 2})
+		assertVersion(t, versions, "id", photo.Version{Stamp: 2})
+	})
+
+	t.Run("put deleted, keeps tombstone until put again", func(t *testing.T) {
+		versions := factory(t)
+		putVersion(t, versions, "id", photo.Version{Stamp: 1})
+		putVersion(t, versions, "other", photo.Version{Stamp: 1})
+		assertTombstones(t, versions, map[string]int64{})
+
+		putVersion(t, versions, "id", photo.Version{Stamp: 2, Deleted: true})
+		assertVersion(t, versions, "id", photo.Version{Stamp: 2, Deleted: true})
+		assertTombstones(t, versions, map[string]int64{"id": 2})
+
+		putVersion(t, versions, "id", photo.Version{Stamp: 3})
+		assertVersion(t, versions, "id", photo.Version{Stamp: 3})
+		assertTombstones(t, versions, map[string]int64{})
+	})
+
+	t.Run("remove, forgets version and tombstone", func(t *testing.T) {
+		versions := factory(t)
+		putVersion(t, versions, "live", photo.Version{Stamp: 1})
+		putVersion(t, versions, "deleted", photo.Version{Stamp: 1, Deleted: true})
+
+		for _, id := range []string{"live", "deleted", "missing"} {
+			if assert.NoError(t, versions.Remove(context.Background(), *photo.IdentifierOf(id))) {
+				_, err := versions.Get(context.Background(), *photo.IdentifierOf(id))
+				assertNotFound(t, err)
+			}
+		}
+		assertTombstones(t, versions, map[string]int64{})
+	})
+
+	t.Run("invalid identifier, returns error", func(t *testing.T) {
+		versions := factory(t)
+		for _, value := range []string{"../../x", "..", "a/b"} {
+			id := photo.IdentifierOf(value)
+			assertInvalid(t, versions.Put(context.Background(), *id, photo.Version{Stamp: 1, Deleted: true}))
+			_, err := versions.Get(context.Background(), *id)
+			assertInvalid(t, err)
+			assertInvalid(t, versions.Remove(context.Background(), *id))
+		}
+		assertTombstones(t, versions, map[string]int64{})
+	})
+
+	t.Run("cancelled context, returns error", func(t *testing.T) {
+		versions := factory(t)
+		putVersion(t, versions, "id", photo.Version{Stamp: 1, Deleted: true})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.Error(t, versions.Put(ctx, *photo.IdentifierOf("other"), photo.Version{Stamp: 1}))
+		_, err := versions.Get(ctx, *photo.IdentifierOf("id"))
+		assert.Error(t, err)
+		assert.Error(t, versions.Remove(ctx, *photo.IdentifierOf("id")))
+		_, err = versions.Tombstones(ctx)
+		assert.Error(t, err)
+
+		assertVersion(t, versions, "id", photo.Version{Stamp: 1, Deleted: true})
+	})
+}
+
+func putVersion(t *testing.T, versions photo.VersionLog, id string, version photo.Version) {
+	t.Helper()
+	if err := versions.Put(context.Background(), *photo.IdentifierOf(id), version); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertVersion(t *testing.T, versions photo.VersionLog, id string, expected photo.Version) {
+	t.Helper()
+	actual, err := versions.Get(context.Background(), *photo.IdentifierOf(id))
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, *actual)
+	}
+}
+
+func assertTombstones(t *testing.T, versions photo.VersionLog, expected map[string]int64) {
+	t.Helper()
+	tombstones, err := versions.Tombstones(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	actual := make(map[string]int64)
+	for id, stamp := range tombstones {
+		actual[id.Value()] = stamp
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func assertInvalid(t *testing.T, err error) {
+	t.Helper()
+	if assert.Error(t, err) {
+		if e, ok := err.(*photo.ResourceError); assert.True(t, ok, "error should be *photo.ResourceError : %v", err) {
+			assert.Equal(t, photo.ErrInvalidIdentifier, e.Err)
+		}
+	}
+}
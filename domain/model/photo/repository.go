@@ -1,9 +1,71 @@
 package photo
 
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+)
+
 type Repository interface {
-	Save(photo Photo) (*Identifier, error)
+	Save(ctx context.Context, photo Photo) (*Identifier, error)
+
+	Read(ctx context.Context, id Identifier) (*Photo, error)
+
+	Delete(ctx context.Context, id Identifier) error
+
+	FindAll(ctx context.Context) ([]Identifier, error)
+
+	FindByTag(ctx context.Context, tag string) ([]Identifier, error)
+
+	// Close flushes pending writes and releases the storage. The repository can't be used after it.
+	Close() error
+}
+
+type StreamRepository interface {
+	Open(ctx context.Context, id Identifier) (io.ReadCloser, error)
+}
+
+// Open streams the photo from the repository, or reads it whole when the repository can't stream.
+func Open(ctx context.Context, repository Repository, id Identifier) (io.ReadCloser, error) {
+	if r, ok := repository.(StreamRepository); ok {
+		return r.Open(ctx, id)
+	}
+	photograph, err := repository.Read(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(photograph.Image())), nil
+}
+
+// PeekRepository is implemented by repositories which cache the photos they read,
+// to read a photo without caching it.
+type PeekRepository interface {
+	Peek(ctx context.Context, id Identifier) (*Photo, error)
+}
+
+// Peek reads the photo without caching it, for scans which would otherwise evict the photos in use.
+func Peek(ctx context.Context, repository Repository, id Identifier) (*Photo, error) {
+	if r, ok := repository.(PeekRepository); ok {
+		return r.Peek(ctx, id)
+	}
+	return repository.Read(ctx, id)
+}
 
-	Read(id Identifier) (*Photo, error)
+// TagRepository is implemented by repositories which keep the tags of a photo apart from its image,
+// to read them without reading the image.
+type TagRepository interface {
+	TagsOf(ctx context.Context, id Identifier) ([]string, error)
+}
 
-	Delete(id Identifier) error
+// TagsOf returns the tags of the photo, or reads the photo whole when the repository can't read them alone.
+func TagsOf(ctx context.Context, repository Repository, id Identifier) ([]string, error) {
+	if r, ok := repository.(TagRepository); ok {
+		return r.TagsOf(ctx, id)
+	}
+	photograph, err := repository.Read(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return photograph.Tags(), nil
 }
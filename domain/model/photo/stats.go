@@ -0,0 +1,60 @@
+package photo
+
+import "context"
+
+type Stats struct {
+	Count int
+	Bytes int64
+}
+
+// StatsRepository is implemented by repositories which can count their photos and stored bytes
+// without reading every photo.
+type StatsRepository interface {
+	Stats(ctx context.Context) (*Stats, error)
+}
+
+// StatsOf returns the repository's own stats, or peeks at every photo when it has none.
+func StatsOf(ctx context.Context, repository Repository) (*Stats, error) {
+	if r, ok := repository.(StatsRepository); ok {
+		return r.Stats(ctx)
+	}
+	ids, err := repository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := &Stats{}
+	for _, id := range ids {
+		photograph, err := Peek(ctx, repository, id)
+		if err != nil {
+			return nil, err
+		}
+		stats.Count++
+		stats.Bytes += int64(len(photograph.Image()))
+	}
+	return stats, nil
+}
+
+// SizeRepository is implemented by repositories which can tell the size of every photo without reading it.
+type SizeRepository interface {
+	Sizes(ctx context.Context) (map[Identifier]int64, error)
+}
+
+// SizesOf returns the repository's own sizes, or peeks at every photo when it has none.
+func SizesOf(ctx context.Context, repository Repository) (map[Identifier]int64, error) {
+	if r, ok := repository.(SizeRepository); ok {
+		return r.Sizes(ctx)
+	}
+	ids, err := repository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[Identifier]int64)
+	for _, id := range ids {
+		photograph, err := Peek(ctx, repository, id)
+		if err != nil {
+			return nil, err
+		}
+		sizes[id] = int64(len(photograph.Image()))
+	}
+	return sizes, nil
+}
@@ -0,0 +1,24 @@
+package photo
+
+import "context"
+
+// Version is what a replica knows of the last write of a photo: when it was written, and whether it deleted the photo.
+type Version struct {
+	Stamp   int64
+	Deleted bool
+}
+
+// VersionLog keeps the versions of the photos of a replica apart from the photos and their tags.
+// A deleted version is a tombstone, kept until removed so that a replica which missed the delete can't bring
+// the photo back. Get returns ErrNotFound for a photo without version.
+type VersionLog interface {
+	Get(ctx context.Context, id Identifier) (*Version, error)
+
+	// Put replaces the version of the photo.
+	Put(ctx context.Context, id Identifier, version Version) error
+
+	Remove(ctx context.Context, id Identifier) error
+
+	// Tombstones returns the stamps of the deleted versions.
+	Tombstones(ctx context.Context) (map[Identifier]int64, error)
+}
@@ -1,28 +1,54 @@
 module github.com/photoshelf/photoshelf-storage
 
+go 1.21
+
 require (
+	github.com/beorn7/perks v1.0.1
 	github.com/boltdb/bolt v1.3.1
-	github.com/davecgh/go-spew v1.1.0
+	github.com/davecgh/go-spew v1.1.1
 	github.com/dgrijalva/jwt-go v0.0.0-20171019215719-dbeaa9332f19
-	github.com/facebookgo/inject v0.0.0-20161006174721-cc1aa653e50f
-	github.com/facebookgo/structtag v0.0.0-20150214074306-217e25fb9691
 	github.com/golang/mock v1.0.0
-	github.com/golang/protobuf v1.1.0
+	github.com/golang/protobuf v1.5.3
 	github.com/golang/snappy v0.0.0-20170215233205-553a64147049
+	github.com/klauspost/compress v1.11.13
 	github.com/labstack/echo v0.0.0-20171223171103-b338075a0fc6
 	github.com/labstack/gommon v0.0.0-20170925052817-57409ada9da0
 	github.com/mattn/go-colorable v0.0.9
 	github.com/mattn/go-isatty v0.0.3
+	github.com/matttproud/golang_protobuf_extensions v1.0.1
 	github.com/pmezard/go-difflib v1.0.0
-	github.com/stretchr/testify v1.2.0
+	github.com/prometheus/client_golang v0.9.0
+	github.com/prometheus/client_model v0.0.0-20170216185247-6f3806018612
+	github.com/prometheus/common v0.0.0-20181020173914-7e9e6cabbd39
+	github.com/prometheus/procfs v0.0.0-20181204211112-1dc9a6cbc91a
+	github.com/stretchr/testify v1.8.4
 	github.com/syndtr/goleveldb v0.0.0-20171214120811-34011bf325bc
 	github.com/valyala/bytebufferpool v0.0.0-20160817181652-e746df99fe4a
 	github.com/valyala/fasttemplate v0.0.0-20170224212429-dcecefd839c4
-	golang.org/x/crypto v0.0.0-20180802221240-56440b844dfe
-	golang.org/x/net v0.0.0-20180801234040-f4c29de78a2a
-	golang.org/x/sys v0.0.0-20180802203216-0ffbfd41fbef
-	golang.org/x/text v0.3.0
-	google.golang.org/genproto v0.0.0-20180709204101-e92b11657268
-	google.golang.org/grpc v1.14.0
+	go.opentelemetry.io/otel v1.19.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.19.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.19.0
+	go.opentelemetry.io/otel/sdk v1.19.0
+	go.opentelemetry.io/otel/trace v1.19.0
+	golang.org/x/crypto v0.11.0
+	golang.org/x/net v0.12.0
+	golang.org/x/sys v0.12.0
+	golang.org/x/text v0.11.0
+	google.golang.org/genproto v0.0.0-20230711160842-782d3b101e98
+	google.golang.org/grpc v1.58.2
 	gopkg.in/yaml.v2 v2.0.0-20180109114331-d670f9405373
 )
+
+require (
+	github.com/cenkalti/backoff/v4 v4.2.1 // indirect
+	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.16.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.19.0 // indirect
+	go.opentelemetry.io/otel/metric v1.19.0 // indirect
+	go.opentelemetry.io/proto/otlp v1.0.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20230711160842-782d3b101e98 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230711160842-782d3b101e98 // indirect
+	google.golang.org/protobuf v1.31.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,135 @@
+package boltdb_storage
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/boltdb/bolt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+)
+
+type BoltdbAlbumStorage struct {
+	db *bolt.DB
+}
+
+type albumRecord struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Cover       string   `json:"cover,omitempty"`
+	Photos      []string `json:"photos"`
+}
+
+func NewAlbumStorage(storage *BoltdbStorage) *BoltdbAlbumStorage {
+	return &BoltdbAlbumStorage{storage.db}
+}
+
+func (storage *BoltdbAlbumStorage) Save(ctx context.Context, a album.Album) (*album.Identifier, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	id := a.Id()
+	if a.IsNew() {
+		id = album.NewIdentifier()
+	}
+	if err := album.ValidateIdentifier(*id); err != nil {
+		return nil, &album.ResourceError{Id: *id, Err: err}
+	}
+
+	data, err := encodeAlbum(a)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(albumsBucket).Put([]byte(id.Value()), data)
+	}); err != nil {
+		return nil, err
+	}
+
+	return id, nil
+}
+
+func (storage *BoltdbAlbumStorage) Read(ctx context.Context, id album.Identifier) (*album.Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := album.ValidateIdentifier(id); err != nil {
+		return nil, &album.ResourceError{Id: id, Err: err}
+	}
+
+	var a *album.Album
+	if err := storage.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(albumsBucket).Get([]byte(id.Value()))
+		if data == nil {
+			return album.ErrNotFound
+		}
+		var err error
+		a, err = decodeAlbum(id, data)
+		return err
+	}); err != nil {
+		return nil, &album.ResourceError{Id: id, Err: err}
+	}
+	return a, nil
+}
+
+func (storage *BoltdbAlbumStorage) ReadAll(ctx context.Context) ([]album.Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var albums []album.Album
+	if err := storage.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(albumsBucket).ForEach(func(k, v []byte) error {
+			a, err := decodeAlbum(*album.IdentifierOf(string(k)), v)
+			if err != nil {
+				return err
+			}
+			albums = append(albums, *a)
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
+
+func (storage *BoltdbAlbumStorage) Delete(ctx context.Context, id album.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := album.ValidateIdentifier(id); err != nil {
+		return &album.ResourceError{Id: id, Err: err}
+	}
+
+	return storage.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(albumsBucket).Delete([]byte(id.Value()))
+	})
+}
+
+func encodeAlbum(a album.Album) ([]byte, error) {
+	record := albumRecord{Title: a.Title(), Description: a.Description(), Photos: []string{}}
+	if a.Cover() != nil {
+		record.Cover = a.Cover().Value()
+	}
+	for _, id := range a.Photos() {
+		record.Photos = append(record.Photos, id.Value())
+	}
+	return json.Marshal(record)
+}
+
+func decodeAlbum(id album.Identifier, data []byte) (*album.Album, error) {
+	var record albumRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	var cover *photo.Identifier
+	if record.Cover != "" {
+		cover = photo.IdentifierOf(record.Cover)
+	}
+	var photos []photo.Identifier
+	for _, value := range record.Photos {
+		photos = append(photos, *photo.IdentifierOf(value))
+	}
+	return album.Of(id, record.Title, record.Description, cover, photos), nil
+}
@@ -1,12 +1,35 @@
 package boltdb_storage
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"github.com/boltdb/bolt"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"io"
+	"io/ioutil"
+)
+
+var (
+	photosBucket     = []byte("photos")
+	tagsBucket       = []byte("tags")
+	tagIndexBucket   = []byte("tag_index")
+	albumsBucket     = []byte("albums")
+	checksumBucket   = []byte("checksums")
+	chunksBucket     = []byte("chunks")
+	manifestBucket   = []byte("manifests")
+	outboxBucket     = []byte("outbox")
+	changesBucket    = []byte("changes")
+	healthBucket     = []byte("health")
+	versionsBucket   = []byte("versions")
+	tombstonesBucket = []byte("tombstones")
+
+	sentinelKey = []byte("sentinel")
 )
 
 type BoltdbStorage struct {
-	db *bolt.DB
+	db        *bolt.DB
+	chunkSize int
 }
 
 func New(path string) (*BoltdbStorage, error) {
@@ -14,50 +37,353 @@ func New(path string) (*BoltdbStorage, error) {
 	if err != nil {
 		return nil, err
 	}
+
 	if err := db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte("photos"))
-		return err
+		for _, name := range [][]byte{photosBucket, tagsBucket, tagIndexBucket, albumsBucket, checksumBucket, chunksBucket, manifestBucket, outboxBucket, changesBucket, healthBucket, versionsBucket, tombstonesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
 	}); err != nil {
 		return nil, err
 	}
 
-	return &BoltdbStorage{db}, nil
+	return &BoltdbStorage{db: db, chunkSize: defaultChunkSize}, nil
 }
 
-func (storage *BoltdbStorage) Save(photograph photo.Photo) (*photo.Identifier, error) {
+func (storage *BoltdbStorage) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
 	data := photograph.Image()
 	id := photograph.Id()
 	if photograph.IsNew() {
 		id = photo.NewIdentifier(data)
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
+	staged := storage.staged(ctx)
 	if err := storage.db.Update(func(tx *bolt.Tx) error {
-		return tx.Bucket([]byte("photos")).Put([]byte(id.Value()), data)
+		key := []byte(id.Value())
+		if err := deleteChunks(tx, key); err != nil {
+			return err
+		}
+		if storage.chunkSize > 0 && len(data) > storage.chunkSize {
+			if err := tx.Bucket(photosBucket).Delete(key); err != nil {
+				return err
+			}
+			if err := putChunks(tx, key, data, storage.chunkSize); err != nil {
+				return err
+			}
+		} else if err := tx.Bucket(photosBucket).Put(key, data); err != nil {
+			return err
+		}
+		if err := tx.Bucket(checksumBucket).Put(key, []byte(photo.Checksum(data))); err != nil {
+			return err
+		}
+		if err := updateTags(tx, *id, photograph.Tags()); err != nil {
+			return err
+		}
+		return appendStaged(tx, staged, *id)
 	}); err != nil {
 		return nil, err
 	}
+	if staged != nil {
+		staged.MarkAppended()
+	}
 
 	return id, nil
 }
 
-func (storage *BoltdbStorage) Read(id photo.Identifier) (*photo.Photo, error) {
+func (storage *BoltdbStorage) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
 	var photograph *photo.Photo
+	if err := storage.db.View(func(tx *bolt.Tx) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := readData(ctx, tx, []byte(id.Value()))
+		if err != nil {
+			return err
+		}
+		tags, err := readTags(tx, id)
+		if err != nil {
+			return err
+		}
+		photograph = photo.Of(id, data, tags...)
+		return nil
+	}); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	return photograph, nil
+}
+
+func (storage *BoltdbStorage) Delete(ctx context.Context, id photo.Identifier) error {
+	staged := storage.staged(ctx)
 	if err := storage.db.Update(func(tx *bolt.Tx) error {
-		data := tx.Bucket([]byte("photos")).Get([]byte(id.Value()))
-		if data == nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := updateTags(tx, id, nil); err != nil {
+			return err
+		}
+		if err := deleteChunks(tx, []byte(id.Value())); err != nil {
+			return err
+		}
+		if err := tx.Bucket(checksumBucket).Delete([]byte(id.Value())); err != nil {
+			return err
+		}
+		if err := tx.Bucket(photosBucket).Delete([]byte(id.Value())); err != nil {
+			return err
+		}
+		return appendStaged(tx, staged, id)
+	}); err != nil {
+		return err
+	}
+	if staged != nil {
+		staged.MarkAppended()
+	}
+	return nil
+}
+
+func (storage *BoltdbStorage) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	var ids []photo.Identifier
+	if err := storage.db.View(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{photosBucket, manifestBucket} {
+			if err := tx.Bucket(name).ForEach(func(k, _ []byte) error {
+				ids = append(ids, *photo.IdentifierOf(string(k)))
+				return ctx.Err()
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (storage *BoltdbStorage) Open(ctx context.Context, id photo.Identifier) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	key := []byte(id.Value())
+	var reader io.ReadCloser
+	if err := storage.db.View(func(tx *bolt.Tx) error {
+		if data := tx.Bucket(photosBucket).Get(key); data != nil {
+			reader = ioutil.NopCloser(bytes.NewReader(append([]byte{}, data...)))
+			return nil
+		}
+		m, err := readManifest(tx, key)
+		if err != nil {
+			return err
+		} else if m == nil {
 			return photo.ErrNotFound
 		}
-		photograph = photo.Of(id, data)
+		checksum := string(tx.Bucket(checksumBucket).Get(key))
+		reader = &chunkReader{ctx: ctx, db: storage.db, key: key, m: m, checksum: checksum}
 		return nil
 	}); err != nil {
 		return nil, &photo.ResourceError{Id: id, Err: err}
 	}
+	return reader, nil
+}
 
-	return photograph, nil
+func (storage *BoltdbStorage) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	var ids []photo.Identifier
+	if err := storage.db.View(func(tx *bolt.Tx) error {
+		index := tx.Bucket(tagIndexBucket).Bucket([]byte(tag))
+		if index == nil {
+			return ctx.Err()
+		}
+		return index.ForEach(func(k, _ []byte) error {
+			ids = append(ids, *photo.IdentifierOf(string(k)))
+			return ctx.Err()
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return ids, nil
 }
 
-func (storage *BoltdbStorage) Delete(id photo.Identifier) error {
-	return storage.db.Update(func(tx *bolt.Tx) error {
-		return tx.Bucket([]byte("photos")).Delete([]byte(id.Value()))
+func (storage *BoltdbStorage) Close() error {
+	return storage.db.Close()
+}
+
+func (storage *BoltdbStorage) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sentinel := photo.Sentinel()
+	if err := storage.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(healthBucket).Put(sentinelKey, sentinel)
+	}); err != nil {
+		return err
+	}
+	return storage.db.View(func(tx *bolt.Tx) error {
+		if !bytes.Equal(tx.Bucket(healthBucket).Get(sentinelKey), sentinel) {
+			return photo.ErrSentinelMismatch
+		}
+		return nil
 	})
 }
+
+func (storage *BoltdbStorage) Checksums(ctx context.Context) (map[photo.Identifier]string, error) {
+	checksums := make(map[photo.Identifier]string)
+	if err := storage.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(checksumBucket).ForEach(func(k, v []byte) error {
+			checksums[*photo.IdentifierOf(string(k))] = string(v)
+			return ctx.Err()
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+func (storage *BoltdbStorage) ChecksumOf(ctx context.Context, id photo.Identifier) (string, error) {
+	var checksum string
+	if err := storage.db.View(func(tx *bolt.Tx) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		value := tx.Bucket(checksumBucket).Get([]byte(id.Value()))
+		if value == nil {
+			return photo.ErrNotFound
+		}
+		checksum = string(value)
+		return nil
+	}); err != nil {
+		return "", &photo.ResourceError{Id: id, Err: err}
+	}
+	return checksum, nil
+}
+
+func (storage *BoltdbStorage) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	var tags []string
+	if err := storage.db.View(func(tx *bolt.Tx) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		key := []byte(id.Value())
+		if tx.Bucket(photosBucket).Get(key) == nil {
+			m, err := readManifest(tx, key)
+			if err != nil {
+				return err
+			}
+			if m == nil {
+				return photo.ErrNotFound
+			}
+		}
+		var err error
+		tags, err = readTags(tx, id)
+		return err
+	}); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	return tags, nil
+}
+
+func (storage *BoltdbStorage) Stats(ctx context.Context) (*photo.Stats, error) {
+	sizes, err := storage.Sizes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &photo.Stats{Count: len(sizes)}
+	for _, size := range sizes {
+		stats.Bytes += size
+	}
+	return stats, nil
+}
+
+func (storage *BoltdbStorage) Sizes(ctx context.Context) (map[photo.Identifier]int64, error) {
+	sizes := make(map[photo.Identifier]int64)
+	if err := storage.db.View(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(photosBucket).ForEach(func(k, v []byte) error {
+			sizes[*photo.IdentifierOf(string(k))] = int64(len(v))
+			return ctx.Err()
+		}); err != nil {
+			return err
+		}
+		return tx.Bucket(manifestBucket).ForEach(func(k, v []byte) error {
+			m := &manifest{}
+			if err := json.Unmarshal(v, m); err != nil {
+				return err
+			}
+			sizes[*photo.IdentifierOf(string(k))] = int64(m.Size)
+			return ctx.Err()
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+func readData(ctx context.Context, tx *bolt.Tx, key []byte) ([]byte, error) {
+	if data := tx.Bucket(photosBucket).Get(key); data != nil {
+		return append([]byte{}, data...), nil
+	}
+	m, err := readManifest(tx, key)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, photo.ErrNotFound
+	}
+	return readChunks(ctx, tx, key, m)
+}
+
+func readTags(tx *bolt.Tx, id photo.Identifier) ([]string, error) {
+	data := tx.Bucket(tagsBucket).Get([]byte(id.Value()))
+	if data == nil {
+		return nil, nil
+	}
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func updateTags(tx *bolt.Tx, id photo.Identifier, tags []string) error {
+	current, err := readTags(tx, id)
+	if err != nil {
+		return err
+	}
+
+	key := []byte(id.Value())
+	index := tx.Bucket(tagIndexBucket)
+	for _, tag := range current {
+		if bucket := index.Bucket([]byte(tag)); bucket != nil {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+			if k, _ := bucket.Cursor().First(); k == nil {
+				if err := index.DeleteBucket([]byte(tag)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, tag := range tags {
+		bucket, err := index.CreateBucketIfNotExists([]byte(tag))
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(key, []byte{}); err != nil {
+			return err
+		}
+	}
+
+	if len(tags) == 0 {
+		return tx.Bucket(tagsBucket).Delete(key)
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(tagsBucket).Put(key, data)
+}
@@ -1,6 +1,7 @@
 package boltdb_storage
 
 import (
+	"context"
 	"fmt"
 	"github.com/boltdb/bolt"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
@@ -11,6 +12,7 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -40,7 +42,7 @@ func TestBoltdbStorage_Save(t *testing.T) {
 		instance := createInstance(t)
 		photograph := photo.New(readTestData(t))
 
-		identifier, err := instance.Save(*photograph)
+		identifier, err := instance.Save(context.Background(), *photograph)
 		if assert.NoError(t, err) {
 			assert.NotNil(t, identifier)
 		}
@@ -52,7 +54,7 @@ func TestBoltdbStorage_Save(t *testing.T) {
 		instance := createInstance(t)
 		photograph := *photo.Of(*photo.IdentifierOf("testdata"), readTestData(t))
 
-		identifier, err := instance.Save(photograph)
+		identifier, err := instance.Save(context.Background(), photograph)
 
 		if assert.NoError(t, err) {
 			t.Run("returns identifier has same value", func(t *testing.T) {
@@ -83,12 +85,12 @@ func TestBoltdbStorage_Read(t *testing.T) {
 	}
 
 	t.Run("with no key, returns err", func(t *testing.T) {
-		_, err := instance.Read(*photo.IdentifierOf("noKey"))
+		_, err := instance.Read(context.Background(), *photo.IdentifierOf("noKey"))
 		assert.Error(t, err)
 	})
 
 	t.Run("returns same data with source", func(t *testing.T) {
-		photograph, err := instance.Read(*photo.IdentifierOf("testdata"))
+		photograph, err := instance.Read(context.Background(), *photo.IdentifierOf("testdata"))
 		if assert.NoError(t, err) {
 			assert.EqualValues(t, readTestData(t), photograph.Image())
 		}
@@ -107,7 +109,7 @@ func TestBoltdbStorage_Delete(t *testing.T) {
 	}
 
 	t.Run("when delete existing key, returns no error", func(t *testing.T) {
-		err := instance.Delete(*photo.IdentifierOf("testdata"))
+		err := instance.Delete(context.Background(), *photo.IdentifierOf("testdata"))
 		if assert.NoError(t, err) {
 			instance.db.View(func(tx *bolt.Tx) error {
 				numOfKeys := tx.Bucket([]byte("photos")).Stats().KeyN
@@ -118,6 +120,275 @@ func TestBoltdbStorage_Delete(t *testing.T) {
 	})
 }
 
+func TestBoltdbStorage_FindAll(t *testing.T) {
+	instance := createInstance(t)
+	for _, id := range []string{"first", "second"} {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), readTestData(t), "tag")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := instance.FindAll(context.Background())
+	if assert.NoError(t, err) {
+		assert.ElementsMatch(t, []photo.Identifier{*photo.IdentifierOf("first"), *photo.IdentifierOf("second")}, ids)
+	}
+	instance.db.Close()
+}
+
+func TestBoltdbStorage_Checksums(t *testing.T) {
+	instance := createInstance(t)
+	data := readTestData(t)
+	for _, id := range []string{"first", "second"} {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := instance.Delete(context.Background(), *photo.IdentifierOf("second")); err != nil {
+		t.Fatal(err)
+	}
+
+	checksums, err := instance.Checksums(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[photo.Identifier]string{*photo.IdentifierOf("first"): photo.Checksum(data)}, checksums)
+	}
+	instance.db.Close()
+}
+
+func TestBoltdbStorage_FindByTag(t *testing.T) {
+	instance := createInstance(t)
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("first"), readTestData(t), "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("second"), readTestData(t), "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("returns identifiers have tag", func(t *testing.T) {
+		ids, err := instance.FindByTag(context.Background(), "b")
+		if assert.NoError(t, err) {
+			assert.ElementsMatch(t, []photo.Identifier{*photo.IdentifierOf("first"), *photo.IdentifierOf("second")}, ids)
+		}
+	})
+
+	t.Run("with no such tag, returns empty", func(t *testing.T) {
+		ids, err := instance.FindByTag(context.Background(), "unknown")
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+
+	t.Run("read photo has tags", func(t *testing.T) {
+		photograph, err := instance.Read(context.Background(), *photo.IdentifierOf("first"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"a", "b"}, photograph.Tags())
+		}
+	})
+
+	t.Run("when tag removed, not found by tag", func(t *testing.T) {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("first"), readTestData(t), "b")); err != nil {
+			t.Fatal(err)
+		}
+		ids, err := instance.FindByTag(context.Background(), "a")
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+
+	t.Run("when photo deleted, not found by tag", func(t *testing.T) {
+		if err := instance.Delete(context.Background(), *photo.IdentifierOf("second")); err != nil {
+			t.Fatal(err)
+		}
+		ids, err := instance.FindByTag(context.Background(), "b")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("first")}, ids)
+		}
+	})
+
+	instance.db.Close()
+}
+
+func TestBoltdbStorage_Chunks(t *testing.T) {
+	instance := createInstance(t)
+	instance.chunkSize = 16 * 1024
+	data := readTestData(t)
+	id := photo.IdentifierOf("testdata")
+
+	t.Run("save large photo, splits it into chunks", func(t *testing.T) {
+		if _, err := instance.Save(context.Background(), *photo.Of(*id, data, "tag")); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, data, actual.Image())
+			assert.Equal(t, []string{"tag"}, actual.Tags())
+		}
+		assert.Equal(t, (len(data)+instance.chunkSize-1)/instance.chunkSize, countKeys(t, instance, chunksBucket))
+		assert.Equal(t, 0, countKeys(t, instance, photosBucket))
+
+		ids, err := instance.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*id}, ids)
+		}
+	})
+
+	t.Run("overwrite with small photo, removes chunks", func(t *testing.T) {
+		if _, err := instance.Save(context.Background(), *photo.Of(*id, []byte("small"))); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("small"), actual.Image())
+		}
+		assert.Equal(t, 0, countKeys(t, instance, chunksBucket))
+		assert.Equal(t, 0, countKeys(t, instance, manifestBucket))
+	})
+
+	t.Run("delete large photo, removes chunks", func(t *testing.T) {
+		if _, err := instance.Save(context.Background(), *photo.Of(*id, data)); err != nil {
+			t.Fatal(err)
+		}
+
+		if assert.NoError(t, instance.Delete(context.Background(), *id)) {
+			_, err := instance.Read(context.Background(), *id)
+			assert.Error(t, err)
+			assert.Equal(t, 0, countKeys(t, instance, chunksBucket))
+			assert.Equal(t, 0, countKeys(t, instance, manifestBucket))
+		}
+	})
+
+	instance.db.Close()
+}
+
+func TestBoltdbStorage_Open(t *testing.T) {
+	instance := createInstance(t)
+	instance.chunkSize = 16 * 1024
+	data := readTestData(t)
+
+	for _, c := range []struct {
+		name string
+		data []byte
+	}{
+		{"chunked", data},
+		{"single value", []byte("small")},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			id, err := instance.Save(context.Background(), *photo.New(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reader, err := instance.Open(context.Background(), *id)
+			if assert.NoError(t, err) {
+				actual, err := ioutil.ReadAll(reader)
+				if assert.NoError(t, err) {
+					assert.Equal(t, c.data, actual)
+				}
+				assert.NoError(t, reader.Close())
+			}
+		})
+	}
+
+	t.Run("with saves growing the database while streaming, doesn't block them", func(t *testing.T) {
+		id, err := instance.Save(context.Background(), *photo.New(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader, err := instance.Open(context.Background(), *id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+
+		saved := make(chan error, 1)
+		go func() {
+			_, err := instance.Save(context.Background(), *photo.New(make([]byte, 8*1024*1024)))
+			saved <- err
+		}()
+		select {
+		case err := <-saved:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("save blocked by stream")
+		}
+
+		actual, err := ioutil.ReadAll(reader)
+		if assert.NoError(t, err) {
+			assert.Equal(t, data, actual)
+		}
+	})
+
+	t.Run("overwritten while streaming, returns modified", func(t *testing.T) {
+		id, err := instance.Save(context.Background(), *photo.New(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader, err := instance.Open(context.Background(), *id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+
+		if _, err := reader.Read(make([]byte, 1)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := instance.Save(context.Background(), *photo.Of(*id, append([]byte{}, data[1:]...))); err != nil {
+			t.Fatal(err)
+		}
+		_, err = ioutil.ReadAll(reader)
+		assert.Equal(t, ErrModified, err)
+	})
+
+	t.Run("with no photo, returns not found", func(t *testing.T) {
+		_, err := instance.Open(context.Background(), *photo.IdentifierOf("noKey"))
+		if assert.Error(t, err) {
+			assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+		}
+	})
+
+	instance.db.Close()
+}
+
+func TestBoltdbStorage_Close(t *testing.T) {
+	t.Run("after close, storage can be reopened", func(t *testing.T) {
+		dbPath := path.Join(tempDir(t), "photos.db")
+		instance, err := New(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("id"), []byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(t, instance.Close())
+
+		reopened, err := New(dbPath)
+		if assert.NoError(t, err) {
+			defer reopened.Close()
+			actual, err := reopened.Read(context.Background(), *photo.IdentifierOf("id"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, []byte("data"), actual.Image())
+			}
+		}
+	})
+}
+
+func TestBoltdbStorage_Conformance(t *testing.T) {
+	phototest.RunRepositoryTests(t, func(t *testing.T) photo.Repository {
+		dir, err := ioutil.TempDir("", "boltdb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		instance, err := New(path.Join(dir, "photos.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { instance.db.Close() })
+		return instance
+	})
+}
+
 func BenchmarkBoltdbStorage_Save(b *testing.B) {
 	data := readTestData(b)
 
@@ -128,7 +399,7 @@ func BenchmarkBoltdbStorage_Save(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", 0)
 			photograph := *photo.Of(*photo.IdentifierOf(key), data)
-			instance.Save(photograph)
+			instance.Save(context.Background(), photograph)
 		}
 		b.StopTimer()
 
@@ -142,7 +413,7 @@ func BenchmarkBoltdbStorage_Save(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", i)
 			photograph := *photo.Of(*photo.IdentifierOf(key), data)
-			instance.Save(photograph)
+			instance.Save(context.Background(), photograph)
 		}
 		b.StopTimer()
 
@@ -157,7 +428,7 @@ func BenchmarkBoltdbStorage_Save(b *testing.B) {
 		for i := 1; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", i%20)
 			photograph := *photo.Of(*photo.IdentifierOf(key), randomTestData[i%20])
-			instance.Save(photograph)
+			instance.Save(context.Background(), photograph)
 		}
 		b.StopTimer()
 
@@ -183,7 +454,7 @@ func BenchmarkBoltdbStorage_Read(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", 0)
-			instance.Read(*photo.IdentifierOf(key))
+			instance.Read(context.Background(), *photo.IdentifierOf(key))
 		}
 		b.StopTimer()
 
@@ -194,7 +465,7 @@ func BenchmarkBoltdbStorage_Read(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", i)
-			instance.Read(*photo.IdentifierOf(key))
+			instance.Read(context.Background(), *photo.IdentifierOf(key))
 		}
 		b.StopTimer()
 
@@ -205,12 +476,45 @@ func BenchmarkBoltdbStorage_Read(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", rand.Intn(100))
-			instance.Read(*photo.IdentifierOf(key))
+			instance.Read(context.Background(), *photo.IdentifierOf(key))
 		}
 		b.StopTimer()
 	})
 }
 
+func BenchmarkBoltdbStorage_Layout(b *testing.B) {
+	dataSet := phototest.RandomTestData(b)
+
+	for _, layout := range []struct {
+		name      string
+		chunkSize int
+	}{
+		{"single value", 0},
+		{"chunked", defaultChunkSize},
+	} {
+		b.Run(layout.name, func(b *testing.B) {
+			instance := createInstance(b)
+			instance.chunkSize = layout.chunkSize
+
+			b.Run("save", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					key := fmt.Sprintf("testdata-%d", i%20)
+					instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(key), dataSet[i%20]))
+				}
+			})
+
+			b.Run("read", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					key := fmt.Sprintf("testdata-%d", i%20)
+					instance.Read(context.Background(), *photo.IdentifierOf(key))
+				}
+			})
+
+			instance.db.Close()
+		})
+	}
+}
+
 func readTestData(tb testing.TB) []byte {
 	tb.Helper()
 
@@ -246,3 +550,15 @@ func createInstance(tb testing.TB) *BoltdbStorage {
 	}
 	return instance
 }
+
+func countKeys(tb testing.TB, instance *BoltdbStorage, bucket []byte) int {
+	tb.Helper()
+	var n int
+	if err := instance.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(bucket).Stats().KeyN
+		return nil
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	return n
+}
@@ -0,0 +1,88 @@
+package boltdb_storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/boltdb/bolt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+)
+
+type BoltdbChangeLog struct {
+	db *bolt.DB
+}
+
+func NewChangeLog(storage *BoltdbStorage) *BoltdbChangeLog {
+	return &BoltdbChangeLog{storage.db}
+}
+
+func (changes *BoltdbChangeLog) Put(ctx context.Context, record event.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := event.Marshal(record.Event)
+	if err != nil {
+		return err
+	}
+	return changes.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(changesBucket).Put(sequenceKey(record.Sequence), data)
+	})
+}
+
+func (changes *BoltdbChangeLog) Since(ctx context.Context, sequence uint64, limit int) ([]event.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var records []event.Record
+	if err := changes.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(changesBucket).Cursor()
+		for k, v := cursor.Seek(sequenceKey(sequence + 1)); k != nil && len(records) < limit; k, v = cursor.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			e, err := event.Unmarshal(v)
+			if err != nil {
+				return err
+			}
+			records = append(records, event.Record{Sequence: binary.BigEndian.Uint64(k), Event: e})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (changes *BoltdbChangeLog) Latest(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var latest uint64
+	err := changes.db.View(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket(changesBucket).Cursor().Last(); k != nil {
+			latest = binary.BigEndian.Uint64(k)
+		}
+		return nil
+	})
+	return latest, err
+}
+
+func (changes *BoltdbChangeLog) Trim(ctx context.Context, before uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return changes.db.Update(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(changesBucket).Cursor()
+		end := sequenceKey(before)
+		for k, _ := cursor.First(); k != nil && bytes.Compare(k, end) < 0; k, _ = cursor.First() {
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,19 @@
+package boltdb_storage
+
+import (
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event/eventtest"
+	"path"
+	"testing"
+)
+
+func TestBoltdbChangeLog_Conformance(t *testing.T) {
+	eventtest.RunChangeLogTests(t, func(t *testing.T) event.ChangeLog {
+		instance, err := New(path.Join(tempDir(t), "photos.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { instance.db.Close() })
+		return NewChangeLog(instance)
+	})
+}
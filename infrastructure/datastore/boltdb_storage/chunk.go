@@ -0,0 +1,135 @@
+package boltdb_storage
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/boltdb/bolt"
+	"io"
+)
+
+const defaultChunkSize = 256 * 1024
+
+// ErrModified is returned by a stream of a photo which was overwritten or deleted while it was read.
+var ErrModified = errors.New("photo was modified while it was read")
+
+type manifest struct {
+	Size      int `json:"size"`
+	ChunkSize int `json:"chunk_size"`
+	Chunks    int `json:"chunks"`
+}
+
+func putChunks(tx *bolt.Tx, key []byte, data []byte, chunkSize int) error {
+	m := manifest{Size: len(data), ChunkSize: chunkSize}
+	chunks := tx.Bucket(chunksBucket)
+	for offset := 0; offset < len(data); offset += chunkSize {
+		end := offset + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if err := chunks.Put(chunkKey(key, m.Chunks), data[offset:end]); err != nil {
+			return err
+		}
+		m.Chunks++
+	}
+
+	value, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(manifestBucket).Put(key, value)
+}
+
+func readManifest(tx *bolt.Tx, key []byte) (*manifest, error) {
+	value := tx.Bucket(manifestBucket).Get(key)
+	if value == nil {
+		return nil, nil
+	}
+	m := &manifest{}
+	if err := json.Unmarshal(value, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func readChunks(ctx context.Context, tx *bolt.Tx, key []byte, m *manifest) ([]byte, error) {
+	data := make([]byte, 0, m.Size)
+	chunks := tx.Bucket(chunksBucket)
+	for i := 0; i < m.Chunks; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chunk := chunks.Get(chunkKey(key, i))
+		if chunk == nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		data = append(data, chunk...)
+	}
+	return data, nil
+}
+
+func deleteChunks(tx *bolt.Tx, key []byte) error {
+	m, err := readManifest(tx, key)
+	if err != nil || m == nil {
+		return err
+	}
+	chunks := tx.Bucket(chunksBucket)
+	for i := 0; i < m.Chunks; i++ {
+		if err := chunks.Delete(chunkKey(key, i)); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(manifestBucket).Delete(key)
+}
+
+func chunkKey(key []byte, index int) []byte {
+	chunk := make([]byte, len(key)+9)
+	copy(chunk, key)
+	binary.BigEndian.PutUint64(chunk[len(key)+1:], uint64(index))
+	return chunk
+}
+
+// chunkReader copies a chunk out of its own read transaction at a time, so that streaming a photo
+// doesn't hold a transaction which would keep the database from growing.
+type chunkReader struct {
+	ctx      context.Context
+	db       *bolt.DB
+	key      []byte
+	m        *manifest
+	checksum string
+	index    int
+	buffer   []byte
+}
+
+func (reader *chunkReader) Read(p []byte) (int, error) {
+	for len(reader.buffer) == 0 {
+		if reader.index >= reader.m.Chunks {
+			return 0, io.EOF
+		}
+		if err := reader.ctx.Err(); err != nil {
+			return 0, err
+		}
+		if err := reader.db.View(func(tx *bolt.Tx) error {
+			if string(tx.Bucket(checksumBucket).Get(reader.key)) != reader.checksum {
+				return ErrModified
+			}
+			chunk := tx.Bucket(chunksBucket).Get(chunkKey(reader.key, reader.index))
+			if chunk == nil {
+				return io.ErrUnexpectedEOF
+			}
+			reader.buffer = append([]byte{}, chunk...)
+			return nil
+		}); err != nil {
+			return 0, err
+		}
+		reader.index++
+	}
+	n := copy(p, reader.buffer)
+	reader.buffer = reader.buffer[n:]
+	return n, nil
+}
+
+func (reader *chunkReader) Close() error {
+	return nil
+}
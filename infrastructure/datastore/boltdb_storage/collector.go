@@ -0,0 +1,98 @@
+package boltdb_storage
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type statistic struct {
+	name      string
+	help      string
+	valueType prometheus.ValueType
+	value     func(stats bolt.Stats) float64
+}
+
+var statistics = []statistic{
+	{"free_pages", "Pages on the freelist.", prometheus.GaugeValue, func(stats bolt.Stats) float64 {
+		return float64(stats.FreePageN)
+	}},
+	{"pending_pages", "Pages pending on the freelist.", prometheus.GaugeValue, func(stats bolt.Stats) float64 {
+		return float64(stats.PendingPageN)
+	}},
+	{"free_alloc_bytes", "Bytes allocated in free pages.", prometheus.GaugeValue, func(stats bolt.Stats) float64 {
+		return float64(stats.FreeAlloc)
+	}},
+	{"freelist_inuse_bytes", "Bytes used by the freelist.", prometheus.GaugeValue, func(stats bolt.Stats) float64 {
+		return float64(stats.FreelistInuse)
+	}},
+	{"read_tx_total", "Started read transactions.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return float64(stats.TxN)
+	}},
+	{"open_read_tx", "Currently open read transactions.", prometheus.GaugeValue, func(stats bolt.Stats) float64 {
+		return float64(stats.OpenTxN)
+	}},
+	{"tx_pages_total", "Pages allocated by transactions.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return float64(stats.TxStats.PageCount)
+	}},
+	{"tx_page_alloc_bytes_total", "Bytes allocated for pages by transactions.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return float64(stats.TxStats.PageAlloc)
+	}},
+	{"tx_cursors_total", "Cursors created by transactions.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return float64(stats.TxStats.CursorCount)
+	}},
+	{"tx_nodes_total", "Node allocations by transactions.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return float64(stats.TxStats.NodeCount)
+	}},
+	{"tx_node_derefs_total", "Node dereferences by transactions.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return float64(stats.TxStats.NodeDeref)
+	}},
+	{"tx_rebalances_total", "Node rebalances by transactions.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return float64(stats.TxStats.Rebalance)
+	}},
+	{"tx_rebalance_seconds_total", "Time spent rebalancing.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return stats.TxStats.RebalanceTime.Seconds()
+	}},
+	{"tx_splits_total", "Node splits by transactions.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return float64(stats.TxStats.Split)
+	}},
+	{"tx_spills_total", "Nodes spilled by transactions.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return float64(stats.TxStats.Spill)
+	}},
+	{"tx_spill_seconds_total", "Time spent spilling.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return stats.TxStats.SpillTime.Seconds()
+	}},
+	{"tx_writes_total", "Writes performed by transactions.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return float64(stats.TxStats.Write)
+	}},
+	{"tx_write_seconds_total", "Time spent writing to disk.", prometheus.CounterValue, func(stats bolt.Stats) float64 {
+		return stats.TxStats.WriteTime.Seconds()
+	}},
+}
+
+// Collector reports the freelist and transaction stats of the database, labelled with its path.
+type Collector struct {
+	db    *bolt.DB
+	descs []*prometheus.Desc
+}
+
+func NewCollector(storage *BoltdbStorage) *Collector {
+	collector := &Collector{db: storage.db}
+	for _, s := range statistics {
+		desc := prometheus.NewDesc("photoshelf_boltdb_"+s.name, s.help, nil, prometheus.Labels{"path": storage.db.Path()})
+		collector.descs = append(collector.descs, desc)
+	}
+	return collector
+}
+
+func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range collector.descs {
+		ch <- desc
+	}
+}
+
+func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := collector.db.Stats()
+	for i, s := range statistics {
+		ch <- prometheus.MustNewConstMetric(collector.descs[i], s.valueType, s.value(stats))
+	}
+}
@@ -0,0 +1,37 @@
+package boltdb_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"path"
+	"testing"
+)
+
+func TestCollector_Collect(t *testing.T) {
+	dbPath := path.Join(tempDir(t), "photos.db")
+	instance, err := New(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer instance.Close()
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("id"), []byte("data"))); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(NewCollector(instance)); err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if assert.NoError(t, err) {
+		assert.Len(t, families, len(statistics))
+		for _, family := range families {
+			if family.GetName() == "photoshelf_boltdb_tx_writes_total" {
+				assert.True(t, family.GetMetric()[0].GetCounter().GetValue() > 0)
+				assert.Equal(t, dbPath, family.GetMetric()[0].GetLabel()[0].GetValue())
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package boltdb_storage
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/boltdb/bolt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+)
+
+type BoltdbOutbox struct {
+	db *bolt.DB
+}
+
+func NewOutbox(storage *BoltdbStorage) *BoltdbOutbox {
+	return &BoltdbOutbox{storage.db}
+}
+
+func (outbox *BoltdbOutbox) Append(ctx context.Context, e event.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return outbox.db.Update(func(tx *bolt.Tx) error {
+		return appendEvent(tx, e)
+	})
+}
+
+func (outbox *BoltdbOutbox) Pending(ctx context.Context) ([]event.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var records []event.Record
+	if err := outbox.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(outboxBucket).ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			e, err := event.Unmarshal(v)
+			if err != nil {
+				return err
+			}
+			records = append(records, event.Record{Sequence: binary.BigEndian.Uint64(k), Event: e})
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (outbox *BoltdbOutbox) Remove(ctx context.Context, sequence uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return outbox.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(outboxBucket).Delete(sequenceKey(sequence))
+	})
+}
+
+// staged returns the event staged for the write of ctx, when it goes to the outbox of this storage.
+func (storage *BoltdbStorage) staged(ctx context.Context) *event.Staged {
+	staged := event.StagedIn(ctx)
+	if staged == nil || staged.Appended() {
+		return nil
+	}
+	if outbox, ok := staged.Outbox.(*BoltdbOutbox); !ok || outbox.db != storage.db {
+		return nil
+	}
+	return staged
+}
+
+func appendStaged(tx *bolt.Tx, staged *event.Staged, id photo.Identifier) error {
+	if staged == nil {
+		return nil
+	}
+	return appendEvent(tx, staged.Event(id))
+}
+
+func appendEvent(tx *bolt.Tx, e event.Event) error {
+	data, err := event.Marshal(e)
+	if err != nil {
+		return err
+	}
+	bucket := tx.Bucket(outboxBucket)
+	sequence, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	return bucket.Put(sequenceKey(sequence), data)
+}
+
+func sequenceKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return key
+}
@@ -0,0 +1,114 @@
+package boltdb_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event/eventtest"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestBoltdbOutbox_Conformance(t *testing.T) {
+	eventtest.RunOutboxTests(t, func(t *testing.T) event.Outbox {
+		instance, err := New(path.Join(tempDir(t), "photos.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { instance.db.Close() })
+		return NewOutbox(instance)
+	})
+}
+
+func TestBoltdbOutbox_Pending(t *testing.T) {
+	t.Run("after reopen, returns appended events", func(t *testing.T) {
+		dbPath := path.Join(tempDir(t), "photos.db")
+		instance, err := New(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := event.PhotoDeleted{Id: *photo.IdentifierOf("id")}
+		if err := NewOutbox(instance).Append(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+		instance.db.Close()
+
+		instance, err = New(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer instance.db.Close()
+
+		records, err := NewOutbox(instance).Pending(context.Background())
+		if assert.NoError(t, err) && assert.Len(t, records, 1) {
+			assert.Equal(t, e.Id, records[0].Event.PhotoId())
+		}
+	})
+}
+
+func TestBoltdbOutbox_staged(t *testing.T) {
+	open := func(t *testing.T) *BoltdbStorage {
+		instance, err := New(path.Join(tempDir(t), "photos.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { instance.db.Close() })
+		return instance
+	}
+	deleted := func(id photo.Identifier) event.Event {
+		return event.PhotoDeleted{Id: id}
+	}
+
+	t.Run("with event staged for its outbox, appends it along with the write", func(t *testing.T) {
+		instance := open(t)
+		outbox := NewOutbox(instance)
+		id := photo.IdentifierOf("id")
+
+		ctx, staged := event.Stage(context.Background(), outbox, deleted)
+		if _, err := instance.Save(ctx, *photo.Of(*id, []byte("test"))); err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, staged.Appended())
+
+		ctx, staged = event.Stage(context.Background(), outbox, deleted)
+		if err := instance.Delete(ctx, *id); err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, staged.Appended())
+
+		records, err := outbox.Pending(context.Background())
+		if assert.NoError(t, err) && assert.Len(t, records, 2) {
+			assert.Equal(t, *id, records[1].Event.PhotoId())
+		}
+	})
+
+	t.Run("with event staged for another outbox, leaves it", func(t *testing.T) {
+		instance, other := open(t), open(t)
+
+		ctx, staged := event.Stage(context.Background(), NewOutbox(other), deleted)
+		if _, err := instance.Save(ctx, *photo.New([]byte("test"))); err != nil {
+			t.Fatal(err)
+		}
+		assert.False(t, staged.Appended())
+
+		for _, outbox := range []*BoltdbOutbox{NewOutbox(instance), NewOutbox(other)} {
+			records, err := outbox.Pending(context.Background())
+			if assert.NoError(t, err) {
+				assert.Empty(t, records)
+			}
+		}
+	})
+}
+
+func tempDir(tb testing.TB) string {
+	tb.Helper()
+	dir, err := ioutil.TempDir("", "boltdb_outbox")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
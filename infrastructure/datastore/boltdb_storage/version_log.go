@@ -0,0 +1,102 @@
+package boltdb_storage
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/boltdb/bolt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+)
+
+// BoltdbVersionLog keeps the stamp of each version by photo, in the tombstones bucket when it is deleted.
+type BoltdbVersionLog struct {
+	db *bolt.DB
+}
+
+func NewVersionLog(storage *BoltdbStorage) *BoltdbVersionLog {
+	return &BoltdbVersionLog{storage.db}
+}
+
+func (versions *BoltdbVersionLog) Get(ctx context.Context, id photo.Identifier) (*photo.Version, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	var found *photo.Version
+	if err := versions.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(versionsBucket).Get([]byte(id.Value())); v != nil {
+			found = &photo.Version{Stamp: int64(binary.BigEndian.Uint64(v))}
+		} else if v := tx.Bucket(tombstonesBucket).Get([]byte(id.Value())); v != nil {
+			found = &photo.Version{Stamp: int64(binary.BigEndian.Uint64(v)), Deleted: true}
+		}
+		return nil
+	}); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	if found == nil {
+		return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	}
+	return found, nil
+}
+
+func (versions *BoltdbVersionLog) Put(ctx context.Context, id photo.Identifier, version photo.Version) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return &photo.ResourceError{Id: id, Err: err}
+	}
+
+	stamp := make([]byte, 8)
+	binary.BigEndian.PutUint64(stamp, uint64(version.Stamp))
+	put, other := versionsBucket, tombstonesBucket
+	if version.Deleted {
+		put, other = tombstonesBucket, versionsBucket
+	}
+	return versions.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(put).Put([]byte(id.Value()), stamp); err != nil {
+			return err
+		}
+		return tx.Bucket(other).Delete([]byte(id.Value()))
+	})
+}
+
+func (versions *BoltdbVersionLog) Remove(ctx context.Context, id photo.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return &photo.ResourceError{Id: id, Err: err}
+	}
+
+	return versions.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{versionsBucket, tombstonesBucket} {
+			if err := tx.Bucket(name).Delete([]byte(id.Value())); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (versions *BoltdbVersionLog) Tombstones(ctx context.Context) (map[photo.Identifier]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	tombstones := make(map[photo.Identifier]int64)
+	if err := versions.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(tombstonesBucket).ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			tombstones[*photo.IdentifierOf(string(k))] = int64(binary.BigEndian.Uint64(v))
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return tombstones, nil
+}
@@ -0,0 +1,19 @@
+package boltdb_storage
+
+import (
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"path"
+	"testing"
+)
+
+func TestBoltdbVersionLog_Conformance(t *testing.T) {
+	phototest.RunVersionLogTests(t, func(t *testing.T) photo.VersionLog {
+		instance, err := New(path.Join(tempDir(t), "photos.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { instance.db.Close() })
+		return NewVersionLog(instance)
+	})
+}
@@ -0,0 +1,248 @@
+package encrypted_storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	nonceSize = 12
+	tagSize   = 16
+)
+
+var magic = []byte("PSE1")
+
+var (
+	ErrMalformed = errors.New("malformed encrypted photo")
+	ErrPlaintext = errors.New("photo is not encrypted")
+)
+
+type Options struct {
+	// AllowPlaintext reads photos stored before encryption was enabled as they are,
+	// until they are re-encrypted. Otherwise reading them fails with ErrPlaintext.
+	AllowPlaintext bool
+}
+
+type EncryptedStorage struct {
+	repository photo.Repository
+	keyring    *Keyring
+	options    Options
+}
+
+func New(repository photo.Repository, keyring *Keyring, options Options) *EncryptedStorage {
+	return &EncryptedStorage{repository: repository, keyring: keyring, options: options}
+}
+
+func (storage *EncryptedStorage) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	id := photograph.Id()
+	if photograph.IsNew() {
+		id = photo.NewIdentifier(photograph.Image())
+	}
+
+	data, err := storage.seal(photograph.Image())
+	if err != nil {
+		return nil, err
+	}
+	return storage.repository.Save(ctx, *photo.Of(*id, data, photograph.Tags()...))
+}
+
+func (storage *EncryptedStorage) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	photograph, err := storage.repository.Read(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return storage.decrypt(*photograph)
+}
+
+func (storage *EncryptedStorage) Peek(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	photograph, err := photo.Peek(ctx, storage.repository, id)
+	if err != nil {
+		return nil, err
+	}
+	return storage.decrypt(*photograph)
+}
+
+// TagsOf reads the tags from the underlying repository, as only images are encrypted.
+func (storage *EncryptedStorage) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	return photo.TagsOf(ctx, storage.repository, id)
+}
+
+func (storage *EncryptedStorage) decrypt(photograph photo.Photo) (*photo.Photo, error) {
+	data, _, err := storage.open(photograph.Image())
+	if err != nil {
+		return nil, &photo.ResourceError{Id: *photograph.Id(), Err: err}
+	}
+	return photo.Of(*photograph.Id(), data, photograph.Tags()...), nil
+}
+
+// Open streams the photo from the underlying repository, but decrypts it whole as it is sealed as one message.
+func (storage *EncryptedStorage) Open(ctx context.Context, id photo.Identifier) (io.ReadCloser, error) {
+	reader, err := photo.Open(ctx, storage.repository, id)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	sealed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	data, _, err := storage.open(sealed)
+	if err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (storage *EncryptedStorage) Delete(ctx context.Context, id photo.Identifier) error {
+	return storage.repository.Delete(ctx, id)
+}
+
+func (storage *EncryptedStorage) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	return storage.repository.FindAll(ctx)
+}
+
+func (storage *EncryptedStorage) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	return storage.repository.FindByTag(ctx, tag)
+}
+
+func (storage *EncryptedStorage) Close() error {
+	return storage.repository.Close()
+}
+
+func (storage *EncryptedStorage) Ping(ctx context.Context) error {
+	return photo.Ping(ctx, storage.repository)
+}
+
+func (storage *EncryptedStorage) Stats(ctx context.Context) (*photo.Stats, error) {
+	return photo.StatsOf(ctx, storage.repository)
+}
+
+// Check tells whether a photo read from the underlying repository can be decrypted.
+func (storage *EncryptedStorage) Check(stored photo.Photo) error {
+	_, _, err := storage.open(stored.Image())
+	return err
+}
+
+// Reencrypt encrypts the photo with the current key, when it is stored with an older key or in plaintext.
+func (storage *EncryptedStorage) Reencrypt(ctx context.Context, id photo.Identifier) (bool, error) {
+	photograph, err := storage.repository.Read(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	data, keyId, err := storage.open(photograph.Image())
+	if err == ErrPlaintext {
+		data = photograph.Image()
+	} else if err != nil {
+		return false, &photo.ResourceError{Id: id, Err: err}
+	} else if keyId == storage.keyring.Current() {
+		return false, nil
+	}
+	if _, err := storage.Save(ctx, *photo.Of(id, data, photograph.Tags()...)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// seal encrypts data with a random data key, and stores the data key wrapped by the current master key:
+// magic | key id length | key id | wrapped data key | nonce | ciphertext
+func (storage *EncryptedStorage) seal(data []byte) ([]byte, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	keyId := storage.keyring.Current()
+	masterKey, err := storage.keyring.key(keyId)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := encrypt(masterKey, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := encrypt(dataKey, data)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(append([]byte{}, magic...))
+	buf.WriteByte(byte(len(keyId)))
+	buf.WriteString(keyId)
+	buf.Write(wrapped)
+	buf.Write(ciphertext)
+	return buf.Bytes(), nil
+}
+
+func (storage *EncryptedStorage) open(data []byte) ([]byte, string, error) {
+	if !bytes.HasPrefix(data, magic) {
+		if storage.options.AllowPlaintext {
+			return data, "", nil
+		}
+		return nil, "", ErrPlaintext
+	}
+
+	rest := data[len(magic):]
+	if len(rest) < 1 || len(rest) < 1+int(rest[0]) {
+		return nil, "", ErrMalformed
+	}
+	keyId := string(rest[1 : 1+int(rest[0])])
+	rest = rest[1+int(rest[0]):]
+
+	wrappedSize := nonceSize + keySize + tagSize
+	if len(rest) < wrappedSize {
+		return nil, "", ErrMalformed
+	}
+	masterKey, err := storage.keyring.key(keyId)
+	if err != nil {
+		return nil, "", err
+	}
+	dataKey, err := decrypt(masterKey, rest[:wrappedSize])
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext, err := decrypt(dataKey, rest[wrappedSize:])
+	if err != nil {
+		return nil, "", err
+	}
+	return plaintext, keyId, nil
+}
+
+func encrypt(key []byte, plaintext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decrypt(key []byte, sealed []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < nonceSize {
+		return nil, ErrMalformed
+	}
+	return aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,159 @@
+package encrypted_storage
+
+import (
+	"bytes"
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+var (
+	oldKey = []byte("fedcba9876543210fedcba9876543210")
+	newKey = []byte("0123456789abcdef0123456789abcdef")
+)
+
+func TestEncryptedStorage_Save(t *testing.T) {
+	t.Run("stores ciphertext, reads plaintext", func(t *testing.T) {
+		inner := createInner(t)
+		instance := New(inner, createKeyring(t, "new"), Options{})
+
+		photograph := photo.New([]byte("secret image"))
+		photograph.AddTag("tag")
+		id, err := instance.Save(context.Background(), *photograph)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err := inner.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.False(t, bytes.Contains(stored.Image(), []byte("secret image")))
+			assert.Equal(t, []string{"tag"}, stored.Tags())
+		}
+		actual, err := instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("secret image"), actual.Image())
+			assert.Equal(t, []string{"tag"}, actual.Tags())
+		}
+	})
+
+	t.Run("same image, stores different ciphertext", func(t *testing.T) {
+		inner := createInner(t)
+		instance := New(inner, createKeyring(t, "new"), Options{})
+
+		first, _ := instance.Save(context.Background(), *photo.New([]byte("image")))
+		second, _ := instance.Save(context.Background(), *photo.New([]byte("image")))
+
+		a, _ := inner.Read(context.Background(), *first)
+		b, _ := inner.Read(context.Background(), *second)
+		assert.NotEqual(t, a.Image(), b.Image())
+	})
+}
+
+func TestEncryptedStorage_Read(t *testing.T) {
+	t.Run("with plaintext photo, returns error", func(t *testing.T) {
+		inner := createInner(t)
+		id := photo.IdentifierOf("legacy")
+		if _, err := inner.Save(context.Background(), *photo.Of(*id, []byte("plain"))); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := New(inner, createKeyring(t, "new"), Options{}).Read(context.Background(), *id)
+		assert.Equal(t, &photo.ResourceError{Id: *id, Err: ErrPlaintext}, err)
+	})
+
+	t.Run("with plaintext photo and plaintext allowed, returns as is", func(t *testing.T) {
+		inner := createInner(t)
+		id := photo.IdentifierOf("legacy")
+		if _, err := inner.Save(context.Background(), *photo.Of(*id, []byte("plain"))); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := New(inner, createKeyring(t, "new"), Options{AllowPlaintext: true}).Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("plain"), actual.Image())
+		}
+	})
+
+	t.Run("with tampered ciphertext, returns error", func(t *testing.T) {
+		inner := createInner(t)
+		instance := New(inner, createKeyring(t, "new"), Options{})
+		id, _ := instance.Save(context.Background(), *photo.New([]byte("image")))
+
+		stored, _ := inner.Read(context.Background(), *id)
+		data := stored.Image()
+		data[len(data)-1] ^= 0xff
+		if _, err := inner.Save(context.Background(), *photo.Of(*id, data)); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := instance.Read(context.Background(), *id)
+		assert.Error(t, err)
+	})
+
+	t.Run("with unknown key, returns error", func(t *testing.T) {
+		inner := createInner(t)
+		id, _ := New(inner, createKeyring(t, "old"), Options{}).Save(context.Background(), *photo.New([]byte("image")))
+
+		keyring, _ := NewKeyring("new", map[string][]byte{"new": newKey})
+		_, err := New(inner, keyring, Options{}).Read(context.Background(), *id)
+		assert.Error(t, err)
+	})
+}
+
+func TestEncryptedStorage_Reencrypt(t *testing.T) {
+	inner := createInner(t)
+	old, _ := New(inner, createKeyring(t, "old"), Options{}).Save(context.Background(), *photo.New([]byte("old")))
+	legacy := photo.IdentifierOf("legacy")
+	if _, err := inner.Save(context.Background(), *photo.Of(*legacy, []byte("legacy"))); err != nil {
+		t.Fatal(err)
+	}
+
+	instance := New(inner, createKeyring(t, "new"), Options{})
+	current, _ := instance.Save(context.Background(), *photo.New([]byte("current")))
+
+	for _, tc := range []struct {
+		id       *photo.Identifier
+		expected bool
+	}{{old, true}, {legacy, true}, {current, false}} {
+		actual, err := instance.Reencrypt(context.Background(), *tc.id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, tc.expected, actual, tc.id.Value())
+		}
+	}
+
+	keyring, _ := NewKeyring("new", map[string][]byte{"new": newKey})
+	for _, id := range []*photo.Identifier{old, legacy, current} {
+		_, err := New(inner, keyring, Options{}).Read(context.Background(), *id)
+		assert.NoError(t, err)
+	}
+}
+
+func TestEncryptedStorage_Conformance(t *testing.T) {
+	phototest.RunRepositoryTests(t, func(t *testing.T) photo.Repository {
+		return New(createInner(t), createKeyring(t, "new"), Options{})
+	})
+}
+
+func createKeyring(tb testing.TB, current string) *Keyring {
+	tb.Helper()
+	keyring, err := NewKeyring(current, map[string][]byte{"old": oldKey, "new": newKey})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return keyring
+}
+
+func createInner(tb testing.TB) *file_storage.FileStorage {
+	tb.Helper()
+	dir, err := ioutil.TempDir("", "encrypted_storage")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+	return file_storage.New(dir)
+}
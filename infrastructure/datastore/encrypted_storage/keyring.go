@@ -0,0 +1,97 @@
+package encrypted_storage
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	keySize      = 32
+	maxKeyIdSize = 255
+)
+
+var (
+	ErrNoKeys       = errors.New("key file has no keys")
+	ErrInvalidKey   = errors.New("key must be 32 bytes encoded in base64")
+	ErrInvalidKeyId = errors.New("key id must be at most 255 bytes")
+	ErrUnknownKey   = errors.New("unknown key id")
+	ErrDuplicateId  = errors.New("duplicate key id")
+)
+
+type Keyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+func NewKeyring(current string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("%s: %s", current, ErrUnknownKey)
+	}
+	for id, key := range keys {
+		if len(id) > maxKeyIdSize {
+			return nil, fmt.Errorf("%s: %s", id, ErrInvalidKeyId)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("%s: %s", id, ErrInvalidKey)
+		}
+	}
+	return &Keyring{current: current, keys: keys}, nil
+}
+
+func LoadKeyring(path string) (*Keyring, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var current string
+	keys := make(map[string][]byte)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s: %s", line, ErrInvalidKey)
+		}
+		id, encoded := fields[0], fields[1]
+		if _, ok := keys[id]; ok {
+			return nil, fmt.Errorf("%s: %s", id, ErrDuplicateId)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", id, ErrInvalidKey)
+		}
+		if current == "" {
+			current = id
+		}
+		keys[id] = key
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current == "" {
+		return nil, ErrNoKeys
+	}
+	return NewKeyring(current, keys)
+}
+
+func (keyring *Keyring) Current() string {
+	return keyring.current
+}
+
+func (keyring *Keyring) key(id string) ([]byte, error) {
+	key, ok := keyring.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %s", id, ErrUnknownKey)
+	}
+	return key, nil
+}
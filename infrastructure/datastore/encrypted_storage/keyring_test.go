@@ -0,0 +1,63 @@
+package encrypted_storage
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestLoadKeyring(t *testing.T) {
+	t.Run("with keys, first key is current", func(t *testing.T) {
+		keyring, err := LoadKeyring(writeKeyFile(t, `
+# current key first
+new MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=
+old ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=
+`))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "new", keyring.Current())
+			key, err := keyring.key("old")
+			if assert.NoError(t, err) {
+				assert.Equal(t, []byte("fedcba9876543210fedcba9876543210"), key)
+			}
+		}
+	})
+
+	t.Run("with no keys, returns error", func(t *testing.T) {
+		_, err := LoadKeyring(writeKeyFile(t, "# nothing\n"))
+		assert.Equal(t, ErrNoKeys, err)
+	})
+
+	t.Run("with short key, returns error", func(t *testing.T) {
+		_, err := LoadKeyring(writeKeyFile(t, "short c2hvcnQ=\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("with duplicate id, returns error", func(t *testing.T) {
+		_, err := LoadKeyring(writeKeyFile(t, `
+key MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=
+key ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=
+`))
+		assert.Error(t, err)
+	})
+
+	t.Run("with no file, returns error", func(t *testing.T) {
+		_, err := LoadKeyring(path.Join(os.TempDir(), "no_key_file"))
+		assert.Error(t, err)
+	})
+}
+
+func writeKeyFile(tb testing.TB, content string) string {
+	tb.Helper()
+	file, err := ioutil.TempFile("", "keys")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.Remove(file.Name()) })
+	if _, err := file.WriteString(content); err != nil {
+		tb.Fatal(err)
+	}
+	file.Close()
+	return file.Name()
+}
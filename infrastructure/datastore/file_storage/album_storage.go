@@ -0,0 +1,139 @@
+package file_storage
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+const albumsDir = ".albums"
+
+type FileAlbumStorage struct {
+	baseDir string
+}
+
+type albumRecord struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Cover       string   `json:"cover,omitempty"`
+	Photos      []string `json:"photos"`
+}
+
+func NewAlbumStorage(storage *FileStorage) *FileAlbumStorage {
+	return &FileAlbumStorage{path.Join(storage.baseDir, albumsDir)}
+}
+
+func (storage *FileAlbumStorage) Save(ctx context.Context, a album.Album) (*album.Identifier, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	id := a.Id()
+	if a.IsNew() {
+		id = album.NewIdentifier()
+	}
+	if err := album.ValidateIdentifier(*id); err != nil {
+		return nil, &album.ResourceError{Id: *id, Err: err}
+	}
+
+	data, err := encodeAlbum(a)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(storage.baseDir, 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path.Join(storage.baseDir, id.Value()), data, 0600); err != nil {
+		return nil, err
+	}
+
+	return id, nil
+}
+
+func (storage *FileAlbumStorage) Read(ctx context.Context, id album.Identifier) (*album.Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := album.ValidateIdentifier(id); err != nil {
+		return nil, &album.ResourceError{Id: id, Err: err}
+	}
+
+	data, err := ioutil.ReadFile(path.Join(storage.baseDir, id.Value()))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &album.ResourceError{Id: id, Err: album.ErrNotFound}
+		}
+		return nil, &album.ResourceError{Id: id, Err: err}
+	}
+
+	a, err := decodeAlbum(id, data)
+	if err != nil {
+		return nil, &album.ResourceError{Id: id, Err: err}
+	}
+	return a, nil
+}
+
+func (storage *FileAlbumStorage) ReadAll(ctx context.Context) ([]album.Album, error) {
+	files, err := ioutil.ReadDir(storage.baseDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var albums []album.Album
+	for _, file := range files {
+		a, err := storage.Read(ctx, *album.IdentifierOf(file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, *a)
+	}
+	return albums, nil
+}
+
+func (storage *FileAlbumStorage) Delete(ctx context.Context, id album.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := album.ValidateIdentifier(id); err != nil {
+		return &album.ResourceError{Id: id, Err: err}
+	}
+
+	if err := os.Remove(path.Join(storage.baseDir, id.Value())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func encodeAlbum(a album.Album) ([]byte, error) {
+	record := albumRecord{Title: a.Title(), Description: a.Description(), Photos: []string{}}
+	if a.Cover() != nil {
+		record.Cover = a.Cover().Value()
+	}
+	for _, id := range a.Photos() {
+		record.Photos = append(record.Photos, id.Value())
+	}
+	return json.Marshal(record)
+}
+
+func decodeAlbum(id album.Identifier, data []byte) (*album.Album, error) {
+	var record albumRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	var cover *photo.Identifier
+	if record.Cover != "" {
+		cover = photo.IdentifierOf(record.Cover)
+	}
+	var photos []photo.Identifier
+	for _, value := range record.Photos {
+		photos = append(photos, *photo.IdentifierOf(value))
+	}
+	return album.Of(id, record.Title, record.Description, cover, photos), nil
+}
@@ -0,0 +1,119 @@
+package file_storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+)
+
+const changesDir = ".changes"
+
+type FileChangeLog struct {
+	baseDir string
+}
+
+func NewChangeLog(storage *FileStorage) *FileChangeLog {
+	return &FileChangeLog{path.Join(storage.baseDir, changesDir)}
+}
+
+func (changes *FileChangeLog) Put(ctx context.Context, record event.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := event.Marshal(record.Event)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(changes.baseDir, 0700); err != nil {
+		return err
+	}
+
+	filename := path.Join(changes.baseDir, fmt.Sprintf("%020d", record.Sequence))
+	if err := ioutil.WriteFile(filename+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(filename+".tmp", filename)
+}
+
+func (changes *FileChangeLog) Since(ctx context.Context, sequence uint64, limit int) ([]event.Record, error) {
+	sequences, err := changes.sequences(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []event.Record
+	for _, s := range sequences {
+		if s <= sequence {
+			continue
+		}
+		if len(records) == limit {
+			break
+		}
+		data, err := ioutil.ReadFile(path.Join(changes.baseDir, fmt.Sprintf("%020d", s)))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		e, err := event.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, event.Record{Sequence: s, Event: e})
+	}
+	return records, nil
+}
+
+func (changes *FileChangeLog) Latest(ctx context.Context) (uint64, error) {
+	sequences, err := changes.sequences(ctx)
+	if err != nil || len(sequences) == 0 {
+		return 0, err
+	}
+	return sequences[len(sequences)-1], nil
+}
+
+func (changes *FileChangeLog) Trim(ctx context.Context, before uint64) error {
+	sequences, err := changes.sequences(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sequences {
+		if s >= before {
+			break
+		}
+		err := os.Remove(path.Join(changes.baseDir, fmt.Sprintf("%020d", s)))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (changes *FileChangeLog) sequences(ctx context.Context) ([]uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(changes.baseDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var sequences []uint64
+	for _, file := range files {
+		sequence, err := strconv.ParseUint(file.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		sequences = append(sequences, sequence)
+	}
+	return sequences, nil
+}
@@ -1,35 +1,76 @@
 package file_storage
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
+	"sync"
 	"syscall"
 )
 
+const (
+	tagsDir     = ".tags"
+	tagIndexDir = ".tag_index_hex"
+	checksumDir = ".checksums"
+
+	// maxHexTagLength is the longest tag whose index directory is named by its hex encoding.
+	// Longer ones are named by their hash, as the encoding would exceed the file name limit.
+	maxHexTagLength = 64
+
+	sentinelFile = ".health"
+)
+
 type FileStorage struct {
 	baseDir string
+	mu      sync.Mutex
 }
 
 func New(baseDir string) *FileStorage {
-	return &FileStorage{baseDir}
+	return &FileStorage{baseDir: baseDir}
 }
 
-func (storage *FileStorage) Save(photograph photo.Photo) (*photo.Identifier, error) {
+func (storage *FileStorage) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
 	data := photograph.Image()
 	id := photograph.Id()
 	if photograph.IsNew() {
 		id = photo.NewIdentifier(data)
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
 
 	filename := path.Join(storage.baseDir, id.Value())
-	ioutil.WriteFile(filename, data, 0600)
+	if err := ioutil.WriteFile(filename, data, 0600); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(path.Join(storage.baseDir, checksumDir), 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path.Join(storage.baseDir, checksumDir, id.Value()), []byte(photo.Checksum(data)), 0600); err != nil {
+		return nil, err
+	}
+	if err := storage.updateTags(*id, photograph.Tags()); err != nil {
+		return nil, err
+	}
 
 	return id, nil
 }
 
-func (storage *FileStorage) Read(id photo.Identifier) (*photo.Photo, error) {
+func (storage *FileStorage) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
 	filename := path.Join(storage.baseDir, id.Value())
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
@@ -41,9 +82,241 @@ func (storage *FileStorage) Read(id photo.Identifier) (*photo.Photo, error) {
 		return nil, &photo.ResourceError{Id: id, Err: err}
 	}
 
-	return photo.Of(id, data), nil
+	tags, err := storage.readTags(id)
+	if err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	return photo.Of(id, data, tags...), nil
+}
+
+func (storage *FileStorage) Delete(ctx context.Context, id photo.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if err := os.Remove(path.Join(storage.baseDir, id.Value())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(path.Join(storage.baseDir, checksumDir, id.Value())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return storage.updateTags(id, nil)
+}
+
+func (storage *FileStorage) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	files, err := ioutil.ReadDir(storage.baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []photo.Identifier
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		ids = append(ids, *photo.IdentifierOf(file.Name()))
+	}
+	return ids, nil
+}
+
+func (storage *FileStorage) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(storage.tagIndexPath(tag))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var ids []photo.Identifier
+	for _, file := range files {
+		ids = append(ids, *photo.IdentifierOf(file.Name()))
+	}
+	return ids, nil
+}
+
+func (storage *FileStorage) Close() error {
+	return nil
 }
 
-func (storage *FileStorage) Delete(id photo.Identifier) error {
-	return os.Remove(path.Join(storage.baseDir, id.Value()))
+func (storage *FileStorage) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sentinel := photo.Sentinel()
+	filename := path.Join(storage.baseDir, sentinelFile)
+	if err := ioutil.WriteFile(filename, sentinel, 0600); err != nil {
+		return err
+	}
+	value, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(value, sentinel) {
+		return photo.ErrSentinelMismatch
+	}
+	return nil
+}
+
+func (storage *FileStorage) Checksums(ctx context.Context) (map[photo.Identifier]string, error) {
+	files, err := ioutil.ReadDir(path.Join(storage.baseDir, checksumDir))
+	if os.IsNotExist(err) {
+		return map[photo.Identifier]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	checksums := make(map[photo.Identifier]string)
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadFile(path.Join(storage.baseDir, checksumDir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		checksums[*photo.IdentifierOf(file.Name())] = string(data)
+	}
+	return checksums, nil
+}
+
+func (storage *FileStorage) ChecksumOf(ctx context.Context, id photo.Identifier) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", &photo.ResourceError{Id: id, Err: err}
+	}
+	data, err := ioutil.ReadFile(path.Join(storage.baseDir, checksumDir, id.Value()))
+	if os.IsNotExist(err) {
+		return "", &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	} else if err != nil {
+		return "", &photo.ResourceError{Id: id, Err: err}
+	}
+	return string(data), nil
+}
+
+func (storage *FileStorage) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	if _, err := os.Stat(path.Join(storage.baseDir, id.Value())); os.IsNotExist(err) {
+		return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	} else if err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	tags, err := storage.readTags(id)
+	if err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	return tags, nil
+}
+
+func (storage *FileStorage) Stats(ctx context.Context) (*photo.Stats, error) {
+	sizes, err := storage.Sizes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &photo.Stats{Count: len(sizes)}
+	for _, size := range sizes {
+		stats.Bytes += size
+	}
+	return stats, nil
+}
+
+func (storage *FileStorage) Sizes(ctx context.Context) (map[photo.Identifier]int64, error) {
+	files, err := ioutil.ReadDir(storage.baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[photo.Identifier]int64)
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		sizes[*photo.IdentifierOf(file.Name())] = file.Size()
+	}
+	return sizes, nil
+}
+
+func (storage *FileStorage) readTags(id photo.Identifier) ([]string, error) {
+	data, err := ioutil.ReadFile(path.Join(storage.baseDir, tagsDir, id.Value()))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (storage *FileStorage) updateTags(id photo.Identifier, tags []string) error {
+	current, err := storage.readTags(id)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range current {
+		dir := storage.tagIndexPath(tag)
+		if err := os.Remove(path.Join(dir, id.Value())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		os.Remove(dir)
+	}
+	for _, tag := range tags {
+		dir := storage.tagIndexPath(tag)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path.Join(dir, id.Value()), nil, 0600); err != nil {
+			return err
+		}
+	}
+
+	filename := path.Join(storage.baseDir, tagsDir, id.Value())
+	if len(tags) == 0 {
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(path.Join(storage.baseDir, tagsDir), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0600)
+}
+
+// tagIndexPath returns the directory indexing photos with tag.
+// Tags are hex encoded, so that no tag can name a dot segment or a nested directory,
+// and long tags are hashed, so that the name fits in a path segment.
+func (storage *FileStorage) tagIndexPath(tag string) string {
+	name := hex.EncodeToString([]byte(tag))
+	if len(tag) > maxHexTagLength {
+		sum := sha256.Sum256([]byte(tag))
+		name = "sha256-" + hex.EncodeToString(sum[:])
+	}
+	return path.Join(storage.baseDir, tagIndexDir, name)
 }
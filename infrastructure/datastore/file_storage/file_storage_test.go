@@ -1,6 +1,7 @@
 package file_storage
 
 import (
+	"context"
 	"fmt"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
@@ -9,6 +10,7 @@ import (
 	"math/rand"
 	"os"
 	"path"
+	"strings"
 	"testing"
 )
 
@@ -24,7 +26,7 @@ func TestFileStorage_Save(t *testing.T) {
 		instance := createInstance(t)
 		photograph := photo.New(readTestData(t))
 
-		identifier, err := instance.Save(*photograph)
+		identifier, err := instance.Save(context.Background(), *photograph)
 		if assert.NoError(t, err) {
 			assert.NotNil(t, identifier)
 		}
@@ -34,7 +36,7 @@ func TestFileStorage_Save(t *testing.T) {
 		instance := createInstance(t)
 		photograph := photo.Of(*photo.IdentifierOf("testdata"), readTestData(t))
 
-		identifier, err := instance.Save(*photograph)
+		identifier, err := instance.Save(context.Background(), *photograph)
 
 		if assert.NoError(t, err) {
 			t.Run("returns identifier has same value", func(t *testing.T) {
@@ -65,12 +67,12 @@ func TestFileStorage_Read(t *testing.T) {
 	}
 
 	t.Run("with no key, returns err", func(t *testing.T) {
-		_, err := instance.Read(*photo.IdentifierOf("noKey"))
+		_, err := instance.Read(context.Background(), *photo.IdentifierOf("noKey"))
 		assert.Error(t, err)
 	})
 
 	t.Run("returns same data with source", func(t *testing.T) {
-		photograph, err := instance.Read(*photo.IdentifierOf("testdata"))
+		photograph, err := instance.Read(context.Background(), *photo.IdentifierOf("testdata"))
 		if assert.NoError(t, err) {
 			assert.EqualValues(t, readTestData(t), photograph.Image())
 		}
@@ -84,16 +86,151 @@ func TestFileStorage_Delete(t *testing.T) {
 	}
 
 	t.Run("when delete existing key, returns no error", func(t *testing.T) {
-		err := instance.Delete(*photo.IdentifierOf("testdata"))
+		err := instance.Delete(context.Background(), *photo.IdentifierOf("testdata"))
 		if assert.NoError(t, err) {
 			files, _ := ioutil.ReadDir(instance.baseDir)
 			assert.EqualValues(t, 0, len(files))
 		}
 	})
 
-	t.Run("with no key, returns error", func(t *testing.T) {
-		err := instance.Delete(*photo.IdentifierOf("noKey"))
-		assert.Error(t, err)
+	t.Run("with no key, returns no error", func(t *testing.T) {
+		err := instance.Delete(context.Background(), *photo.IdentifierOf("noKey"))
+		assert.NoError(t, err)
+	})
+}
+
+func TestFileStorage_FindAll(t *testing.T) {
+	instance := createInstance(t)
+	for _, id := range []string{"first", "second"} {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), readTestData(t), "tag")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := instance.FindAll(context.Background())
+	if assert.NoError(t, err) {
+		assert.ElementsMatch(t, []photo.Identifier{*photo.IdentifierOf("first"), *photo.IdentifierOf("second")}, ids)
+	}
+}
+
+func TestFileStorage_Checksums(t *testing.T) {
+	instance := createInstance(t)
+	data := readTestData(t)
+	for _, id := range []string{"first", "second"} {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := instance.Delete(context.Background(), *photo.IdentifierOf("second")); err != nil {
+		t.Fatal(err)
+	}
+
+	checksums, err := instance.Checksums(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[photo.Identifier]string{*photo.IdentifierOf("first"): photo.Checksum(data)}, checksums)
+	}
+}
+
+func TestFileStorage_FindByTag(t *testing.T) {
+	instance := createInstance(t)
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("first"), readTestData(t), "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("second"), readTestData(t), "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("returns identifiers have tag", func(t *testing.T) {
+		ids, err := instance.FindByTag(context.Background(), "b")
+		if assert.NoError(t, err) {
+			assert.ElementsMatch(t, []photo.Identifier{*photo.IdentifierOf("first"), *photo.IdentifierOf("second")}, ids)
+		}
+	})
+
+	t.Run("with no such tag, returns empty", func(t *testing.T) {
+		ids, err := instance.FindByTag(context.Background(), "unknown")
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+
+	t.Run("read photo has tags", func(t *testing.T) {
+		photograph, err := instance.Read(context.Background(), *photo.IdentifierOf("first"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"a", "b"}, photograph.Tags())
+		}
+	})
+
+	t.Run("when tag removed, not found by tag", func(t *testing.T) {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("first"), readTestData(t), "b")); err != nil {
+			t.Fatal(err)
+		}
+		ids, err := instance.FindByTag(context.Background(), "a")
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+
+	t.Run("when photo deleted, not found by tag", func(t *testing.T) {
+		if err := instance.Delete(context.Background(), *photo.IdentifierOf("second")); err != nil {
+			t.Fatal(err)
+		}
+		ids, err := instance.FindByTag(context.Background(), "b")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("first")}, ids)
+		}
+	})
+}
+
+func TestFileStorage_FindByTag_dotSegments(t *testing.T) {
+	instance := createInstance(t)
+	for _, tag := range []string{"..", "."} {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("first"), readTestData(t), tag)); err != nil {
+			t.Fatal(err)
+		}
+
+		photograph, err := instance.Read(context.Background(), *photo.IdentifierOf("first"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, readTestData(t), photograph.Image(), tag)
+		}
+		ids, err := instance.FindByTag(context.Background(), tag)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("first")}, ids, tag)
+		}
+	}
+}
+
+func TestFileStorage_FindByTag_longTag(t *testing.T) {
+	instance := createInstance(t)
+	long := strings.Repeat("ab", 200)
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("first"), readTestData(t), long, long[1:])); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tag := range []string{long, long[1:]} {
+		ids, err := instance.FindByTag(context.Background(), tag)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("first")}, ids)
+		}
+	}
+
+	if err := instance.Delete(context.Background(), *photo.IdentifierOf("first")); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := instance.FindByTag(context.Background(), long)
+	if assert.NoError(t, err) {
+		assert.Empty(t, ids)
+	}
+}
+
+func TestFileStorage_Conformance(t *testing.T) {
+	phototest.RunRepositoryTests(t, func(t *testing.T) photo.Repository {
+		dir, err := ioutil.TempDir("", "file_storage")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		return New(dir)
 	})
 }
 
@@ -107,7 +244,7 @@ func BenchmarkFileStorage_Save(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", 0)
 			photograph := *photo.Of(*photo.IdentifierOf(key), data)
-			instance.Save(photograph)
+			instance.Save(context.Background(), photograph)
 		}
 		b.StopTimer()
 	})
@@ -119,7 +256,7 @@ func BenchmarkFileStorage_Save(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", i%100)
 			photograph := *photo.Of(*photo.IdentifierOf(key), data)
-			instance.Save(photograph)
+			instance.Save(context.Background(), photograph)
 		}
 		b.StopTimer()
 	})
@@ -132,7 +269,7 @@ func BenchmarkFileStorage_Save(b *testing.B) {
 		for i := 1; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", i%20)
 			photograph := *photo.Of(*photo.IdentifierOf(key), randomTestData[i%20])
-			instance.Save(photograph)
+			instance.Save(context.Background(), photograph)
 		}
 		b.StopTimer()
 	})
@@ -153,7 +290,7 @@ func BenchmarkFileStorage_Read(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", 0)
-			instance.Read(*photo.IdentifierOf(key))
+			instance.Read(context.Background(), *photo.IdentifierOf(key))
 		}
 		b.StopTimer()
 	})
@@ -162,7 +299,7 @@ func BenchmarkFileStorage_Read(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", i)
-			instance.Read(*photo.IdentifierOf(key))
+			instance.Read(context.Background(), *photo.IdentifierOf(key))
 		}
 		b.StopTimer()
 	})
@@ -171,7 +308,7 @@ func BenchmarkFileStorage_Read(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", rand.Intn(100))
-			instance.Read(*photo.IdentifierOf(key))
+			instance.Read(context.Background(), *photo.IdentifierOf(key))
 		}
 		b.StopTimer()
 	})
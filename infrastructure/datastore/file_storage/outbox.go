@@ -0,0 +1,121 @@
+package file_storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	outboxDir    = ".outbox"
+	sequenceFile = ".sequence"
+)
+
+type FileOutbox struct {
+	baseDir string
+	mu      sync.Mutex
+}
+
+func NewOutbox(storage *FileStorage) *FileOutbox {
+	return &FileOutbox{baseDir: path.Join(storage.baseDir, outboxDir)}
+}
+
+func (outbox *FileOutbox) Append(ctx context.Context, e event.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := event.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	if err := os.MkdirAll(outbox.baseDir, 0700); err != nil {
+		return err
+	}
+	sequence, err := outbox.nextSequence()
+	if err != nil {
+		return err
+	}
+
+	filename := path.Join(outbox.baseDir, fmt.Sprintf("%020d", sequence))
+	if err := ioutil.WriteFile(filename+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(filename+".tmp", filename)
+}
+
+func (outbox *FileOutbox) Pending(ctx context.Context) ([]event.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(outbox.baseDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var records []event.Record
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sequence, err := strconv.ParseUint(file.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		data, err := ioutil.ReadFile(path.Join(outbox.baseDir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		e, err := event.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, event.Record{Sequence: sequence, Event: e})
+	}
+	return records, nil
+}
+
+func (outbox *FileOutbox) Remove(ctx context.Context, sequence uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := os.Remove(path.Join(outbox.baseDir, fmt.Sprintf("%020d", sequence)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (outbox *FileOutbox) nextSequence() (uint64, error) {
+	filename := path.Join(outbox.baseDir, sequenceFile)
+	var sequence uint64
+	data, err := ioutil.ReadFile(filename)
+	if err == nil {
+		sequence, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	sequence++
+	if err := ioutil.WriteFile(filename, []byte(strconv.FormatUint(sequence, 10)), 0600); err != nil {
+		return 0, err
+	}
+	return sequence, nil
+}
@@ -0,0 +1,42 @@
+package file_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event/eventtest"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestFileOutbox_Conformance(t *testing.T) {
+	eventtest.RunOutboxTests(t, func(t *testing.T) event.Outbox {
+		return NewOutbox(New(tempDir(t)))
+	})
+}
+
+func TestFileOutbox_Append(t *testing.T) {
+	t.Run("with outbox, photos are not listed", func(t *testing.T) {
+		storage := New(tempDir(t))
+		if err := NewOutbox(storage).Append(context.Background(), event.PhotoDeleted{Id: *photo.IdentifierOf("id")}); err != nil {
+			t.Fatal(err)
+		}
+
+		ids, err := storage.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+}
+
+func tempDir(tb testing.TB) string {
+	tb.Helper()
+	dir, err := ioutil.TempDir("", "file_outbox")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
@@ -0,0 +1,144 @@
+package file_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	versionsDir   = ".versions"
+	tombstonesDir = ".tombstones"
+)
+
+// FileVersionLog keeps a file holding the stamp of each version, in the tombstones directory when it is deleted.
+// A photo found in both directories, after a put was interrupted, has the newer of both versions.
+type FileVersionLog struct {
+	baseDir string
+}
+
+func NewVersionLog(storage *FileStorage) *FileVersionLog {
+	return &FileVersionLog{baseDir: storage.baseDir}
+}
+
+func (versions *FileVersionLog) Get(ctx context.Context, id photo.Identifier) (*photo.Version, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	var found *photo.Version
+	for _, deleted := range []bool{false, true} {
+		stamp, err := readStamp(versions.filename(id, deleted))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, &photo.ResourceError{Id: id, Err: err}
+		}
+		if found == nil || stamp > found.Stamp {
+			found = &photo.Version{Stamp: stamp, Deleted: deleted}
+		}
+	}
+	if found == nil {
+		return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	}
+	return found, nil
+}
+
+func (versions *FileVersionLog) Put(ctx context.Context, id photo.Identifier, version photo.Version) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return &photo.ResourceError{Id: id, Err: err}
+	}
+
+	filename := versions.filename(id, version.Deleted)
+	if err := os.MkdirAll(path.Dir(filename), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filename+".tmp", []byte(strconv.FormatInt(version.Stamp, 10)), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(filename+".tmp", filename); err != nil {
+		return err
+	}
+	return removeIfExists(versions.filename(id, !version.Deleted))
+}
+
+func (versions *FileVersionLog) Remove(ctx context.Context, id photo.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return &photo.ResourceError{Id: id, Err: err}
+	}
+
+	if err := removeIfExists(versions.filename(id, true)); err != nil {
+		return err
+	}
+	return removeIfExists(versions.filename(id, false))
+}
+
+func (versions *FileVersionLog) Tombstones(ctx context.Context) (map[photo.Identifier]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	dir := path.Join(versions.baseDir, tombstonesDir)
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return map[photo.Identifier]int64{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	tombstones := make(map[photo.Identifier]int64)
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(file.Name(), ".tmp") {
+			continue
+		}
+		id := photo.IdentifierOf(file.Name())
+		version, err := versions.Get(ctx, *id)
+		if e, ok := err.(*photo.ResourceError); ok && e.Err == photo.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if version.Deleted {
+			tombstones[*id] = version.Stamp
+		}
+	}
+	return tombstones, nil
+}
+
+func (versions *FileVersionLog) filename(id photo.Identifier, deleted bool) string {
+	if deleted {
+		return path.Join(versions.baseDir, tombstonesDir, id.Value())
+	}
+	return path.Join(versions.baseDir, versionsDir, id.Value())
+}
+
+func readStamp(filename string) (int64, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func removeIfExists(filename string) error {
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
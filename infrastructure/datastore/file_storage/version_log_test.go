@@ -0,0 +1,32 @@
+package file_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFileVersionLog_Conformance(t *testing.T) {
+	phototest.RunVersionLogTests(t, func(t *testing.T) photo.VersionLog {
+		return NewVersionLog(New(tempDir(t)))
+	})
+}
+
+func TestFileVersionLog_Put(t *testing.T) {
+	t.Run("with versions, photos are not listed", func(t *testing.T) {
+		storage := New(tempDir(t))
+		versions := NewVersionLog(storage)
+		for _, version := range []photo.Version{{Stamp: 1}, {Stamp: 2, Deleted: true}} {
+			if err := versions.Put(context.Background(), *photo.IdentifierOf("id"), version); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ids, err := storage.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+}
@@ -0,0 +1,256 @@
+package instrumented_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var (
+	objectsDesc = prometheus.NewDesc(
+		"photoshelf_repository_objects",
+		"Photos currently stored by backend.",
+		[]string{"backend"}, nil,
+	)
+	storedBytesDesc = prometheus.NewDesc(
+		"photoshelf_repository_stored_bytes",
+		"Bytes of photos currently stored by backend.",
+		[]string{"backend"}, nil,
+	)
+)
+
+// InstrumentedStorage records latencies, errors and transferred bytes of the repository it wraps,
+// and reports the photo count and stored bytes of its last Refresh when collected.
+type InstrumentedStorage struct {
+	backend    func() string
+	repository photo.Repository
+	metrics    *metrics.Metrics
+
+	mu           sync.Mutex
+	stats        *photo.Stats
+	statsBackend string
+	statsErr     error
+	running      sync.WaitGroup
+}
+
+func New(backend string, repository photo.Repository, m *metrics.Metrics) *InstrumentedStorage {
+	return NewWithBackend(func() string { return backend }, repository, m)
+}
+
+// NewWithBackend labels the metrics with the backend currently returned by backend,
+// for repositories which switch between backends, like a migration.
+func NewWithBackend(backend func() string, repository photo.Repository, m *metrics.Metrics) *InstrumentedStorage {
+	return &InstrumentedStorage{backend: backend, repository: repository, metrics: m}
+}
+
+// Repository returns the wrapped repository.
+func (storage *InstrumentedStorage) Repository() photo.Repository {
+	return storage.repository
+}
+
+func (storage *InstrumentedStorage) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	defer storage.observe("save", time.Now())
+	id, err := storage.repository.Save(ctx, photograph)
+	if err != nil {
+		storage.fail("save")
+		return nil, err
+	}
+	storage.metrics.UploadedBytes.WithLabelValues(storage.backend()).Add(float64(len(photograph.Image())))
+	return id, nil
+}
+
+func (storage *InstrumentedStorage) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	defer storage.observe("read", time.Now())
+	photograph, err := storage.repository.Read(ctx, id)
+	if err != nil {
+		storage.fail("read")
+		return nil, err
+	}
+	storage.metrics.ServedBytes.WithLabelValues(storage.backend()).Add(float64(len(photograph.Image())))
+	return photograph, nil
+}
+
+// Peek doesn't count served bytes, as it reads photos for scans rather than for clients.
+func (storage *InstrumentedStorage) Peek(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	defer storage.observe("peek", time.Now())
+	photograph, err := photo.Peek(ctx, storage.repository, id)
+	if err != nil {
+		storage.fail("peek")
+	}
+	return photograph, err
+}
+
+func (storage *InstrumentedStorage) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	defer storage.observe("tags_of", time.Now())
+	tags, err := photo.TagsOf(ctx, storage.repository, id)
+	if err != nil {
+		storage.fail("tags_of")
+	}
+	return tags, err
+}
+
+// Open counts the bytes served as they are read from the stream.
+func (storage *InstrumentedStorage) Open(ctx context.Context, id photo.Identifier) (io.ReadCloser, error) {
+	defer storage.observe("open", time.Now())
+	reader, err := photo.Open(ctx, storage.repository, id)
+	if err != nil {
+		storage.fail("open")
+		return nil, err
+	}
+	return &countingReader{ReadCloser: reader, served: storage.metrics.ServedBytes.WithLabelValues(storage.backend())}, nil
+}
+
+func (storage *InstrumentedStorage) Delete(ctx context.Context, id photo.Identifier) error {
+	defer storage.observe("delete", time.Now())
+	err := storage.repository.Delete(ctx, id)
+	if err != nil {
+		storage.fail("delete")
+	}
+	return err
+}
+
+func (storage *InstrumentedStorage) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	defer storage.observe("find_all", time.Now())
+	ids, err := storage.repository.FindAll(ctx)
+	if err != nil {
+		storage.fail("find_all")
+	}
+	return ids, err
+}
+
+func (storage *InstrumentedStorage) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	defer storage.observe("find_by_tag", time.Now())
+	ids, err := storage.repository.FindByTag(ctx, tag)
+	if err != nil {
+		storage.fail("find_by_tag")
+	}
+	return ids, err
+}
+
+func (storage *InstrumentedStorage) Close() error {
+	return storage.repository.Close()
+}
+
+func (storage *InstrumentedStorage) Ping(ctx context.Context) error {
+	defer storage.observe("ping", time.Now())
+	err := photo.Ping(ctx, storage.repository)
+	if err != nil {
+		storage.fail("ping")
+	}
+	return err
+}
+
+func (storage *InstrumentedStorage) Checksums(ctx context.Context) (map[photo.Identifier]string, error) {
+	checksums, ok := storage.repository.(photo.ChecksumRepository)
+	if !ok {
+		return nil, photo.ErrNoChecksums
+	}
+	return checksums.Checksums(ctx)
+}
+
+func (storage *InstrumentedStorage) ChecksumOf(ctx context.Context, id photo.Identifier) (string, error) {
+	checksums, ok := storage.repository.(photo.ChecksumRepository)
+	if !ok {
+		return "", photo.ErrNoChecksums
+	}
+	return checksums.ChecksumOf(ctx, id)
+}
+
+func (storage *InstrumentedStorage) Stats(ctx context.Context) (*photo.Stats, error) {
+	defer storage.observe("stats", time.Now())
+	stats, err := photo.StatsOf(ctx, storage.repository)
+	if err != nil {
+		storage.fail("stats")
+	}
+	return stats, err
+}
+
+func (storage *InstrumentedStorage) Sizes(ctx context.Context) (map[photo.Identifier]int64, error) {
+	defer storage.observe("sizes", time.Now())
+	sizes, err := photo.SizesOf(ctx, storage.repository)
+	if err != nil {
+		storage.fail("sizes")
+	}
+	return sizes, err
+}
+
+func (storage *InstrumentedStorage) Describe(ch chan<- *prometheus.Desc) {
+	ch <- objectsDesc
+	ch <- storedBytesDesc
+}
+
+// Collect reports the cached stats, as computing them may read every photo of the repository.
+// Nothing is reported before the first Refresh.
+func (storage *InstrumentedStorage) Collect(ch chan<- prometheus.Metric) {
+	storage.mu.Lock()
+	stats, backend, err := storage.stats, storage.statsBackend, storage.statsErr
+	storage.mu.Unlock()
+
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(objectsDesc, err)
+		return
+	}
+	if stats == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(objectsDesc, prometheus.GaugeValue, float64(stats.Count), backend)
+	ch <- prometheus.MustNewConstMetric(storedBytesDesc, prometheus.GaugeValue, float64(stats.Bytes), backend)
+}
+
+// Refresh recomputes the photo count and stored bytes reported by Collect.
+func (storage *InstrumentedStorage) Refresh(ctx context.Context) error {
+	backend := storage.backend()
+	stats, err := storage.Stats(ctx)
+	storage.mu.Lock()
+	storage.stats, storage.statsBackend, storage.statsErr = stats, backend, err
+	storage.mu.Unlock()
+	return err
+}
+
+// Start refreshes the stats now and then every interval in the background until ctx is done.
+func (storage *InstrumentedStorage) Start(ctx context.Context, interval time.Duration) {
+	storage.running.Add(1)
+	go func() {
+		defer storage.running.Done()
+		for {
+			if err := storage.Refresh(ctx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "refreshing repository stats failed", "backend", storage.backend(), "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+// Wait blocks until the refreshing started by Start stopped.
+func (storage *InstrumentedStorage) Wait() {
+	storage.running.Wait()
+}
+
+func (storage *InstrumentedStorage) observe(operation string, start time.Time) {
+	storage.metrics.OperationDuration.WithLabelValues(storage.backend(), operation).Observe(time.Since(start).Seconds())
+}
+
+func (storage *InstrumentedStorage) fail(operation string) {
+	storage.metrics.OperationErrors.WithLabelValues(storage.backend(), operation).Inc()
+}
+
+type countingReader struct {
+	io.ReadCloser
+	served prometheus.Counter
+}
+
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.ReadCloser.Read(p)
+	reader.served.Add(float64(n))
+	return n, err
+}
@@ -0,0 +1,136 @@
+package instrumented_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstrumentedStorage_Save(t *testing.T) {
+	m := metrics.New()
+	instance := New("memory", memory_storage.New(0), m)
+
+	_, err := instance.Save(context.Background(), *photo.New([]byte("image")))
+	if assert.NoError(t, err) {
+		assert.Equal(t, float64(len("image")), testutil.ToFloat64(m.UploadedBytes.WithLabelValues("memory")))
+		assert.Equal(t, float64(0), testutil.ToFloat64(m.OperationErrors.WithLabelValues("memory", "save")))
+	}
+}
+
+func TestInstrumentedStorage_Read(t *testing.T) {
+	t.Run("found, counts served bytes", func(t *testing.T) {
+		m := metrics.New()
+		instance := New("memory", memory_storage.New(0), m)
+		id, err := instance.Save(context.Background(), *photo.New([]byte("image")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, float64(len("image")), testutil.ToFloat64(m.ServedBytes.WithLabelValues("memory")))
+		}
+	})
+
+	t.Run("missing, counts error", func(t *testing.T) {
+		m := metrics.New()
+		instance := New("memory", memory_storage.New(0), m)
+
+		_, err := instance.Read(context.Background(), *photo.IdentifierOf("missing"))
+		if assert.Error(t, err) {
+			assert.Equal(t, float64(1), testutil.ToFloat64(m.OperationErrors.WithLabelValues("memory", "read")))
+			assert.Equal(t, float64(0), testutil.ToFloat64(m.ServedBytes.WithLabelValues("memory")))
+		}
+	})
+}
+
+func TestInstrumentedStorage_Open(t *testing.T) {
+	m := metrics.New()
+	instance := New("memory", memory_storage.New(0), m)
+	id, err := instance.Save(context.Background(), *photo.New([]byte("image")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := instance.Open(context.Background(), *id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.ServedBytes.WithLabelValues("memory")))
+
+	if _, err := ioutil.ReadAll(reader); assert.NoError(t, err) {
+		assert.Equal(t, float64(len("image")), testutil.ToFloat64(m.ServedBytes.WithLabelValues("memory")))
+	}
+}
+
+func TestInstrumentedStorage_Collect(t *testing.T) {
+	t.Run("before refresh, reports nothing", func(t *testing.T) {
+		instance := New("memory", memory_storage.New(0), metrics.New())
+		instance.Save(context.Background(), *photo.New([]byte("first")))
+
+		assert.Equal(t, 0, collected(instance))
+	})
+
+	t.Run("after refresh, reports refreshed stats without reading them again", func(t *testing.T) {
+		instance := New("memory", memory_storage.New(0), metrics.New())
+		instance.Save(context.Background(), *photo.New([]byte("first")))
+		instance.Save(context.Background(), *photo.New([]byte("second")))
+		if err := instance.Refresh(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		instance.Save(context.Background(), *photo.New([]byte("third")))
+
+		expected := `
+# HELP photoshelf_repository_objects Photos currently stored by backend.
+# TYPE photoshelf_repository_objects gauge
+photoshelf_repository_objects{backend="memory"} 2
+# HELP photoshelf_repository_stored_bytes Bytes of photos currently stored by backend.
+# TYPE photoshelf_repository_stored_bytes gauge
+photoshelf_repository_stored_bytes{backend="memory"} 11
+`
+		assert.NoError(t, testutil.CollectAndCompare(instance, strings.NewReader(expected)))
+	})
+}
+
+func TestInstrumentedStorage_Start(t *testing.T) {
+	instance := New("memory", memory_storage.New(0), metrics.New())
+	instance.Save(context.Background(), *photo.New([]byte("first")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	instance.Start(ctx, time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return collected(instance) == 2
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	instance.Wait()
+}
+
+func TestInstrumentedStorage_Conformance(t *testing.T) {
+	phototest.RunRepositoryTests(t, func(t *testing.T) photo.Repository {
+		return New("memory", memory_storage.New(0), metrics.New())
+	})
+}
+
+func collected(instance *InstrumentedStorage) int {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		instance.Collect(ch)
+		close(ch)
+	}()
+	count := 0
+	for range ch {
+		count++
+	}
+	return count
+}
@@ -0,0 +1,140 @@
+package leveldb_storage
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var albumsPrefix = []byte("albums:")
+
+type LeveldbAlbumStorage struct {
+	db *leveldb.DB
+}
+
+type albumRecord struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Cover       string   `json:"cover,omitempty"`
+	Photos      []string `json:"photos"`
+}
+
+func NewAlbumStorage(storage *LeveldbStorage) *LeveldbAlbumStorage {
+	return &LeveldbAlbumStorage{storage.db}
+}
+
+func (storage *LeveldbAlbumStorage) Save(ctx context.Context, a album.Album) (*album.Identifier, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	id := a.Id()
+	if a.IsNew() {
+		id = album.NewIdentifier()
+	}
+	if err := album.ValidateIdentifier(*id); err != nil {
+		return nil, &album.ResourceError{Id: *id, Err: err}
+	}
+
+	data, err := encodeAlbum(a)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.db.Put(albumKey(id.Value()), data, nil); err != nil {
+		return nil, err
+	}
+
+	return id, nil
+}
+
+func (storage *LeveldbAlbumStorage) Read(ctx context.Context, id album.Identifier) (*album.Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := album.ValidateIdentifier(id); err != nil {
+		return nil, &album.ResourceError{Id: id, Err: err}
+	}
+
+	data, err := storage.db.Get(albumKey(id.Value()), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, &album.ResourceError{Id: id, Err: album.ErrNotFound}
+		}
+		return nil, &album.ResourceError{Id: id, Err: err}
+	}
+
+	a, err := decodeAlbum(id, data)
+	if err != nil {
+		return nil, &album.ResourceError{Id: id, Err: err}
+	}
+	return a, nil
+}
+
+func (storage *LeveldbAlbumStorage) ReadAll(ctx context.Context) ([]album.Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	iter := storage.db.NewIterator(util.BytesPrefix(albumsPrefix), nil)
+	defer iter.Release()
+
+	var albums []album.Album
+	for iter.Next() {
+		id := album.IdentifierOf(string(iter.Key()[len(albumsPrefix):]))
+		a, err := decodeAlbum(*id, iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, *a)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
+
+func (storage *LeveldbAlbumStorage) Delete(ctx context.Context, id album.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := album.ValidateIdentifier(id); err != nil {
+		return &album.ResourceError{Id: id, Err: err}
+	}
+
+	return storage.db.Delete(albumKey(id.Value()), nil)
+}
+
+func albumKey(id string) []byte {
+	return append(append([]byte{}, albumsPrefix...), id...)
+}
+
+func encodeAlbum(a album.Album) ([]byte, error) {
+	record := albumRecord{Title: a.Title(), Description: a.Description(), Photos: []string{}}
+	if a.Cover() != nil {
+		record.Cover = a.Cover().Value()
+	}
+	for _, id := range a.Photos() {
+		record.Photos = append(record.Photos, id.Value())
+	}
+	return json.Marshal(record)
+}
+
+func decodeAlbum(id album.Identifier, data []byte) (*album.Album, error) {
+	var record albumRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	var cover *photo.Identifier
+	if record.Cover != "" {
+		cover = photo.IdentifierOf(record.Cover)
+	}
+	var photos []photo.Identifier
+	for _, value := range record.Photos {
+		photos = append(photos, *photo.IdentifierOf(value))
+	}
+	return album.Of(id, record.Title, record.Description, cover, photos), nil
+}
@@ -0,0 +1,90 @@
+package leveldb_storage
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var changesPrefix = []byte("changes:")
+
+type LeveldbChangeLog struct {
+	db *leveldb.DB
+}
+
+func NewChangeLog(storage *LeveldbStorage) *LeveldbChangeLog {
+	return &LeveldbChangeLog{storage.db}
+}
+
+func (changes *LeveldbChangeLog) Put(ctx context.Context, record event.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := event.Marshal(record.Event)
+	if err != nil {
+		return err
+	}
+	return changes.db.Put(changesKey(record.Sequence), data, nil)
+}
+
+func (changes *LeveldbChangeLog) Since(ctx context.Context, sequence uint64, limit int) ([]event.Record, error) {
+	iter := changes.db.NewIterator(&util.Range{Start: changesKey(sequence + 1), Limit: util.BytesPrefix(changesPrefix).Limit}, nil)
+	defer iter.Release()
+
+	var records []event.Record
+	for len(records) < limit && iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		e, err := event.Unmarshal(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		sequence := binary.BigEndian.Uint64(iter.Key()[len(changesPrefix):])
+		records = append(records, event.Record{Sequence: sequence, Event: e})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return records, ctx.Err()
+}
+
+func (changes *LeveldbChangeLog) Latest(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	iter := changes.db.NewIterator(util.BytesPrefix(changesPrefix), nil)
+	defer iter.Release()
+
+	var latest uint64
+	if iter.Last() {
+		latest = binary.BigEndian.Uint64(iter.Key()[len(changesPrefix):])
+	}
+	return latest, iter.Error()
+}
+
+func (changes *LeveldbChangeLog) Trim(ctx context.Context, before uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	iter := changes.db.NewIterator(&util.Range{Start: changesKey(0), Limit: changesKey(before)}, nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(append([]byte{}, iter.Key()...))
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return changes.db.Write(batch, nil)
+}
+
+func changesKey(sequence uint64) []byte {
+	return append(append([]byte{}, changesPrefix...), sequenceBytes(sequence)...)
+}
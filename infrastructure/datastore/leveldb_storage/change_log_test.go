@@ -0,0 +1,18 @@
+package leveldb_storage
+
+import (
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event/eventtest"
+	"testing"
+)
+
+func TestLeveldbChangeLog_Conformance(t *testing.T) {
+	eventtest.RunChangeLogTests(t, func(t *testing.T) event.ChangeLog {
+		instance, err := New(tempDir(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { instance.db.Close() })
+		return NewChangeLog(instance)
+	})
+}
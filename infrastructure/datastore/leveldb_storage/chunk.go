@@ -0,0 +1,136 @@
+package leveldb_storage
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"io"
+)
+
+const defaultChunkSize = 256 * 1024
+
+var (
+	chunksPrefix   = []byte("chunks:")
+	manifestPrefix = []byte("manifests:")
+)
+
+type manifest struct {
+	Size      int `json:"size"`
+	ChunkSize int `json:"chunk_size"`
+	Chunks    int `json:"chunks"`
+}
+
+type getter interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+}
+
+func putChunks(batch *leveldb.Batch, id string, data []byte, chunkSize int) error {
+	m := manifest{Size: len(data), ChunkSize: chunkSize}
+	for offset := 0; offset < len(data); offset += chunkSize {
+		end := offset + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		batch.Put(chunkKey(id, m.Chunks), data[offset:end])
+		m.Chunks++
+	}
+
+	value, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	batch.Put(manifestKey(id), value)
+	return nil
+}
+
+func readManifest(db getter, id string) (*manifest, error) {
+	value, err := db.Get(manifestKey(id), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	m := &manifest{}
+	if err := json.Unmarshal(value, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func readChunks(ctx context.Context, db getter, id string, m *manifest) ([]byte, error) {
+	data := make([]byte, 0, m.Size)
+	for i := 0; i < m.Chunks; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chunk, err := db.Get(chunkKey(id, i), nil)
+		if err == leveldb.ErrNotFound {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+	}
+	return data, nil
+}
+
+func deleteChunks(db getter, batch *leveldb.Batch, id string) error {
+	m, err := readManifest(db, id)
+	if err != nil || m == nil {
+		return err
+	}
+	for i := 0; i < m.Chunks; i++ {
+		batch.Delete(chunkKey(id, i))
+	}
+	batch.Delete(manifestKey(id))
+	return nil
+}
+
+func chunkKey(id string, index int) []byte {
+	key := append(append([]byte{}, chunksPrefix...), id...)
+	key = append(key, make([]byte, 9)...)
+	binary.BigEndian.PutUint64(key[len(key)-8:], uint64(index))
+	return key
+}
+
+func manifestKey(id string) []byte {
+	return append(append([]byte{}, manifestPrefix...), id...)
+}
+
+type chunkReader struct {
+	ctx      context.Context
+	snapshot *leveldb.Snapshot
+	id       string
+	m        *manifest
+	index    int
+	buffer   []byte
+}
+
+func (reader *chunkReader) Read(p []byte) (int, error) {
+	for len(reader.buffer) == 0 {
+		if reader.index >= reader.m.Chunks {
+			return 0, io.EOF
+		}
+		if err := reader.ctx.Err(); err != nil {
+			return 0, err
+		}
+		chunk, err := reader.snapshot.Get(chunkKey(reader.id, reader.index), nil)
+		if err == leveldb.ErrNotFound {
+			return 0, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return 0, err
+		}
+		reader.buffer = chunk
+		reader.index++
+	}
+	n := copy(p, reader.buffer)
+	reader.buffer = reader.buffer[n:]
+	return n, nil
+}
+
+func (reader *chunkReader) Close() error {
+	reader.snapshot.Release()
+	return nil
+}
@@ -0,0 +1,162 @@
+package leveldb_storage
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/syndtr/goleveldb/leveldb"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const megabyte = 1048576
+
+var gauges = []struct {
+	property string
+	name     string
+	help     string
+}{
+	{"leveldb.openedtables", "opened_tables", "Tables currently opened."},
+	{"leveldb.alivesnaps", "alive_snapshots", "Snapshots currently alive."},
+	{"leveldb.aliveiters", "alive_iterators", "Iterators currently alive."},
+}
+
+// Collector reports the per level compaction stats and the write delays of the database.
+type Collector struct {
+	db *leveldb.DB
+
+	levelTables            *prometheus.Desc
+	levelBytes             *prometheus.Desc
+	compactionSeconds      *prometheus.Desc
+	compactionReadBytes    *prometheus.Desc
+	compactionWrittenBytes *prometheus.Desc
+	writeDelays            *prometheus.Desc
+	writeDelaySeconds      *prometheus.Desc
+	gauges                 []*prometheus.Desc
+}
+
+// NewCollector labels the metrics with the path of the database.
+func NewCollector(storage *LeveldbStorage) *Collector {
+	labels := prometheus.Labels{"path": storage.path}
+	desc := func(name string, help string, variableLabels ...string) *prometheus.Desc {
+		return prometheus.NewDesc("photoshelf_leveldb_"+name, help, variableLabels, labels)
+	}
+	collector := &Collector{
+		db:                     storage.db,
+		levelTables:            desc("level_tables", "Tables per level.", "level"),
+		levelBytes:             desc("level_bytes", "Size of the tables per level.", "level"),
+		compactionSeconds:      desc("compaction_seconds_total", "Time spent compacting per level.", "level"),
+		compactionReadBytes:    desc("compaction_read_bytes_total", "Bytes read by compactions per level.", "level"),
+		compactionWrittenBytes: desc("compaction_written_bytes_total", "Bytes written by compactions per level.", "level"),
+		writeDelays:            desc("write_delays_total", "Writes delayed by compactions."),
+		writeDelaySeconds:      desc("write_delay_seconds_total", "Time writes were delayed by compactions."),
+	}
+	for _, gauge := range gauges {
+		collector.gauges = append(collector.gauges, desc(gauge.name, gauge.help))
+	}
+	return collector
+}
+
+func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.levelTables
+	ch <- collector.levelBytes
+	ch <- collector.compactionSeconds
+	ch <- collector.compactionReadBytes
+	ch <- collector.compactionWrittenBytes
+	ch <- collector.writeDelays
+	ch <- collector.writeDelaySeconds
+	for _, desc := range collector.gauges {
+		ch <- desc
+	}
+}
+
+func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
+	if err := collector.collectLevels(ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.levelTables, err)
+	}
+	if err := collector.collectWriteDelay(ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.writeDelays, err)
+	}
+	for i, gauge := range gauges {
+		desc := collector.gauges[i]
+		value, err := collector.db.GetProperty(gauge.property)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(desc, err)
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(desc, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, n)
+	}
+}
+
+func (collector *Collector) collectLevels(ch chan<- prometheus.Metric) error {
+	stats, err := collector.db.GetProperty("leveldb.stats")
+	if err != nil {
+		return err
+	}
+	levels, err := parseLevels(stats)
+	if err != nil {
+		return err
+	}
+	for _, l := range levels {
+		level := strconv.Itoa(l.level)
+		ch <- prometheus.MustNewConstMetric(collector.levelTables, prometheus.GaugeValue, float64(l.tables), level)
+		ch <- prometheus.MustNewConstMetric(collector.levelBytes, prometheus.GaugeValue, l.size*megabyte, level)
+		ch <- prometheus.MustNewConstMetric(collector.compactionSeconds, prometheus.CounterValue, l.seconds, level)
+		ch <- prometheus.MustNewConstMetric(collector.compactionReadBytes, prometheus.CounterValue, l.read*megabyte, level)
+		ch <- prometheus.MustNewConstMetric(collector.compactionWrittenBytes, prometheus.CounterValue, l.written*megabyte, level)
+	}
+	return nil
+}
+
+func (collector *Collector) collectWriteDelay(ch chan<- prometheus.Metric) error {
+	value, err := collector.db.GetProperty("leveldb.writedelay")
+	if err != nil {
+		return err
+	}
+	var n int
+	var delay string
+	if _, err := fmt.Sscanf(value, "DelayN:%d Delay:%s", &n, &delay); err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(delay)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(collector.writeDelays, prometheus.CounterValue, float64(n))
+	ch <- prometheus.MustNewConstMetric(collector.writeDelaySeconds, prometheus.CounterValue, d.Seconds())
+	return nil
+}
+
+type level struct {
+	level   int
+	tables  int
+	size    float64
+	seconds float64
+	read    float64
+	written float64
+}
+
+// parseLevels reads the rows of the leveldb.stats table, sizes being in megabytes.
+func parseLevels(stats string) ([]level, error) {
+	var levels []level
+	for _, line := range strings.Split(stats, "\n") {
+		columns := strings.Split(line, "|")
+		if len(columns) != 6 {
+			continue
+		}
+		var l level
+		if _, err := fmt.Sscanf(strings.Join(columns, " "), "%d %d %g %g %g %g", &l.level, &l.tables, &l.size, &l.seconds, &l.read, &l.written); err != nil {
+			if strings.TrimSpace(columns[0]) == "Level" {
+				continue
+			}
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+	return levels, nil
+}
@@ -0,0 +1,57 @@
+package leveldb_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"testing"
+)
+
+func TestCollector_Collect(t *testing.T) {
+	dbPath := tempDir(t)
+	instance, err := New(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer instance.Close()
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("id"), []byte("data"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := instance.db.CompactRange(util.Range{}); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(NewCollector(instance)); err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if assert.NoError(t, err) {
+		names := make(map[string]bool)
+		for _, family := range families {
+			names[family.GetName()] = true
+		}
+		assert.True(t, names["photoshelf_leveldb_level_tables"])
+		assert.True(t, names["photoshelf_leveldb_compaction_written_bytes_total"])
+		assert.True(t, names["photoshelf_leveldb_write_delays_total"])
+		assert.True(t, names["photoshelf_leveldb_opened_tables"])
+	}
+}
+
+func TestParseLevels(t *testing.T) {
+	stats := "Compactions\n" +
+		" Level |   Tables   |    Size(MB)   |    Time(sec)  |    Read(MB)   |   Write(MB)\n" +
+		"-------+------------+---------------+---------------+---------------+---------------\n" +
+		"   0   |          1 |       0.50000 |       0.25000 |       0.00000 |       0.50000\n" +
+		"   1   |          2 |       1.00000 |       1.50000 |       2.00000 |       1.00000\n"
+
+	levels, err := parseLevels(stats)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []level{
+			{level: 0, tables: 1, size: 0.5, seconds: 0.25, read: 0, written: 0.5},
+			{level: 1, tables: 2, size: 1, seconds: 1.5, read: 2, written: 1},
+		}, levels)
+	}
+}
@@ -1,12 +1,34 @@
 package leveldb_storage
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"io"
+	"io/ioutil"
+	"sync"
 )
 
+var (
+	tagsPrefix     = []byte("tags:")
+	tagIndexPrefix = []byte("tag_index:")
+	checksumPrefix = []byte("checksums:")
+	sentinelKey    = []byte("health:sentinel")
+)
+
+var reservedPrefixes = [][]byte{tagsPrefix, tagIndexPrefix, albumsPrefix, checksumPrefix, chunksPrefix, manifestPrefix, outboxPrefix, outboxSequenceKey, changesPrefix, versionsPrefix, tombstonesPrefix, sentinelKey}
+
 type LeveldbStorage struct {
-	db *leveldb.DB
+	db        *leveldb.DB
+	path      string
+	mu        sync.Mutex
+	chunkSize int
+
+	// outboxMu guards the outbox sequence, which photo writes advance when they append their event.
+	outboxMu sync.Mutex
 }
 
 func New(path string) (*LeveldbStorage, error) {
@@ -14,36 +36,395 @@ func New(path string) (*LeveldbStorage, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	return &LeveldbStorage{db}, nil
+	return &LeveldbStorage{db: db, path: path, chunkSize: defaultChunkSize}, nil
 }
 
-func (storage *LeveldbStorage) Save(photograph photo.Photo) (*photo.Identifier, error) {
+func (storage *LeveldbStorage) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
 	data := photograph.Image()
 	id := photograph.Id()
 	if photograph.IsNew() {
 		id = photo.NewIdentifier(data)
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if isReserved([]byte(id.Value())) {
+		return nil, &photo.ResourceError{Id: *id, Err: photo.ErrInvalidIdentifier}
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
 
-	if err := storage.db.Put([]byte(id.Value()), data, nil); err != nil {
+	batch := new(leveldb.Batch)
+	if err := deleteChunks(storage.db, batch, id.Value()); err != nil {
+		return nil, err
+	}
+	if storage.chunkSize > 0 && len(data) > storage.chunkSize {
+		batch.Delete([]byte(id.Value()))
+		if err := putChunks(batch, id.Value(), data, storage.chunkSize); err != nil {
+			return nil, err
+		}
+	} else {
+		batch.Put([]byte(id.Value()), data)
+	}
+	batch.Put(checksumKey(id.Value()), []byte(photo.Checksum(data)))
+	if err := storage.updateTags(batch, *id, photograph.Tags()); err != nil {
+		return nil, err
+	}
+	if err := storage.write(ctx, batch, *id); err != nil {
 		return nil, err
 	}
 
 	return id, nil
 }
 
-func (storage *LeveldbStorage) Read(id photo.Identifier) (*photo.Photo, error) {
-	data, err := storage.db.Get([]byte(id.Value()), nil)
+func (storage *LeveldbStorage) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	if isReserved([]byte(id.Value())) {
+		return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	}
+
+	snapshot, err := storage.db.GetSnapshot()
 	if err != nil {
-		if err == leveldb.ErrNotFound {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	defer snapshot.Release()
+
+	data, err := readData(ctx, snapshot, id.Value())
+	if err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	tags, err := readTags(snapshot, id)
+	if err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	return photo.Of(id, data, tags...), nil
+}
+
+func (storage *LeveldbStorage) Delete(ctx context.Context, id photo.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if isReserved([]byte(id.Value())) {
+		return nil
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+	if err := deleteChunks(storage.db, batch, id.Value()); err != nil {
+		return err
+	}
+	batch.Delete([]byte(id.Value()))
+	batch.Delete(checksumKey(id.Value()))
+	if err := storage.updateTags(batch, id, nil); err != nil {
+		return err
+	}
+	return storage.write(ctx, batch, id)
+}
+
+func (storage *LeveldbStorage) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	iter := storage.db.NewIterator(nil, nil)
+	defer iter.Release()
+
+	var ids []photo.Identifier
+	for iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if isReserved(iter.Key()) {
+			continue
+		}
+		ids = append(ids, *photo.IdentifierOf(string(iter.Key())))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	manifests := storage.db.NewIterator(util.BytesPrefix(manifestPrefix), nil)
+	defer manifests.Release()
+	for manifests.Next() {
+		ids = append(ids, *photo.IdentifierOf(string(manifests.Key()[len(manifestPrefix):])))
+	}
+	if err := manifests.Error(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (storage *LeveldbStorage) Open(ctx context.Context, id photo.Identifier) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	if isReserved([]byte(id.Value())) {
+		return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	}
+
+	snapshot, err := storage.db.GetSnapshot()
+	if err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	data, err := snapshot.Get([]byte(id.Value()), nil)
+	if err == nil {
+		snapshot.Release()
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	} else if err != leveldb.ErrNotFound {
+		snapshot.Release()
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	m, err := readManifest(snapshot, id.Value())
+	if err != nil || m == nil {
+		snapshot.Release()
+		if err == nil {
+			err = photo.ErrNotFound
+		}
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	return &chunkReader{ctx: ctx, snapshot: snapshot, id: id.Value(), m: m}, nil
+}
+
+func (storage *LeveldbStorage) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	prefix := tagIndexKey(tag, "")
+	iter := storage.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	var ids []photo.Identifier
+	for iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ids = append(ids, *photo.IdentifierOf(string(iter.Key()[len(prefix):])))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (storage *LeveldbStorage) Close() error {
+	return storage.db.Close()
+}
+
+func (storage *LeveldbStorage) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sentinel := photo.Sentinel()
+	if err := storage.db.Put(sentinelKey, sentinel, nil); err != nil {
+		return err
+	}
+	value, err := storage.db.Get(sentinelKey, nil)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(value, sentinel) {
+		return photo.ErrSentinelMismatch
+	}
+	return nil
+}
+
+func (storage *LeveldbStorage) Checksums(ctx context.Context) (map[photo.Identifier]string, error) {
+	iter := storage.db.NewIterator(util.BytesPrefix(checksumPrefix), nil)
+	defer iter.Release()
+
+	checksums := make(map[photo.Identifier]string)
+	for iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		checksums[*photo.IdentifierOf(string(iter.Key()[len(checksumPrefix):]))] = string(iter.Value())
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+func (storage *LeveldbStorage) ChecksumOf(ctx context.Context, id photo.Identifier) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", &photo.ResourceError{Id: id, Err: err}
+	}
+	value, err := storage.db.Get(checksumKey(id.Value()), nil)
+	if err == leveldb.ErrNotFound {
+		return "", &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	} else if err != nil {
+		return "", &photo.ResourceError{Id: id, Err: err}
+	}
+	return string(value), nil
+}
+
+func (storage *LeveldbStorage) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	if isReserved([]byte(id.Value())) {
+		return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	}
+
+	snapshot, err := storage.db.GetSnapshot()
+	if err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	defer snapshot.Release()
+
+	stored, err := snapshot.Has([]byte(id.Value()), nil)
+	if err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+	if !stored {
+		m, err := readManifest(snapshot, id.Value())
+		if err != nil {
+			return nil, &photo.ResourceError{Id: id, Err: err}
+		}
+		if m == nil {
 			return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
 		}
+	}
+
+	tags, err := readTags(snapshot, id)
+	if err != nil {
 		return nil, &photo.ResourceError{Id: id, Err: err}
 	}
+	return tags, nil
+}
+
+func (storage *LeveldbStorage) Stats(ctx context.Context) (*photo.Stats, error) {
+	sizes, err := storage.Sizes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &photo.Stats{Count: len(sizes)}
+	for _, size := range sizes {
+		stats.Bytes += size
+	}
+	return stats, nil
+}
+
+func (storage *LeveldbStorage) Sizes(ctx context.Context) (map[photo.Identifier]int64, error) {
+	snapshot, err := storage.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer snapshot.Release()
+
+	iter := snapshot.NewIterator(nil, nil)
+	defer iter.Release()
+
+	sizes := make(map[photo.Identifier]int64)
+	for iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if isReserved(iter.Key()) {
+			continue
+		}
+		sizes[*photo.IdentifierOf(string(iter.Key()))] = int64(len(iter.Value()))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	manifests := snapshot.NewIterator(util.BytesPrefix(manifestPrefix), nil)
+	defer manifests.Release()
+	for manifests.Next() {
+		m := &manifest{}
+		if err := json.Unmarshal(manifests.Value(), m); err != nil {
+			return nil, err
+		}
+		sizes[*photo.IdentifierOf(string(manifests.Key()[len(manifestPrefix):]))] = int64(m.Size)
+	}
+	if err := manifests.Error(); err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+func readData(ctx context.Context, db getter, id string) ([]byte, error) {
+	data, err := db.Get([]byte(id), nil)
+	if err == nil {
+		return data, nil
+	} else if err != leveldb.ErrNotFound {
+		return nil, err
+	}
+
+	m, err := readManifest(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, photo.ErrNotFound
+	}
+	return readChunks(ctx, db, id, m)
+}
+
+func readTags(db getter, id photo.Identifier) ([]string, error) {
+	data, err := db.Get(tagsKey(id.Value()), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (storage *LeveldbStorage) updateTags(batch *leveldb.Batch, id photo.Identifier, tags []string) error {
+	current, err := readTags(storage.db, id)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range current {
+		batch.Delete(tagIndexKey(tag, id.Value()))
+	}
+	for _, tag := range tags {
+		batch.Put(tagIndexKey(tag, id.Value()), []byte{})
+	}
+
+	if len(tags) == 0 {
+		batch.Delete(tagsKey(id.Value()))
+		return nil
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	batch.Put(tagsKey(id.Value()), data)
+	return nil
+}
+
+func isReserved(key []byte) bool {
+	for _, prefix := range reservedPrefixes {
+		if bytes.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func tagsKey(id string) []byte {
+	return append(append([]byte{}, tagsPrefix...), id...)
+}
 
-	return photo.Of(id, data), nil
+func checksumKey(id string) []byte {
+	return append(append([]byte{}, checksumPrefix...), id...)
 }
 
-func (storage *LeveldbStorage) Delete(id photo.Identifier) error {
-	return storage.db.Delete([]byte(id.Value()), nil)
+func tagIndexKey(tag string, id string) []byte {
+	key := append(append([]byte{}, tagIndexPrefix...), tag...)
+	key = append(key, 0)
+	return append(key, id...)
 }
@@ -1,10 +1,13 @@
 package leveldb_storage
 
 import (
+	"context"
 	"fmt"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
 	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -39,7 +42,7 @@ func TestLeveldbStorage_Save(t *testing.T) {
 		instance := createInstance(t)
 		photograph := photo.New(readTestData(t))
 
-		identifier, err := instance.Save(*photograph)
+		identifier, err := instance.Save(context.Background(), *photograph)
 		if assert.NoError(t, err) {
 			assert.NotNil(t, identifier)
 		}
@@ -51,7 +54,7 @@ func TestLeveldbStorage_Save(t *testing.T) {
 		instance := createInstance(t)
 		photograph := *photo.Of(*photo.IdentifierOf("testdata"), readTestData(t))
 
-		identifier, err := instance.Save(photograph)
+		identifier, err := instance.Save(context.Background(), photograph)
 
 		if assert.NoError(t, err) {
 			t.Run("returns identifier has same value", func(t *testing.T) {
@@ -70,6 +73,25 @@ func TestLeveldbStorage_Save(t *testing.T) {
 
 		instance.db.Close()
 	})
+
+	t.Run("with identifier colliding with internal keys, returns error", func(t *testing.T) {
+		instance := createInstance(t)
+		id, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("testdata"), []byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("checksums:" + id.Value()), []byte("forged")))
+		if assert.Error(t, err) {
+			assert.Equal(t, photo.ErrInvalidIdentifier, err.(*photo.ResourceError).Err)
+		}
+		actual, err := instance.ChecksumOf(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, photo.Checksum([]byte("data")), actual)
+		}
+
+		instance.db.Close()
+	})
 }
 
 func TestLeveldbStorage_Read(t *testing.T) {
@@ -80,17 +102,28 @@ func TestLeveldbStorage_Read(t *testing.T) {
 	}
 
 	t.Run("with no key, returns err", func(t *testing.T) {
-		_, err := instance.Read(*photo.IdentifierOf("noKey"))
+		_, err := instance.Read(context.Background(), *photo.IdentifierOf("noKey"))
 		assert.Error(t, err)
 	})
 
 	t.Run("returns same data with source", func(t *testing.T) {
-		photograph, err := instance.Read(*photo.IdentifierOf("testdata"))
+		photograph, err := instance.Read(context.Background(), *photo.IdentifierOf("testdata"))
 		if assert.NoError(t, err) {
 			assert.EqualValues(t, readTestData(t), photograph.Image())
 		}
 	})
 
+	t.Run("with identifier colliding with internal keys, returns not found", func(t *testing.T) {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("tagged"), []byte("data"), "tag")); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := instance.Read(context.Background(), *photo.IdentifierOf("tags:tagged"))
+		if assert.Error(t, err) {
+			assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+		}
+	})
+
 	instance.db.Close()
 }
 
@@ -102,12 +135,244 @@ func TestLeveldbStorage_Delete(t *testing.T) {
 	}
 
 	t.Run("when delete existing key, returns no error", func(t *testing.T) {
-		err := instance.Delete(*photo.IdentifierOf("testdata"))
+		err := instance.Delete(context.Background(), *photo.IdentifierOf("testdata"))
 		if assert.NoError(t, err) {
 			actual, _ := instance.db.Get([]byte("testdata"), nil)
 			assert.EqualValues(t, []byte{}, actual)
 		}
 	})
+
+	t.Run("with identifier colliding with internal keys, keeps them", func(t *testing.T) {
+		id, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("kept"), []byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if assert.NoError(t, instance.Delete(context.Background(), *photo.IdentifierOf("checksums:kept"))) {
+			_, err := instance.ChecksumOf(context.Background(), *id)
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestLeveldbStorage_FindAll(t *testing.T) {
+	instance := createInstance(t)
+	for _, id := range []string{"first", "second"} {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), readTestData(t), "tag")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := instance.FindAll(context.Background())
+	if assert.NoError(t, err) {
+		assert.ElementsMatch(t, []photo.Identifier{*photo.IdentifierOf("first"), *photo.IdentifierOf("second")}, ids)
+	}
+	instance.db.Close()
+}
+
+func TestLeveldbStorage_Checksums(t *testing.T) {
+	instance := createInstance(t)
+	data := readTestData(t)
+	for _, id := range []string{"first", "second"} {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := instance.Delete(context.Background(), *photo.IdentifierOf("second")); err != nil {
+		t.Fatal(err)
+	}
+
+	checksums, err := instance.Checksums(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[photo.Identifier]string{*photo.IdentifierOf("first"): photo.Checksum(data)}, checksums)
+	}
+	instance.db.Close()
+}
+
+func TestLeveldbStorage_FindByTag(t *testing.T) {
+	instance := createInstance(t)
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("first"), readTestData(t), "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("second"), readTestData(t), "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("returns identifiers have tag", func(t *testing.T) {
+		ids, err := instance.FindByTag(context.Background(), "b")
+		if assert.NoError(t, err) {
+			assert.ElementsMatch(t, []photo.Identifier{*photo.IdentifierOf("first"), *photo.IdentifierOf("second")}, ids)
+		}
+	})
+
+	t.Run("with no such tag, returns empty", func(t *testing.T) {
+		ids, err := instance.FindByTag(context.Background(), "unknown")
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+
+	t.Run("read photo has tags", func(t *testing.T) {
+		photograph, err := instance.Read(context.Background(), *photo.IdentifierOf("first"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"a", "b"}, photograph.Tags())
+		}
+	})
+
+	t.Run("when tag removed, not found by tag", func(t *testing.T) {
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("first"), readTestData(t), "b")); err != nil {
+			t.Fatal(err)
+		}
+		ids, err := instance.FindByTag(context.Background(), "a")
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+
+	t.Run("when photo deleted, not found by tag", func(t *testing.T) {
+		if err := instance.Delete(context.Background(), *photo.IdentifierOf("second")); err != nil {
+			t.Fatal(err)
+		}
+		ids, err := instance.FindByTag(context.Background(), "b")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("first")}, ids)
+		}
+	})
+
+	instance.db.Close()
+}
+
+func TestLeveldbStorage_Chunks(t *testing.T) {
+	instance := createInstance(t)
+	instance.chunkSize = 16 * 1024
+	data := readTestData(t)
+	id := photo.IdentifierOf("testdata")
+
+	t.Run("save large photo, splits it into chunks", func(t *testing.T) {
+		if _, err := instance.Save(context.Background(), *photo.Of(*id, data, "tag")); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, data, actual.Image())
+			assert.Equal(t, []string{"tag"}, actual.Tags())
+		}
+		assert.Equal(t, (len(data)+instance.chunkSize-1)/instance.chunkSize, countKeys(t, instance, chunksPrefix))
+		_, err = instance.db.Get([]byte(id.Value()), nil)
+		assert.Equal(t, leveldb.ErrNotFound, err)
+
+		ids, err := instance.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*id}, ids)
+		}
+	})
+
+	t.Run("overwrite with small photo, removes chunks", func(t *testing.T) {
+		if _, err := instance.Save(context.Background(), *photo.Of(*id, []byte("small"))); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("small"), actual.Image())
+		}
+		assert.Equal(t, 0, countKeys(t, instance, chunksPrefix))
+		assert.Equal(t, 0, countKeys(t, instance, manifestPrefix))
+	})
+
+	t.Run("delete large photo, removes chunks", func(t *testing.T) {
+		if _, err := instance.Save(context.Background(), *photo.Of(*id, data)); err != nil {
+			t.Fatal(err)
+		}
+
+		if assert.NoError(t, instance.Delete(context.Background(), *id)) {
+			_, err := instance.Read(context.Background(), *id)
+			assert.Error(t, err)
+			assert.Equal(t, 0, countKeys(t, instance, chunksPrefix))
+			assert.Equal(t, 0, countKeys(t, instance, manifestPrefix))
+		}
+	})
+
+	instance.db.Close()
+}
+
+func TestLeveldbStorage_Open(t *testing.T) {
+	instance := createInstance(t)
+	instance.chunkSize = 16 * 1024
+	data := readTestData(t)
+
+	for _, c := range []struct {
+		name string
+		data []byte
+	}{
+		{"chunked", data},
+		{"single value", []byte("small")},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			id, err := instance.Save(context.Background(), *photo.New(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reader, err := instance.Open(context.Background(), *id)
+			if assert.NoError(t, err) {
+				actual, err := ioutil.ReadAll(reader)
+				if assert.NoError(t, err) {
+					assert.Equal(t, c.data, actual)
+				}
+				assert.NoError(t, reader.Close())
+			}
+		})
+	}
+
+	t.Run("with no photo, returns not found", func(t *testing.T) {
+		_, err := instance.Open(context.Background(), *photo.IdentifierOf("noKey"))
+		if assert.Error(t, err) {
+			assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+		}
+	})
+
+	instance.db.Close()
+}
+
+func TestLeveldbStorage_Close(t *testing.T) {
+	t.Run("after close, storage can be reopened", func(t *testing.T) {
+		dbPath := tempDir(t)
+		instance, err := New(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("id"), []byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(t, instance.Close())
+
+		reopened, err := New(dbPath)
+		if assert.NoError(t, err) {
+			defer reopened.Close()
+			actual, err := reopened.Read(context.Background(), *photo.IdentifierOf("id"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, []byte("data"), actual.Image())
+			}
+		}
+	})
+}
+
+func TestLeveldbStorage_Conformance(t *testing.T) {
+	phototest.RunRepositoryTests(t, func(t *testing.T) photo.Repository {
+		dir, err := ioutil.TempDir("", "leveldb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		instance, err := New(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { instance.db.Close() })
+		return instance
+	})
 }
 
 func BenchmarkLeveldbStorage_Save(b *testing.B) {
@@ -120,7 +385,7 @@ func BenchmarkLeveldbStorage_Save(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", 0)
 			photograph := *photo.Of(*photo.IdentifierOf(key), data)
-			instance.Save(photograph)
+			instance.Save(context.Background(), photograph)
 		}
 		b.StopTimer()
 
@@ -134,7 +399,7 @@ func BenchmarkLeveldbStorage_Save(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", i)
 			photograph := *photo.Of(*photo.IdentifierOf(key), data)
-			instance.Save(photograph)
+			instance.Save(context.Background(), photograph)
 		}
 		b.StopTimer()
 
@@ -149,7 +414,7 @@ func BenchmarkLeveldbStorage_Save(b *testing.B) {
 		for i := 1; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", i%20)
 			photograph := *photo.Of(*photo.IdentifierOf(key), randomTestData[i%20])
-			instance.Save(photograph)
+			instance.Save(context.Background(), photograph)
 		}
 		b.StopTimer()
 
@@ -172,7 +437,7 @@ func BenchmarkLeveldbStorage_Read(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", 0)
-			instance.Read(*photo.IdentifierOf(key))
+			instance.Read(context.Background(), *photo.IdentifierOf(key))
 		}
 		b.StopTimer()
 	})
@@ -181,7 +446,7 @@ func BenchmarkLeveldbStorage_Read(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", i%100)
-			instance.Read(*photo.IdentifierOf(key))
+			instance.Read(context.Background(), *photo.IdentifierOf(key))
 		}
 		b.StopTimer()
 	})
@@ -190,12 +455,45 @@ func BenchmarkLeveldbStorage_Read(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			key := fmt.Sprintf("testdata-%d", rand.Intn(100))
-			instance.Read(*photo.IdentifierOf(key))
+			instance.Read(context.Background(), *photo.IdentifierOf(key))
 		}
 		b.StopTimer()
 	})
 }
 
+func BenchmarkLeveldbStorage_Layout(b *testing.B) {
+	dataSet := phototest.RandomTestData(b)
+
+	for _, layout := range []struct {
+		name      string
+		chunkSize int
+	}{
+		{"single value", 0},
+		{"chunked", defaultChunkSize},
+	} {
+		b.Run(layout.name, func(b *testing.B) {
+			instance := createInstance(b)
+			instance.chunkSize = layout.chunkSize
+
+			b.Run("save", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					key := fmt.Sprintf("testdata-%d", i%20)
+					instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(key), dataSet[i%20]))
+				}
+			})
+
+			b.Run("read", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					key := fmt.Sprintf("testdata-%d", i%20)
+					instance.Read(context.Background(), *photo.IdentifierOf(key))
+				}
+			})
+
+			instance.db.Close()
+		})
+	}
+}
+
 func readTestData(tb testing.TB) []byte {
 	tb.Helper()
 
@@ -225,3 +523,18 @@ func createInstance(tb testing.TB) *LeveldbStorage {
 	}
 	return instance
 }
+
+func countKeys(tb testing.TB, instance *LeveldbStorage, prefix []byte) int {
+	tb.Helper()
+	iter := instance.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	var n int
+	for iter.Next() {
+		n++
+	}
+	if err := iter.Error(); err != nil {
+		tb.Fatal(err)
+	}
+	return n
+}
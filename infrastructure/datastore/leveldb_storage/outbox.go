@@ -0,0 +1,126 @@
+package leveldb_storage
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"sync"
+)
+
+var (
+	outboxPrefix      = []byte("outbox:")
+	outboxSequenceKey = []byte("outbox_sequence:")
+)
+
+type LeveldbOutbox struct {
+	db *leveldb.DB
+	mu *sync.Mutex
+}
+
+func NewOutbox(storage *LeveldbStorage) *LeveldbOutbox {
+	return &LeveldbOutbox{db: storage.db, mu: &storage.outboxMu}
+}
+
+func (outbox *LeveldbOutbox) Append(ctx context.Context, e event.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := event.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+	if err := appendEvent(outbox.db, batch, data); err != nil {
+		return err
+	}
+	return outbox.db.Write(batch, nil)
+}
+
+func (outbox *LeveldbOutbox) Pending(ctx context.Context) ([]event.Record, error) {
+	iter := outbox.db.NewIterator(util.BytesPrefix(outboxPrefix), nil)
+	defer iter.Release()
+
+	var records []event.Record
+	for iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		e, err := event.Unmarshal(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		sequence := binary.BigEndian.Uint64(iter.Key()[len(outboxPrefix):])
+		records = append(records, event.Record{Sequence: sequence, Event: e})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return records, ctx.Err()
+}
+
+func (outbox *LeveldbOutbox) Remove(ctx context.Context, sequence uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return outbox.db.Delete(outboxKey(sequence), nil)
+}
+
+// write writes batch, along with the event staged for the write of ctx when it goes to the outbox of this storage.
+func (storage *LeveldbStorage) write(ctx context.Context, batch *leveldb.Batch, id photo.Identifier) error {
+	staged := event.StagedIn(ctx)
+	if staged == nil || staged.Appended() {
+		return storage.db.Write(batch, nil)
+	}
+	if outbox, ok := staged.Outbox.(*LeveldbOutbox); !ok || outbox.db != storage.db {
+		return storage.db.Write(batch, nil)
+	}
+
+	data, err := event.Marshal(staged.Event(id))
+	if err != nil {
+		return err
+	}
+	storage.outboxMu.Lock()
+	defer storage.outboxMu.Unlock()
+	if err := appendEvent(storage.db, batch, data); err != nil {
+		return err
+	}
+	if err := storage.db.Write(batch, nil); err != nil {
+		return err
+	}
+	staged.MarkAppended()
+	return nil
+}
+
+// appendEvent adds the next record of the outbox to batch. The caller holds the outbox lock until batch is written.
+func appendEvent(db *leveldb.DB, batch *leveldb.Batch, data []byte) error {
+	var sequence uint64
+	value, err := db.Get(outboxSequenceKey, nil)
+	if err == nil {
+		sequence = binary.BigEndian.Uint64(value)
+	} else if err != leveldb.ErrNotFound {
+		return err
+	}
+	sequence++
+
+	batch.Put(outboxSequenceKey, sequenceBytes(sequence))
+	batch.Put(outboxKey(sequence), data)
+	return nil
+}
+
+func outboxKey(sequence uint64) []byte {
+	return append(append([]byte{}, outboxPrefix...), sequenceBytes(sequence)...)
+}
+
+func sequenceBytes(sequence uint64) []byte {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, sequence)
+	return value
+}
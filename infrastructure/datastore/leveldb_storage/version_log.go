@@ -0,0 +1,109 @@
+package leveldb_storage
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var (
+	versionsPrefix   = []byte("versions:")
+	tombstonesPrefix = []byte("tombstones:")
+)
+
+// LeveldbVersionLog keeps the stamp of each version by photo, under the tombstones prefix when it is deleted.
+type LeveldbVersionLog struct {
+	db *leveldb.DB
+}
+
+func NewVersionLog(storage *LeveldbStorage) *LeveldbVersionLog {
+	return &LeveldbVersionLog{storage.db}
+}
+
+func (versions *LeveldbVersionLog) Get(ctx context.Context, id photo.Identifier) (*photo.Version, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	for _, version := range []photo.Version{{}, {Deleted: true}} {
+		prefix := versionsPrefix
+		if version.Deleted {
+			prefix = tombstonesPrefix
+		}
+		data, err := versions.db.Get(versionKey(prefix, id.Value()), nil)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, &photo.ResourceError{Id: id, Err: err}
+		}
+		version.Stamp = int64(binary.BigEndian.Uint64(data))
+		return &version, nil
+	}
+	return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+}
+
+func (versions *LeveldbVersionLog) Put(ctx context.Context, id photo.Identifier, version photo.Version) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return &photo.ResourceError{Id: id, Err: err}
+	}
+
+	stamp := make([]byte, 8)
+	binary.BigEndian.PutUint64(stamp, uint64(version.Stamp))
+	put, other := versionsPrefix, tombstonesPrefix
+	if version.Deleted {
+		put, other = tombstonesPrefix, versionsPrefix
+	}
+	batch := new(leveldb.Batch)
+	batch.Put(versionKey(put, id.Value()), stamp)
+	batch.Delete(versionKey(other, id.Value()))
+	return versions.db.Write(batch, nil)
+}
+
+func (versions *LeveldbVersionLog) Remove(ctx context.Context, id photo.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return &photo.ResourceError{Id: id, Err: err}
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Delete(versionKey(versionsPrefix, id.Value()))
+	batch.Delete(versionKey(tombstonesPrefix, id.Value()))
+	return versions.db.Write(batch, nil)
+}
+
+func (versions *LeveldbVersionLog) Tombstones(ctx context.Context) (map[photo.Identifier]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	iter := versions.db.NewIterator(util.BytesPrefix(tombstonesPrefix), nil)
+	defer iter.Release()
+
+	tombstones := make(map[photo.Identifier]int64)
+	for iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		id := photo.IdentifierOf(string(iter.Key()[len(tombstonesPrefix):]))
+		tombstones[*id] = int64(binary.BigEndian.Uint64(iter.Value()))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return tombstones, nil
+}
+
+func versionKey(prefix []byte, id string) []byte {
+	return append(append([]byte{}, prefix...), id...)
+}
@@ -0,0 +1,44 @@
+package leveldb_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLeveldbVersionLog_Conformance(t *testing.T) {
+	phototest.RunVersionLogTests(t, func(t *testing.T) photo.VersionLog {
+		instance, err := New(tempDir(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { instance.db.Close() })
+		return NewVersionLog(instance)
+	})
+}
+
+func TestLeveldbVersionLog_Put(t *testing.T) {
+	t.Run("with versions, photos are not listed", func(t *testing.T) {
+		instance, err := New(tempDir(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer instance.db.Close()
+		versions := NewVersionLog(instance)
+		for _, version := range []photo.Version{{Stamp: 1}, {Stamp: 2, Deleted: true}} {
+			if err := versions.Put(context.Background(), *photo.IdentifierOf("id"), version); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := versions.Put(context.Background(), *photo.IdentifierOf("other"), photo.Version{Stamp: 3}); err != nil {
+			t.Fatal(err)
+		}
+
+		ids, err := instance.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+}
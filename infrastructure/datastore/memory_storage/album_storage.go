@@ -0,0 +1,100 @@
+package memory_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"sort"
+	"sync"
+)
+
+type MemoryAlbumStorage struct {
+	mu     sync.Mutex
+	albums map[album.Identifier]album.Album
+}
+
+func NewAlbumStorage() *MemoryAlbumStorage {
+	return &MemoryAlbumStorage{albums: make(map[album.Identifier]album.Album)}
+}
+
+func (storage *MemoryAlbumStorage) Save(ctx context.Context, a album.Album) (*album.Identifier, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	id := a.Id()
+	if a.IsNew() {
+		id = album.NewIdentifier()
+	}
+	if err := album.ValidateIdentifier(*id); err != nil {
+		return nil, &album.ResourceError{Id: *id, Err: err}
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	storage.albums[*id] = *copyAlbum(*id, a)
+	return id, nil
+}
+
+func (storage *MemoryAlbumStorage) Read(ctx context.Context, id album.Identifier) (*album.Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := album.ValidateIdentifier(id); err != nil {
+		return nil, &album.ResourceError{Id: id, Err: err}
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	a, ok := storage.albums[id]
+	if !ok {
+		return nil, &album.ResourceError{Id: id, Err: album.ErrNotFound}
+	}
+	return copyAlbum(id, a), nil
+}
+
+func (storage *MemoryAlbumStorage) ReadAll(ctx context.Context) ([]album.Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	var albums []album.Album
+	for id, a := range storage.albums {
+		albums = append(albums, *copyAlbum(id, a))
+	}
+	sort.Slice(albums, func(i, j int) bool {
+		return albums[i].Id().Value() < albums[j].Id().Value()
+	})
+	return albums, nil
+}
+
+func (storage *MemoryAlbumStorage) Delete(ctx context.Context, id album.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := album.ValidateIdentifier(id); err != nil {
+		return &album.ResourceError{Id: id, Err: err}
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	delete(storage.albums, id)
+	return nil
+}
+
+func copyAlbum(id album.Identifier, a album.Album) *album.Album {
+	var cover *photo.Identifier
+	if a.Cover() != nil {
+		c := *a.Cover()
+		cover = &c
+	}
+	var photos []photo.Identifier
+	photos = append(photos, a.Photos()...)
+	return album.Of(id, a.Title(), a.Description(), cover, photos)
+}
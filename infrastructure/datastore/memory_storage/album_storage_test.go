@@ -0,0 +1,100 @@
+package memory_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album/albumtest"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryAlbumStorage_Save(t *testing.T) {
+	t.Run("save without identifier, generate new identifier", func(t *testing.T) {
+		instance := createAlbumInstance(t)
+		identifier, err := instance.Save(context.Background(), *album.New("title", "description"))
+		if assert.NoError(t, err) {
+			assert.NotEmpty(t, identifier.Value())
+		}
+	})
+
+	t.Run("save with identifier, can read same album", func(t *testing.T) {
+		instance := createAlbumInstance(t)
+		photos := []photo.Identifier{*photo.IdentifierOf("b"), *photo.IdentifierOf("a")}
+		expected := album.Of(*album.IdentifierOf("album"), "title", "description", photo.IdentifierOf("a"), photos)
+		identifier, err := instance.Save(context.Background(), *expected)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "album", identifier.Value())
+
+			actual, err := instance.Read(context.Background(), *identifier)
+			if assert.NoError(t, err) {
+				assert.Equal(t, expected, actual)
+			}
+		}
+	})
+}
+
+func TestMemoryAlbumStorage_Read(t *testing.T) {
+	t.Run("with no key, returns not found", func(t *testing.T) {
+		instance := createAlbumInstance(t)
+		_, err := instance.Read(context.Background(), *album.IdentifierOf("noKey"))
+		if assert.Error(t, err) {
+			assert.Equal(t, album.ErrNotFound, err.(*album.ResourceError).Err)
+		}
+	})
+}
+
+func TestMemoryAlbumStorage_ReadAll(t *testing.T) {
+	instance := createAlbumInstance(t)
+	for _, id := range []string{"first", "second"} {
+		if _, err := instance.Save(context.Background(), *album.Of(*album.IdentifierOf(id), id, "", nil, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	albums, err := instance.ReadAll(context.Background())
+	if assert.NoError(t, err) {
+		var titles []string
+		for _, a := range albums {
+			titles = append(titles, a.Title())
+		}
+		assert.ElementsMatch(t, []string{"first", "second"}, titles)
+	}
+}
+
+func TestMemoryAlbumStorage_Delete(t *testing.T) {
+	instance := createAlbumInstance(t)
+	if _, err := instance.Save(context.Background(), *album.Of(*album.IdentifierOf("album"), "", "", nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NoError(t, instance.Delete(context.Background(), *album.IdentifierOf("album"))) {
+		_, err := instance.Read(context.Background(), *album.IdentifierOf("album"))
+		assert.Error(t, err)
+	}
+}
+
+func TestMemoryAlbumStorage_Isolation(t *testing.T) {
+	instance := createAlbumInstance(t)
+	a := album.Of(*album.IdentifierOf("album"), "title", "", nil, []photo.Identifier{*photo.IdentifierOf("a")})
+	if _, err := instance.Save(context.Background(), *a); err != nil {
+		t.Fatal(err)
+	}
+	a.AddPhoto(*photo.IdentifierOf("b"))
+
+	actual, err := instance.Read(context.Background(), *album.IdentifierOf("album"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("a")}, actual.Photos())
+	}
+}
+
+func TestMemoryAlbumStorage_Conformance(t *testing.T) {
+	albumtest.RunRepositoryTests(t, func(t *testing.T) album.Repository {
+		return createAlbumInstance(t)
+	})
+}
+
+func createAlbumInstance(tb testing.TB) *MemoryAlbumStorage {
+	tb.Helper()
+	return NewAlbumStorage()
+}
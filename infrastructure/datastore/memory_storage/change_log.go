@@ -0,0 +1,83 @@
+package memory_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"sort"
+	"sync"
+)
+
+type MemoryChangeLog struct {
+	mu      sync.Mutex
+	records []event.Record
+}
+
+func NewChangeLog() *MemoryChangeLog {
+	return &MemoryChangeLog{}
+}
+
+func (changes *MemoryChangeLog) Put(ctx context.Context, record event.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+
+	i := changes.search(record.Sequence)
+	if i < len(changes.records) && changes.records[i].Sequence == record.Sequence {
+		changes.records[i] = record
+		return nil
+	}
+	changes.records = append(changes.records, event.Record{})
+	copy(changes.records[i+1:], changes.records[i:])
+	changes.records[i] = record
+	return nil
+}
+
+func (changes *MemoryChangeLog) Since(ctx context.Context, sequence uint64, limit int) ([]event.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+
+	records := changes.records[changes.search(sequence+1):]
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return append([]event.Record(nil), records...), nil
+}
+
+func (changes *MemoryChangeLog) Latest(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+
+	if len(changes.records) == 0 {
+		return 0, nil
+	}
+	return changes.records[len(changes.records)-1].Sequence, nil
+}
+
+func (changes *MemoryChangeLog) Trim(ctx context.Context, before uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+
+	changes.records = append([]event.Record{}, changes.records[changes.search(before):]...)
+	return nil
+}
+
+func (changes *MemoryChangeLog) search(sequence uint64) int {
+	return sort.Search(len(changes.records), func(i int) bool {
+		return changes.records[i].Sequence >= sequence
+	})
+}
@@ -0,0 +1,13 @@
+package memory_storage
+
+import (
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event/eventtest"
+	"testing"
+)
+
+func TestMemoryChangeLog_Conformance(t *testing.T) {
+	eventtest.RunChangeLogTests(t, func(t *testing.T) event.ChangeLog {
+		return NewChangeLog()
+	})
+}
@@ -0,0 +1,243 @@
+package memory_storage
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+)
+
+var ErrTooLarge = errors.New("photo is larger than storage capacity")
+
+type MemoryStorage struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[photo.Identifier]*list.Element
+	bytes   int64
+}
+
+type entry struct {
+	id       photo.Identifier
+	data     []byte
+	tags     []string
+	checksum string
+}
+
+func New(maxBytes int64) *MemoryStorage {
+	return &MemoryStorage{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[photo.Identifier]*list.Element),
+	}
+}
+
+func (storage *MemoryStorage) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	data := photograph.Image()
+	id := photograph.Id()
+	if photograph.IsNew() {
+		id = photo.NewIdentifier(data)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if storage.maxBytes > 0 && int64(len(data)) > storage.maxBytes {
+		return nil, &photo.ResourceError{Id: *id, Err: ErrTooLarge}
+	}
+
+	e := &entry{
+		id:       *id,
+		data:     append([]byte{}, data...),
+		tags:     append([]string{}, photograph.Tags()...),
+		checksum: photo.Checksum(data),
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if element, ok := storage.entries[*id]; ok {
+		storage.remove(element)
+	}
+	storage.entries[*id] = storage.lru.PushFront(e)
+	storage.bytes += int64(len(e.data))
+	storage.evict()
+
+	return id, nil
+}
+
+func (storage *MemoryStorage) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	e, err := storage.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return photo.Of(id, append([]byte{}, e.data...), e.tags...), nil
+}
+
+func (storage *MemoryStorage) Open(ctx context.Context, id photo.Identifier) (io.ReadCloser, error) {
+	e, err := storage.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(e.data)), nil
+}
+
+func (storage *MemoryStorage) Delete(ctx context.Context, id photo.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if element, ok := storage.entries[id]; ok {
+		storage.remove(element)
+	}
+	return nil
+}
+
+func (storage *MemoryStorage) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	return storage.find(ctx, func(e *entry) bool {
+		return true
+	})
+}
+
+func (storage *MemoryStorage) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	return storage.find(ctx, func(e *entry) bool {
+		for _, t := range e.tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (storage *MemoryStorage) Close() error {
+	return nil
+}
+
+func (storage *MemoryStorage) Stats(ctx context.Context) (*photo.Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	return &photo.Stats{Count: len(storage.entries), Bytes: storage.bytes}, nil
+}
+
+func (storage *MemoryStorage) Sizes(ctx context.Context) (map[photo.Identifier]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	sizes := make(map[photo.Identifier]int64)
+	for id, element := range storage.entries {
+		sizes[id] = int64(len(element.Value.(*entry).data))
+	}
+	return sizes, nil
+}
+
+func (storage *MemoryStorage) Checksums(ctx context.Context) (map[photo.Identifier]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	checksums := make(map[photo.Identifier]string)
+	for id, element := range storage.entries {
+		checksums[id] = element.Value.(*entry).checksum
+	}
+	return checksums, nil
+}
+
+func (storage *MemoryStorage) ChecksumOf(ctx context.Context, id photo.Identifier) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", &photo.ResourceError{Id: id, Err: err}
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	element, ok := storage.entries[id]
+	if !ok {
+		return "", &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	}
+	return element.Value.(*entry).checksum, nil
+}
+
+func (storage *MemoryStorage) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	element, ok := storage.entries[id]
+	if !ok {
+		return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	}
+	return append([]string{}, element.Value.(*entry).tags...), nil
+}
+
+func (storage *MemoryStorage) get(ctx context.Context, id photo.Identifier) (*entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	element, ok := storage.entries[id]
+	if !ok {
+		return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	}
+	storage.lru.MoveToFront(element)
+	return element.Value.(*entry), nil
+}
+
+func (storage *MemoryStorage) find(ctx context.Context, match func(e *entry) bool) ([]photo.Identifier, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	var ids []photo.Identifier
+	for id, element := range storage.entries {
+		if match(element.Value.(*entry)) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Value() < ids[j].Value()
+	})
+	return ids, nil
+}
+
+func (storage *MemoryStorage) remove(element *list.Element) {
+	e := element.Value.(*entry)
+	storage.lru.Remove(element)
+	delete(storage.entries, e.id)
+	storage.bytes -= int64(len(e.data))
+}
+
+func (storage *MemoryStorage) evict() {
+	for storage.maxBytes > 0 && storage.bytes > storage.maxBytes {
+		storage.remove(storage.lru.Back())
+	}
+}
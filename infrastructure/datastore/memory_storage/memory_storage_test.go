@@ -0,0 +1,154 @@
+package memory_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+)
+
+func TestMemoryStorage_Conformance(t *testing.T) {
+	phototest.RunRepositoryTests(t, func(t *testing.T) photo.Repository {
+		return New(0)
+	})
+}
+
+func TestMemoryStorage_Save(t *testing.T) {
+	t.Run("save without identifier, generate new identifier", func(t *testing.T) {
+		instance := New(0)
+		identifier, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		if assert.NoError(t, err) {
+			assert.NotEmpty(t, identifier.Value())
+		}
+	})
+
+	t.Run("save with identifier, can read same photo", func(t *testing.T) {
+		instance := New(0)
+		id := photo.IdentifierOf("id")
+		if _, err := instance.Save(context.Background(), *photo.Of(*id, []byte("data"), "tag")); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("data"), actual.Image())
+			assert.Equal(t, []string{"tag"}, actual.Tags())
+		}
+	})
+
+	t.Run("over byte budget, evicts least recently used", func(t *testing.T) {
+		instance := New(10)
+		for _, id := range []string{"a", "b"} {
+			if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), []byte("1234"))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := instance.Read(context.Background(), *photo.IdentifierOf("a")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("c"), []byte("1234"))); err != nil {
+			t.Fatal(err)
+		}
+
+		ids, err := instance.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("a"), *photo.IdentifierOf("c")}, ids)
+		}
+	})
+
+	t.Run("larger than byte budget, returns error", func(t *testing.T) {
+		instance := New(3)
+		_, err := instance.Save(context.Background(), *photo.New([]byte("1234")))
+		if assert.Error(t, err) {
+			assert.Equal(t, ErrTooLarge, err.(*photo.ResourceError).Err)
+		}
+	})
+}
+
+func TestMemoryStorage_Read(t *testing.T) {
+	t.Run("with no key, returns not found", func(t *testing.T) {
+		_, err := New(0).Read(context.Background(), *photo.IdentifierOf("noKey"))
+		if assert.Error(t, err) {
+			assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+		}
+	})
+
+	t.Run("modifying read photo, does not change stored one", func(t *testing.T) {
+		instance := New(0)
+		id := photo.IdentifierOf("id")
+		if _, err := instance.Save(context.Background(), *photo.Of(*id, []byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+
+		first, _ := instance.Read(context.Background(), *id)
+		first.Image()[0] = 'x'
+		actual, err := instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("data"), actual.Image())
+		}
+	})
+}
+
+func TestMemoryStorage_Open(t *testing.T) {
+	instance := New(0)
+	id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := instance.Open(context.Background(), *id)
+	if assert.NoError(t, err) {
+		actual, err := ioutil.ReadAll(reader)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("data"), actual)
+		}
+		assert.NoError(t, reader.Close())
+	}
+}
+
+func TestMemoryStorage_Delete(t *testing.T) {
+	instance := New(0)
+	id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NoError(t, instance.Delete(context.Background(), *id)) {
+		_, err := instance.Read(context.Background(), *id)
+		assert.Error(t, err)
+	}
+}
+
+func TestMemoryStorage_FindByTag(t *testing.T) {
+	instance := New(0)
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("first"), []byte("first"), "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("second"), []byte("second"), "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := instance.FindByTag(context.Background(), "a")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("first")}, ids)
+	}
+	ids, err = instance.FindByTag(context.Background(), "b")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("first"), *photo.IdentifierOf("second")}, ids)
+	}
+}
+
+func TestMemoryStorage_Checksums(t *testing.T) {
+	instance := New(0)
+	id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checksums, err := instance.Checksums(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[photo.Identifier]string{*id: photo.Checksum([]byte("data"))}, checksums)
+	}
+}
@@ -0,0 +1,58 @@
+package memory_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"sync"
+)
+
+type MemoryOutbox struct {
+	mu       sync.Mutex
+	sequence uint64
+	records  []event.Record
+}
+
+func NewOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+func (outbox *MemoryOutbox) Append(ctx context.Context, e event.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	outbox.sequence++
+	outbox.records = append(outbox.records, event.Record{Sequence: outbox.sequence, Event: e})
+	return nil
+}
+
+func (outbox *MemoryOutbox) Pending(ctx context.Context) ([]event.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	return append([]event.Record{}, outbox.records...), nil
+}
+
+func (outbox *MemoryOutbox) Remove(ctx context.Context, sequence uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	for i, record := range outbox.records {
+		if record.Sequence == sequence {
+			outbox.records = append(outbox.records[:i], outbox.records[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
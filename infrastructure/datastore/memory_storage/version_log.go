@@ -0,0 +1,79 @@
+package memory_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"sync"
+)
+
+type MemoryVersionLog struct {
+	mu       sync.Mutex
+	versions map[photo.Identifier]photo.Version
+}
+
+func NewVersionLog() *MemoryVersionLog {
+	return &MemoryVersionLog{versions: make(map[photo.Identifier]photo.Version)}
+}
+
+func (versions *MemoryVersionLog) Get(ctx context.Context, id photo.Identifier) (*photo.Version, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	versions.mu.Lock()
+	defer versions.mu.Unlock()
+
+	version, ok := versions.versions[id]
+	if !ok {
+		return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	}
+	return &version, nil
+}
+
+func (versions *MemoryVersionLog) Put(ctx context.Context, id photo.Identifier, version photo.Version) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return &photo.ResourceError{Id: id, Err: err}
+	}
+
+	versions.mu.Lock()
+	defer versions.mu.Unlock()
+	versions.versions[id] = version
+	return nil
+}
+
+func (versions *MemoryVersionLog) Remove(ctx context.Context, id photo.Identifier) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return &photo.ResourceError{Id: id, Err: err}
+	}
+
+	versions.mu.Lock()
+	defer versions.mu.Unlock()
+	delete(versions.versions, id)
+	return nil
+}
+
+func (versions *MemoryVersionLog) Tombstones(ctx context.Context) (map[photo.Identifier]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	versions.mu.Lock()
+	defer versions.mu.Unlock()
+
+	tombstones := make(map[photo.Identifier]int64)
+	for id, version := range versions.versions {
+		if version.Deleted {
+			tombstones[id] = version.Stamp
+		}
+	}
+	return tombstones, nil
+}
@@ -0,0 +1,13 @@
+package memory_storage
+
+import (
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"testing"
+)
+
+func TestMemoryVersionLog_Conformance(t *testing.T) {
+	phototest.RunVersionLogTests(t, func(t *testing.T) photo.VersionLog {
+		return NewVersionLog()
+	})
+}
@@ -0,0 +1,107 @@
+package replicated_storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"log/slog"
+)
+
+type ReplicatedAlbumStorage struct {
+	replicas []album.Repository
+	quorum   int
+}
+
+func NewAlbumStorage(quorum int, replicas ...album.Repository) (*ReplicatedAlbumStorage, error) {
+	if quorum < 1 || quorum > len(replicas) {
+		return nil, ErrInvalidQuorum
+	}
+	return &ReplicatedAlbumStorage{replicas: replicas, quorum: quorum}, nil
+}
+
+func (storage *ReplicatedAlbumStorage) Save(ctx context.Context, a album.Album) (*album.Identifier, error) {
+	id := a.Id()
+	if a.IsNew() {
+		id = album.NewIdentifier()
+	}
+	if err := album.ValidateIdentifier(*id); err != nil {
+		return nil, &album.ResourceError{Id: *id, Err: err}
+	}
+	a = *album.Of(*id, a.Title(), a.Description(), a.Cover(), a.Photos())
+
+	if err := storage.write(ctx, func(replica album.Repository) error {
+		_, err := replica.Save(ctx, a)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (storage *ReplicatedAlbumStorage) Read(ctx context.Context, id album.Identifier) (*album.Album, error) {
+	if err := album.ValidateIdentifier(id); err != nil {
+		return nil, &album.ResourceError{Id: id, Err: err}
+	}
+
+	var lacking []album.Repository
+	var lastErr error = &album.ResourceError{Id: id, Err: album.ErrNotFound}
+	for _, replica := range storage.replicas {
+		a, err := replica.Read(ctx, id)
+		if e, ok := err.(*album.ResourceError); ok && e.Err == album.ErrNotFound {
+			lacking = append(lacking, replica)
+			continue
+		} else if err != nil {
+			lastErr = err
+			continue
+		}
+
+		for _, replica := range lacking {
+			if _, err := replica.Save(ctx, *a); err != nil {
+				slog.WarnContext(ctx, "read repair failed", "album_id", id.Value(), "error", err)
+			}
+		}
+		return a, nil
+	}
+	return nil, lastErr
+}
+
+func (storage *ReplicatedAlbumStorage) ReadAll(ctx context.Context) ([]album.Album, error) {
+	var lastErr error
+	for _, replica := range storage.replicas {
+		albums, err := replica.ReadAll(ctx)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return albums, nil
+	}
+	return nil, lastErr
+}
+
+func (storage *ReplicatedAlbumStorage) Delete(ctx context.Context, id album.Identifier) error {
+	if err := album.ValidateIdentifier(id); err != nil {
+		return &album.ResourceError{Id: id, Err: err}
+	}
+	return storage.write(ctx, func(replica album.Repository) error {
+		return replica.Delete(ctx, id)
+	})
+}
+
+func (storage *ReplicatedAlbumStorage) write(ctx context.Context, operation func(replica album.Repository) error) error {
+	succeeded := 0
+	var lastErr error
+	for _, replica := range storage.replicas {
+		if err := operation(replica); err != nil {
+			lastErr = err
+			continue
+		}
+		succeeded++
+	}
+	if succeeded < storage.quorum {
+		return fmt.Errorf("%s (%d/%d): %s", ErrQuorumFailed, succeeded, storage.quorum, lastErr)
+	}
+	if lastErr != nil {
+		slog.WarnContext(ctx, "replica write failed", "error", lastErr)
+	}
+	return nil
+}
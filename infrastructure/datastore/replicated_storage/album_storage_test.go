@@ -0,0 +1,80 @@
+package replicated_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album/albumtest"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReplicatedAlbumStorage_Save(t *testing.T) {
+	first, second := createAlbumReplica(t), createAlbumReplica(t)
+	instance, _ := NewAlbumStorage(2, first, second)
+
+	id, err := instance.Save(context.Background(), *album.New("title", "description"))
+	if assert.NoError(t, err) {
+		for _, replica := range []album.Repository{first, second} {
+			actual, err := replica.Read(context.Background(), *id)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "title", actual.Title())
+			}
+		}
+	}
+}
+
+func TestReplicatedAlbumStorage_Read(t *testing.T) {
+	t.Run("when replica misses album, repairs it", func(t *testing.T) {
+		first, second := createAlbumReplica(t), createAlbumReplica(t)
+		id := album.IdentifierOf("album")
+		if _, err := second.Save(context.Background(), *album.Of(*id, "title", "", nil, nil)); err != nil {
+			t.Fatal(err)
+		}
+
+		instance, _ := NewAlbumStorage(1, first, second)
+		if _, err := instance.Read(context.Background(), *id); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := first.Read(context.Background(), *id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("when no replica has album, returns not found", func(t *testing.T) {
+		instance, _ := NewAlbumStorage(1, createAlbumReplica(t))
+		_, err := instance.Read(context.Background(), *album.IdentifierOf("noKey"))
+		if assert.Error(t, err) {
+			assert.Equal(t, album.ErrNotFound, err.(*album.ResourceError).Err)
+		}
+	})
+}
+
+func TestReplicatedAlbumStorage_Delete(t *testing.T) {
+	first, second := createAlbumReplica(t), createAlbumReplica(t)
+	instance, _ := NewAlbumStorage(2, first, second)
+	id, err := instance.Save(context.Background(), *album.New("title", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NoError(t, instance.Delete(context.Background(), *id)) {
+		_, err := second.Read(context.Background(), *id)
+		assert.Error(t, err)
+	}
+}
+
+func TestReplicatedAlbumStorage_Conformance(t *testing.T) {
+	albumtest.RunRepositoryTests(t, func(t *testing.T) album.Repository {
+		instance, err := NewAlbumStorage(2, createAlbumReplica(t), createAlbumReplica(t), createAlbumReplica(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return instance
+	})
+}
+
+func createAlbumReplica(tb testing.TB) *file_storage.FileAlbumStorage {
+	tb.Helper()
+	return file_storage.NewAlbumStorage(createReplica(tb).Photos.(*file_storage.FileStorage))
+}
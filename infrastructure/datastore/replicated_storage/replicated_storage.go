@@ -0,0 +1,617 @@
+package replicated_storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"hash/fnv"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+const lockStripes = 64
+
+var (
+	// purgeInterval is the delay between purges of the tombstones every replica agrees on,
+	// and tombstoneGrace how long a tombstone is kept before it can be purged.
+	purgeInterval  = time.Hour
+	tombstoneGrace = 24 * time.Hour
+)
+
+var (
+	ErrInvalidQuorum    = errors.New("quorum must be between 1 and number of replicas")
+	ErrQuorumFailed     = errors.New("write quorum not reached")
+	ErrReadQuorumFailed = errors.New("read quorum not reached")
+)
+
+// Replica is a repository to replicate photos to, with the log of the versions written to it.
+type Replica struct {
+	Photos   photo.Repository
+	Versions photo.VersionLog
+}
+
+// ReplicatedStorage writes every photo to all replicas and succeeds when a quorum of them did.
+// A photo is read from the first healthy replica holding a copy, which repairs the replicas found without one.
+// Listing resolves a photo some replica deleted from enough replicas to meet every write quorum. Deleted photos are kept as tombstones in the version logs, until every replica agrees on the delete.
+type ReplicatedStorage struct {
+	replicas []Replica
+	quorum   int
+
+	mu          sync.RWMutex
+	healthy     []bool
+	lastVersion int64
+
+	// locks serialize the writes of a photo to the replicas, and the repairs of its copies.
+	locks [lockStripes]sync.Mutex
+
+	cancel  context.CancelFunc
+	purging sync.WaitGroup
+}
+
+// New replicates to replicas, and purges their agreed tombstones in the background until closed.
+func New(quorum int, replicas ...Replica) (*ReplicatedStorage, error) {
+	if quorum < 1 || quorum > len(replicas) {
+		return nil, ErrInvalidQuorum
+	}
+
+	healthy := make([]bool, len(replicas))
+	for i := range healthy {
+		healthy[i] = true
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	storage := &ReplicatedStorage{replicas: replicas, quorum: quorum, healthy: healthy, cancel: cancel}
+	storage.purging.Add(1)
+	go storage.purgeTombstones(ctx, purgeInterval, tombstoneGrace)
+	return storage, nil
+}
+
+func (storage *ReplicatedStorage) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	id := photograph.Id()
+	if photograph.IsNew() {
+		id = photo.NewIdentifier(photograph.Image())
+	}
+	if err := photo.ValidateIdentifier(*id); err != nil {
+		return nil, &photo.ResourceError{Id: *id, Err: err}
+	}
+	stored := photo.Of(*id, photograph.Image(), photograph.Tags()...)
+
+	unlock := storage.lock(*id)
+	defer unlock()
+
+	version := photo.Version{Stamp: storage.nextVersion()}
+	if err := storage.write(ctx, func(replica Replica) error {
+		if _, err := replica.Photos.Save(ctx, *stored); err != nil {
+			return err
+		}
+		return replica.Versions.Put(ctx, *id, version)
+	}); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (storage *ReplicatedStorage) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	first, err := storage.readFirst(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if first.photograph == nil {
+		return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	}
+	return first.photograph, nil
+}
+
+// Delete records a tombstone, then deletes the photo, on a quorum of replicas.
+func (storage *ReplicatedStorage) Delete(ctx context.Context, id photo.Identifier) error {
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return &photo.ResourceError{Id: id, Err: err}
+	}
+
+	unlock := storage.lock(id)
+	defer unlock()
+
+	version := photo.Version{Stamp: storage.nextVersion(), Deleted: true}
+	return storage.write(ctx, func(replica Replica) error {
+		return remove(ctx, replica, id, version)
+	})
+}
+
+func (storage *ReplicatedStorage) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	return storage.union(ctx, func(replica photo.Repository) ([]photo.Identifier, error) {
+		return replica.FindAll(ctx)
+	})
+}
+
+func (storage *ReplicatedStorage) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	return storage.union(ctx, func(replica photo.Repository) ([]photo.Identifier, error) {
+		return replica.FindByTag(ctx, tag)
+	})
+}
+
+// Close stops purging tombstones, then closes the replicas.
+func (storage *ReplicatedStorage) Close() error {
+	storage.cancel()
+	storage.purging.Wait()
+
+	var err error
+	for _, replica := range storage.replicas {
+		if e := replica.Photos.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Ping succeeds when a quorum of replicas is writable.
+func (storage *ReplicatedStorage) Ping(ctx context.Context) error {
+	var err error
+	var succeeded int
+	for _, replica := range storage.replicas {
+		if e := photo.Ping(ctx, replica.Photos); e != nil {
+			err = e
+			continue
+		}
+		succeeded++
+	}
+	if succeeded < storage.quorum {
+		return err
+	}
+	return nil
+}
+
+func (storage *ReplicatedStorage) Checksums(ctx context.Context) (map[photo.Identifier]string, error) {
+	for _, i := range storage.order() {
+		if repository, ok := storage.replicas[i].Photos.(photo.ChecksumRepository); ok {
+			return repository.Checksums(ctx)
+		}
+	}
+	return nil, photo.ErrNoChecksums
+}
+
+// ChecksumOf is the checksum recorded by the first replica holding a copy of the photo, healthy replicas first.
+func (storage *ReplicatedStorage) ChecksumOf(ctx context.Context, id photo.Identifier) (string, error) {
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return "", &photo.ResourceError{Id: id, Err: err}
+	}
+
+	var lastErr error = &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	for _, i := range storage.order() {
+		replica := storage.replicas[i]
+		version, err := replica.Versions.Get(ctx, id)
+		if err == nil && version.Deleted {
+			return "", &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+		} else if err != nil && !isNotFound(err) {
+			lastErr = err
+			continue
+		}
+
+		repository, ok := replica.Photos.(photo.ChecksumRepository)
+		if !ok {
+			lastErr = photo.ErrNoChecksums
+			continue
+		}
+		checksum, err := repository.ChecksumOf(ctx, id)
+		if isNotFound(err) {
+			continue
+		} else if err != nil {
+			lastErr = err
+			continue
+		}
+		return checksum, nil
+	}
+	return "", lastErr
+}
+
+// TagsOf are the tags of the first replica holding a copy of the photo, healthy replicas first.
+func (storage *ReplicatedStorage) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	if err := photo.ValidateIdentifier(id); err != nil {
+		return nil, &photo.ResourceError{Id: id, Err: err}
+	}
+
+	var lastErr error = &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	for _, i := range storage.order() {
+		replica := storage.replicas[i]
+		version, err := replica.Versions.Get(ctx, id)
+		if err == nil && version.Deleted {
+			return nil, &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+		} else if err != nil && !isNotFound(err) {
+			lastErr = err
+			continue
+		}
+
+		tags, err := photo.TagsOf(ctx, replica.Photos, id)
+		if isNotFound(err) {
+			continue
+		} else if err != nil {
+			lastErr = err
+			continue
+		}
+		return tags, nil
+	}
+	return nil, lastErr
+}
+
+// Stats are those of the first replica able to report them, healthy replicas first.
+func (storage *ReplicatedStorage) Stats(ctx context.Context) (*photo.Stats, error) {
+	var err error
+	for _, i := range storage.order() {
+		stats, e := photo.StatsOf(ctx, storage.replicas[i].Photos)
+		if e != nil {
+			err = e
+			continue
+		}
+		return stats, nil
+	}
+	return nil, err
+}
+
+func (storage *ReplicatedStorage) Healthy() []bool {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+	return append([]bool{}, storage.healthy...)
+}
+
+// Purge removes the tombstones stamped before the given time from the version logs, once every replica
+// agrees the photo is deleted. Replicas holding an older copy are repaired first. A photo is not purged while
+// any replica can't be read, since it could bring the photo back. Purge returns the number of photos purged.
+func (storage *ReplicatedStorage) Purge(ctx context.Context, before time.Time) (int, error) {
+	expired := make(map[photo.Identifier]bool)
+	for _, replica := range storage.replicas {
+		tombstones, err := replica.Versions.Tombstones(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for id, stamp := range tombstones {
+			if stamp < before.UnixNano() {
+				expired[id] = true
+			}
+		}
+	}
+
+	purged := 0
+	for id := range expired {
+		ok, err := storage.purge(ctx, id)
+		if err != nil {
+			return purged, err
+		} else if ok {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (storage *ReplicatedStorage) purge(ctx context.Context, id photo.Identifier) (bool, error) {
+	unlock := storage.lock(id)
+	defer unlock()
+
+	newest := &versioned{}
+	var copies []*versioned
+	for i := range storage.replicas {
+		current, err := storage.readCopy(ctx, i, id)
+		if err != nil {
+			return false, err
+		}
+		copies = append(copies, current)
+		if current.newerThan(newest) {
+			newest = current
+		}
+	}
+	if !newest.version.Deleted {
+		return false, nil
+	}
+
+	for _, current := range copies {
+		if newest.newerThan(current) && current.found {
+			if err := remove(ctx, storage.replicas[current.replica], id, newest.version); err != nil {
+				return false, err
+			}
+		}
+	}
+	for _, replica := range storage.replicas {
+		if err := replica.Versions.Remove(ctx, id); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (storage *ReplicatedStorage) purgeTombstones(ctx context.Context, interval time.Duration, grace time.Duration) {
+	defer storage.purging.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		purged, err := storage.Purge(ctx, time.Now().Add(-grace))
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "tombstone purge failed", "error", err)
+		} else if purged > 0 {
+			slog.InfoContext(ctx, "tombstones purged", "count", purged)
+		}
+	}
+}
+
+func (storage *ReplicatedStorage) write(ctx context.Context, operation func(replica Replica) error) error {
+	errs := make([]error, len(storage.replicas))
+	wg := sync.WaitGroup{}
+	for i, replica := range storage.replicas {
+		wg.Add(1)
+		go func(i int, replica Replica) {
+			defer wg.Done()
+			errs[i] = operation(replica)
+			if ctx.Err() == nil {
+				storage.setHealthy(i, errs[i] == nil)
+			}
+		}(i, replica)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	var lastErr error
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			lastErr = err
+		}
+	}
+	if succeeded >= storage.quorum {
+		if lastErr != nil {
+			slog.WarnContext(ctx, "replica write failed", "error", lastErr)
+		}
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("%s (%d/%d): %s", ErrQuorumFailed, succeeded, storage.quorum, lastErr)
+}
+
+// readFirst reads the photo from the first replica holding a copy or a tombstone of it, healthy replicas first,
+// then repairs the replicas found without one.
+func (storage *ReplicatedStorage) readFirst(ctx context.Context, id photo.Identifier) (*versioned, error) {
+	var lacking []int
+	var lastErr error = &photo.ResourceError{Id: id, Err: photo.ErrNotFound}
+	for _, i := range storage.order() {
+		current, err := storage.readCopy(ctx, i, id)
+		if ctx.Err() != nil {
+			return nil, &photo.ResourceError{Id: id, Err: ctx.Err()}
+		} else if err != nil {
+			storage.setHealthy(i, false)
+			lastErr = err
+			continue
+		}
+		storage.setHealthy(i, true)
+
+		if !current.found {
+			lacking = append(lacking, i)
+			continue
+		}
+		for _, j := range lacking {
+			storage.repair(ctx, j, id, current)
+		}
+		return current, nil
+	}
+	return nil, lastErr
+}
+
+// readNewest reads the photo from enough replicas to see the last write a quorum acknowledged,
+// then repairs the replicas which returned an older copy or none.
+func (storage *ReplicatedStorage) readNewest(ctx context.Context, id photo.Identifier) (*versioned, error) {
+	needed := len(storage.replicas) - storage.quorum + 1
+	newest := &versioned{}
+	var copies []*versioned
+	var lastErr error
+	for _, i := range storage.order() {
+		if len(copies) >= needed {
+			break
+		}
+		current, err := storage.readCopy(ctx, i, id)
+		if ctx.Err() != nil {
+			return nil, &photo.ResourceError{Id: id, Err: ctx.Err()}
+		} else if err != nil {
+			storage.setHealthy(i, false)
+			lastErr = err
+			continue
+		}
+		storage.setHealthy(i, true)
+
+		copies = append(copies, current)
+		if current.newerThan(newest) {
+			newest = current
+		}
+	}
+	if len(copies) < needed {
+		return nil, &photo.ResourceError{Id: id, Err: fmt.Errorf("%s (%d/%d): %s", ErrReadQuorumFailed, len(copies), needed, lastErr)}
+	}
+
+	for _, current := range copies {
+		if newest.newerThan(current) {
+			storage.repair(ctx, current.replica, id, newest)
+		}
+	}
+	return newest, nil
+}
+
+// readCopy reads the version of the photo a replica holds, and the photo unless the version deleted it.
+// A version without its photo, left by a save which failed halfway, is no copy.
+func (storage *ReplicatedStorage) readCopy(ctx context.Context, i int, id photo.Identifier) (*versioned, error) {
+	replica := storage.replicas[i]
+	current := &versioned{replica: i}
+	version, err := replica.Versions.Get(ctx, id)
+	if err == nil {
+		current.version = *version
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+	if current.version.Deleted {
+		current.found = true
+		return current, nil
+	}
+
+	photograph, err := replica.Photos.Read(ctx, id)
+	if err == nil {
+		current.photograph, current.found = photograph, true
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+	return current, nil
+}
+
+// repair writes the newest copy to a replica, unless a write since it was read made the replica newer.
+func (storage *ReplicatedStorage) repair(ctx context.Context, i int, id photo.Identifier, newest *versioned) {
+	unlock := storage.lock(id)
+	defer unlock()
+
+	replica := storage.replicas[i]
+	current, err := storage.readCopy(ctx, i, id)
+	if err == nil && newest.newerThan(current) {
+		if newest.version.Deleted {
+			err = remove(ctx, replica, id, newest.version)
+		} else if _, err = replica.Photos.Save(ctx, *newest.photograph); err == nil {
+			err = replica.Versions.Put(ctx, id, newest.version)
+		}
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "read repair failed", "photo_id", id.Value(), "error", err)
+	}
+}
+
+// union merges the photos found on every replica. A photo some replica holds a tombstone of
+// is kept only when its newest copy is not deleted.
+func (storage *ReplicatedStorage) union(ctx context.Context, find func(replica photo.Repository) ([]photo.Identifier, error)) ([]photo.Identifier, error) {
+	found := make(map[photo.Identifier]bool)
+	deleted := make(map[photo.Identifier]bool)
+	var lastErr error
+	succeeded := false
+	for _, i := range storage.order() {
+		replica := storage.replicas[i]
+		ids, err := find(replica.Photos)
+		var tombstones map[photo.Identifier]int64
+		if err == nil {
+			tombstones, err = replica.Versions.Tombstones(ctx)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		} else if err != nil {
+			storage.setHealthy(i, false)
+			lastErr = err
+			continue
+		}
+		succeeded = true
+
+		for id := range tombstones {
+			deleted[id] = true
+		}
+		for _, id := range ids {
+			if _, ok := tombstones[id]; !ok {
+				found[id] = true
+			}
+		}
+	}
+	if !succeeded {
+		return nil, lastErr
+	}
+
+	var ids []photo.Identifier
+	for id := range found {
+		if deleted[id] {
+			newest, err := storage.readNewest(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if newest.photograph == nil {
+				continue
+			}
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Value() < ids[j].Value()
+	})
+	return ids, nil
+}
+
+func (storage *ReplicatedStorage) order() []int {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	var healthy, unhealthy []int
+	for i, ok := range storage.healthy {
+		if ok {
+			healthy = append(healthy, i)
+		} else {
+			unhealthy = append(unhealthy, i)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// nextVersion is the current time, moved forward when the clock didn't advance since the last write.
+func (storage *ReplicatedStorage) nextVersion() int64 {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	version := time.Now().UnixNano()
+	if version <= storage.lastVersion {
+		version = storage.lastVersion + 1
+	}
+	storage.lastVersion = version
+	return version
+}
+
+func (storage *ReplicatedStorage) setHealthy(i int, healthy bool) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	storage.healthy[i] = healthy
+}
+
+func (storage *ReplicatedStorage) lock(id photo.Identifier) func() {
+	h := fnv.New32a()
+	h.Write([]byte(id.Value()))
+	mu := &storage.locks[h.Sum32()%lockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// remove records the tombstone before deleting the photo, so that a replica failing in between
+// still knows the photo is deleted.
+func remove(ctx context.Context, replica Replica, id photo.Identifier, tombstone photo.Version) error {
+	if err := replica.Versions.Put(ctx, id, tombstone); err != nil {
+		return err
+	}
+	return replica.Photos.Delete(ctx, id)
+}
+
+func isNotFound(err error) bool {
+	if e, ok := err.(*photo.ResourceError); ok {
+		return e.Err == photo.ErrNotFound
+	}
+	return false
+}
+
+// versioned is a copy of a photo read from a replica, without photograph when the replica deleted it.
+// A photo stored without version, before the replica was replicated to, is older than any version.
+type versioned struct {
+	replica    int
+	photograph *photo.Photo
+	version    photo.Version
+	found      bool
+}
+
+func (copy *versioned) newerThan(other *versioned) bool {
+	if !copy.found {
+		return false
+	}
+	return !other.found || copy.version.Stamp > other.version.Stamp
+}
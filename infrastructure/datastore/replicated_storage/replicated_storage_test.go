@@ -0,0 +1,475 @@
+package replicated_storage
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/photoshelf/photoshelf-storage/domain/model/mock_photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("with quorum greater than replicas, returns error", func(t *testing.T) {
+		_, err := New(2, createReplica(t))
+		assert.Equal(t, ErrInvalidQuorum, err)
+	})
+
+	t.Run("with zero quorum, returns error", func(t *testing.T) {
+		_, err := New(0, createReplica(t))
+		assert.Equal(t, ErrInvalidQuorum, err)
+	})
+}
+
+func TestReplicatedStorage_Save(t *testing.T) {
+	t.Run("writes to all replicas", func(t *testing.T) {
+		first, second := createReplica(t), createReplica(t)
+		instance, _ := New(2, first, second)
+
+		id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		if assert.NoError(t, err) {
+			for _, replica := range []Replica{first, second} {
+				actual, err := replica.Photos.Read(context.Background(), *id)
+				if assert.NoError(t, err) {
+					assert.Equal(t, []byte("data"), actual.Image())
+				}
+			}
+		}
+	})
+
+	t.Run("when quorum reached, succeeds despite failed replica", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		failing := mock_photo.NewMockRepository(ctrl)
+		failing.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil, errors.New("disk full"))
+
+		instance, _ := New(1, createReplica(t), mockReplica(failing))
+		_, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []bool{true, false}, instance.Healthy())
+		}
+	})
+
+	t.Run("when quorum not reached, returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		failing := mock_photo.NewMockRepository(ctrl)
+		failing.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil, errors.New("disk full"))
+
+		instance, _ := New(2, createReplica(t), mockReplica(failing))
+		_, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		assert.Error(t, err)
+	})
+}
+
+func TestReplicatedStorage_Read(t *testing.T) {
+	t.Run("reads first replica only", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		first := createReplica(t)
+		instance, _ := New(1, first, mockReplica(mock_photo.NewMockRepository(ctrl)))
+		id := photo.IdentifierOf("id")
+		if _, err := first.Photos.Save(context.Background(), *photo.Of(*id, []byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("data"), actual.Image())
+		}
+	})
+
+	t.Run("when first replica fails, falls back to next", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		failing := mock_photo.NewMockRepository(ctrl)
+		failing.EXPECT().Read(gomock.Any(), *id).Return(nil, errors.New("io error"))
+
+		second := createReplica(t)
+		if _, err := second.Photos.Save(context.Background(), *photo.Of(*id, []byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+
+		instance, _ := New(2, mockReplica(failing), second)
+		actual, err := instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("data"), actual.Image())
+			assert.Equal(t, []bool{false, true}, instance.Healthy())
+		}
+	})
+
+	t.Run("when replica misses photo, falls back and repairs it", func(t *testing.T) {
+		first, second := createReplica(t), createReplica(t)
+		id := photo.IdentifierOf("id")
+		if _, err := second.Photos.Save(context.Background(), *photo.Of(*id, []byte("data"), "tag")); err != nil {
+			t.Fatal(err)
+		}
+
+		instance, _ := New(1, first, second)
+		if _, err := instance.Read(context.Background(), *id); err != nil {
+			t.Fatal(err)
+		}
+
+		repaired, err := first.Photos.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("data"), repaired.Image())
+			assert.Equal(t, []string{"tag"}, repaired.Tags())
+		}
+	})
+
+	t.Run("when first replica deleted photo, returns not found", func(t *testing.T) {
+		first, second := createReplica(t), createReplica(t)
+		instance, _ := New(1, first, second)
+		id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stale := snapshot(t, second, *id)
+		if err := instance.Delete(context.Background(), *id); err != nil {
+			t.Fatal(err)
+		}
+		stale.restore(t)
+
+		_, err = instance.Read(context.Background(), *id)
+		if assert.Error(t, err) {
+			assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+		}
+	})
+
+	t.Run("when every replica fails, returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		failing := mock_photo.NewMockRepository(ctrl)
+		failing.EXPECT().Read(gomock.Any(), gomock.Any()).Return(nil, errors.New("io error")).Times(2)
+
+		instance, _ := New(1, mockReplica(failing), mockReplica(failing))
+		_, err := instance.Read(context.Background(), *photo.IdentifierOf("id"))
+		if assert.Error(t, err) {
+			assert.Equal(t, []bool{false, false}, instance.Healthy())
+		}
+	})
+
+	t.Run("when no replica has photo, returns not found", func(t *testing.T) {
+		instance, _ := New(1, createReplica(t), createReplica(t))
+		_, err := instance.Read(context.Background(), *photo.IdentifierOf("noKey"))
+		if assert.Error(t, err) {
+			assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+		}
+	})
+}
+
+func TestReplicatedStorage_ChecksumOf(t *testing.T) {
+	t.Run("returns checksum of first replica holding photo", func(t *testing.T) {
+		first, second := createReplica(t), createReplica(t)
+		id := photo.IdentifierOf("id")
+		if _, err := second.Photos.Save(context.Background(), *photo.Of(*id, []byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+
+		instance, _ := New(1, first, second)
+		checksum, err := instance.ChecksumOf(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, photo.Checksum([]byte("data")), checksum)
+		}
+	})
+
+	t.Run("when first replica deleted photo, returns not found", func(t *testing.T) {
+		first, second := createReplica(t), createReplica(t)
+		instance, _ := New(1, first, second)
+		id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stale := snapshot(t, second, *id)
+		if err := instance.Delete(context.Background(), *id); err != nil {
+			t.Fatal(err)
+		}
+		stale.restore(t)
+
+		_, err = instance.ChecksumOf(context.Background(), *id)
+		if assert.Error(t, err) {
+			assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+		}
+	})
+}
+
+func TestReplicatedStorage_Delete(t *testing.T) {
+	t.Run("removes photo and records tombstone apart from tags", func(t *testing.T) {
+		first, second := createReplica(t), createReplica(t)
+		id := photo.IdentifierOf("id")
+		if _, err := first.Photos.Save(context.Background(), *photo.Of(*id, []byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+
+		instance, _ := New(2, first, second)
+		if assert.NoError(t, instance.Delete(context.Background(), *id)) {
+			for _, replica := range []Replica{first, second} {
+				_, err := replica.Photos.Read(context.Background(), *id)
+				if assert.Error(t, err) {
+					assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+				}
+				ids, err := replica.Photos.FindAll(context.Background())
+				if assert.NoError(t, err) {
+					assert.Empty(t, ids)
+				}
+				version, err := replica.Versions.Get(context.Background(), *id)
+				if assert.NoError(t, err) {
+					assert.True(t, version.Deleted)
+				}
+			}
+		}
+	})
+
+	t.Run("when replica missed delete, photo does not come back", func(t *testing.T) {
+		first, second, third := createReplica(t), createReplica(t), createReplica(t)
+		instance, _ := New(2, first, second, third)
+		id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stale := snapshot(t, third, *id)
+		if err := instance.Delete(context.Background(), *id); err != nil {
+			t.Fatal(err)
+		}
+		stale.restore(t)
+
+		for i := 0; i < 3; i++ {
+			_, err := instance.Read(context.Background(), *id)
+			if assert.Error(t, err) {
+				assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+			}
+		}
+		ids, err := instance.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.Empty(t, ids)
+		}
+	})
+
+	t.Run("with invalid identifier, returns error", func(t *testing.T) {
+		instance, _ := New(1, createReplica(t))
+		err := instance.Delete(context.Background(), *photo.IdentifierOf("../../x"))
+		if assert.Error(t, err) {
+			assert.Equal(t, photo.ErrInvalidIdentifier, err.(*photo.ResourceError).Err)
+		}
+	})
+
+	t.Run("when context is cancelled after quorum acknowledged, succeeds", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		fast := createReplica(t)
+		notifying := &notifyingReplica{Repository: fast.Photos, deleted: make(chan struct{})}
+		slow := mock_photo.NewMockRepository(ctrl)
+		slow.EXPECT().Delete(gomock.Any(), gomock.Any()).
+			Do(func(ctx context.Context, id photo.Identifier) {
+				<-notifying.deleted
+				cancel()
+			}).
+			Return(context.Canceled)
+
+		instance, _ := New(1, Replica{notifying, fast.Versions}, mockReplica(slow))
+		assert.NoError(t, instance.Delete(ctx, *photo.IdentifierOf("id")))
+	})
+}
+
+func TestReplicatedStorage_Purge(t *testing.T) {
+	t.Run("when every replica agrees, removes tombstones", func(t *testing.T) {
+		first, second := createReplica(t), createReplica(t)
+		instance, _ := New(2, first, second)
+		id := deleted(t, instance)
+
+		purged, err := instance.Purge(context.Background(), time.Now())
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, purged)
+			for _, replica := range []Replica{first, second} {
+				assertNoVersion(t, replica, *id)
+			}
+		}
+	})
+
+	t.Run("purges agreed tombstones in the background until closed", func(t *testing.T) {
+		defer func(interval, grace time.Duration) {
+			purgeInterval, tombstoneGrace = interval, grace
+		}(purgeInterval, tombstoneGrace)
+		purgeInterval, tombstoneGrace = 10*time.Millisecond, 0
+
+		first := createReplica(t)
+		instance, _ := New(1, first)
+		id := deleted(t, instance)
+
+		assert.Eventually(t, func() bool {
+			_, err := first.Versions.Get(context.Background(), *id)
+			return err != nil
+		}, time.Second, 10*time.Millisecond)
+		assert.NoError(t, instance.Close())
+	})
+
+	t.Run("with recent tombstones, keeps them", func(t *testing.T) {
+		first := createReplica(t)
+		instance, _ := New(1, first)
+		id := deleted(t, instance)
+
+		purged, err := instance.Purge(context.Background(), time.Now().Add(-time.Hour))
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, purged)
+			_, err := first.Versions.Get(context.Background(), *id)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("when replica holds older copy, repairs it before removing tombstones", func(t *testing.T) {
+		first, second := createReplica(t), createReplica(t)
+		instance, _ := New(1, first, second)
+		id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stale := snapshot(t, second, *id)
+		if err := instance.Delete(context.Background(), *id); err != nil {
+			t.Fatal(err)
+		}
+		stale.restore(t)
+
+		purged, err := instance.Purge(context.Background(), time.Now())
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, purged)
+			ids, err := instance.FindAll(context.Background())
+			if assert.NoError(t, err) {
+				assert.Empty(t, ids)
+			}
+			assertNoVersion(t, second, *id)
+		}
+	})
+
+	t.Run("when replica fails, keeps tombstones", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		first := createReplica(t)
+		single, _ := New(1, first)
+		id := deleted(t, single)
+		failing := mock_photo.NewMockRepository(ctrl)
+		failing.EXPECT().Read(gomock.Any(), *id).Return(nil, errors.New("io error"))
+
+		instance, _ := New(1, first, mockReplica(failing))
+		_, err := instance.Purge(context.Background(), time.Now())
+		assert.Error(t, err)
+		_, err = first.Versions.Get(context.Background(), *id)
+		assert.NoError(t, err)
+	})
+}
+
+func TestReplicatedStorage_FindAll(t *testing.T) {
+	first, second := createReplica(t), createReplica(t)
+	if _, err := first.Photos.Save(context.Background(), *photo.Of(*photo.IdentifierOf("a"), []byte("a"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Photos.Save(context.Background(), *photo.Of(*photo.IdentifierOf("b"), []byte("b"))); err != nil {
+		t.Fatal(err)
+	}
+
+	instance, _ := New(1, first, second)
+	ids, err := instance.FindAll(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("a"), *photo.IdentifierOf("b")}, ids)
+	}
+}
+
+func TestReplicatedStorage_Conformance(t *testing.T) {
+	phototest.RunRepositoryTests(t, func(t *testing.T) photo.Repository {
+		instance, err := New(2, createReplica(t), createReplica(t), createReplica(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return instance
+	})
+}
+
+func createReplica(tb testing.TB) Replica {
+	tb.Helper()
+	dir, err := ioutil.TempDir("", "replicated_storage")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+	storage := file_storage.New(dir)
+	return Replica{storage, file_storage.NewVersionLog(storage)}
+}
+
+func mockReplica(repository photo.Repository) Replica {
+	return Replica{repository, memory_storage.NewVersionLog()}
+}
+
+func deleted(tb testing.TB, instance *ReplicatedStorage) *photo.Identifier {
+	tb.Helper()
+	id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := instance.Delete(context.Background(), *id); err != nil {
+		tb.Fatal(err)
+	}
+	return id
+}
+
+// stale is the copy a replica held of a photo, to write back as if the replica missed the later writes.
+type stale struct {
+	replica    Replica
+	photograph *photo.Photo
+	version    *photo.Version
+}
+
+func snapshot(tb testing.TB, replica Replica, id photo.Identifier) *stale {
+	tb.Helper()
+	photograph, err := replica.Photos.Read(context.Background(), id)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	version, err := replica.Versions.Get(context.Background(), id)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return &stale{replica, photograph, version}
+}
+
+func (copy *stale) restore(tb testing.TB) {
+	tb.Helper()
+	if _, err := copy.replica.Photos.Save(context.Background(), *copy.photograph); err != nil {
+		tb.Fatal(err)
+	}
+	if err := copy.replica.Versions.Put(context.Background(), *copy.photograph.Id(), *copy.version); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func assertNoVersion(t *testing.T, replica Replica, id photo.Identifier) {
+	t.Helper()
+	_, err := replica.Versions.Get(context.Background(), id)
+	if assert.Error(t, err) {
+		assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+	}
+}
+
+type notifyingReplica struct {
+	photo.Repository
+	deleted chan struct{}
+}
+
+func (replica *notifyingReplica) Delete(ctx context.Context, id photo.Identifier) error {
+	defer close(replica.deleted)
+	return replica.Repository.Delete(ctx, id)
+}
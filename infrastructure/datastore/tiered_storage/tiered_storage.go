@@ -0,0 +1,620 @@
+package tiered_storage
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"hash/fnv"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	queueSize   = 1024
+	lockStripes = 64
+)
+
+var (
+	// minBackoff and maxBackoff bound the delay before a failed write behind is retried.
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = time.Minute
+)
+
+var ErrClosed = errors.New("tiered storage is closed")
+
+type Options struct {
+	MaxBytes    int64
+	MaxItems    int
+	WriteBehind bool
+}
+
+type TieredStorage struct {
+	hot     photo.Repository
+	cold    photo.Repository
+	options Options
+
+	// mu guards the LRU bookkeeping only, never the I/O of the tiers.
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[photo.Identifier]*list.Element
+	bytes   int64
+	dirty   map[photo.Identifier]int
+
+	// locks serialize the writes of a photo to both tiers.
+	locks [lockStripes]sync.Mutex
+
+	queue   chan queued
+	queueMu sync.RWMutex
+	closed  bool
+	done    chan struct{}
+}
+
+type entry struct {
+	id   photo.Identifier
+	size int64
+}
+
+// queued is a photo to write behind, with the context of the request which saved it.
+type queued struct {
+	ctx      context.Context
+	id       photo.Identifier
+	attempts int
+	due      time.Time
+}
+
+func New(hot photo.Repository, cold photo.Repository, options Options) (*TieredStorage, error) {
+	storage := &TieredStorage{
+		hot:     hot,
+		cold:    cold,
+		options: options,
+		lru:     list.New(),
+		entries: make(map[photo.Identifier]*list.Element),
+		dirty:   make(map[photo.Identifier]int),
+	}
+
+	ctx := context.Background()
+	sizes, err := photo.SizesOf(ctx, hot)
+	if err != nil {
+		return nil, err
+	}
+	var ids []photo.Identifier
+	for id := range sizes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Value() < ids[j].Value()
+	})
+	for _, id := range ids {
+		storage.touch(id, sizes[id])
+	}
+
+	var pending []photo.Identifier
+	if options.WriteBehind {
+		coldIds, err := cold.FindAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stored := make(map[photo.Identifier]bool)
+		for _, id := range coldIds {
+			stored[id] = true
+		}
+		for _, id := range ids {
+			if !stored[id] {
+				storage.dirty[id]++
+				pending = append(pending, id)
+			}
+		}
+	}
+
+	storage.mu.Lock()
+	victims := storage.victims()
+	storage.mu.Unlock()
+	if err := storage.evict(victims); err != nil {
+		return nil, err
+	}
+
+	if options.WriteBehind {
+		storage.queue = make(chan queued, queueSize+len(pending))
+		storage.done = make(chan struct{})
+		for _, id := range pending {
+			storage.queue <- queued{ctx: ctx, id: id}
+		}
+		go storage.writeBehind()
+	}
+	return storage, nil
+}
+
+func (storage *TieredStorage) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	id := photograph.Id()
+	if photograph.IsNew() {
+		id = photo.NewIdentifier(photograph.Image())
+	}
+	photograph = *photo.Of(*id, photograph.Image(), photograph.Tags()...)
+
+	storage.queueMu.RLock()
+	defer storage.queueMu.RUnlock()
+	if storage.closed {
+		return nil, &photo.ResourceError{Id: *id, Err: ErrClosed}
+	}
+
+	if err := storage.save(ctx, photograph); err != nil {
+		return nil, err
+	}
+	if storage.options.WriteBehind {
+		storage.queue <- queued{ctx: context.WithoutCancel(ctx), id: *id}
+	}
+	return id, nil
+}
+
+// save writes a photo through to the cold tier, or marks it dirty, and caches it in the hot tier.
+func (storage *TieredStorage) save(ctx context.Context, photograph photo.Photo) error {
+	victims, err := storage.put(ctx, photograph)
+	if err != nil {
+		return err
+	}
+	return storage.evict(victims)
+}
+
+func (storage *TieredStorage) put(ctx context.Context, photograph photo.Photo) ([]*entry, error) {
+	unlock := storage.lock(*photograph.Id())
+	defer unlock()
+
+	if !storage.options.WriteBehind {
+		if _, err := storage.cold.Save(ctx, photograph); err != nil {
+			return nil, err
+		}
+	}
+	return storage.store(ctx, photograph, storage.options.WriteBehind)
+}
+
+func (storage *TieredStorage) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	storage.mu.Lock()
+	element, ok := storage.entries[id]
+	if ok {
+		storage.lru.MoveToFront(element)
+	}
+	storage.mu.Unlock()
+
+	if ok {
+		photograph, err := storage.hot.Read(ctx, id)
+		if !isNotFound(err) {
+			return photograph, err
+		}
+	}
+
+	photograph, victims, err := storage.fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.evict(victims); err != nil {
+		slog.WarnContext(ctx, "eviction failed", "error", err)
+	}
+	return photograph, nil
+}
+
+// fetch reads a photo from the cold tier and promotes it.
+func (storage *TieredStorage) fetch(ctx context.Context, id photo.Identifier) (*photo.Photo, []*entry, error) {
+	unlock := storage.lock(id)
+	defer unlock()
+
+	photograph, err := storage.cold.Read(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	victims, err := storage.promote(ctx, *photograph)
+	if err != nil {
+		slog.WarnContext(ctx, "promotion failed", "photo_id", id.Value(), "error", err)
+	}
+	return photograph, victims, nil
+}
+
+// Peek reads a photo without promoting it to the hot tier or moving it in the LRU,
+// so that scrubbing or counting photos doesn't evict the ones in use.
+func (storage *TieredStorage) Peek(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	storage.mu.Lock()
+	_, ok := storage.entries[id]
+	storage.mu.Unlock()
+
+	if ok {
+		photograph, err := storage.hot.Read(ctx, id)
+		if !isNotFound(err) {
+			return photograph, err
+		}
+	}
+	return photo.Peek(ctx, storage.cold, id)
+}
+
+// TagsOf reads the tags from the tier holding the photo, without promoting it.
+func (storage *TieredStorage) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	if storage.cached(id) {
+		tags, err := photo.TagsOf(ctx, storage.hot, id)
+		if !isNotFound(err) {
+			return tags, err
+		}
+	}
+	return photo.TagsOf(ctx, storage.cold, id)
+}
+
+func (storage *TieredStorage) Delete(ctx context.Context, id photo.Identifier) error {
+	unlock := storage.lock(id)
+	defer unlock()
+
+	if storage.cached(id) {
+		if err := storage.hot.Delete(ctx, id); err != nil {
+			return err
+		}
+		storage.mu.Lock()
+		if element, ok := storage.entries[id]; ok {
+			storage.remove(element)
+		}
+		storage.mu.Unlock()
+	}
+
+	err := storage.cold.Delete(ctx, id)
+	if err != nil {
+		if _, readErr := storage.cold.Read(ctx, id); isNotFound(readErr) {
+			return nil
+		}
+	}
+	return err
+}
+
+func (storage *TieredStorage) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	return storage.union(func(repository photo.Repository) ([]photo.Identifier, error) {
+		return repository.FindAll(ctx)
+	})
+}
+
+func (storage *TieredStorage) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	return storage.union(func(repository photo.Repository) ([]photo.Identifier, error) {
+		return repository.FindByTag(ctx, tag)
+	})
+}
+
+func (storage *TieredStorage) Checksums(ctx context.Context) (map[photo.Identifier]string, error) {
+	cold, ok := storage.cold.(photo.ChecksumRepository)
+	if !ok {
+		return nil, photo.ErrNoChecksums
+	}
+
+	dirty := storage.pending()
+	checksums, err := cold.Checksums(ctx)
+	if err != nil || len(dirty) == 0 {
+		return checksums, err
+	}
+	hot, ok := storage.hot.(photo.ChecksumRepository)
+	if !ok {
+		return checksums, nil
+	}
+	pending, err := hot.Checksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for id := range dirty {
+		if checksum, ok := pending[id]; ok {
+			checksums[id] = checksum
+		}
+	}
+	return checksums, nil
+}
+
+func (storage *TieredStorage) ChecksumOf(ctx context.Context, id photo.Identifier) (string, error) {
+	cold, ok := storage.cold.(photo.ChecksumRepository)
+	if !ok {
+		return "", photo.ErrNoChecksums
+	}
+
+	storage.mu.Lock()
+	dirty := storage.dirty[id] > 0
+	storage.mu.Unlock()
+
+	if hot, ok := storage.hot.(photo.ChecksumRepository); ok && dirty {
+		return hot.ChecksumOf(ctx, id)
+	}
+	return cold.ChecksumOf(ctx, id)
+}
+
+// Stats are those of the cold tier, counting photos not written behind yet as cached in the hot tier.
+func (storage *TieredStorage) Stats(ctx context.Context) (*photo.Stats, error) {
+	if len(storage.pending()) == 0 {
+		return photo.StatsOf(ctx, storage.cold)
+	}
+	sizes, err := storage.Sizes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := &photo.Stats{}
+	for _, size := range sizes {
+		stats.Count++
+		stats.Bytes += size
+	}
+	return stats, nil
+}
+
+// Sizes are those of the cold tier, with photos not written behind yet sized as cached in the hot tier.
+func (storage *TieredStorage) Sizes(ctx context.Context) (map[photo.Identifier]int64, error) {
+	pending := storage.pending()
+	sizes, err := photo.SizesOf(ctx, storage.cold)
+	if err != nil {
+		return nil, err
+	}
+	for id, size := range pending {
+		sizes[id] = size
+	}
+	return sizes, nil
+}
+
+// pending returns the sizes of the photos not written behind yet.
+func (storage *TieredStorage) pending() map[photo.Identifier]int64 {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	pending := make(map[photo.Identifier]int64)
+	for id := range storage.dirty {
+		if element, ok := storage.entries[id]; ok {
+			pending[id] = element.Value.(*entry).size
+		}
+	}
+	return pending
+}
+
+// Flush writes pending photos behind. Saving fails with ErrClosed after it.
+func (storage *TieredStorage) Flush() {
+	storage.queueMu.Lock()
+	if !storage.closed && storage.options.WriteBehind {
+		close(storage.queue)
+	}
+	storage.closed = true
+	storage.queueMu.Unlock()
+
+	if storage.options.WriteBehind {
+		<-storage.done
+	}
+}
+
+// Close writes pending photos behind, then closes both tiers.
+func (storage *TieredStorage) Close() error {
+	storage.Flush()
+	err := storage.hot.Close()
+	if e := storage.cold.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (storage *TieredStorage) Ping(ctx context.Context) error {
+	if err := photo.Ping(ctx, storage.hot); err != nil {
+		return err
+	}
+	return photo.Ping(ctx, storage.cold)
+}
+
+// writeBehind writes queued photos to the cold tier. A failed write is retried with exponential backoff,
+// and once more when flushing. A photo still failing stays dirty in the hot tier, and New resumes writing it.
+func (storage *TieredStorage) writeBehind() {
+	defer close(storage.done)
+
+	var retries []queued
+	timer := time.NewTimer(maxBackoff)
+	stop(timer)
+	for {
+		var wake <-chan time.Time
+		if len(retries) > 0 {
+			wake = timer.C
+		}
+
+		select {
+		case q, ok := <-storage.queue:
+			if !ok {
+				for _, q := range retries {
+					storage.write(q)
+				}
+				return
+			}
+			if !storage.write(q) {
+				retries = schedule(retries, q)
+			}
+		case <-wake:
+			q := retries[0]
+			retries = retries[1:]
+			if !storage.write(q) {
+				retries = schedule(retries, q)
+			}
+		}
+
+		stop(timer)
+		if len(retries) > 0 {
+			timer.Reset(time.Until(retries[0].due))
+		}
+	}
+}
+
+// write copies a photo from the hot tier to the cold one, and tells whether it is no longer dirty.
+func (storage *TieredStorage) write(q queued) bool {
+	unlock := storage.lock(q.id)
+	defer unlock()
+
+	photograph, err := storage.hot.Read(q.ctx, q.id)
+	if err == nil {
+		_, err = storage.cold.Save(q.ctx, *photograph)
+	} else if isNotFound(err) {
+		err = nil
+	}
+	if err != nil {
+		slog.ErrorContext(q.ctx, "write behind failed", "photo_id", q.id.Value(), "attempts", q.attempts+1, "error", err)
+		return false
+	}
+
+	storage.mu.Lock()
+	if storage.dirty[q.id]--; storage.dirty[q.id] <= 0 {
+		delete(storage.dirty, q.id)
+	}
+	storage.mu.Unlock()
+	return true
+}
+
+// schedule adds a failed write to the retries ordered by when they are due.
+func schedule(retries []queued, q queued) []queued {
+	backoff := maxBackoff
+	if q.attempts < 32 && minBackoff<<q.attempts < maxBackoff {
+		backoff = minBackoff << q.attempts
+	}
+	q.attempts++
+	q.due = time.Now().Add(backoff)
+
+	i := sort.Search(len(retries), func(i int) bool {
+		return retries[i].due.After(q.due)
+	})
+	retries = append(retries, queued{})
+	copy(retries[i+1:], retries[i:])
+	retries[i] = q
+	return retries
+}
+
+func stop(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
+func (storage *TieredStorage) promote(ctx context.Context, photograph photo.Photo) ([]*entry, error) {
+	if storage.cached(*photograph.Id()) {
+		return nil, nil
+	}
+	return storage.store(ctx, photograph, false)
+}
+
+// store caches a photo in the hot tier, and returns the photos to evict for it. The caller holds the lock of the photo.
+func (storage *TieredStorage) store(ctx context.Context, photograph photo.Photo, dirty bool) ([]*entry, error) {
+	if _, err := storage.hot.Save(ctx, photograph); err != nil {
+		return nil, err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	storage.touch(*photograph.Id(), int64(len(photograph.Image())))
+	if dirty {
+		storage.dirty[*photograph.Id()]++
+	}
+	return storage.victims(), nil
+}
+
+func (storage *TieredStorage) cached(id photo.Identifier) bool {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	_, ok := storage.entries[id]
+	return ok
+}
+
+func (storage *TieredStorage) union(find func(repository photo.Repository) ([]photo.Identifier, error)) ([]photo.Identifier, error) {
+	found := make(map[photo.Identifier]bool)
+	for _, repository := range []photo.Repository{storage.hot, storage.cold} {
+		ids, err := find(repository)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			found[id] = true
+		}
+	}
+
+	var ids []photo.Identifier
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Value() < ids[j].Value()
+	})
+	return ids, nil
+}
+
+func (storage *TieredStorage) touch(id photo.Identifier, size int64) {
+	if element, ok := storage.entries[id]; ok {
+		storage.bytes += size - element.Value.(*entry).size
+		element.Value.(*entry).size = size
+		storage.lru.MoveToFront(element)
+		return
+	}
+	storage.entries[id] = storage.lru.PushFront(&entry{id, size})
+	storage.bytes += size
+}
+
+func (storage *TieredStorage) remove(element *list.Element) {
+	e := element.Value.(*entry)
+	storage.lru.Remove(element)
+	delete(storage.entries, e.id)
+	storage.bytes -= e.size
+}
+
+// victims takes the least recently used clean photos out of the LRU until it is within budget.
+// The caller holds mu, and deletes them from the hot tier with evict.
+func (storage *TieredStorage) victims() []*entry {
+	var victims []*entry
+	element := storage.lru.Back()
+	for element != nil && storage.overBudget() {
+		prev := element.Prev()
+		e := element.Value.(*entry)
+		if storage.dirty[e.id] == 0 {
+			storage.remove(element)
+			victims = append(victims, e)
+		}
+		element = prev
+	}
+	return victims
+}
+
+// evict deletes the victims from the hot tier, unless they were cached again meanwhile.
+// A victim failing to be deleted is put back as the least recently used photo.
+func (storage *TieredStorage) evict(victims []*entry) error {
+	for _, e := range victims {
+		if err := storage.drop(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (storage *TieredStorage) drop(e *entry) error {
+	unlock := storage.lock(e.id)
+	defer unlock()
+
+	if storage.cached(e.id) {
+		return nil
+	}
+	if err := storage.hot.Delete(context.Background(), e.id); err != nil {
+		storage.mu.Lock()
+		storage.entries[e.id] = storage.lru.PushBack(e)
+		storage.bytes += e.size
+		storage.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (storage *TieredStorage) overBudget() bool {
+	if storage.options.MaxBytes > 0 && storage.bytes > storage.options.MaxBytes {
+		return true
+	}
+	return storage.options.MaxItems > 0 && storage.lru.Len() > storage.options.MaxItems
+}
+
+func (storage *TieredStorage) lock(id photo.Identifier) func() {
+	h := fnv.New32a()
+	h.Write([]byte(id.Value()))
+	mu := &storage.locks[h.Sum32()%lockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+func isNotFound(err error) bool {
+	if e, ok := err.(*photo.ResourceError); ok {
+		return e.Err == photo.ErrNotFound
+	}
+	return false
+}
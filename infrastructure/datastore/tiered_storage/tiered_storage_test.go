@@ -0,0 +1,480 @@
+package tiered_storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/file_storage"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("with existing hot photos over budget, evicts them", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		for _, id := range []string{"a", "b", "c"} {
+			if _, err := hot.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), []byte(id))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := New(hot, cold, Options{MaxItems: 2}); err != nil {
+			t.Fatal(err)
+		}
+		ids, err := hot.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.Len(t, ids, 2)
+		}
+	})
+
+	t.Run("with existing hot photos, sizes them without reading", func(t *testing.T) {
+		hot, cold := &countingRepository{FileStorage: createTier(t)}, createTier(t)
+		for _, id := range []string{"a", "b"} {
+			if _, err := hot.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), []byte("123456"))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		instance, err := New(hot, cold, Options{})
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, hot.reads)
+			assert.Equal(t, int64(12), instance.bytes)
+		}
+	})
+
+	t.Run("write behind, resumes writing hot photos missing in cold", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		id := photo.IdentifierOf("unsynced")
+		if _, err := hot.Save(context.Background(), *photo.Of(*id, []byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+
+		instance, err := New(hot, cold, Options{WriteBehind: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		instance.Flush()
+
+		_, err = cold.Read(context.Background(), *id)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTieredStorage_Save(t *testing.T) {
+	t.Run("write through, saves to both tiers", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		instance, _ := New(hot, cold, Options{})
+
+		id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		if assert.NoError(t, err) {
+			_, err := hot.Read(context.Background(), *id)
+			assert.NoError(t, err)
+			_, err = cold.Read(context.Background(), *id)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("write behind, saves to cold after flush", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		instance, _ := New(hot, cold, Options{WriteBehind: true, MaxItems: 1})
+
+		first, err := instance.Save(context.Background(), *photo.New([]byte("first")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := instance.Save(context.Background(), *photo.New([]byte("second")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		instance.Flush()
+
+		for _, id := range []*photo.Identifier{first, second} {
+			actual, err := cold.Read(context.Background(), *id)
+			if assert.NoError(t, err) {
+				assert.NotEmpty(t, actual.Image())
+			}
+		}
+	})
+
+	t.Run("write behind, retries failed writes", func(t *testing.T) {
+		previous := minBackoff
+		minBackoff = time.Millisecond
+		t.Cleanup(func() { minBackoff = previous })
+
+		hot, cold := createTier(t), &failingRepository{FileStorage: createTier(t), failures: 3}
+		instance, _ := New(hot, cold, Options{WriteBehind: true})
+
+		id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 100 && cold.remaining() > 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		instance.Flush()
+
+		_, err = cold.Read(context.Background(), *id)
+		assert.NoError(t, err)
+		assert.Empty(t, instance.dirty)
+	})
+
+	t.Run("write behind, keeps photos failing when flushing dirty", func(t *testing.T) {
+		hot, cold := createTier(t), &failingRepository{FileStorage: createTier(t), failures: 2}
+		instance, _ := New(hot, cold, Options{WriteBehind: true})
+
+		id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		instance.Flush()
+
+		_, err = hot.Read(context.Background(), *id)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, instance.dirty[*id])
+	})
+
+	t.Run("concurrent saves of a photo, keep both tiers equal", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		instance, _ := New(hot, cold, Options{})
+		id := photo.IdentifierOf("id")
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if _, err := instance.Save(context.Background(), *photo.Of(*id, []byte(fmt.Sprint(i)))); err != nil {
+					t.Error(err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		cached, err := hot.Read(context.Background(), *id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored, err := cold.Read(context.Background(), *id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, bytes.Equal(cached.Image(), stored.Image()), "hot %s, cold %s", cached.Image(), stored.Image())
+	})
+
+	t.Run("while the hot tier writes a photo, saves other photos", func(t *testing.T) {
+		hot := &blockingRepository{FileStorage: createTier(t), id: *photo.IdentifierOf("slow"), entered: make(chan struct{}), release: make(chan struct{})}
+		instance, _ := New(hot, createTier(t), Options{WriteBehind: true})
+		defer instance.Flush()
+
+		slow := make(chan error)
+		go func() {
+			_, err := instance.Save(context.Background(), *photo.Of(hot.id, []byte("slow")))
+			slow <- err
+		}()
+		<-hot.entered
+		fast := make(chan error)
+		go func() {
+			_, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("fast"), []byte("fast")))
+			fast <- err
+		}()
+
+		select {
+		case err := <-fast:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Error("save of another photo waited for the hot tier")
+		}
+		close(hot.release)
+		assert.NoError(t, <-slow)
+	})
+
+	t.Run("after flush, returns closed", func(t *testing.T) {
+		for _, options := range []Options{{}, {WriteBehind: true}} {
+			instance, _ := New(createTier(t), createTier(t), options)
+			instance.Flush()
+
+			_, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("id"), []byte("data")))
+			if assert.Error(t, err) {
+				assert.Equal(t, ErrClosed, err.(*photo.ResourceError).Err)
+			}
+		}
+	})
+
+	t.Run("over item budget, evicts least recently used", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		instance, _ := New(hot, cold, Options{MaxItems: 2})
+
+		for _, id := range []string{"a", "b"} {
+			if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), []byte(id))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := instance.Read(context.Background(), *photo.IdentifierOf("a")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("c"), []byte("c"))); err != nil {
+			t.Fatal(err)
+		}
+
+		ids, err := hot.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.ElementsMatch(t, []photo.Identifier{*photo.IdentifierOf("a"), *photo.IdentifierOf("c")}, ids)
+		}
+	})
+
+	t.Run("over byte budget, evicts until within budget", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		instance, _ := New(hot, cold, Options{MaxBytes: 10})
+
+		for _, id := range []string{"a", "b"} {
+			if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf(id), []byte("123456"))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ids, err := hot.FindAll(context.Background())
+		if assert.NoError(t, err) {
+			assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("b")}, ids)
+		}
+	})
+}
+
+func TestTieredStorage_Read(t *testing.T) {
+	t.Run("on miss, reads cold and promotes", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		id := photo.IdentifierOf("id")
+		if _, err := cold.Save(context.Background(), *photo.Of(*id, []byte("data"), "tag")); err != nil {
+			t.Fatal(err)
+		}
+
+		instance, _ := New(hot, cold, Options{})
+		actual, err := instance.Read(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("data"), actual.Image())
+
+			promoted, err := hot.Read(context.Background(), *id)
+			if assert.NoError(t, err) {
+				assert.Equal(t, []string{"tag"}, promoted.Tags())
+			}
+		}
+	})
+
+	t.Run("with no photo, returns not found", func(t *testing.T) {
+		instance, _ := New(createTier(t), createTier(t), Options{})
+		_, err := instance.Read(context.Background(), *photo.IdentifierOf("noKey"))
+		if assert.Error(t, err) {
+			assert.Equal(t, photo.ErrNotFound, err.(*photo.ResourceError).Err)
+		}
+	})
+}
+
+func TestTieredStorage_Peek(t *testing.T) {
+	t.Run("on miss, reads cold without promoting", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		id := photo.IdentifierOf("id")
+		if _, err := cold.Save(context.Background(), *photo.Of(*id, []byte("data"))); err != nil {
+			t.Fatal(err)
+		}
+
+		instance, _ := New(hot, cold, Options{})
+		actual, err := instance.Peek(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("data"), actual.Image())
+			_, err := hot.Read(context.Background(), *id)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("on hit, keeps least recently used order", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		instance, _ := New(hot, cold, Options{MaxItems: 2})
+		first, _ := instance.Save(context.Background(), *photo.New([]byte("first")))
+		second, _ := instance.Save(context.Background(), *photo.New([]byte("second")))
+
+		if _, err := instance.Peek(context.Background(), *first); err != nil {
+			t.Fatal(err)
+		}
+		instance.Save(context.Background(), *photo.New([]byte("third")))
+
+		_, err := hot.Read(context.Background(), *first)
+		assert.Error(t, err)
+		_, err = hot.Read(context.Background(), *second)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTieredStorage_TagsOf(t *testing.T) {
+	t.Run("on miss, reads cold tags without promoting", func(t *testing.T) {
+		hot, cold := createTier(t), createTier(t)
+		id := photo.IdentifierOf("id")
+		if _, err := cold.Save(context.Background(), *photo.Of(*id, []byte("data"), "cat")); err != nil {
+			t.Fatal(err)
+		}
+
+		instance, _ := New(hot, cold, Options{})
+		tags, err := instance.TagsOf(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"cat"}, tags)
+			_, err := hot.Read(context.Background(), *id)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("with pending write behind, reads hot tags", func(t *testing.T) {
+		hot := createTier(t)
+		cold := &failingRepository{FileStorage: createTier(t), failures: 1 << 30}
+		instance, _ := New(hot, cold, Options{WriteBehind: true})
+		id, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("id"), []byte("data"), "cat"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tags, err := instance.TagsOf(context.Background(), *id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"cat"}, tags)
+		}
+	})
+}
+
+func TestTieredStorage_Stats(t *testing.T) {
+	hot := createTier(t)
+	cold := &failingRepository{FileStorage: createTier(t), failures: 1 << 30}
+	if _, err := cold.FileStorage.Save(context.Background(), *photo.Of(*photo.IdentifierOf("cold"), []byte("cold"))); err != nil {
+		t.Fatal(err)
+	}
+	instance, _ := New(hot, cold, Options{WriteBehind: true})
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("hot"), []byte("hot"))); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := instance.Stats(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, &photo.Stats{Count: 2, Bytes: 7}, stats)
+	}
+	sizes, err := instance.Sizes(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[photo.Identifier]int64{*photo.IdentifierOf("cold"): 4, *photo.IdentifierOf("hot"): 3}, sizes)
+	}
+	_, err = hot.Read(context.Background(), *photo.IdentifierOf("cold"))
+	assert.Error(t, err, "counting should not promote cold photos")
+
+	cold.mu.Lock()
+	cold.failures = 0
+	cold.mu.Unlock()
+	instance.Flush()
+}
+
+func TestTieredStorage_Delete(t *testing.T) {
+	hot, cold := createTier(t), createTier(t)
+	instance, _ := New(hot, cold, Options{})
+	id, err := instance.Save(context.Background(), *photo.New([]byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.NoError(t, instance.Delete(context.Background(), *id)) {
+		_, err := instance.Read(context.Background(), *id)
+		assert.Error(t, err)
+		_, err = hot.Read(context.Background(), *id)
+		assert.Error(t, err)
+	}
+}
+
+func TestTieredStorage_FindAll(t *testing.T) {
+	hot, cold := createTier(t), createTier(t)
+	if _, err := cold.Save(context.Background(), *photo.Of(*photo.IdentifierOf("cold"), []byte("cold"))); err != nil {
+		t.Fatal(err)
+	}
+	instance, _ := New(hot, cold, Options{WriteBehind: true})
+	if _, err := instance.Save(context.Background(), *photo.Of(*photo.IdentifierOf("hot"), []byte("hot"))); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := instance.FindAll(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, []photo.Identifier{*photo.IdentifierOf("cold"), *photo.IdentifierOf("hot")}, ids)
+	}
+	instance.Flush()
+}
+
+func TestTieredStorage_Conformance(t *testing.T) {
+	for _, options := range []Options{{MaxItems: 2}, {MaxItems: 2, WriteBehind: true}} {
+		t.Run(fmt.Sprintf("write behind %v", options.WriteBehind), func(t *testing.T) {
+			phototest.RunRepositoryTests(t, func(t *testing.T) photo.Repository {
+				instance, err := New(createTier(t), createTier(t), options)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(instance.Flush)
+				return instance
+			})
+		})
+	}
+}
+
+// countingRepository counts the photos read from it.
+type countingRepository struct {
+	*file_storage.FileStorage
+	reads int
+}
+
+func (repository *countingRepository) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	repository.reads++
+	return repository.FileStorage.Read(ctx, id)
+}
+
+// blockingRepository closes entered when it starts saving the photo with id, then blocks until release is closed.
+type blockingRepository struct {
+	*file_storage.FileStorage
+	id      photo.Identifier
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (repository *blockingRepository) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	if *photograph.Id() == repository.id {
+		close(repository.entered)
+		<-repository.release
+	}
+	return repository.FileStorage.Save(ctx, photograph)
+}
+
+// failingRepository fails its first saves.
+type failingRepository struct {
+	*file_storage.FileStorage
+	mu       sync.Mutex
+	failures int
+}
+
+func (repository *failingRepository) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+	if repository.failures > 0 {
+		repository.failures--
+		return nil, errors.New("unavailable")
+	}
+	return repository.FileStorage.Save(ctx, photograph)
+}
+
+func (repository *failingRepository) remaining() int {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+	return repository.failures
+}
+
+func createTier(tb testing.TB) *file_storage.FileStorage {
+	tb.Helper()
+	dir, err := ioutil.TempDir("", "tiered_storage")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+	return file_storage.New(dir)
+}
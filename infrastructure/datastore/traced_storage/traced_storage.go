@@ -0,0 +1,151 @@
+package traced_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+)
+
+const instrumentationName = "github.com/photoshelf/photoshelf-storage/infrastructure/datastore/traced_storage"
+
+// TracedStorage records a span named after the backend for every operation of the repository it wraps.
+type TracedStorage struct {
+	backend    string
+	repository photo.Repository
+}
+
+func New(backend string, repository photo.Repository) *TracedStorage {
+	return &TracedStorage{backend: backend, repository: repository}
+}
+
+// Repository returns the wrapped repository.
+func (storage *TracedStorage) Repository() photo.Repository {
+	return storage.repository
+}
+
+func (storage *TracedStorage) Save(ctx context.Context, photograph photo.Photo) (*photo.Identifier, error) {
+	ctx, span := storage.start(ctx, "Save", attribute.Int("photo.size", len(photograph.Image())))
+	if !photograph.IsNew() {
+		span.SetAttributes(attribute.String("photo.id", photograph.Id().Value()))
+	}
+	id, err := storage.repository.Save(ctx, photograph)
+	if err == nil {
+		span.SetAttributes(attribute.String("photo.id", id.Value()))
+	}
+	tracing.End(span, err)
+	return id, err
+}
+
+func (storage *TracedStorage) Read(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	ctx, span := storage.start(ctx, "Read", attribute.String("photo.id", id.Value()))
+	photograph, err := storage.repository.Read(ctx, id)
+	if err == nil {
+		span.SetAttributes(attribute.Int("photo.size", len(photograph.Image())))
+	}
+	tracing.End(span, err)
+	return photograph, err
+}
+
+func (storage *TracedStorage) Peek(ctx context.Context, id photo.Identifier) (*photo.Photo, error) {
+	ctx, span := storage.start(ctx, "Peek", attribute.String("photo.id", id.Value()))
+	photograph, err := photo.Peek(ctx, storage.repository, id)
+	if err == nil {
+		span.SetAttributes(attribute.Int("photo.size", len(photograph.Image())))
+	}
+	tracing.End(span, err)
+	return photograph, err
+}
+
+func (storage *TracedStorage) TagsOf(ctx context.Context, id photo.Identifier) ([]string, error) {
+	ctx, span := storage.start(ctx, "TagsOf", attribute.String("photo.id", id.Value()))
+	tags, err := photo.TagsOf(ctx, storage.repository, id)
+	tracing.End(span, err)
+	return tags, err
+}
+
+// Open records a span for opening the stream, not for reading it.
+func (storage *TracedStorage) Open(ctx context.Context, id photo.Identifier) (io.ReadCloser, error) {
+	ctx, span := storage.start(ctx, "Open", attribute.String("photo.id", id.Value()))
+	reader, err := photo.Open(ctx, storage.repository, id)
+	tracing.End(span, err)
+	return reader, err
+}
+
+func (storage *TracedStorage) Delete(ctx context.Context, id photo.Identifier) error {
+	ctx, span := storage.start(ctx, "Delete", attribute.String("photo.id", id.Value()))
+	err := storage.repository.Delete(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (storage *TracedStorage) FindAll(ctx context.Context) ([]photo.Identifier, error) {
+	ctx, span := storage.start(ctx, "FindAll")
+	ids, err := storage.repository.FindAll(ctx)
+	span.SetAttributes(attribute.Int("photo.count", len(ids)))
+	tracing.End(span, err)
+	return ids, err
+}
+
+func (storage *TracedStorage) FindByTag(ctx context.Context, tag string) ([]photo.Identifier, error) {
+	ctx, span := storage.start(ctx, "FindByTag", attribute.String("photo.tag", tag))
+	ids, err := storage.repository.FindByTag(ctx, tag)
+	span.SetAttributes(attribute.Int("photo.count", len(ids)))
+	tracing.End(span, err)
+	return ids, err
+}
+
+func (storage *TracedStorage) Close() error {
+	return storage.repository.Close()
+}
+
+func (storage *TracedStorage) Ping(ctx context.Context) error {
+	ctx, span := storage.start(ctx, "Ping")
+	err := photo.Ping(ctx, storage.repository)
+	tracing.End(span, err)
+	return err
+}
+
+func (storage *TracedStorage) Checksums(ctx context.Context) (map[photo.Identifier]string, error) {
+	checksums, ok := storage.repository.(photo.ChecksumRepository)
+	if !ok {
+		return nil, photo.ErrNoChecksums
+	}
+	ctx, span := storage.start(ctx, "Checksums")
+	result, err := checksums.Checksums(ctx)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (storage *TracedStorage) ChecksumOf(ctx context.Context, id photo.Identifier) (string, error) {
+	checksums, ok := storage.repository.(photo.ChecksumRepository)
+	if !ok {
+		return "", photo.ErrNoChecksums
+	}
+	ctx, span := storage.start(ctx, "ChecksumOf", attribute.String("photo.id", id.Value()))
+	checksum, err := checksums.ChecksumOf(ctx, id)
+	tracing.End(span, err)
+	return checksum, err
+}
+
+func (storage *TracedStorage) Stats(ctx context.Context) (*photo.Stats, error) {
+	ctx, span := storage.start(ctx, "Stats")
+	stats, err := photo.StatsOf(ctx, storage.repository)
+	tracing.End(span, err)
+	return stats, err
+}
+
+func (storage *TracedStorage) Sizes(ctx context.Context) (map[photo.Identifier]int64, error) {
+	ctx, span := storage.start(ctx, "Sizes")
+	sizes, err := photo.SizesOf(ctx, storage.repository)
+	tracing.End(span, err)
+	return sizes, err
+}
+
+func (storage *TracedStorage) start(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	attributes = append(attributes, attribute.String("repository.backend", storage.backend))
+	return otel.Tracer(instrumentationName).Start(ctx, storage.backend+"."+operation, trace.WithAttributes(attributes...))
+}
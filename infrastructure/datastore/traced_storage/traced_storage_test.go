@@ -0,0 +1,62 @@
+package traced_storage
+
+import (
+	"context"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo/phototest"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestTracedStorage_Read(t *testing.T) {
+	recorder := record(t)
+	instance := New("memory", memory_storage.New(0))
+
+	t.Run("found, records span as child", func(t *testing.T) {
+		id, err := instance.Save(context.Background(), *photo.New([]byte("image")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+		_, err = instance.Read(ctx, *id)
+		parent.End()
+
+		if assert.NoError(t, err) {
+			span := recorder.Ended()[len(recorder.Ended())-2]
+			assert.Equal(t, "memory.Read", span.Name())
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+			assert.Contains(t, span.Attributes(), attribute.String("photo.id", id.Value()))
+			assert.Contains(t, span.Attributes(), attribute.Int("photo.size", len("image")))
+		}
+	})
+
+	t.Run("missing, records error", func(t *testing.T) {
+		_, err := instance.Read(context.Background(), *photo.IdentifierOf("missing"))
+		if assert.Error(t, err) {
+			span := recorder.Ended()[len(recorder.Ended())-1]
+			assert.Equal(t, "memory.Read", span.Name())
+			assert.Equal(t, codes.Error, span.Status().Code)
+		}
+	})
+}
+
+func TestTracedStorage_Conformance(t *testing.T) {
+	phototest.RunRepositoryTests(t, func(t *testing.T) photo.Repository {
+		return New("memory", memory_storage.New(0))
+	})
+}
+
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const (
+	FormatJson = "json"
+	FormatText = "text"
+
+	HeaderRequestId = "X-Request-ID"
+	requestIdKey    = "request_id"
+)
+
+type Options struct {
+	Level  string
+	Format string
+}
+
+type requestIdContextKey struct{}
+
+// New returns a logger writing records of the level and above, which carry the request id of their context.
+func New(w io.Writer, options Options) (*slog.Logger, error) {
+	var level slog.Level
+	if options.Level != "" {
+		if err := level.UnmarshalText([]byte(options.Level)); err != nil {
+			return nil, fmt.Errorf("unknown log level : %s", options.Level)
+		}
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(options.Format) {
+	case "", FormatJson:
+		handler = slog.NewJSONHandler(w, handlerOptions)
+	case FormatText:
+		handler = slog.NewTextHandler(w, handlerOptions)
+	default:
+		return nil, fmt.Errorf("unknown log format : %s", options.Format)
+	}
+	return slog.New(&contextHandler{handler}), nil
+}
+
+// Setup makes a logger writing to stderr the default, which the standard log package writes through as well.
+func Setup(options Options) error {
+	logger, err := New(os.Stderr, options)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdContextKey{}, id)
+}
+
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdContextKey{}).(string)
+	return id
+}
+
+func NewRequestId() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (handler *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestId(ctx); id != "" {
+		record.AddAttrs(slog.String(requestIdKey, id))
+	}
+	return handler.Handler.Handle(ctx, record)
+}
+
+func (handler *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{handler.Handler.WithAttrs(attrs)}
+}
+
+func (handler *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{handler.Handler.WithGroup(name)}
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("writes json with the request id of the context", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger, err := New(buf, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.With("photo_id", "test").InfoContext(WithRequestId(context.Background(), "abc"), "photo saved")
+
+		var record map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "INFO", record["level"])
+		assert.Equal(t, "photo saved", record["msg"])
+		assert.Equal(t, "test", record["photo_id"])
+		assert.Equal(t, "abc", record["request_id"])
+	})
+
+	t.Run("with text format and level, drops records below the level", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger, err := New(buf, Options{Level: "warn", Format: "text"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.Info("dropped")
+		logger.Warn("written")
+
+		assert.NotContains(t, buf.String(), "dropped")
+		assert.Contains(t, buf.String(), "level=WARN msg=written")
+	})
+
+	t.Run("without request id, omits it", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger, err := New(buf, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.InfoContext(context.Background(), "started")
+
+		assert.NotContains(t, buf.String(), "request_id")
+	})
+
+	t.Run("with unknown level, returns error", func(t *testing.T) {
+		_, err := New(&bytes.Buffer{}, Options{Level: "verbose"})
+		assert.Error(t, err)
+	})
+
+	t.Run("with unknown format, returns error", func(t *testing.T) {
+		_, err := New(&bytes.Buffer{}, Options{Format: "xml"})
+		assert.Error(t, err)
+	})
+}
+
+func TestNewRequestId(t *testing.T) {
+	id := NewRequestId()
+
+	assert.Len(t, id, 32)
+	assert.NotEqual(t, id, NewRequestId())
+}
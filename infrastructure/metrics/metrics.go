@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "photoshelf"
+
+var (
+	requestBuckets   = prometheus.ExponentialBuckets(0.001, 2, 15)
+	operationBuckets = prometheus.ExponentialBuckets(0.0001, 2, 18)
+)
+
+// Metrics holds the collectors of a server, registered on their own registry.
+type Metrics struct {
+	HttpRequests *prometheus.CounterVec
+	HttpDuration *prometheus.HistogramVec
+	GrpcRequests *prometheus.CounterVec
+	GrpcDuration *prometheus.HistogramVec
+
+	OperationDuration *prometheus.HistogramVec
+	OperationErrors   *prometheus.CounterVec
+	UploadedBytes     *prometheus.CounterVec
+	ServedBytes       *prometheus.CounterVec
+
+	ScrubRuns         prometheus.Counter
+	ScrubPhotos       *prometheus.GaugeVec
+	WebhookDeliveries *prometheus.CounterVec
+
+	registry *prometheus.Registry
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		HttpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "REST requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		HttpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "REST request latencies by method, route and status.",
+			Buckets:   requestBuckets,
+		}, []string{"method", "route", "status"}),
+		GrpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "requests_total",
+			Help:      "gRPC calls by method and code.",
+		}, []string{"method", "code"}),
+		GrpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "gRPC call latencies by method and code.",
+			Buckets:   requestBuckets,
+		}, []string{"method", "code"}),
+		OperationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "operation_duration_seconds",
+			Help:      "Repository operation latencies by backend and operation.",
+			Buckets:   operationBuckets,
+		}, []string{"backend", "operation"}),
+		OperationErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "operation_errors_total",
+			Help:      "Failed repository operations by backend and operation.",
+		}, []string{"backend", "operation"}),
+		UploadedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "uploaded_bytes_total",
+			Help:      "Bytes of photos saved by backend.",
+		}, []string{"backend"}),
+		ServedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "served_bytes_total",
+			Help:      "Bytes of photos read by backend.",
+		}, []string{"backend"}),
+		ScrubRuns: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "scrub",
+			Name:      "runs_total",
+			Help:      "Completed scrubs.",
+		}),
+		ScrubPhotos: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scrub",
+			Name:      "photos",
+			Help:      "Photos of the last scrub by result, checked, corrupt, missing or orphaned.",
+		}, []string{"result"}),
+		WebhookDeliveries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "webhook",
+			Name:      "deliveries_total",
+			Help:      "Webhook delivery attempts by result, delivered, failed or dead_lettered.",
+		}, []string{"result"}),
+		registry: prometheus.NewRegistry(),
+	}
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.HttpRequests,
+		m.HttpDuration,
+		m.GrpcRequests,
+		m.GrpcDuration,
+		m.OperationDuration,
+		m.OperationErrors,
+		m.UploadedBytes,
+		m.ServedBytes,
+		m.ScrubRuns,
+		m.ScrubPhotos,
+		m.WebhookDeliveries,
+	)
+	return m
+}
+
+func (m *Metrics) Register(collectors ...prometheus.Collector) error {
+	for _, collector := range collectors {
+		if err := m.registry.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	t.Run("with file exporter, writes spans on shutdown", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "tracing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		filename := path.Join(dir, "spans.json")
+
+		shutdown, err := Setup(context.Background(), Options{Exporter: ExporterFile, File: filename})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, span := otel.Tracer("test").Start(context.Background(), "traced operation")
+		span.End()
+		assert.NoError(t, shutdown(context.Background()))
+
+		data, err := ioutil.ReadFile(filename)
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(data), `"Name":"traced operation"`)
+			assert.Contains(t, string(data), "photoshelf-storage")
+		}
+	})
+
+	t.Run("without exporter, propagates trace context only", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Options{})
+		if assert.NoError(t, err) {
+			assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+			assert.NoError(t, shutdown(context.Background()))
+		}
+	})
+
+	t.Run("with unknown exporter, returns error", func(t *testing.T) {
+		_, err := Setup(context.Background(), Options{Exporter: "zipkin"})
+		assert.Error(t, err)
+	})
+}
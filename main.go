@@ -1,44 +1,65 @@
 package main
 
 import (
-	"fmt"
 	"github.com/photoshelf/photoshelf-storage/application"
-	"github.com/photoshelf/photoshelf-storage/presentation/router"
-	"log"
-	"net"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	conf, err := application.Configure(os.Args[1:]...)
-	if err != nil {
-		log.Fatal(err)
-		os.Exit(-1)
+	if len(os.Args) > 1 {
+		var command func(...string) error
+		switch os.Args[1] {
+		case "export":
+			command = application.Export
+		case "import":
+			command = application.Import
+		case "verify":
+			command = application.Verify
+		case "reencrypt":
+			command = application.Reencrypt
+		case "config":
+			command = application.Config
+		}
+		if command != nil {
+			if err := command(os.Args[2:]...); err != nil {
+				fatal(err)
+			}
+			return
+		}
 	}
 
-	address := fmt.Sprintf(":%d", conf.Server.Port)
-
-	switch conf.Server.Mode {
-	case "rest":
-		e, err := router.LoadEchoServer()
-		if err != nil {
-			log.Fatal(err)
-			os.Exit(-1)
-		}
-		e.Logger.Info(e.Start(address))
+	app, err := application.Configure(os.Args[1:]...)
+	if err != nil {
+		fatal(err)
+	}
 
-	case "grpc":
-		s := router.LoadGrpcServer()
+	server, err := app.NewServer()
+	if err != nil {
+		app.Close()
+		fatal(err)
+	}
 
-		listener, err := net.Listen("tcp", address)
-		if err != nil {
-			log.Fatal(err)
-			os.Exit(-1)
-		}
-		s.Serve(listener)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		slog.Info("shutting down", "signal", sig.String())
+		server.Stop()
+	}()
 
-	default:
-		log.Fatalf("No such as server mode: %s", conf.Server.Mode)
-		os.Exit(-1)
+	serveErr := server.Serve()
+	if err := app.Close(); err != nil {
+		slog.Error("closing storage failed", "error", err)
 	}
+	if serveErr != nil {
+		fatal(serveErr)
+	}
+}
+
+func fatal(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
 }
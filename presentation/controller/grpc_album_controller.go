@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"github.com/photoshelf/photoshelf-storage/application/service"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
+	"golang.org/x/net/context"
+)
+
+type grpcAlbumControllerImpl struct {
+	Service service.AlbumService
+}
+
+func NewGrpcAlbumController(albumService service.AlbumService) protobuf.AlbumServiceServer {
+	return &grpcAlbumControllerImpl{albumService}
+}
+
+func (ctrl *grpcAlbumControllerImpl) Save(ctx context.Context, req *protobuf.Album) (*protobuf.AlbumId, error) {
+	var model *album.Album
+	if req.Id != nil {
+		model = album.Of(*album.IdentifierOf(req.Id.Value), req.Title, req.Description, nil, nil)
+	} else {
+		model = album.New(req.Title, req.Description)
+	}
+	for _, id := range req.Photos {
+		model.AddPhoto(*photo.IdentifierOf(id.Value))
+	}
+	if req.Cover != nil {
+		if err := model.SetCover(photo.IdentifierOf(req.Cover.Value)); err != nil {
+			return nil, grpcError(err)
+		}
+	}
+
+	id, err := ctrl.Service.Save(ctx, *model)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &protobuf.AlbumId{Value: id.Value()}, nil
+}
+
+func (ctrl *grpcAlbumControllerImpl) Find(ctx context.Context, req *protobuf.AlbumId) (*protobuf.Album, error) {
+	a, err := ctrl.Service.Find(ctx, *album.IdentifierOf(req.Value))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return albumMessage(*a), nil
+}
+
+func (ctrl *grpcAlbumControllerImpl) FindAll(ctx context.Context, req *protobuf.Empty) (*protobuf.Albums, error) {
+	albums, err := ctrl.Service.FindAll(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	result := &protobuf.Albums{}
+	for _, a := range albums {
+		result.Albums = append(result.Albums, albumMessage(a))
+	}
+	return result, nil
+}
+
+func (ctrl *grpcAlbumControllerImpl) Delete(ctx context.Context, req *protobuf.AlbumId) (*protobuf.Empty, error) {
+	if err := ctrl.Service.Delete(ctx, *album.IdentifierOf(req.Value)); err != nil {
+		return nil, grpcError(err)
+	}
+	return &protobuf.Empty{}, nil
+}
+
+func albumMessage(a album.Album) *protobuf.Album {
+	message := &protobuf.Album{
+		Id:          &protobuf.AlbumId{Value: a.Id().Value()},
+		Title:       a.Title(),
+		Description: a.Description(),
+	}
+	if a.Cover() != nil {
+		message.Cover = &protobuf.Id{Value: a.Cover().Value()}
+	}
+	for _, id := range a.Photos() {
+		message.Photos = append(message.Photos, &protobuf.Id{Value: id.Value()})
+	}
+	return message
+}
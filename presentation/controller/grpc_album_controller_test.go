@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/photoshelf/photoshelf-storage/application/mock_service"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestGrpcAlbumController_Save(t *testing.T) {
+	t.Run("when service no error, returns identifier", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		photos := []photo.Identifier{*photo.IdentifierOf("b"), *photo.IdentifierOf("a")}
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Save(gomock.Any(), *album.Of(*album.IdentifierOf("id"), "title", "", photo.IdentifierOf("a"), photos)).
+			Return(album.IdentifierOf("id"), nil)
+
+		albumController := &grpcAlbumControllerImpl{mockAlbumService}
+
+		actual, err := albumController.Save(context.Background(), &protobuf.Album{
+			Id:     &protobuf.AlbumId{Value: "id"},
+			Title:  "title",
+			Cover:  &protobuf.Id{Value: "a"},
+			Photos: []*protobuf.Id{{Value: "b"}, {Value: "a"}},
+		})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "id", actual.Value)
+		}
+	})
+
+	t.Run("with cover not in photos, returns invalid argument", func(t *testing.T) {
+		albumController := &grpcAlbumControllerImpl{}
+
+		_, err := albumController.Save(context.Background(), &protobuf.Album{Cover: &protobuf.Id{Value: "a"}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("with photo which does not exist, returns invalid argument", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(nil, &photo.ResourceError{Id: *photo.IdentifierOf("a"), Err: album.ErrPhotoNotFound})
+
+		albumController := &grpcAlbumControllerImpl{mockAlbumService}
+
+		_, err := albumController.Save(context.Background(), &protobuf.Album{Photos: []*protobuf.Id{{Value: "a"}}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestGrpcAlbumController_Find(t *testing.T) {
+	t.Run("when service no error, returns album", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Find(gomock.Any(), *album.IdentifierOf("id")).
+			Return(album.Of(*album.IdentifierOf("id"), "title", "", nil, []photo.Identifier{*photo.IdentifierOf("a")}), nil)
+
+		albumController := &grpcAlbumControllerImpl{mockAlbumService}
+
+		actual, err := albumController.Find(context.Background(), &protobuf.AlbumId{Value: "id"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "title", actual.Title)
+			assert.Equal(t, "a", actual.Photos[0].Value)
+			assert.Nil(t, actual.Cover)
+		}
+	})
+
+	t.Run("when service error, returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Find(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("error"))
+
+		albumController := &grpcAlbumControllerImpl{mockAlbumService}
+
+		_, err := albumController.Find(context.Background(), &protobuf.AlbumId{Value: "id"})
+		assert.Error(t, err)
+	})
+
+	t.Run("when album does not exist, returns not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Find(gomock.Any(), gomock.Any()).
+			Return(nil, &album.ResourceError{Id: *album.IdentifierOf("id"), Err: album.ErrNotFound})
+
+		albumController := &grpcAlbumControllerImpl{mockAlbumService}
+
+		_, err := albumController.Find(context.Background(), &protobuf.AlbumId{Value: "id"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestGrpcAlbumController_FindAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+	mockAlbumService.EXPECT().
+		FindAll(gomock.Any()).
+		Return([]album.Album{*album.Of(*album.IdentifierOf("id"), "title", "", nil, nil)}, nil)
+
+	albumController := &grpcAlbumControllerImpl{mockAlbumService}
+
+	actual, err := albumController.FindAll(context.Background(), &protobuf.Empty{})
+	if assert.NoError(t, err) {
+		assert.Len(t, actual.Albums, 1)
+	}
+}
+
+func TestGrpcAlbumController_Delete(t *testing.T) {
+	t.Run("when service no error, returns empty", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Delete(gomock.Any(), *album.IdentifierOf("id")).
+			Return(nil)
+
+		albumController := &grpcAlbumControllerImpl{mockAlbumService}
+
+		_, err := albumController.Delete(context.Background(), &protobuf.AlbumId{Value: "id"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("with invalid identifier, returns invalid argument", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Delete(gomock.Any(), *album.IdentifierOf("../../x")).
+			Return(album.ErrInvalidIdentifier)
+
+		albumController := &grpcAlbumControllerImpl{mockAlbumService}
+
+		_, err := albumController.Delete(context.Background(), &protobuf.AlbumId{Value: "../../x"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"github.com/photoshelf/photoshelf-storage/application/service"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const healthWatchInterval = time.Second
+
+var healthCheckedServices = map[string]bool{
+	"":                      true,
+	"protobuf.PhotoService": true,
+	"protobuf.AlbumService": true,
+}
+
+type grpcHealthControllerImpl struct {
+	Service service.HealthService
+}
+
+func NewGrpcHealthController(healthService service.HealthService) healthpb.HealthServer {
+	return &grpcHealthControllerImpl{healthService}
+}
+
+func (ctrl *grpcHealthControllerImpl) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !healthCheckedServices[req.Service] {
+		return nil, status.Errorf(codes.NotFound, "unknown service : %s", req.Service)
+	}
+	return &healthpb.HealthCheckResponse{Status: ctrl.status(ctx)}, nil
+}
+
+// Watch sends the status of the service, then every change of it found by checking each healthWatchInterval.
+func (ctrl *grpcHealthControllerImpl) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	if !healthCheckedServices[req.Service] {
+		return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN})
+	}
+
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		if current := ctrl.status(ctx); current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ctrl *grpcHealthControllerImpl) status(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if err := ctrl.Service.Ready(ctx); err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/photoshelf/photoshelf-storage/application/mock_service"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestGrpcHealthController_Check(t *testing.T) {
+	t.Run("when ready, returns serving", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockHealthService := mock_service.NewMockHealthService(ctrl)
+		mockHealthService.EXPECT().
+			Ready(gomock.Any()).
+			Return(nil)
+
+		healthController := &grpcHealthControllerImpl{mockHealthService}
+
+		actual, err := healthController.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "protobuf.PhotoService"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, healthpb.HealthCheckResponse_SERVING, actual.Status)
+		}
+	})
+
+	t.Run("when not ready, returns not serving", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockHealthService := mock_service.NewMockHealthService(ctrl)
+		mockHealthService.EXPECT().
+			Ready(gomock.Any()).
+			Return(errors.New("not serving"))
+
+		healthController := &grpcHealthControllerImpl{mockHealthService}
+
+		actual, err := healthController.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if assert.NoError(t, err) {
+			assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, actual.Status)
+		}
+	})
+
+	t.Run("with unknown service, returns not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		healthController := &grpcHealthControllerImpl{mock_service.NewMockHealthService(ctrl)}
+
+		_, err := healthController.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
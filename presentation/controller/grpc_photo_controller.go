@@ -1,31 +1,45 @@
 package controller
 
 import (
+	"bytes"
 	"github.com/photoshelf/photoshelf-storage/application/service"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
 	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"time"
 )
 
+// chunkSize is how many bytes of an image each message of a download carries.
+const chunkSize = 64 << 10
+
 type grpcPhotoControllerImpl struct {
-	Service service.PhotoService `inject:""`
+	Service       service.PhotoService
+	ChangeService service.ChangeService
 }
 
-func NewGrpcPhotoController() protobuf.PhotoServiceServer {
-	return &grpcPhotoControllerImpl{}
+func NewGrpcPhotoController(photoService service.PhotoService, changeService service.ChangeService) protobuf.PhotoServiceServer {
+	return &grpcPhotoControllerImpl{photoService, changeService}
 }
 
 func (ctrl *grpcPhotoControllerImpl) Save(ctx context.Context, req *protobuf.Photo) (*protobuf.Id, error) {
 	var model *photo.Photo
 	if req.Id != nil {
-		model = photo.Of(*photo.IdentifierOf(req.Id.Value), req.Image)
+		model = photo.Of(*photo.IdentifierOf(req.Id.Value), req.Image, req.Tags...)
 	} else {
 		model = photo.New(req.Image)
+		for _, tag := range req.Tags {
+			model.AddTag(tag)
+		}
 	}
 
-	id, err := ctrl.Service.Save(*model)
+	id, err := ctrl.Service.Save(ctx, *model)
 	if err != nil {
-		return nil, err
+		return nil, grpcError(err)
 	}
 
 	return &protobuf.Id{Value: id.Value()}, nil
@@ -33,17 +47,127 @@ func (ctrl *grpcPhotoControllerImpl) Save(ctx context.Context, req *protobuf.Pho
 
 func (ctrl *grpcPhotoControllerImpl) Find(ctx context.Context, req *protobuf.Id) (*protobuf.Photo, error) {
 	id := photo.IdentifierOf(req.Value)
-	photograph, err := ctrl.Service.Find(*id)
+	photograph, err := ctrl.Service.Find(ctx, *id)
 	if err != nil {
-		return nil, err
+		return nil, grpcError(err)
 	}
-	return &protobuf.Photo{Id: &protobuf.Id{Value: photograph.Id().Value()}, Image: photograph.Image()}, nil
+	return &protobuf.Photo{
+		Id:    &protobuf.Id{Value: photograph.Id().Value()},
+		Image: photograph.Image(),
+		Tags:  photograph.Tags(),
+	}, nil
 }
 
 func (ctrl *grpcPhotoControllerImpl) Delete(ctx context.Context, req *protobuf.Id) (*protobuf.Empty, error) {
 	id := photo.IdentifierOf(req.Value)
-	if err := ctrl.Service.Delete(*id); err != nil {
-		return nil, err
+	if err := ctrl.Service.Delete(ctx, *id); err != nil {
+		return nil, grpcError(err)
 	}
 	return &protobuf.Empty{}, nil
 }
+
+// Upload saves a new photo from a stream of chunks. The image is gathered before it is saved,
+// as photos are stored whole, but no message has to hold all of it.
+func (ctrl *grpcPhotoControllerImpl) Upload(stream protobuf.PhotoService_UploadServer) error {
+	var image bytes.Buffer
+	var tags []string
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		tags = append(tags, req.Tags...)
+		image.Write(req.Chunk)
+	}
+
+	model := photo.New(image.Bytes())
+	for _, tag := range tags {
+		model.AddTag(tag)
+	}
+	id, err := ctrl.Service.Save(stream.Context(), *model)
+	if err != nil {
+		return grpcError(err)
+	}
+	return stream.SendAndClose(&protobuf.Id{Value: id.Value()})
+}
+
+// Download streams the image of a photo in chunks, from storages which can stream it.
+func (ctrl *grpcPhotoControllerImpl) Download(req *protobuf.Id, stream protobuf.PhotoService_DownloadServer) error {
+	id := photo.IdentifierOf(req.Value)
+	reader, err := ctrl.Service.Open(stream.Context(), *id)
+	if err != nil {
+		return grpcError(err)
+	}
+	defer reader.Close()
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if n > 0 {
+			if err := stream.Send(&protobuf.Chunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		} else if err != nil {
+			return grpcError(err)
+		}
+	}
+}
+
+func (ctrl *grpcPhotoControllerImpl) Watch(req *protobuf.WatchRequest, stream protobuf.PhotoService_WatchServer) error {
+	var after *uint64
+	if req.After != nil {
+		after = &req.After.Sequence
+	}
+
+	ctx, cancel := streamContext(stream.Context())
+	defer cancel()
+	err := ctrl.ChangeService.Watch(ctx, after, func(record event.Record) error {
+		id := record.Event.PhotoId()
+		change := &protobuf.Change{
+			Sequence:   record.Sequence,
+			Type:       record.Event.Type(),
+			Id:         &protobuf.Id{Value: id.Value()},
+			OccurredAt: record.Event.Time().Format(time.RFC3339Nano),
+		}
+		switch e := record.Event.(type) {
+		case event.PhotoCreated:
+			change.Tags = e.Tags
+		case event.PhotoUpdated:
+			change.Tags = e.Tags
+		}
+		return stream.Send(change)
+	})
+	if err == context.Canceled && shuttingDown(ctx) {
+		return status.Error(codes.Unavailable, "server is shutting down")
+	} else if err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func grpcError(err error) error {
+	cause := err
+	switch e := err.(type) {
+	case *photo.ResourceError:
+		cause = e.Err
+	case *album.ResourceError:
+		cause = e.Err
+	}
+	switch cause {
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case photo.ErrNotFound, album.ErrNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case photo.ErrInvalidTag, photo.ErrInvalidIdentifier,
+		album.ErrInvalidIdentifier, album.ErrCoverNotInAlbum, album.ErrInvalidPhotoList, album.ErrPhotoNotFound:
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
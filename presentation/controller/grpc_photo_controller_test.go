@@ -1,14 +1,22 @@
 package controller
 
 import (
+	"bytes"
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/photoshelf/photoshelf-storage/application/mock_service"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
 	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"io/ioutil"
 	"testing"
+	"time"
 )
 
 func TestGrpcPhotoControllerImpl_Find(t *testing.T) {
@@ -20,10 +28,10 @@ func TestGrpcPhotoControllerImpl_Find(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Find(*identifier).
+			Find(gomock.Any(), *identifier).
 			Return(photo.Of(*identifier, readTestData(t)), nil)
 
-		photoController := &grpcPhotoControllerImpl{mockPhotoService}
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
 
 		actual, err := photoController.Find(context.Background(), &protobuf.Id{Value: identifier.Value()})
 		if assert.NoError(t, err) {
@@ -38,14 +46,47 @@ func TestGrpcPhotoControllerImpl_Find(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Find(*photo.IdentifierOf("not_found")).
+			Find(gomock.Any(), *photo.IdentifierOf("not_found")).
 			Return(nil, errors.New("error not found"))
 
-		photoController := &grpcPhotoControllerImpl{mockPhotoService}
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
 
 		_, err := photoController.Find(context.Background(), &protobuf.Id{Value: "not_found"})
 		assert.Error(t, err)
 	})
+
+	t.Run("when service NotFoundError, returns not found status", func(t *testing.T) {
+		identifier := photo.IdentifierOf("not_found")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Find(gomock.Any(), *identifier).
+			Return(nil, &photo.ResourceError{Id: *identifier, Err: photo.ErrNotFound})
+
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
+
+		_, err := photoController.Find(context.Background(), &protobuf.Id{Value: identifier.Value()})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("when deadline exceeded, returns deadline exceeded status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		identifier := photo.IdentifierOf("slow")
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Find(gomock.Any(), *identifier).
+			Return(nil, &photo.ResourceError{Id: *identifier, Err: context.DeadlineExceeded})
+
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
+
+		_, err := photoController.Find(context.Background(), &protobuf.Id{Value: identifier.Value()})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
 }
 
 func TestGrpcPhotoController_Save(t *testing.T) {
@@ -57,10 +98,10 @@ func TestGrpcPhotoController_Save(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Save(gomock.Any()).
+			Save(gomock.Any(), gomock.Any()).
 			Return(identifier, nil)
 
-		photoController := &grpcPhotoControllerImpl{mockPhotoService}
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
 
 		actual, err := photoController.Save(context.Background(), &protobuf.Photo{})
 		if assert.NoError(t, err) {
@@ -74,14 +115,44 @@ func TestGrpcPhotoController_Save(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Save(gomock.Any()).
+			Save(gomock.Any(), gomock.Any()).
 			Return(nil, errors.New("mock error"))
 
-		photoController := &grpcPhotoControllerImpl{mockPhotoService}
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
 
 		_, err := photoController.Save(context.Background(), &protobuf.Photo{})
 		assert.Error(t, err)
 	})
+
+	t.Run("when service ErrInvalidTag, returns invalid argument status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(nil, photo.ErrInvalidTag)
+
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
+
+		_, err := photoController.Save(context.Background(), &protobuf.Photo{Tags: []string{".."}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("when service ErrInvalidIdentifier, returns invalid argument status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(nil, photo.ErrInvalidIdentifier)
+
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
+
+		_, err := photoController.Save(context.Background(), &protobuf.Photo{Id: &protobuf.Id{Value: "checksums:id"}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
 }
 
 func TestGrpcPhotoController_Delete(t *testing.T) {
@@ -93,10 +164,10 @@ func TestGrpcPhotoController_Delete(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Delete(*identifier).
+			Delete(gomock.Any(), *identifier).
 			Return(nil)
 
-		photoController := &grpcPhotoControllerImpl{mockPhotoService}
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
 
 		_, err := photoController.Delete(context.Background(), &protobuf.Id{Value: identifier.Value()})
 		assert.NoError(t, err)
@@ -110,12 +181,192 @@ func TestGrpcPhotoController_Delete(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Delete(*identifier).
+			Delete(gomock.Any(), *identifier).
 			Return(errors.New("error"))
 
-		photoController := &grpcPhotoControllerImpl{mockPhotoService}
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
 
 		_, err := photoController.Delete(context.Background(), &protobuf.Id{Value: identifier.Value()})
 		assert.Error(t, err)
 	})
 }
+
+func TestGrpcPhotoController_Watch(t *testing.T) {
+	t.Run("with cursor, sends changes after it", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		after := uint64(1)
+		mockChangeService := mock_service.NewMockChangeService(ctrl)
+		mockChangeService.EXPECT().
+			Watch(gomock.Any(), &after, gomock.Any()).
+			Do(func(ctx context.Context, after *uint64, send func(event.Record) error) {
+				e := event.PhotoCreated{Id: *photo.IdentifierOf("photo"), Tags: []string{"tag"}, OccurredAt: time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)}
+				send(event.Record{Sequence: 2, Event: e})
+			}).
+			Return(nil)
+
+		photoController := &grpcPhotoControllerImpl{ChangeService: mockChangeService}
+
+		stream := &watchStream{ctx: context.Background()}
+		err := photoController.Watch(&protobuf.WatchRequest{After: &protobuf.Cursor{Sequence: 1}}, stream)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []*protobuf.Change{{
+				Sequence:   2,
+				Type:       "photo.created",
+				Id:         &protobuf.Id{Value: "photo"},
+				Tags:       []string{"tag"},
+				OccurredAt: "2018-01-02T03:04:05Z",
+			}}, stream.sent)
+		}
+	})
+
+	t.Run("when client cancels, returns canceled status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockChangeService := mock_service.NewMockChangeService(ctrl)
+		mockChangeService.EXPECT().
+			Watch(gomock.Any(), nil, gomock.Any()).
+			Return(context.Canceled)
+
+		photoController := &grpcPhotoControllerImpl{ChangeService: mockChangeService}
+
+		err := photoController.Watch(&protobuf.WatchRequest{}, &watchStream{ctx: context.Background()})
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	})
+}
+
+func TestGrpcPhotoController_Upload(t *testing.T) {
+	t.Run("when service no error, saves chunks as one photo with tags", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := photo.New([]byte("chunked"))
+		expected.AddTag("cat")
+		expected.AddTag("dog")
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Save(gomock.Any(), *expected).
+			Return(photo.IdentifierOf("id"), nil)
+
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
+
+		stream := &uploadStream{requests: []*protobuf.UploadRequest{
+			{Tags: []string{"cat", "dog"}, Chunk: []byte("chu")},
+			{Chunk: []byte("nked")},
+		}}
+		if assert.NoError(t, photoController.Upload(stream)) {
+			assert.Equal(t, &protobuf.Id{Value: "id"}, stream.id)
+		}
+	})
+
+	t.Run("when service ErrInvalidTag, returns invalid argument status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(nil, photo.ErrInvalidTag)
+
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
+
+		err := photoController.Upload(&uploadStream{requests: []*protobuf.UploadRequest{{Tags: []string{"a b"}}}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestGrpcPhotoController_Download(t *testing.T) {
+	t.Run("when service no error, sends image in chunks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		image := make([]byte, chunkSize+1)
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Open(gomock.Any(), *photo.IdentifierOf("id")).
+			Return(ioutil.NopCloser(bytes.NewReader(image)), nil)
+
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
+
+		stream := &downloadStream{}
+		if assert.NoError(t, photoController.Download(&protobuf.Id{Value: "id"}, stream)) {
+			if assert.Len(t, stream.sent, 2) {
+				assert.Len(t, stream.sent[0].Data, chunkSize)
+				assert.Len(t, stream.sent[1].Data, 1)
+			}
+		}
+	})
+
+	t.Run("when service NotFoundError, returns not found status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := photo.IdentifierOf("id")
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Open(gomock.Any(), *id).
+			Return(nil, &photo.ResourceError{Id: *id, Err: photo.ErrNotFound})
+
+		photoController := &grpcPhotoControllerImpl{Service: mockPhotoService}
+
+		stream := &downloadStream{}
+		err := photoController.Download(&protobuf.Id{Value: "id"}, stream)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Empty(t, stream.sent)
+	})
+}
+
+type uploadStream struct {
+	grpc.ServerStream
+	requests []*protobuf.UploadRequest
+	id       *protobuf.Id
+}
+
+func (stream *uploadStream) Context() context.Context {
+	return context.Background()
+}
+
+func (stream *uploadStream) Recv() (*protobuf.UploadRequest, error) {
+	if len(stream.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := stream.requests[0]
+	stream.requests = stream.requests[1:]
+	return req, nil
+}
+
+func (stream *uploadStream) SendAndClose(id *protobuf.Id) error {
+	stream.id = id
+	return nil
+}
+
+type downloadStream struct {
+	grpc.ServerStream
+	sent []*protobuf.Chunk
+}
+
+func (stream *downloadStream) Context() context.Context {
+	return context.Background()
+}
+
+func (stream *downloadStream) Send(chunk *protobuf.Chunk) error {
+	stream.sent = append(stream.sent, &protobuf.Chunk{Data: append([]byte{}, chunk.Data...)})
+	return nil
+}
+
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*protobuf.Change
+}
+
+func (stream *watchStream) Context() context.Context {
+	return stream.ctx
+}
+
+func (stream *watchStream) Send(change *protobuf.Change) error {
+	stream.sent = append(stream.sent, change)
+	return nil
+}
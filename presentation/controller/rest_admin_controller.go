@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/application/service"
+	"net/http"
+)
+
+type RestAdminController interface {
+	ScrubReport(c echo.Context) error
+	WebhookDeliveries(c echo.Context) error
+}
+
+type restAdminControllerImpl struct {
+	Service        service.ScrubService
+	WebhookService service.WebhookService
+}
+
+func NewRestAdminController(scrubService service.ScrubService, webhookService service.WebhookService) RestAdminController {
+	return &restAdminControllerImpl{scrubService, webhookService}
+}
+
+func (controller *restAdminControllerImpl) ScrubReport(c echo.Context) error {
+	report := controller.Service.LastReport()
+	if report == nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+func (controller *restAdminControllerImpl) WebhookDeliveries(c echo.Context) error {
+	return c.JSON(http.StatusOK, controller.WebhookService.Deliveries())
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/application/mock_service"
+	"github.com/photoshelf/photoshelf-storage/application/scrub"
+	"github.com/photoshelf/photoshelf-storage/application/webhook"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRestAdminController_ScrubReport(t *testing.T) {
+	t.Run("when no report yet, returns not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockScrubService := mock_service.NewMockScrubService(ctrl)
+		mockScrubService.EXPECT().
+			LastReport().
+			Return(nil)
+
+		adminController := &restAdminControllerImpl{Service: mockScrubService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, adminController.ScrubReport(c)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("when reported, returns report", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockScrubService := mock_service.NewMockScrubService(ctrl)
+		mockScrubService.EXPECT().
+			LastReport().
+			Return(&scrub.Report{
+				Checked:  2,
+				Corrupt:  []scrub.Corruption{{Id: "corrupt", Expected: "a", Actual: "b"}},
+				Missing:  []string{"missing"},
+				Orphaned: []string{},
+			})
+
+		adminController := &restAdminControllerImpl{Service: mockScrubService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, adminController.ScrubReport(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{
+				"started": "0001-01-01T00:00:00Z",
+				"finished": "0001-01-01T00:00:00Z",
+				"checked": 2,
+				"corrupt": [{"id": "corrupt", "expected": "a", "actual": "b"}],
+				"missing": ["missing"],
+				"orphaned": []
+			}`, rec.Body.String())
+		}
+	})
+}
+
+func TestRestAdminController_WebhookDeliveries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lastAttempt := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	mockWebhookService := mock_service.NewMockWebhookService(ctrl)
+	mockWebhookService.EXPECT().
+		Deliveries().
+		Return(&webhook.Report{
+			Failing: []webhook.Delivery{},
+			DeadLetters: []webhook.Delivery{{
+				Webhook:     "indexer",
+				URL:         "http://indexer/hook",
+				Sequence:    1,
+				Event:       "photo.created",
+				PhotoId:     "id",
+				Attempts:    8,
+				LastError:   "unexpected status : 500 Internal Server Error",
+				LastAttempt: lastAttempt,
+			}},
+		})
+
+	adminController := &restAdminControllerImpl{WebhookService: mockWebhookService}
+
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if assert.NoError(t, adminController.WebhookDeliveries(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{
+			"failing": [],
+			"dead_letters": [{
+				"webhook": "indexer",
+				"url": "http://indexer/hook",
+				"sequence": 1,
+				"event": "photo.created",
+				"photo_id": "id",
+				"attempts": 8,
+				"last_error": "unexpected status : 500 Internal Server Error",
+				"last_attempt": "2018-06-01T12:00:00Z"
+			}]
+		}`, rec.Body.String())
+	}
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/application/service"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/presentation/view"
+	"net/http"
+)
+
+type RestAlbumController interface {
+	List(c echo.Context) error
+	Get(c echo.Context) error
+	Post(c echo.Context) error
+	Put(c echo.Context) error
+	Delete(c echo.Context) error
+}
+
+type restAlbumControllerImpl struct {
+	Service service.AlbumService
+}
+
+func NewRestAlbumController(albumService service.AlbumService) RestAlbumController {
+	return &restAlbumControllerImpl{albumService}
+}
+
+func (controller *restAlbumControllerImpl) List(c echo.Context) error {
+	albums, err := controller.Service.FindAll(c.Request().Context())
+	if err != nil {
+		logError(c.Request().Context(), err)
+		return err
+	}
+
+	result := view.Albums{Albums: []view.Album{}}
+	for _, a := range albums {
+		result.Albums = append(result.Albums, albumView(a))
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (controller *restAlbumControllerImpl) Get(c echo.Context) error {
+	id := album.IdentifierOf(c.Param("id"))
+	a, err := controller.Service.Find(c.Request().Context(), *id)
+	if err != nil {
+		if isAlbumNotFound(err) {
+			return c.NoContent(http.StatusNotFound)
+		}
+		if isInvalidAlbumId(err) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		logError(c.Request().Context(), err)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, albumView(*a))
+}
+
+func (controller *restAlbumControllerImpl) Post(c echo.Context) error {
+	req := new(view.Album)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	a, err := albumOf(album.New(req.Title, req.Description), req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	id, err := controller.Service.Save(c.Request().Context(), *a)
+	if err != nil {
+		if isMissingPhoto(err) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		logError(c.Request().Context(), err)
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, view.Created{Id: id.Value()})
+}
+
+func (controller *restAlbumControllerImpl) Put(c echo.Context) error {
+	req := new(view.Album)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	id := album.IdentifierOf(c.Param("id"))
+	a, err := albumOf(album.Of(*id, req.Title, req.Description, nil, nil), req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if _, err := controller.Service.Save(c.Request().Context(), *a); err != nil {
+		if isMissingPhoto(err) || isInvalidAlbumId(err) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		logError(c.Request().Context(), err)
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (controller *restAlbumControllerImpl) Delete(c echo.Context) error {
+	id := album.IdentifierOf(c.Param("id"))
+
+	if err := controller.Service.Delete(c.Request().Context(), *id); err != nil {
+		if isInvalidAlbumId(err) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		logError(c.Request().Context(), err)
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func albumOf(a *album.Album, req *view.Album) (*album.Album, error) {
+	for _, value := range req.Photos {
+		a.AddPhoto(*photo.IdentifierOf(value))
+	}
+	if req.Cover != "" {
+		if err := a.SetCover(photo.IdentifierOf(req.Cover)); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func albumView(a album.Album) view.Album {
+	result := view.Album{
+		Id:          a.Id().Value(),
+		Title:       a.Title(),
+		Description: a.Description(),
+		Photos:      []string{},
+	}
+	if a.Cover() != nil {
+		result.Cover = a.Cover().Value()
+	}
+	for _, id := range a.Photos() {
+		result.Photos = append(result.Photos, id.Value())
+	}
+	return result
+}
+
+func isAlbumNotFound(err error) bool {
+	if e, success := err.(*album.ResourceError); success {
+		return e.Err == album.ErrNotFound
+	}
+	return false
+}
+
+// isInvalidAlbumId tells whether an album was named by an id which can't name one.
+func isInvalidAlbumId(err error) bool {
+	if e, success := err.(*album.ResourceError); success {
+		err = e.Err
+	}
+	return err == album.ErrInvalidIdentifier
+}
+
+// isMissingPhoto tells whether an album was saved with a photo which does not exist.
+func isMissingPhoto(err error) bool {
+	if e, success := err.(*photo.ResourceError); success {
+		return e.Err == album.ErrPhotoNotFound
+	}
+	return false
+}
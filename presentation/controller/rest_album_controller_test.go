@@ -0,0 +1,320 @@
+package controller
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/application/mock_service"
+	"github.com/photoshelf/photoshelf-storage/domain/model/album"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRestAlbumController_List(t *testing.T) {
+	t.Run("when service no error, returns albums", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			FindAll(gomock.Any()).
+			Return([]album.Album{*album.Of(*album.IdentifierOf("id"), "title", "description", nil, nil)}, nil)
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, albumController.List(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{"albums":[{"id":"id","title":"title","description":"description","photos":[]}]}`, rec.Body.String())
+		}
+	})
+
+	t.Run("when service error, returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			FindAll(gomock.Any()).
+			Return(nil, errors.New("error"))
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Error(t, albumController.List(c))
+	})
+}
+
+func TestRestAlbumController_Get(t *testing.T) {
+	t.Run("when service no error, returns album", func(t *testing.T) {
+		identifier := album.IdentifierOf("id")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		photos := []photo.Identifier{*photo.IdentifierOf("b"), *photo.IdentifierOf("a")}
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Find(gomock.Any(), *identifier).
+			Return(album.Of(*identifier, "title", "", photo.IdentifierOf("a"), photos), nil)
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		if assert.NoError(t, albumController.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{"id":"id","title":"title","description":"","cover":"a","photos":["b","a"]}`, rec.Body.String())
+		}
+	})
+
+	t.Run("when service NotFoundError, returns status not found", func(t *testing.T) {
+		identifier := album.IdentifierOf("not_found")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Find(gomock.Any(), *identifier).
+			Return(nil, &album.ResourceError{Id: *identifier, Err: album.ErrNotFound})
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		if assert.NoError(t, albumController.Get(c)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+}
+
+func TestRestAlbumController_Post(t *testing.T) {
+	t.Run("when service no error, returns status created", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := album.New("title", "description")
+		expected.AddPhoto(*photo.IdentifierOf("a"))
+		expected.SetCover(photo.IdentifierOf("a"))
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Save(gomock.Any(), *expected).
+			Return(album.IdentifierOf("id"), nil)
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		body := `{"title":"title","description":"description","cover":"a","photos":["a"]}`
+		req := httptest.NewRequest(echo.POST, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, albumController.Post(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.JSONEq(t, `{"id":"id"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("with cover not in photos, returns error", func(t *testing.T) {
+		albumController := &restAlbumControllerImpl{}
+
+		e := echo.New()
+		body := `{"title":"title","cover":"a","photos":["b"]}`
+		req := httptest.NewRequest(echo.POST, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Error(t, albumController.Post(c))
+	})
+}
+
+func TestRestAlbumController_Put(t *testing.T) {
+	t.Run("when service no error, returns status ok", func(t *testing.T) {
+		identifier := album.IdentifierOf("id")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		photos := []photo.Identifier{*photo.IdentifierOf("b"), *photo.IdentifierOf("a")}
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Save(gomock.Any(), *album.Of(*identifier, "title", "", nil, photos)).
+			Return(identifier, nil)
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		body := `{"title":"title","photos":["b","a"]}`
+		req := httptest.NewRequest(echo.PUT, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		if assert.NoError(t, albumController.Put(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("when service error, returns error", func(t *testing.T) {
+		identifier := album.IdentifierOf("id")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("error"))
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.PUT, "/", strings.NewReader(`{"title":"title"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		assert.Error(t, albumController.Put(c))
+	})
+
+	t.Run("with photo which does not exist, returns bad request", func(t *testing.T) {
+		identifier := album.IdentifierOf("id")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(nil, &photo.ResourceError{Id: *photo.IdentifierOf("a"), Err: album.ErrPhotoNotFound})
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.PUT, "/", strings.NewReader(`{"title":"title","photos":["a"]}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		err := albumController.Put(c)
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		}
+	})
+}
+
+func TestRestAlbumController_Delete(t *testing.T) {
+	t.Run("when service no error, returns status ok", func(t *testing.T) {
+		identifier := album.IdentifierOf("id")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Delete(gomock.Any(), *identifier).
+			Return(nil)
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.DELETE, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		if assert.NoError(t, albumController.Delete(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("when service error, returns error", func(t *testing.T) {
+		identifier := album.IdentifierOf("id")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Delete(gomock.Any(), *identifier).
+			Return(errors.New("error"))
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.DELETE, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		assert.Error(t, albumController.Delete(c))
+	})
+
+	t.Run("with invalid identifier, returns bad request", func(t *testing.T) {
+		identifier := album.IdentifierOf("..")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockAlbumService := mock_service.NewMockAlbumService(ctrl)
+		mockAlbumService.EXPECT().
+			Delete(gomock.Any(), *identifier).
+			Return(album.ErrInvalidIdentifier)
+
+		albumController := &restAlbumControllerImpl{mockAlbumService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.DELETE, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		err := albumController.Delete(c)
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/application/service"
+	"net/http"
+)
+
+type RestHealthController interface {
+	Healthz(c echo.Context) error
+	Readyz(c echo.Context) error
+}
+
+type restHealthControllerImpl struct {
+	Service service.HealthService
+}
+
+func NewRestHealthController(healthService service.HealthService) RestHealthController {
+	return &restHealthControllerImpl{healthService}
+}
+
+func (controller *restHealthControllerImpl) Healthz(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (controller *restHealthControllerImpl) Readyz(c echo.Context) error {
+	if err := controller.Service.Ready(c.Request().Context()); err != nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
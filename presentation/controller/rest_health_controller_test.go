@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/application/mock_service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRestHealthController_Healthz(t *testing.T) {
+	t.Run("returns ok", func(t *testing.T) {
+		healthController := &restHealthControllerImpl{}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, healthController.Healthz(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+}
+
+func TestRestHealthController_Readyz(t *testing.T) {
+	t.Run("when ready, returns ok", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockHealthService := mock_service.NewMockHealthService(ctrl)
+		mockHealthService.EXPECT().
+			Ready(gomock.Any()).
+			Return(nil)
+
+		healthController := &restHealthControllerImpl{Service: mockHealthService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, healthController.Readyz(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("when not ready, returns service unavailable", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockHealthService := mock_service.NewMockHealthService(ctrl)
+		mockHealthService.EXPECT().
+			Ready(gomock.Any()).
+			Return(errors.New("disk failure"))
+
+		healthController := &restHealthControllerImpl{Service: mockHealthService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, healthController.Readyz(c)) {
+			assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+			assert.Contains(t, rec.Body.String(), "disk failure")
+		}
+	})
+}
@@ -1,58 +1,99 @@
 package controller
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"github.com/labstack/echo"
-	"github.com/labstack/gommon/log"
 	"github.com/photoshelf/photoshelf-storage/application/service"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
 	"github.com/photoshelf/photoshelf-storage/presentation/view"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"io"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
+	"strconv"
 )
 
+const instrumentationName = "github.com/photoshelf/photoshelf-storage/presentation/controller"
+
+// sniffLen is how many bytes http.DetectContentType considers.
+const sniffLen = 512
+
 type RestPhotoController interface {
 	Get(c echo.Context) error
 	Post(c echo.Context) error
 	Put(c echo.Context) error
 	Delete(c echo.Context) error
+	Search(c echo.Context) error
+	Tags(c echo.Context) error
+	AddTag(c echo.Context) error
+	RemoveTag(c echo.Context) error
+	Changes(c echo.Context) error
 }
 
 type restPhotoControllerImpl struct {
-	Service service.PhotoService `inject:""`
+	Service       service.PhotoService
+	ChangeService service.ChangeService
 }
 
-func NewRestPhotoController() RestPhotoController {
-	return &restPhotoControllerImpl{}
+func NewRestPhotoController(photoService service.PhotoService, changeService service.ChangeService) RestPhotoController {
+	return &restPhotoControllerImpl{photoService, changeService}
 }
 
 func (controller *restPhotoControllerImpl) Get(c echo.Context) error {
 	id := photo.IdentifierOf(c.Param("id"))
-	photograph, err := controller.Service.Find(*id)
+	reader, err := controller.Service.Open(c.Request().Context(), *id)
 	if err != nil {
-		if e, success := err.(*photo.ResourceError); success {
-			if e.Err == photo.ErrNotFound {
-				return c.NoContent(http.StatusNotFound)
-			}
+		if isNotFound(err) {
+			return c.NoContent(http.StatusNotFound)
 		}
-		log.Error(err)
+		logError(c.Request().Context(), err)
+		return err
+	}
+	defer reader.Close()
+
+	// DetectContentType considers only the first bytes, so the rest is streamed after them.
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		logError(c.Request().Context(), err)
 		return err
 	}
+	head = head[:n]
 
-	mimeType := http.DetectContentType(photograph.Image())
-	return c.Blob(http.StatusOK, mimeType, photograph.Image())
+	_, span := otel.Tracer(instrumentationName).Start(c.Request().Context(), "DetectContentType")
+	mimeType := http.DetectContentType(head)
+	span.SetAttributes(attribute.String("photo.content_type", mimeType))
+	span.End()
+
+	if err := c.Stream(http.StatusOK, mimeType, io.MultiReader(bytes.NewReader(head), reader)); err != nil {
+		logError(c.Request().Context(), err)
+		return err
+	}
+	return nil
 }
 
 func (controller *restPhotoControllerImpl) Post(c echo.Context) error {
 	data, err := readPhotoBytes(c)
 	if err != nil {
-		log.Error(err)
+		logError(c.Request().Context(), err)
 		return err
 	}
 
 	photograph := photo.New(data)
-	id, err := controller.Service.Save(*photograph)
+	for _, tag := range formTags(c) {
+		photograph.AddTag(tag)
+	}
+	id, err := controller.Service.Save(c.Request().Context(), *photograph)
 	if err != nil {
-		log.Error(err)
+		if err == photo.ErrInvalidTag {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		logError(c.Request().Context(), err)
 		return err
 	}
 
@@ -62,13 +103,16 @@ func (controller *restPhotoControllerImpl) Post(c echo.Context) error {
 func (controller *restPhotoControllerImpl) Put(c echo.Context) error {
 	data, err := readPhotoBytes(c)
 	if err != nil {
-		log.Error(err)
+		logError(c.Request().Context(), err)
 		return err
 	}
 
 	id := photo.IdentifierOf(c.Param("id"))
-	if _, err := controller.Service.Save(*photo.Of(*id, data)); err != nil {
-		log.Error(err)
+	if _, err := controller.Service.Save(c.Request().Context(), *photo.Of(*id, data, formTags(c)...)); err != nil {
+		if err == photo.ErrInvalidIdentifier || err == photo.ErrInvalidTag {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		logError(c.Request().Context(), err)
 		return err
 	}
 	return c.NoContent(http.StatusOK)
@@ -77,14 +121,144 @@ func (controller *restPhotoControllerImpl) Put(c echo.Context) error {
 func (controller *restPhotoControllerImpl) Delete(c echo.Context) error {
 	id := photo.IdentifierOf(c.Param("id"))
 
-	if err := controller.Service.Delete(*id); err != nil {
-		log.Error(err)
+	if err := controller.Service.Delete(c.Request().Context(), *id); err != nil {
+		logError(c.Request().Context(), err)
 		return err
 	}
 
 	return c.NoContent(http.StatusOK)
 }
 
+func (controller *restPhotoControllerImpl) Search(c echo.Context) error {
+	tags := c.QueryParams()["tag"]
+	if len(tags) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "tag is required")
+	}
+
+	var matchAll bool
+	switch c.QueryParam("match") {
+	case "", "all":
+		matchAll = true
+	case "any":
+		matchAll = false
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "match must be all or any")
+	}
+
+	ids, err := controller.Service.FindByTags(c.Request().Context(), tags, matchAll)
+	if err != nil {
+		if err == photo.ErrInvalidTag {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		logError(c.Request().Context(), err)
+		return err
+	}
+
+	found := view.Found{Ids: []string{}}
+	for _, id := range ids {
+		found.Ids = append(found.Ids, id.Value())
+	}
+	return c.JSON(http.StatusOK, found)
+}
+
+func (controller *restPhotoControllerImpl) Tags(c echo.Context) error {
+	id := photo.IdentifierOf(c.Param("id"))
+	tags, err := controller.Service.Tags(c.Request().Context(), *id)
+	if err != nil {
+		if isNotFound(err) {
+			return c.NoContent(http.StatusNotFound)
+		}
+		logError(c.Request().Context(), err)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, view.Tags{Tags: append([]string{}, tags...)})
+}
+
+func (controller *restPhotoControllerImpl) AddTag(c echo.Context) error {
+	id := photo.IdentifierOf(c.Param("id"))
+
+	if err := controller.Service.AddTag(c.Request().Context(), *id, c.Param("tag")); err != nil {
+		if isNotFound(err) {
+			return c.NoContent(http.StatusNotFound)
+		}
+		if err == photo.ErrInvalidTag {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		logError(c.Request().Context(), err)
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (controller *restPhotoControllerImpl) RemoveTag(c echo.Context) error {
+	id := photo.IdentifierOf(c.Param("id"))
+
+	if err := controller.Service.RemoveTag(c.Request().Context(), *id, c.Param("tag")); err != nil {
+		if isNotFound(err) {
+			return c.NoContent(http.StatusNotFound)
+		}
+		if err == photo.ErrInvalidTag {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		logError(c.Request().Context(), err)
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (controller *restPhotoControllerImpl) Changes(c echo.Context) error {
+	cursor := c.Request().Header.Get("Last-Event-ID")
+	if cursor == "" {
+		cursor = c.QueryParam("after")
+	}
+	var after *uint64
+	if cursor != "" {
+		sequence, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "cursor must be a sequence number")
+		}
+		after = &sequence
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	ctx, cancel := streamContext(c.Request().Context())
+	defer cancel()
+	err := controller.ChangeService.Watch(ctx, after, func(record event.Record) error {
+		data, err := event.Marshal(record.Event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", record.Sequence, record.Event.Type(), data); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	})
+	if err != nil && err != context.Canceled {
+		logError(c.Request().Context(), err)
+	}
+	return nil
+}
+
+func logError(ctx context.Context, err error) {
+	slog.ErrorContext(ctx, "request failed", "error", err)
+}
+
+func isNotFound(err error) bool {
+	if e, success := err.(*photo.ResourceError); success {
+		return e.Err == photo.ErrNotFound
+	}
+	return false
+}
+
 func readPhotoBytes(c echo.Context) ([]byte, error) {
 	fileHeader, err := c.FormFile("photo")
 	if err != nil {
@@ -103,3 +277,11 @@ func readPhotoBytes(c echo.Context) ([]byte, error) {
 	}
 	return data, nil
 }
+
+// formTags returns the tag fields of the multipart form readPhotoBytes parsed, saved along with the photo.
+func formTags(c echo.Context) []string {
+	if form := c.Request().MultipartForm; form != nil {
+		return form.Value["tag"]
+	}
+	return nil
+}
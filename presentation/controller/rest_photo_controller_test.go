@@ -2,10 +2,12 @@ package controller
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo"
 	"github.com/photoshelf/photoshelf-storage/application/mock_service"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
 	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
@@ -15,6 +17,7 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 )
 
 func TestRestPhotoController_Get(t *testing.T) {
@@ -26,10 +29,10 @@ func TestRestPhotoController_Get(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Find(*identifier).
-			Return(photo.Of(*identifier, readTestData(t)), nil)
+			Open(gomock.Any(), *identifier).
+			Return(ioutil.NopCloser(bytes.NewReader(readTestData(t))), nil)
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		e := echo.New()
 		req := httptest.NewRequest(echo.GET, "/", nil)
@@ -40,6 +43,7 @@ func TestRestPhotoController_Get(t *testing.T) {
 
 		if assert.NoError(t, photoController.Get(c)) {
 			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "image/jpeg", rec.Header().Get(echo.HeaderContentType))
 			assert.Equal(t, readTestData(t), rec.Body.Bytes())
 		}
 	})
@@ -50,10 +54,10 @@ func TestRestPhotoController_Get(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Find(*photo.IdentifierOf("not_found")).
+			Open(gomock.Any(), *photo.IdentifierOf("not_found")).
 			Return(nil, errors.New("error not found"))
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		e := echo.New()
 		req := httptest.NewRequest(echo.GET, "/", nil)
@@ -72,10 +76,10 @@ func TestRestPhotoController_Get(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Find(*photo.IdentifierOf("not_found")).
+			Open(gomock.Any(), *photo.IdentifierOf("not_found")).
 			Return(nil, &photo.ResourceError{Id: *photo.IdentifierOf("not_found"), Err: photo.ErrNotFound})
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		e := echo.New()
 		req := httptest.NewRequest(echo.GET, "/", nil)
@@ -98,10 +102,10 @@ func TestRestPhotoController_Post(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Save(gomock.Any()).
+			Save(gomock.Any(), gomock.Any()).
 			Return(identifier, nil)
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		body := new(bytes.Buffer)
 		writer := multipart.NewWriter(body)
@@ -132,10 +136,10 @@ func TestRestPhotoController_Post(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Save(gomock.Any()).
+			Save(gomock.Any(), gomock.Any()).
 			Return(nil, errors.New("mock error"))
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		body := new(bytes.Buffer)
 		writer := multipart.NewWriter(body)
@@ -156,6 +160,79 @@ func TestRestPhotoController_Post(t *testing.T) {
 		assert.Error(t, photoController.Post(c))
 	})
 
+	t.Run("with tag fields, saves photo with tags", func(t *testing.T) {
+		identifier := photo.IdentifierOf("e3158990bdee63f8594c260cd51a011d")
+		expected := photo.New(readTestData(t))
+		expected.AddTag("cat")
+		expected.AddTag("dog")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Save(gomock.Any(), *expected).
+			Return(identifier, nil)
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		writer.WriteField("tag", "cat")
+		writer.WriteField("tag", "dog")
+		part, err := writer.CreateFormFile("photo", "photo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(readTestData(t))
+		writer.Close()
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.POST, "/", body)
+		req.Header.Add("Content-Type", writer.FormDataContentType())
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, photoController.Post(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+		}
+	})
+
+	t.Run("when service ErrInvalidTag, returns status bad request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(nil, photo.ErrInvalidTag)
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		writer.WriteField("tag", "a/b")
+		part, err := writer.CreateFormFile("photo", "photo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(readTestData(t))
+		writer.Close()
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.POST, "/", body)
+		req.Header.Add("Content-Type", writer.FormDataContentType())
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err = photoController.Post(c)
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		}
+	})
+
 	t.Run("with nil body, returns error", func(t *testing.T) {
 		identifier := photo.IdentifierOf("e3158990bdee63f8594c260cd51a011d")
 
@@ -164,10 +241,10 @@ func TestRestPhotoController_Post(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Save(*identifier).
+			Save(gomock.Any(), *identifier).
 			Times(0)
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		e := echo.New()
 		req := httptest.NewRequest(echo.POST, "/", nil)
@@ -187,10 +264,10 @@ func TestRestPhotoController_Put(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Save(*photo.Of(*identifier, readTestData(t))).
+			Save(gomock.Any(), *photo.Of(*identifier, readTestData(t))).
 			Return(identifier, nil)
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		body := new(bytes.Buffer)
 		writer := multipart.NewWriter(body)
@@ -224,10 +301,10 @@ func TestRestPhotoController_Put(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Save(*photo.Of(*identifier, readTestData(t))).
+			Save(gomock.Any(), *photo.Of(*identifier, readTestData(t))).
 			Return(nil, errors.New("mock error"))
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		body := new(bytes.Buffer)
 		writer := multipart.NewWriter(body)
@@ -251,6 +328,44 @@ func TestRestPhotoController_Put(t *testing.T) {
 		assert.Error(t, photoController.Put(c))
 	})
 
+	t.Run("when service ErrInvalidIdentifier, returns status bad request", func(t *testing.T) {
+		identifier := photo.IdentifierOf("checksums:e3158990bdee63f8594c260cd51a011d")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Save(gomock.Any(), *photo.Of(*identifier, readTestData(t))).
+			Return(nil, photo.ErrInvalidIdentifier)
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("photo", "photo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(readTestData(t))
+		writer.Close()
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.PUT, "/", body)
+		req.Header.Add("Content-Type", writer.FormDataContentType())
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		err = photoController.Put(c)
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		}
+	})
+
 	t.Run("with nil body, returns error", func(t *testing.T) {
 		identifier := photo.IdentifierOf("e3158990bdee63f8594c260cd51a011d")
 
@@ -259,10 +374,10 @@ func TestRestPhotoController_Put(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Save(*identifier).
+			Save(gomock.Any(), *identifier).
 			Times(0)
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		e := echo.New()
 		req := httptest.NewRequest(echo.PUT, "/", nil)
@@ -306,10 +421,10 @@ func TestRestPhotoController_Delete(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Delete(*identifier).
+			Delete(gomock.Any(), *identifier).
 			Return(nil)
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		e := echo.New()
 		req := httptest.NewRequest(echo.DELETE, "/", nil)
@@ -332,10 +447,10 @@ func TestRestPhotoController_Delete(t *testing.T) {
 
 		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
 		mockPhotoService.EXPECT().
-			Delete(*identifier).
+			Delete(gomock.Any(), *identifier).
 			Return(errors.New("error"))
 
-		photoController := &restPhotoControllerImpl{mockPhotoService}
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
 
 		e := echo.New()
 		req := httptest.NewRequest(echo.DELETE, "/", nil)
@@ -349,6 +464,352 @@ func TestRestPhotoController_Delete(t *testing.T) {
 	})
 }
 
+func TestRestPhotoController_Search(t *testing.T) {
+	t.Run("with tags, returns identifiers", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			FindByTags(gomock.Any(), []string{"a", "b"}, true).
+			Return([]photo.Identifier{*photo.IdentifierOf("id")}, nil)
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/?tag=a&tag=b", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, photoController.Search(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{"ids":["id"]}`, rec.Body.String())
+		}
+	})
+
+	t.Run("with match any, finds any of tags", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			FindByTags(gomock.Any(), []string{"a", "b"}, false).
+			Return(nil, nil)
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/?tag=a&tag=b&match=any", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, photoController.Search(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{"ids":[]}`, rec.Body.String())
+		}
+	})
+
+	t.Run("without tag, returns error", func(t *testing.T) {
+		photoController := &restPhotoControllerImpl{}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Error(t, photoController.Search(c))
+	})
+
+	t.Run("with unknown match, returns error", func(t *testing.T) {
+		photoController := &restPhotoControllerImpl{}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/?tag=a&match=unknown", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Error(t, photoController.Search(c))
+	})
+
+	t.Run("when service error, returns error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			FindByTags(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("error"))
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/?tag=a", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Error(t, photoController.Search(c))
+	})
+}
+
+func TestRestPhotoController_Tags(t *testing.T) {
+	t.Run("when service no error, returns tags", func(t *testing.T) {
+		identifier := photo.IdentifierOf("e3158990bdee63f8594c260cd51a011d")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Tags(gomock.Any(), *identifier).
+			Return([]string{"cat", "dog"}, nil)
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/tags")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		if assert.NoError(t, photoController.Tags(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{"tags":["cat","dog"]}`, rec.Body.String())
+		}
+	})
+
+	t.Run("when service NotFoundError, returns status not found", func(t *testing.T) {
+		identifier := photo.IdentifierOf("not_found")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			Tags(gomock.Any(), *identifier).
+			Return(nil, &photo.ResourceError{Id: *identifier, Err: photo.ErrNotFound})
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/tags")
+		c.SetParamNames("id")
+		c.SetParamValues(identifier.Value())
+
+		if assert.NoError(t, photoController.Tags(c)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+}
+
+func TestRestPhotoController_AddTag(t *testing.T) {
+	t.Run("when service no error, returns status ok", func(t *testing.T) {
+		identifier := photo.IdentifierOf("e3158990bdee63f8594c260cd51a011d")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			AddTag(gomock.Any(), *identifier, "tag").
+			Return(nil)
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.PUT, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/tags/:tag")
+		c.SetParamNames("id", "tag")
+		c.SetParamValues(identifier.Value(), "tag")
+
+		if assert.NoError(t, photoController.AddTag(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("when service NotFoundError, returns status not found", func(t *testing.T) {
+		identifier := photo.IdentifierOf("not_found")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			AddTag(gomock.Any(), *identifier, "tag").
+			Return(&photo.ResourceError{Id: *identifier, Err: photo.ErrNotFound})
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.PUT, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/tags/:tag")
+		c.SetParamNames("id", "tag")
+		c.SetParamValues(identifier.Value(), "tag")
+
+		if assert.NoError(t, photoController.AddTag(c)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("when service ErrInvalidTag, returns status bad request", func(t *testing.T) {
+		identifier := photo.IdentifierOf("e3158990bdee63f8594c260cd51a011d")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			AddTag(gomock.Any(), *identifier, "..").
+			Return(photo.ErrInvalidTag)
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.PUT, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/tags/:tag")
+		c.SetParamNames("id", "tag")
+		c.SetParamValues(identifier.Value(), "..")
+
+		err := photoController.AddTag(c)
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		}
+	})
+
+	t.Run("when service error, returns error", func(t *testing.T) {
+		identifier := photo.IdentifierOf("e3158990bdee63f8594c260cd51a011d")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			AddTag(gomock.Any(), *identifier, "tag").
+			Return(errors.New("error"))
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.PUT, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/tags/:tag")
+		c.SetParamNames("id", "tag")
+		c.SetParamValues(identifier.Value(), "tag")
+
+		assert.Error(t, photoController.AddTag(c))
+	})
+}
+
+func TestRestPhotoController_RemoveTag(t *testing.T) {
+	t.Run("when service no error, returns status ok", func(t *testing.T) {
+		identifier := photo.IdentifierOf("e3158990bdee63f8594c260cd51a011d")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			RemoveTag(gomock.Any(), *identifier, "tag").
+			Return(nil)
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.DELETE, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/tags/:tag")
+		c.SetParamNames("id", "tag")
+		c.SetParamValues(identifier.Value(), "tag")
+
+		if assert.NoError(t, photoController.RemoveTag(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("when service error, returns error", func(t *testing.T) {
+		identifier := photo.IdentifierOf("e3158990bdee63f8594c260cd51a011d")
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPhotoService := mock_service.NewMockPhotoService(ctrl)
+		mockPhotoService.EXPECT().
+			RemoveTag(gomock.Any(), *identifier, "tag").
+			Return(errors.New("error"))
+
+		photoController := &restPhotoControllerImpl{Service: mockPhotoService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.DELETE, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/tags/:tag")
+		c.SetParamNames("id", "tag")
+		c.SetParamValues(identifier.Value(), "tag")
+
+		assert.Error(t, photoController.RemoveTag(c))
+	})
+}
+
+func TestRestPhotoController_Changes(t *testing.T) {
+	t.Run("with Last-Event-ID, streams changes after it", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		after := uint64(41)
+		mockChangeService := mock_service.NewMockChangeService(ctrl)
+		mockChangeService.EXPECT().
+			Watch(gomock.Any(), &after, gomock.Any()).
+			Do(func(ctx context.Context, after *uint64, send func(event.Record) error) {
+				e := event.PhotoDeleted{Id: *photo.IdentifierOf("photo"), OccurredAt: time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)}
+				send(event.Record{Sequence: 42, Event: e})
+			}).
+			Return(context.Canceled)
+
+		photoController := &restPhotoControllerImpl{ChangeService: mockChangeService}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.Header.Set("Last-Event-ID", "41")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, photoController.Changes(c)) {
+			assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+			assert.Equal(t, "id: 42\nevent: photo.deleted\ndata: {\"type\":\"photo.deleted\",\"id\":\"photo\",\"occurred_at\":\"2018-01-02T03:04:05Z\"}\n\n", rec.Body.String())
+		}
+	})
+
+	t.Run("with invalid cursor, returns bad request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		photoController := &restPhotoControllerImpl{ChangeService: mock_service.NewMockChangeService(ctrl)}
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/?after=latest", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := photoController.Changes(c)
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		}
+	})
+}
+
 func readTestData(tb testing.TB) []byte {
 	tb.Helper()
 
@@ -0,0 +1,31 @@
+package controller
+
+import "context"
+
+type streamsKey struct{}
+
+// WithStreams returns a context whose long-lived streams, like the change feeds, end when streams is done.
+// A server cancels streams when it shuts down, as they never end by themselves, while other requests drain.
+func WithStreams(ctx context.Context, streams context.Context) context.Context {
+	return context.WithValue(ctx, streamsKey{}, streams)
+}
+
+// streamContext returns the context of a stream served under ctx, which is cancelled when its streams are.
+func streamContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	streams, ok := ctx.Value(streamsKey{}).(context.Context)
+	if !ok {
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(streams, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// shuttingDown tells whether the stream served under ctx ended because its server shuts down.
+func shuttingDown(ctx context.Context) bool {
+	streams, ok := ctx.Value(streamsKey{}).(context.Context)
+	return ok && streams.Err() != nil
+}
@@ -80,3 +80,63 @@ func (m *MockPhotoController) Delete(c echo.Context) error {
 func (mr *MockPhotoControllerMockRecorder) Delete(c interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPhotoController)(nil).Delete), c)
 }
+
+// Search mocks base method
+func (m *MockPhotoController) Search(c echo.Context) error {
+	ret := m.ctrl.Call(m, "Search", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Search indicates an expected call of Search
+func (mr *MockPhotoControllerMockRecorder) Search(c interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockPhotoController)(nil).Search), c)
+}
+
+// Tags mocks base method
+func (m *MockPhotoController) Tags(c echo.Context) error {
+	ret := m.ctrl.Call(m, "Tags", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Tags indicates an expected call of Tags
+func (mr *MockPhotoControllerMockRecorder) Tags(c interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tags", reflect.TypeOf((*MockPhotoController)(nil).Tags), c)
+}
+
+// AddTag mocks base method
+func (m *MockPhotoController) AddTag(c echo.Context) error {
+	ret := m.ctrl.Call(m, "AddTag", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddTag indicates an expected call of AddTag
+func (mr *MockPhotoControllerMockRecorder) AddTag(c interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTag", reflect.TypeOf((*MockPhotoController)(nil).AddTag), c)
+}
+
+// RemoveTag mocks base method
+func (m *MockPhotoController) RemoveTag(c echo.Context) error {
+	ret := m.ctrl.Call(m, "RemoveTag", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveTag indicates an expected call of RemoveTag
+func (mr *MockPhotoControllerMockRecorder) RemoveTag(c interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveTag", reflect.TypeOf((*MockPhotoController)(nil).RemoveTag), c)
+}
+
+// Changes mocks base method
+func (m *MockPhotoController) Changes(c echo.Context) error {
+	ret := m.ctrl.Call(m, "Changes", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Changes indicates an expected call of Changes
+func (mr *MockPhotoControllerMockRecorder) Changes(c interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Changes", reflect.TypeOf((*MockPhotoController)(nil).Changes), c)
+}
@@ -10,6 +10,14 @@ It is generated from these files:
 It has these top-level messages:
 	Id
 	Photo
+	Cursor
+	WatchRequest
+	Change
+	UploadRequest
+	Chunk
+	AlbumId
+	Album
+	Albums
 	Empty
 */
 package protobuf
@@ -51,8 +59,9 @@ func (m *Id) GetValue() string {
 }
 
 type Photo struct {
-	Id    *Id    `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
-	Image []byte `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	Id    *Id      `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Image []byte   `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	Tags  []string `protobuf:"bytes,3,rep,name=tags" json:"tags,omitempty"`
 }
 
 func (m *Photo) Reset()                    { *m = Photo{} }
@@ -74,17 +83,233 @@ func (m *Photo) GetImage() []byte {
 	return nil
 }
 
+func (m *Photo) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+type Cursor struct {
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence" json:"sequence,omitempty"`
+}
+
+func (m *Cursor) Reset()                    { *m = Cursor{} }
+func (m *Cursor) String() string            { return proto.CompactTextString(m) }
+func (*Cursor) ProtoMessage()               {}
+func (*Cursor) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *Cursor) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+type WatchRequest struct {
+	After *Cursor `protobuf:"bytes,1,opt,name=after" json:"after,omitempty"`
+}
+
+func (m *WatchRequest) Reset()                    { *m = WatchRequest{} }
+func (m *WatchRequest) String() string            { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()               {}
+func (*WatchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *WatchRequest) GetAfter() *Cursor {
+	if m != nil {
+		return m.After
+	}
+	return nil
+}
+
+type Change struct {
+	Sequence   uint64   `protobuf:"varint,1,opt,name=sequence" json:"sequence,omitempty"`
+	Type       string   `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	Id         *Id      `protobuf:"bytes,3,opt,name=id" json:"id,omitempty"`
+	Tags       []string `protobuf:"bytes,4,rep,name=tags" json:"tags,omitempty"`
+	OccurredAt string   `protobuf:"bytes,5,opt,name=occurred_at,json=occurredAt" json:"occurred_at,omitempty"`
+}
+
+func (m *Change) Reset()                    { *m = Change{} }
+func (m *Change) String() string            { return proto.CompactTextString(m) }
+func (*Change) ProtoMessage()               {}
+func (*Change) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *Change) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *Change) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Change) GetId() *Id {
+	if m != nil {
+		return m.Id
+	}
+	return nil
+}
+
+func (m *Change) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Change) GetOccurredAt() string {
+	if m != nil {
+		return m.OccurredAt
+	}
+	return ""
+}
+
+// The first request of an upload carries the tags of the photo, and each request a chunk of its image.
+type UploadRequest struct {
+	Tags  []string `protobuf:"bytes,1,rep,name=tags" json:"tags,omitempty"`
+	Chunk []byte   `protobuf:"bytes,2,opt,name=chunk,proto3" json:"chunk,omitempty"`
+}
+
+func (m *UploadRequest) Reset()                    { *m = UploadRequest{} }
+func (m *UploadRequest) String() string            { return proto.CompactTextString(m) }
+func (*UploadRequest) ProtoMessage()               {}
+func (*UploadRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *UploadRequest) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *UploadRequest) GetChunk() []byte {
+	if m != nil {
+		return m.Chunk
+	}
+	return nil
+}
+
+type Chunk struct {
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *Chunk) Reset()                    { *m = Chunk{} }
+func (m *Chunk) String() string            { return proto.CompactTextString(m) }
+func (*Chunk) ProtoMessage()               {}
+func (*Chunk) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *Chunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type AlbumId struct {
+	Value string `protobuf:"bytes,1,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *AlbumId) Reset()                    { *m = AlbumId{} }
+func (m *AlbumId) String() string            { return proto.CompactTextString(m) }
+func (*AlbumId) ProtoMessage()               {}
+func (*AlbumId) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *AlbumId) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type Album struct {
+	Id          *AlbumId `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Title       string   `protobuf:"bytes,2,opt,name=title" json:"title,omitempty"`
+	Description string   `protobuf:"bytes,3,opt,name=description" json:"description,omitempty"`
+	Cover       *Id      `protobuf:"bytes,4,opt,name=cover" json:"cover,omitempty"`
+	Photos      []*Id    `protobuf:"bytes,5,rep,name=photos" json:"photos,omitempty"`
+}
+
+func (m *Album) Reset()                    { *m = Album{} }
+func (m *Album) String() string            { return proto.CompactTextString(m) }
+func (*Album) ProtoMessage()               {}
+func (*Album) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *Album) GetId() *AlbumId {
+	if m != nil {
+		return m.Id
+	}
+	return nil
+}
+
+func (m *Album) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *Album) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Album) GetCover() *Id {
+	if m != nil {
+		return m.Cover
+	}
+	return nil
+}
+
+func (m *Album) GetPhotos() []*Id {
+	if m != nil {
+		return m.Photos
+	}
+	return nil
+}
+
+type Albums struct {
+	Albums []*Album `protobuf:"bytes,1,rep,name=albums" json:"albums,omitempty"`
+}
+
+func (m *Albums) Reset()                    { *m = Albums{} }
+func (m *Albums) String() string            { return proto.CompactTextString(m) }
+func (*Albums) ProtoMessage()               {}
+func (*Albums) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *Albums) GetAlbums() []*Album {
+	if m != nil {
+		return m.Albums
+	}
+	return nil
+}
+
 type Empty struct {
 }
 
 func (m *Empty) Reset()                    { *m = Empty{} }
 func (m *Empty) String() string            { return proto.CompactTextString(m) }
 func (*Empty) ProtoMessage()               {}
-func (*Empty) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*Empty) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
 func init() {
 	proto.RegisterType((*Id)(nil), "protobuf.Id")
 	proto.RegisterType((*Photo)(nil), "protobuf.Photo")
+	proto.RegisterType((*Cursor)(nil), "protobuf.Cursor")
+	proto.RegisterType((*WatchRequest)(nil), "protobuf.WatchRequest")
+	proto.RegisterType((*Change)(nil), "protobuf.Change")
+	proto.RegisterType((*UploadRequest)(nil), "protobuf.UploadRequest")
+	proto.RegisterType((*Chunk)(nil), "protobuf.Chunk")
+	proto.RegisterType((*AlbumId)(nil), "protobuf.AlbumId")
+	proto.RegisterType((*Album)(nil), "protobuf.Album")
+	proto.RegisterType((*Albums)(nil), "protobuf.Albums")
 	proto.RegisterType((*Empty)(nil), "protobuf.Empty")
 }
 
@@ -102,6 +327,9 @@ type PhotoServiceClient interface {
 	Save(ctx context.Context, in *Photo, opts ...grpc.CallOption) (*Id, error)
 	Find(ctx context.Context, in *Id, opts ...grpc.CallOption) (*Photo, error)
 	Delete(ctx context.Context, in *Id, opts ...grpc.CallOption) (*Empty, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (PhotoService_WatchClient, error)
+	Upload(ctx context.Context, opts ...grpc.CallOption) (PhotoService_UploadClient, error)
+	Download(ctx context.Context, in *Id, opts ...grpc.CallOption) (PhotoService_DownloadClient, error)
 }
 
 type photoServiceClient struct {
@@ -139,12 +367,113 @@ func (c *photoServiceClient) Delete(ctx context.Context, in *Id, opts ...grpc.Ca
 	return out, nil
 }
 
+func (c *photoServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (PhotoService_WatchClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_PhotoService_serviceDesc.Streams[0], c.cc, "/protobuf.PhotoService/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &photoServiceWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PhotoService_WatchClient interface {
+	Recv() (*Change, error)
+	grpc.ClientStream
+}
+
+type photoServiceWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *photoServiceWatchClient) Recv() (*Change, error) {
+	m := new(Change)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *photoServiceClient) Upload(ctx context.Context, opts ...grpc.CallOption) (PhotoService_UploadClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_PhotoService_serviceDesc.Streams[1], c.cc, "/protobuf.PhotoService/Upload", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &photoServiceUploadClient{stream}
+	return x, nil
+}
+
+type PhotoService_UploadClient interface {
+	Send(*UploadRequest) error
+	CloseAndRecv() (*Id, error)
+	grpc.ClientStream
+}
+
+type photoServiceUploadClient struct {
+	grpc.ClientStream
+}
+
+func (x *photoServiceUploadClient) Send(m *UploadRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *photoServiceUploadClient) CloseAndRecv() (*Id, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(Id)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *photoServiceClient) Download(ctx context.Context, in *Id, opts ...grpc.CallOption) (PhotoService_DownloadClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_PhotoService_serviceDesc.Streams[2], c.cc, "/protobuf.PhotoService/Download", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &photoServiceDownloadClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PhotoService_DownloadClient interface {
+	Recv() (*Chunk, error)
+	grpc.ClientStream
+}
+
+type photoServiceDownloadClient struct {
+	grpc.ClientStream
+}
+
+func (x *photoServiceDownloadClient) Recv() (*Chunk, error) {
+	m := new(Chunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for PhotoService service
 
 type PhotoServiceServer interface {
 	Save(context.Context, *Photo) (*Id, error)
 	Find(context.Context, *Id) (*Photo, error)
 	Delete(context.Context, *Id) (*Empty, error)
+	Watch(*WatchRequest, PhotoService_WatchServer) error
+	Upload(PhotoService_UploadServer) error
+	Download(*Id, PhotoService_DownloadServer) error
 }
 
 func RegisterPhotoServiceServer(s *grpc.Server, srv PhotoServiceServer) {
@@ -205,6 +534,74 @@ func _PhotoService_Delete_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PhotoService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PhotoServiceServer).Watch(m, &photoServiceWatchServer{stream})
+}
+
+type PhotoService_WatchServer interface {
+	Send(*Change) error
+	grpc.ServerStream
+}
+
+type photoServiceWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *photoServiceWatchServer) Send(m *Change) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PhotoService_Upload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PhotoServiceServer).Upload(&photoServiceUploadServer{stream})
+}
+
+type PhotoService_UploadServer interface {
+	SendAndClose(*Id) error
+	Recv() (*UploadRequest, error)
+	grpc.ServerStream
+}
+
+type photoServiceUploadServer struct {
+	grpc.ServerStream
+}
+
+func (x *photoServiceUploadServer) SendAndClose(m *Id) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *photoServiceUploadServer) Recv() (*UploadRequest, error) {
+	m := new(UploadRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _PhotoService_Download_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Id)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PhotoServiceServer).Download(m, &photoServiceDownloadServer{stream})
+}
+
+type PhotoService_DownloadServer interface {
+	Send(*Chunk) error
+	grpc.ServerStream
+}
+
+type photoServiceDownloadServer struct {
+	grpc.ServerStream
+}
+
+func (x *photoServiceDownloadServer) Send(m *Chunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _PhotoService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "protobuf.PhotoService",
 	HandlerType: (*PhotoServiceServer)(nil),
@@ -222,6 +619,185 @@ var _PhotoService_serviceDesc = grpc.ServiceDesc{
 			Handler:    _PhotoService_Delete_Handler,
 		},
 	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _PhotoService_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Upload",
+			Handler:       _PhotoService_Upload_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Download",
+			Handler:       _PhotoService_Download_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "photos.proto",
+}
+
+// Client API for AlbumService service
+
+type AlbumServiceClient interface {
+	Save(ctx context.Context, in *Album, opts ...grpc.CallOption) (*AlbumId, error)
+	Find(ctx context.Context, in *AlbumId, opts ...grpc.CallOption) (*Album, error)
+	FindAll(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Albums, error)
+	Delete(ctx context.Context, in *AlbumId, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type albumServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAlbumServiceClient(cc *grpc.ClientConn) AlbumServiceClient {
+	return &albumServiceClient{cc}
+}
+
+func (c *albumServiceClient) Save(ctx context.Context, in *Album, opts ...grpc.CallOption) (*AlbumId, error) {
+	out := new(AlbumId)
+	err := grpc.Invoke(ctx, "/protobuf.AlbumService/Save", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *albumServiceClient) Find(ctx context.Context, in *AlbumId, opts ...grpc.CallOption) (*Album, error) {
+	out := new(Album)
+	err := grpc.Invoke(ctx, "/protobuf.AlbumService/Find", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *albumServiceClient) FindAll(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Albums, error) {
+	out := new(Albums)
+	err := grpc.Invoke(ctx, "/protobuf.AlbumService/FindAll", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *albumServiceClient) Delete(ctx context.Context, in *AlbumId, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/protobuf.AlbumService/Delete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for AlbumService service
+
+type AlbumServiceServer interface {
+	Save(context.Context, *Album) (*AlbumId, error)
+	Find(context.Context, *AlbumId) (*Album, error)
+	FindAll(context.Context, *Empty) (*Albums, error)
+	Delete(context.Context, *AlbumId) (*Empty, error)
+}
+
+func RegisterAlbumServiceServer(s *grpc.Server, srv AlbumServiceServer) {
+	s.RegisterService(&_AlbumService_serviceDesc, srv)
+}
+
+func _AlbumService_Save_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Album)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlbumServiceServer).Save(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.AlbumService/Save",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlbumServiceServer).Save(ctx, req.(*Album))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AlbumService_Find_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AlbumId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlbumServiceServer).Find(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.AlbumService/Find",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlbumServiceServer).Find(ctx, req.(*AlbumId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AlbumService_FindAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlbumServiceServer).FindAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.AlbumService/FindAll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlbumServiceServer).FindAll(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AlbumService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AlbumId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlbumServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.AlbumService/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlbumServiceServer).Delete(ctx, req.(*AlbumId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _AlbumService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "protobuf.AlbumService",
+	HandlerType: (*AlbumServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Save",
+			Handler:    _AlbumService_Save_Handler,
+		},
+		{
+			MethodName: "Find",
+			Handler:    _AlbumService_Find_Handler,
+		},
+		{
+			MethodName: "FindAll",
+			Handler:    _AlbumService_FindAll_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _AlbumService_Delete_Handler,
+		},
+	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "photos.proto",
 }
@@ -229,17 +805,40 @@ var _PhotoService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("photos.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 189 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x29, 0xc8, 0xc8, 0x2f,
-	0xc9, 0x2f, 0xd6, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x00, 0x53, 0x49, 0xa5, 0x69, 0x4a,
-	0x52, 0x5c, 0x4c, 0x9e, 0x29, 0x42, 0x22, 0x5c, 0xac, 0x65, 0x89, 0x39, 0xa5, 0xa9, 0x12, 0x8c,
-	0x0a, 0x8c, 0x1a, 0x9c, 0x41, 0x10, 0x8e, 0x92, 0x35, 0x17, 0x6b, 0x00, 0x48, 0x97, 0x90, 0x0c,
-	0x17, 0x53, 0x66, 0x0a, 0x58, 0x8e, 0xdb, 0x88, 0x47, 0x0f, 0xa6, 0x57, 0xcf, 0x33, 0x25, 0x88,
-	0x29, 0x13, 0xac, 0x39, 0x33, 0x37, 0x31, 0x3d, 0x55, 0x82, 0x49, 0x81, 0x51, 0x83, 0x27, 0x08,
-	0xc2, 0x51, 0x62, 0xe7, 0x62, 0x75, 0xcd, 0x2d, 0x28, 0xa9, 0x34, 0xea, 0x66, 0xe4, 0xe2, 0x01,
-	0x1b, 0x13, 0x9c, 0x5a, 0x54, 0x96, 0x99, 0x9c, 0x2a, 0xa4, 0xce, 0xc5, 0x12, 0x9c, 0x58, 0x96,
-	0x2a, 0xc4, 0x8f, 0x30, 0x09, 0x2c, 0x2f, 0x85, 0x62, 0xb4, 0x12, 0x03, 0x48, 0xa1, 0x5b, 0x66,
-	0x5e, 0x8a, 0x10, 0x8a, 0xb8, 0x14, 0xba, 0x36, 0x25, 0x06, 0x21, 0x4d, 0x2e, 0x36, 0x97, 0xd4,
-	0x9c, 0xd4, 0x92, 0x54, 0xdc, 0x4a, 0xc1, 0x6e, 0x51, 0x62, 0x48, 0x62, 0x03, 0x8b, 0x18, 0x03,
-	0x02, 0x00, 0x00, 0xff, 0xff, 0x77, 0xd6, 0xe6, 0xa4, 0x10, 0x01, 0x00, 0x00,
+	// 548 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x52, 0xd1, 0x8e, 0x93, 0x5c,
+	0x10, 0x86, 0xb6, 0x87, 0xb6, 0x53, 0xfe, 0xfc, 0xeb, 0x64, 0xa3, 0x04, 0x4d, 0xb6, 0x9e, 0x6c,
+	0xdc, 0x9a, 0xac, 0x8d, 0x76, 0xa3, 0x89, 0x97, 0x4d, 0x57, 0x93, 0xbd, 0xd2, 0xb0, 0x31, 0x5e,
+	0x9a, 0x53, 0x38, 0xdb, 0x12, 0x29, 0x20, 0x1c, 0x6a, 0xfa, 0x0c, 0xbe, 0x87, 0x57, 0x3e, 0x89,
+	0x4f, 0x65, 0x18, 0x40, 0x28, 0xb5, 0x7b, 0xc5, 0xcc, 0x7c, 0x33, 0xc3, 0x37, 0xdf, 0xf9, 0xc0,
+	0x8c, 0xd7, 0x91, 0x8a, 0xd2, 0x69, 0x9c, 0x44, 0x2a, 0xc2, 0x01, 0x7d, 0x96, 0xd9, 0x1d, 0xb7,
+	0xa1, 0x73, 0xe3, 0xe1, 0x29, 0xb0, 0xad, 0x08, 0x32, 0x69, 0xe9, 0x63, 0x7d, 0x32, 0x74, 0x8a,
+	0x84, 0x7f, 0x00, 0xf6, 0x31, 0x9f, 0xc2, 0x27, 0xd0, 0xf1, 0x3d, 0xc2, 0x46, 0x33, 0x73, 0x5a,
+	0xcd, 0x4e, 0x6f, 0x3c, 0xa7, 0xe3, 0xd3, 0xb0, 0xbf, 0x11, 0x2b, 0x69, 0x75, 0xc6, 0xfa, 0xc4,
+	0x74, 0x8a, 0x04, 0x11, 0x7a, 0x4a, 0xac, 0x52, 0xab, 0x3b, 0xee, 0x4e, 0x86, 0x0e, 0xc5, 0xfc,
+	0x1c, 0x8c, 0x45, 0x96, 0xa4, 0x51, 0x82, 0x36, 0x0c, 0x52, 0xf9, 0x2d, 0x93, 0xa1, 0x5b, 0xfc,
+	0xb3, 0xe7, 0xfc, 0xcd, 0xf9, 0x1b, 0x30, 0x3f, 0x0b, 0xe5, 0xae, 0x9d, 0xbc, 0x90, 0x2a, 0x7c,
+	0x06, 0x4c, 0xdc, 0x29, 0x99, 0x94, 0x04, 0x4e, 0x6a, 0x02, 0xc5, 0x32, 0xa7, 0x80, 0xf9, 0x0f,
+	0x1d, 0x8c, 0xc5, 0x5a, 0x84, 0x2b, 0x79, 0xdf, 0x7a, 0x22, 0xb6, 0x8b, 0x0b, 0xb6, 0x39, 0xb1,
+	0x5d, 0x2c, 0xcb, 0x03, 0xbb, 0x47, 0x0e, 0xac, 0x4e, 0xe9, 0xd5, 0xa7, 0xe0, 0x19, 0x8c, 0x22,
+	0xd7, 0xcd, 0x92, 0x44, 0x7a, 0x5f, 0x84, 0xb2, 0x18, 0x2d, 0x83, 0xaa, 0x34, 0x57, 0xfc, 0x2d,
+	0xfc, 0xf7, 0x29, 0x0e, 0x22, 0xe1, 0x55, 0x67, 0x54, 0x5b, 0xf4, 0xc6, 0x96, 0x53, 0x60, 0xee,
+	0x3a, 0x0b, 0xbf, 0x56, 0xd2, 0x51, 0xc2, 0x1f, 0x03, 0x5b, 0xe4, 0x41, 0x3e, 0xe2, 0x09, 0x25,
+	0xe8, 0x04, 0xd3, 0xa1, 0x98, 0x9f, 0x41, 0x7f, 0x1e, 0x2c, 0xb3, 0xcd, 0xd1, 0x57, 0xfb, 0xa5,
+	0x03, 0xa3, 0x0e, 0x7c, 0xda, 0x78, 0xb6, 0x07, 0xf5, 0x55, 0xe5, 0x78, 0xf5, 0x76, 0xca, 0x57,
+	0x41, 0xa5, 0x46, 0x91, 0xe0, 0x18, 0x46, 0x9e, 0x4c, 0xdd, 0xc4, 0x8f, 0x95, 0x1f, 0x85, 0xa4,
+	0xcb, 0xd0, 0x69, 0x96, 0x90, 0x03, 0x73, 0xa3, 0xad, 0x4c, 0xac, 0xde, 0x3f, 0x34, 0x2b, 0x20,
+	0x3c, 0x07, 0xa3, 0x30, 0x9d, 0xc5, 0xc6, 0xdd, 0x83, 0xa6, 0x12, 0xe3, 0xaf, 0xc0, 0x20, 0x42,
+	0x29, 0x5e, 0x80, 0x21, 0x28, 0x22, 0x89, 0x46, 0xb3, 0xff, 0x5b, 0x94, 0x9d, 0x12, 0xe6, 0x7d,
+	0x60, 0xef, 0x36, 0xb1, 0xda, 0xcd, 0x7e, 0x76, 0xc0, 0x24, 0x87, 0xde, 0xca, 0x64, 0xeb, 0xbb,
+	0x12, 0x2f, 0xa0, 0x77, 0x2b, 0xb6, 0x12, 0x1b, 0xa3, 0x84, 0xdb, 0x7b, 0xff, 0xe6, 0x5a, 0xde,
+	0xf8, 0xde, 0x0f, 0x3d, 0xdc, 0xab, 0xdb, 0xed, 0x31, 0xae, 0xe1, 0x73, 0x30, 0xae, 0x65, 0x20,
+	0x95, 0x3c, 0xde, 0x4a, 0x5c, 0xb8, 0x86, 0xaf, 0x81, 0x91, 0x6f, 0xf1, 0x61, 0x8d, 0x35, 0x8d,
+	0x6c, 0x37, 0x9d, 0x4b, 0x3e, 0xe5, 0xda, 0x4b, 0x1d, 0xaf, 0xc0, 0x28, 0x8c, 0x82, 0x8f, 0x6a,
+	0x7c, 0xcf, 0x3a, 0x6d, 0xf6, 0x13, 0x1d, 0x5f, 0xc0, 0xe0, 0x3a, 0xfa, 0x1e, 0xd2, 0xd8, 0x51,
+	0x62, 0x64, 0xa2, 0xfc, 0x1f, 0xb3, 0xdf, 0x3a, 0x98, 0xa4, 0x61, 0x25, 0xd4, 0xe5, 0xa1, 0x50,
+	0x84, 0xdb, 0x87, 0x3e, 0xe1, 0x1a, 0x5e, 0x96, 0x6a, 0x1d, 0x82, 0x76, 0x7b, 0x01, 0xd7, 0x70,
+	0x0a, 0xfd, 0xbc, 0x7b, 0x1e, 0x04, 0xd8, 0x56, 0xc9, 0x3e, 0x69, 0xb5, 0xa7, 0xd4, 0x5f, 0x49,
+	0x7c, 0xff, 0xfe, 0x52, 0xe7, 0xa5, 0x41, 0x95, 0xab, 0x3f, 0x03, 0x00, 0x57, 0x4a, 0xee, 0xce,
+	0xd3, 0x04, 0x00, 0x00,
 }
@@ -0,0 +1,146 @@
+package router
+
+import (
+	"context"
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var requestIdMetadata = strings.ToLower(logging.HeaderRequestId)
+
+// restLogging propagates the X-Request-ID header, or generates one, and writes an access log per request.
+// As the outermost middleware, it handles the error of the request, so that it is answered and logged once.
+func restLogging() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+			id := req.Header.Get(logging.HeaderRequestId)
+			if id == "" {
+				id = logging.NewRequestId()
+			}
+			ctx := logging.WithRequestId(req.Context(), id)
+			c.SetRequest(req.WithContext(ctx))
+			c.Response().Header().Set(logging.HeaderRequestId, id)
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			res := c.Response()
+			level := slog.LevelInfo
+			if res.Status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("route", c.Path()),
+				slog.String("uri", req.RequestURI),
+				slog.Int("status", res.Status),
+				slog.Int64("bytes_out", res.Size),
+				slog.String("remote_ip", c.RealIP()),
+				slog.Duration("latency", time.Since(start)),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.Any("error", err))
+			}
+			slog.LogAttrs(ctx, level, "request", attrs...)
+			return nil
+		}
+	}
+}
+
+// handleError answers with the status and message of the error like echo does,
+// leaving it to the access log instead of logging it again.
+func handleError(err error, c echo.Context) {
+	var message interface{} = http.StatusText(http.StatusInternalServerError)
+	code := http.StatusInternalServerError
+	if he, ok := err.(*echo.HTTPError); ok {
+		code, message = he.Code, he.Message
+	}
+	if s, ok := message.(string); ok {
+		message = echo.Map{"message": s}
+	}
+	if c.Response().Committed {
+		return
+	}
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(code)
+	} else {
+		err = c.JSON(code, message)
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "writing error response failed", "error", err)
+	}
+}
+
+// logWriter writes what echo logs by itself to slog.
+type logWriter struct{}
+
+func (logWriter) Write(p []byte) (int, error) {
+	slog.Error(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func unaryLogging() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx, id := grpcRequestId(ctx)
+		grpc.SetHeader(ctx, metadata.Pairs(requestIdMetadata, id))
+		resp, err := handler(ctx, req)
+		logGrpc(ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func streamLogging() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, id := grpcRequestId(stream.Context())
+		stream.SetHeader(metadata.Pairs(requestIdMetadata, id))
+		err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+		logGrpc(ctx, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// grpcRequestId propagates the x-request-id metadata of a call, or generates one.
+func grpcRequestId(ctx context.Context) (context.Context, string) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIdMetadata); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = logging.NewRequestId()
+	}
+	return logging.WithRequestId(ctx, id), id
+}
+
+func logGrpc(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled, codes.NotFound, codes.InvalidArgument, codes.AlreadyExists, codes.FailedPrecondition:
+	default:
+		level = slog.LevelError
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	slog.LogAttrs(ctx, level, "call", attrs...)
+}
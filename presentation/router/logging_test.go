@@ -0,0 +1,124 @@
+package router
+
+import (
+	"bytes"
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/logging"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRestLogging(t *testing.T) {
+	logs := captureLogs(t)
+
+	e := echo.New()
+	e.Use(restLogging())
+	var handled string
+	e.GET("/photos/:id", func(c echo.Context) error {
+		handled = logging.RequestId(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	t.Run("with X-Request-ID, propagates it", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/photos/test", nil)
+		req.Header.Set(logging.HeaderRequestId, "abc")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, "abc", handled)
+		assert.Equal(t, "abc", rec.Header().Get(logging.HeaderRequestId))
+		assert.Contains(t, logs.String(), `"route":"/photos/:id","uri":"/photos/test","status":200`)
+		assert.Contains(t, logs.String(), `"request_id":"abc"`)
+	})
+
+	t.Run("without X-Request-ID, generates one", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/photos/test", nil))
+
+		assert.NotEmpty(t, handled)
+		assert.NotEqual(t, "abc", handled)
+		assert.Equal(t, handled, rec.Header().Get(logging.HeaderRequestId))
+	})
+}
+
+func TestRestLogging_error(t *testing.T) {
+	logs := captureLogs(t)
+
+	e := echo.New()
+	var handled int
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		handled++
+		handleError(err, c)
+	}
+	e.Use(restLogging(), restTracing(), restMetrics(metrics.New()))
+	e.GET("/photos/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid tag")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/photos/test", nil))
+
+	assert.Equal(t, 1, handled)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"message":"invalid tag"}`, rec.Body.String())
+	assert.Equal(t, 1, strings.Count(logs.String(), "\n"))
+	assert.Contains(t, logs.String(), `"status":400`)
+}
+
+func TestGrpcLogging(t *testing.T) {
+	logs := captureLogs(t)
+	_, conn := serveAll(t, Components{PhotoServiceServer: &stubPhotoService{}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", "abc")
+	var header metadata.MD
+	if _, err := protobuf.NewPhotoServiceClient(conn).Find(ctx, &protobuf.Id{Value: "test"}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"abc"}, header.Get("x-request-id"))
+	assert.Contains(t, logs.String(), `"method":"/protobuf.PhotoService/Find","code":"OK"`)
+	assert.Contains(t, logs.String(), `"request_id":"abc"`)
+}
+
+func captureLogs(t *testing.T) *syncBuffer {
+	t.Helper()
+	buf := &syncBuffer{}
+	logger, err := logging.New(buf, logging.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return buf
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
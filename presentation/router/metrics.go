@@ -0,0 +1,68 @@
+package router
+
+import (
+	"context"
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// loadMetrics returns the metrics of the components, or unregistered ones when they have none.
+func loadMetrics(components Components) *metrics.Metrics {
+	if components.Metrics != nil {
+		return components.Metrics
+	}
+	return metrics.New()
+}
+
+func restMetrics(m *metrics.Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			method, route, status := c.Request().Method, c.Path(), strconv.Itoa(statusOf(c, err))
+			m.HttpRequests.WithLabelValues(method, route, status).Inc()
+			m.HttpDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+// statusOf returns the status a request is answered with, once the outermost middleware handled its error.
+func statusOf(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+	if he, ok := err.(*echo.HTTPError); ok {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
+
+func unaryMetrics(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeGrpc(m, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func streamMetrics(m *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		observeGrpc(m, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observeGrpc(m *metrics.Metrics, method string, start time.Time, err error) {
+	code := status.Code(err).String()
+	m.GrpcRequests.WithLabelValues(method, code).Inc()
+	m.GrpcDuration.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRestMetrics(t *testing.T) {
+	m := metrics.New()
+	e := echo.New()
+	e.Use(restMetrics(m))
+	e.GET("/photos/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound)
+	})
+	e.GET("/albums/:id", func(c echo.Context) error {
+		return echo.ErrUnsupportedMediaType
+	})
+
+	t.Run("with error, counts its status and leaves the response to the error handler", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/photos/test", nil))
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/albums/test", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = httptest.NewRecorder()
+		m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body, _ := ioutil.ReadAll(rec.Body)
+		assert.Contains(t, string(body), `photoshelf_http_requests_total{method="GET",route="/photos/:id",status="404"} 1`)
+		assert.Contains(t, string(body), `photoshelf_http_requests_total{method="GET",route="/albums/:id",status="415"} 1`)
+	})
+}
@@ -3,38 +3,104 @@ package router
 import (
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
-	"github.com/photoshelf/photoshelf-storage/infrastructure/container"
+	"github.com/photoshelf/photoshelf-storage/application/service"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
 	"github.com/photoshelf/photoshelf-storage/presentation/controller"
 	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-func LoadEchoServer() (*echo.Echo, error) {
+// Components are the controllers the servers route to. Routes of a nil controller are not served.
+type Components struct {
+	PhotoController  controller.RestPhotoController
+	AlbumController  controller.RestAlbumController
+	AdminController  controller.RestAdminController
+	HealthController controller.RestHealthController
+
+	PhotoServiceServer protobuf.PhotoServiceServer
+	AlbumServiceServer protobuf.AlbumServiceServer
+	HealthServer       healthpb.HealthServer
+
+	Health  service.HealthService
+	Metrics *metrics.Metrics
+}
+
+func LoadEchoServer(components Components) (*echo.Echo, error) {
 	e := echo.New()
 	e.HideBanner = true
+	e.HTTPErrorHandler = handleError
+	e.Logger.SetOutput(logWriter{})
+
+	RegisterRoutes(e.Group(""), components)
 
-	photoController := controller.NewRestPhotoController()
-	container.Get(&photoController)
+	if healthController := components.HealthController; healthController != nil {
+		e.GET("/healthz", healthController.Healthz)
+		e.GET("/readyz", healthController.Readyz)
+	}
 
-	g := e.Group("photos")
-	g.GET("/:id", photoController.Get)
-	g.POST("/", photoController.Post)
-	g.PUT("/:id", photoController.Put)
-	g.DELETE("/:id", photoController.Delete)
+	m := loadMetrics(components)
+	e.GET("/metrics", echo.WrapHandler(m.Handler()))
 
-	e.Use(middleware.Logger())
+	e.Use(restLogging())
+	e.Use(restTracing())
+	e.Use(restMetrics(m))
 	e.Use(middleware.BodyLimit("20M"))
 
 	return e, nil
 }
 
-func LoadGrpcServer() *grpc.Server {
-	s := grpc.NewServer()
+// RegisterRoutes routes the photo, album and admin APIs under the group.
+func RegisterRoutes(e *echo.Group, components Components) {
+	if photoController := components.PhotoController; photoController != nil {
+		g := e.Group("/photos")
+		g.GET("", photoController.Search)
+		g.GET("/changes", photoController.Changes)
+		g.GET("/:id", photoController.Get)
+		g.POST("/", photoController.Post)
+		g.PUT("/:id", photoController.Put)
+		g.DELETE("/:id", photoController.Delete)
+		g.GET("/:id/tags", photoController.Tags)
+		g.PUT("/:id/tags/:tag", photoController.AddTag)
+		g.DELETE("/:id/tags/:tag", photoController.RemoveTag)
+	}
 
-	photoServiceServer := controller.NewGrpcPhotoController()
-	container.Get(&photoServiceServer)
+	if albumController := components.AlbumController; albumController != nil {
+		a := e.Group("/albums")
+		a.GET("", albumController.List)
+		a.POST("", albumController.Post)
+		a.GET("/:id", albumController.Get)
+		a.PUT("/:id", albumController.Put)
+		a.DELETE("/:id", albumController.Delete)
+	}
 
-	protobuf.RegisterPhotoServiceServer(s, photoServiceServer)
+	if adminController := components.AdminController; adminController != nil {
+		e.GET("/admin/scrub", adminController.ScrubReport)
+		e.GET("/webhooks/deliveries", adminController.WebhookDeliveries)
+	}
+}
+
+func LoadGrpcServer(components Components, options ...grpc.ServerOption) *grpc.Server {
+	m := loadMetrics(components)
+	s := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryLogging(), unaryTracing(), unaryMetrics(m)),
+		grpc.ChainStreamInterceptor(streamLogging(), streamTracing(), streamMetrics(m)),
+	}, options...)...)
+
+	RegisterServices(s, components)
+	if components.HealthServer != nil {
+		healthpb.RegisterHealthServer(s, components.HealthServer)
+	}
 
 	return s
 }
+
+// RegisterServices registers the photo and album services on the server.
+func RegisterServices(s *grpc.Server, components Components) {
+	if components.PhotoServiceServer != nil {
+		protobuf.RegisterPhotoServiceServer(s, components.PhotoServiceServer)
+	}
+	if components.AlbumServiceServer != nil {
+		protobuf.RegisterAlbumServiceServer(s, components.AlbumServiceServer)
+	}
+}
@@ -2,10 +2,10 @@ package router
 
 import (
 	"github.com/golang/mock/gomock"
-	"github.com/photoshelf/photoshelf-storage/infrastructure/container"
 	"github.com/photoshelf/photoshelf-storage/presentation/mock_controller"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -21,9 +21,33 @@ func TestLoadEchoServer(t *testing.T) {
 	con.EXPECT().Post(gomock.Any()).Times(1)
 	con.EXPECT().Put(gomock.Any()).Times(1)
 	con.EXPECT().Delete(gomock.Any()).Times(1)
-	container.Set(con)
+	con.EXPECT().Search(gomock.Any()).Times(1)
+	con.EXPECT().Tags(gomock.Any()).Times(1)
+	con.EXPECT().AddTag(gomock.Any()).Times(1)
+	con.EXPECT().RemoveTag(gomock.Any()).Times(1)
+	con.EXPECT().Changes(gomock.Any()).Times(1)
 
-	e, err := LoadEchoServer()
+	albumCon := mock_controller.NewMockAlbumController(ctrl)
+	albumCon.EXPECT().List(gomock.Any()).Times(1)
+	albumCon.EXPECT().Post(gomock.Any()).Times(1)
+	albumCon.EXPECT().Get(gomock.Any()).Times(1)
+	albumCon.EXPECT().Put(gomock.Any()).Times(1)
+	albumCon.EXPECT().Delete(gomock.Any()).Times(1)
+
+	adminCon := mock_controller.NewMockAdminController(ctrl)
+	adminCon.EXPECT().ScrubReport(gomock.Any()).Times(1)
+	adminCon.EXPECT().WebhookDeliveries(gomock.Any()).Times(1)
+
+	healthCon := mock_controller.NewMockHealthController(ctrl)
+	healthCon.EXPECT().Healthz(gomock.Any()).Times(1)
+	healthCon.EXPECT().Readyz(gomock.Any()).Times(1)
+
+	e, err := LoadEchoServer(Components{
+		PhotoController:  con,
+		AlbumController:  albumCon,
+		AdminController:  adminCon,
+		HealthController: healthCon,
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -66,10 +90,149 @@ func TestLoadEchoServer(t *testing.T) {
 			t.Fatal(err)
 		}
 	})
+
+	t.Run("route GET /photos", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/photos?tag=test")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("route GET /photos/changes", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/photos/changes")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("route GET /photos/:id/tags", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/photos/test/tags")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("routes PUT /photos/:id/tags/:tag", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/photos/test/tags/tag", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("routes DELETE /photos/:id/tags/:tag", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodDelete, server.URL+"/photos/test/tags/tag", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("route GET /albums", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/albums")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("route POST /albums", func(t *testing.T) {
+		_, err := client.Post(server.URL+"/albums", "application/json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("route GET /albums/:id", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/albums/test")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("routes PUT /albums/:id", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/albums/test", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("routes DELETE /albums/:id", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodDelete, server.URL+"/albums/test", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("route GET /admin/scrub", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/admin/scrub")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("route GET /webhooks/deliveries", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/webhooks/deliveries")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("route GET /healthz", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("route GET /readyz", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/readyz")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("does not route GET /debug/vars", func(t *testing.T) {
+		res, err := client.Get(server.URL + "/debug/vars")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("route GET /metrics, counts requests per route and status", func(t *testing.T) {
+		res, err := client.Get(server.URL + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, string(body), `photoshelf_http_requests_total{method="GET",route="/photos/:id",status="200"} 1`)
+		assert.Contains(t, string(body), `photoshelf_http_request_duration_seconds_count{method="PUT",route="/albums/:id",status="200"} 1`)
+	})
 }
 
 func TestLoadGrpcServer(t *testing.T) {
-	s := LoadGrpcServer()
+	s := LoadGrpcServer(Components{PhotoServiceServer: &stubPhotoService{}})
 
 	assert.IsType(t, &grpc.Server{}, s)
+	assert.Contains(t, s.GetServiceInfo(), "protobuf.PhotoService")
+	assert.NotContains(t, s.GetServiceInfo(), "protobuf.AlbumService")
 }
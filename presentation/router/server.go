@@ -0,0 +1,285 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"github.com/photoshelf/photoshelf-storage/application/service"
+	"github.com/photoshelf/photoshelf-storage/presentation/controller"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ModeRest = "rest"
+	ModeGrpc = "grpc"
+	ModeAll  = "all"
+)
+
+type listener interface {
+	serve() error
+	shutdown(ctx context.Context)
+	addr() net.Addr
+}
+
+// Server runs the REST and gRPC servers of a mode.
+// With ModeAll and no gRPC port, or the same port for both, gRPC requests are multiplexed on the REST port.
+type Server struct {
+	ShutdownTimeout time.Duration
+
+	listeners    []listener
+	health       service.HealthService
+	shutdownOnce sync.Once
+
+	// streams is the base context of the change feeds, which Shutdown cancels before draining requests.
+	streams      context.Context
+	closeStreams context.CancelFunc
+}
+
+func NewServer(components Components, mode string, port int, grpcPort int) (*Server, error) {
+	server := &Server{health: components.Health}
+	server.streams, server.closeStreams = context.WithCancel(context.Background())
+	switch mode {
+	case ModeRest:
+		e, err := LoadEchoServer(components)
+		if err != nil {
+			return nil, err
+		}
+		if err := server.listenHTTP(port, e); err != nil {
+			return nil, err
+		}
+	case ModeGrpc:
+		if err := server.listenGrpc(port, server.loadGrpcServer(components)); err != nil {
+			return nil, err
+		}
+	case ModeAll:
+		e, err := LoadEchoServer(components)
+		if err != nil {
+			return nil, err
+		}
+		s := server.loadGrpcServer(components)
+		if grpcPort == 0 || grpcPort == port {
+			mux := &multiplexer{grpc: s, http: e}
+			if err := server.listenHTTP(port, h2c.NewHandler(mux, &http2.Server{})); err != nil {
+				return nil, err
+			}
+			server.listeners[0].(*httpListener).mux = mux
+			break
+		}
+		if err := server.listenHTTP(port, e); err != nil {
+			return nil, err
+		}
+		if err := server.listenGrpc(grpcPort, s); err != nil {
+			server.Shutdown(context.Background())
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("no such server mode : %s", mode)
+	}
+	return server, nil
+}
+
+// Serve blocks until a server stops. Then it shuts down the others within ShutdownTimeout and returns the first error.
+func (server *Server) Serve() error {
+	errs := make(chan error, len(server.listeners))
+	for _, l := range server.listeners {
+		go func(l listener) { errs <- l.serve() }(l)
+	}
+	server.setServing(true)
+
+	err := <-errs
+	server.Stop()
+	for i := 1; i < len(server.listeners); i++ {
+		if e := <-errs; err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Stop shuts down the servers within ShutdownTimeout, or waits for in-flight requests without limit when it is 0.
+func (server *Server) Stop() {
+	ctx := context.Background()
+	if server.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, server.ShutdownTimeout)
+		defer cancel()
+	}
+	server.Shutdown(ctx)
+}
+
+// Shutdown ends the change feeds, stops accepting requests and waits for in-flight ones until ctx is done,
+// then closes them. Concurrent calls wait until the first one returned.
+func (server *Server) Shutdown(ctx context.Context) {
+	server.shutdownOnce.Do(func() {
+		server.closeStreams()
+		server.setServing(false)
+		var wg sync.WaitGroup
+		for _, l := range server.listeners {
+			wg.Add(1)
+			go func(l listener) {
+				defer wg.Done()
+				l.shutdown(ctx)
+			}(l)
+		}
+		wg.Wait()
+	})
+}
+
+func (server *Server) setServing(serving bool) {
+	if server.health != nil {
+		server.health.SetServing(serving)
+	}
+}
+
+func (server *Server) Addrs() []net.Addr {
+	var addrs []net.Addr
+	for _, l := range server.listeners {
+		addrs = append(addrs, l.addr())
+	}
+	return addrs
+}
+
+func (server *Server) listenHTTP(port int, handler http.Handler) error {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	s := &http.Server{
+		Handler: handler,
+		BaseContext: func(net.Listener) context.Context {
+			return controller.WithStreams(context.Background(), server.streams)
+		},
+	}
+	server.listeners = append(server.listeners, &httpListener{server: s, listener: l})
+	return nil
+}
+
+// loadGrpcServer loads the gRPC server with the streams of the server as base context of the change feeds.
+func (server *Server) loadGrpcServer(components Components) *grpc.Server {
+	return LoadGrpcServer(components, grpc.ChainStreamInterceptor(func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := controller.WithStreams(stream.Context(), server.streams)
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}))
+}
+
+func (server *Server) listenGrpc(port int, s *grpc.Server) error {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	server.listeners = append(server.listeners, &grpcListener{s, l})
+	return nil
+}
+
+// multiplexer passes HTTP/2 requests with a gRPC content type to the gRPC server, and others to the REST server.
+// grpc.Server can't drain calls served by ServeHTTP, so it tracks them itself.
+type multiplexer struct {
+	grpc *grpc.Server
+	http http.Handler
+
+	mu       sync.Mutex
+	draining bool
+	calls    sync.WaitGroup
+}
+
+func (mux *multiplexer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		mux.http.ServeHTTP(w, r)
+		return
+	}
+
+	mux.mu.Lock()
+	if mux.draining {
+		mux.mu.Unlock()
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	mux.calls.Add(1)
+	mux.mu.Unlock()
+	defer mux.calls.Done()
+
+	mux.grpc.ServeHTTP(w, r)
+}
+
+func (mux *multiplexer) shutdown(ctx context.Context) {
+	mux.mu.Lock()
+	mux.draining = true
+	mux.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		mux.calls.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+	}
+	mux.grpc.Stop()
+}
+
+type httpListener struct {
+	server   *http.Server
+	listener net.Listener
+	mux      *multiplexer
+}
+
+func (l *httpListener) serve() error {
+	if err := l.server.Serve(l.listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (l *httpListener) shutdown(ctx context.Context) {
+	if l.mux != nil {
+		l.mux.shutdown(ctx)
+	}
+	if err := l.server.Shutdown(ctx); err != nil {
+		l.server.Close()
+	}
+	l.listener.Close()
+}
+
+func (l *httpListener) addr() net.Addr {
+	return l.listener.Addr()
+}
+
+type grpcListener struct {
+	server   *grpc.Server
+	listener net.Listener
+}
+
+func (l *grpcListener) serve() error {
+	if err := l.server.Serve(l.listener); err != grpc.ErrServerStopped {
+		return err
+	}
+	return nil
+}
+
+func (l *grpcListener) shutdown(ctx context.Context) {
+	stopGrpc(ctx, l.server)
+}
+
+func (l *grpcListener) addr() net.Addr {
+	return l.listener.Addr()
+}
+
+func stopGrpc(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
@@ -0,0 +1,320 @@
+package router
+
+import (
+	"github.com/photoshelf/photoshelf-storage/application/health"
+	"github.com/photoshelf/photoshelf-storage/domain/model/event"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/datastore/memory_storage"
+	"github.com/photoshelf/photoshelf-storage/infrastructure/metrics"
+	"github.com/photoshelf/photoshelf-storage/presentation/controller"
+	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestServer_Serve(t *testing.T) {
+	components := Components{PhotoServiceServer: &stubPhotoService{}}
+
+	t.Run("in all mode without gRPC port, serves both on one port", func(t *testing.T) {
+		server, err := NewServer(components, ModeAll, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done := make(chan error)
+		go func() { done <- server.Serve() }()
+
+		addrs := server.Addrs()
+		if assert.Len(t, addrs, 1) {
+			address := addrs[0].String()
+
+			res, err := http.Get("http://" + address + "/metrics")
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.StatusOK, res.StatusCode)
+			}
+
+			conn, err := grpc.Dial(address, grpc.WithInsecure())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			found, err := protobuf.NewPhotoServiceClient(conn).Find(ctx, &protobuf.Id{Value: "test"})
+			if assert.NoError(t, err) {
+				assert.Equal(t, "test", found.Id.Value)
+			}
+		}
+
+		server.Shutdown(context.Background())
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("when a port is in use, returns error", func(t *testing.T) {
+		l, err := net.Listen("tcp", ":0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		_, err = NewServer(components, ModeRest, l.Addr().(*net.TCPAddr).Port, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("with unknown mode, returns error", func(t *testing.T) {
+		_, err := NewServer(components, "soap", 0, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("when a server stops, stops the others", func(t *testing.T) {
+		grpcListener, err := net.Listen("tcp", ":0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := grpcListener.Addr().(*net.TCPAddr).Port
+		grpcListener.Close()
+
+		server, err := NewServer(components, ModeAll, 0, port)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done := make(chan error)
+		go func() { done <- server.Serve() }()
+
+		addrs := server.Addrs()
+		if assert.Len(t, addrs, 2) {
+			assert.Equal(t, strconv.Itoa(port), portOf(t, addrs[1]))
+		}
+		server.listeners[1].shutdown(context.Background())
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("REST server did not stop")
+		}
+	})
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	t.Run("in one port mode, waits for in-flight gRPC calls", func(t *testing.T) {
+		stub := &stubPhotoService{entered: make(chan struct{}), release: make(chan struct{})}
+		server, conn := serveAll(t, Components{PhotoServiceServer: stub})
+
+		called := make(chan error)
+		go func() {
+			_, err := protobuf.NewPhotoServiceClient(conn).Find(context.Background(), &protobuf.Id{Value: "slow"})
+			called <- err
+		}()
+		<-stub.entered
+
+		shutdown := make(chan struct{})
+		go func() {
+			server.Shutdown(context.Background())
+			close(shutdown)
+		}()
+		select {
+		case <-shutdown:
+			t.Fatal("shutdown did not wait for the call")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(stub.release)
+		assert.NoError(t, <-called)
+		<-shutdown
+	})
+
+	t.Run("when timed out, cancels in-flight gRPC calls", func(t *testing.T) {
+		stub := &stubPhotoService{entered: make(chan struct{}), release: make(chan struct{})}
+		server, conn := serveAll(t, Components{PhotoServiceServer: stub})
+
+		called := make(chan error)
+		go func() {
+			_, err := protobuf.NewPhotoServiceClient(conn).Find(context.Background(), &protobuf.Id{Value: "slow"})
+			called <- err
+		}()
+		<-stub.entered
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		server.Shutdown(ctx)
+		assert.Error(t, <-called)
+	})
+
+	t.Run("ends gRPC change feeds", func(t *testing.T) {
+		changes := &blockingChanges{entered: make(chan struct{})}
+		server, err := NewServer(Components{PhotoServiceServer: controller.NewGrpcPhotoController(nil, changes)}, ModeGrpc, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go server.Serve()
+		conn, err := grpc.Dial(server.Addrs()[0].String(), grpc.WithInsecure())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		stream, err := protobuf.NewPhotoServiceClient(conn).Watch(context.Background(), &protobuf.WatchRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-changes.entered
+
+		shutdown(t, server)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("ends REST change feeds", func(t *testing.T) {
+		changes := &blockingChanges{entered: make(chan struct{})}
+		server, _ := serveAll(t, Components{PhotoController: controller.NewRestPhotoController(nil, changes)})
+
+		res, err := http.Get("http://" + server.Addrs()[0].String() + "/photos/changes")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		<-changes.entered
+
+		shutdown(t, server)
+		_, err = ioutil.ReadAll(res.Body)
+		assert.NoError(t, err)
+	})
+
+	t.Run("while serving, is ready", func(t *testing.T) {
+		checker := health.New(memory_storage.New(0))
+		server, err := NewServer(Components{Health: checker}, ModeRest, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, health.ErrNotServing, checker.Ready(context.Background()))
+
+		done := make(chan error)
+		go func() { done <- server.Serve() }()
+		for i := 0; i < 100 && checker.Ready(context.Background()) != nil; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.NoError(t, checker.Ready(context.Background()))
+
+		server.Shutdown(context.Background())
+		assert.Equal(t, health.ErrNotServing, checker.Ready(context.Background()))
+		assert.NoError(t, <-done)
+	})
+}
+
+func TestServer_Metrics(t *testing.T) {
+	server, conn := serveAll(t, Components{PhotoServiceServer: &stubPhotoService{}, Metrics: metrics.New()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := protobuf.NewPhotoServiceClient(conn).Find(ctx, &protobuf.Id{Value: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := http.Get("http://" + server.Addrs()[0].String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(body), `photoshelf_grpc_requests_total{code="OK",method="/protobuf.PhotoService/Find"} 1`)
+	assert.Contains(t, string(body), `photoshelf_grpc_request_duration_seconds_count{code="OK",method="/protobuf.PhotoService/Find"} 1`)
+}
+
+// shutdown shuts the server down, failing when it waits for streams which never end.
+func shutdown(tb testing.TB, server *Server) {
+	tb.Helper()
+	done := make(chan struct{})
+	go func() {
+		server.Shutdown(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		tb.Fatal("shutdown waits for change feeds")
+	}
+}
+
+func serveAll(tb testing.TB, components Components) (*Server, *grpc.ClientConn) {
+	tb.Helper()
+	server, err := NewServer(components, ModeAll, 0, 0)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	go server.Serve()
+	tb.Cleanup(func() { server.Shutdown(context.Background()) })
+
+	conn, err := grpc.Dial(server.Addrs()[0].String(), grpc.WithInsecure())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { conn.Close() })
+	return server, conn
+}
+
+func portOf(tb testing.TB, addr net.Addr) string {
+	tb.Helper()
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return port
+}
+
+type stubPhotoService struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *stubPhotoService) Save(ctx context.Context, req *protobuf.Photo) (*protobuf.Id, error) {
+	return req.Id, nil
+}
+
+func (s *stubPhotoService) Find(ctx context.Context, req *protobuf.Id) (*protobuf.Photo, error) {
+	if req.Value == "slow" {
+		s.entered <- struct{}{}
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &protobuf.Photo{Id: req}, nil
+}
+
+func (s *stubPhotoService) Delete(ctx context.Context, req *protobuf.Id) (*protobuf.Empty, error) {
+	return &protobuf.Empty{}, nil
+}
+
+func (s *stubPhotoService) Watch(req *protobuf.WatchRequest, stream protobuf.PhotoService_WatchServer) error {
+	return nil
+}
+
+func (s *stubPhotoService) Upload(stream protobuf.PhotoService_UploadServer) error {
+	return stream.SendAndClose(&protobuf.Id{})
+}
+
+func (s *stubPhotoService) Download(req *protobuf.Id, stream protobuf.PhotoService_DownloadServer) error {
+	return nil
+}
+
+// blockingChanges watches changes until the context of the watch is done.
+type blockingChanges struct {
+	entered chan struct{}
+}
+
+func (changes *blockingChanges) Watch(ctx context.Context, after *uint64, send func(event.Record) error) error {
+	changes.entered <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"errors"
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	traceId     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentId    = "00f067aa0ba902b7"
+)
+
+func TestRestTracing(t *testing.T) {
+	recorder := recordSpans(t)
+
+	e := echo.New()
+	e.Use(restTracing())
+	var handled trace.SpanContext
+	e.GET("/photos/:id", func(c echo.Context) error {
+		handled = trace.SpanContextFromContext(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/photos/test", nil)
+	req.Header.Set("traceparent", traceparent)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	if assert.Len(t, recorder.Ended(), 1) {
+		span := recorder.Ended()[0]
+		assert.Equal(t, "GET /photos/:id", span.Name())
+		assert.Equal(t, traceId, span.SpanContext().TraceID().String())
+		assert.Equal(t, parentId, span.Parent().SpanID().String())
+		assert.Equal(t, span.SpanContext().SpanID(), handled.SpanID())
+	}
+}
+
+func TestRestTracing_error(t *testing.T) {
+	recorder := recordSpans(t)
+
+	e := echo.New()
+	e.Use(restTracing())
+	e.GET("/photos/:id", func(c echo.Context) error {
+		return errors.New("broken")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/photos/test", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	if assert.Len(t, recorder.Ended(), 1) {
+		span := recorder.Ended()[0]
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Contains(t, span.Attributes(), semconv.HTTPStatusCode(http.StatusInternalServerError))
+	}
+}
+
+func TestGrpcTracing(t *testing.T) {
+	recorder := recordSpans(t)
+	_, conn := serveAll(t, Components{PhotoServiceServer: &stubPhotoService{}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "traceparent", traceparent)
+	if _, err := protobuf.NewPhotoServiceClient(conn).Find(ctx, &protobuf.Id{Value: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, span := range recorder.Ended() {
+		if span.Name() != "protobuf.PhotoService/Find" {
+			continue
+		}
+		found = true
+		assert.Equal(t, traceId, span.SpanContext().TraceID().String())
+		assert.Equal(t, parentId, span.Parent().SpanID().String())
+	}
+	assert.True(t, found)
+}
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
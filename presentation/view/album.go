@@ -0,0 +1,13 @@
+package view
+
+type Album struct {
+	Id          string   `json:"id,omitempty"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Cover       string   `json:"cover,omitempty"`
+	Photos      []string `json:"photos"`
+}
+
+type Albums struct {
+	Albums []Album `json:"albums"`
+}
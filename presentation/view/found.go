@@ -0,0 +1,5 @@
+package view
+
+type Found struct {
+	Ids []string `json:"ids"`
+}
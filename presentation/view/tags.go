@@ -0,0 +1,5 @@
+package view
+
+type Tags struct {
+	Tags []string `json:"tags"`
+}
@@ -0,0 +1,147 @@
+// Package storage embeds photoshelf storage in another Echo, net/http or gRPC server.
+package storage
+
+import (
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/application"
+	"github.com/photoshelf/photoshelf-storage/application/service"
+	"github.com/photoshelf/photoshelf-storage/application/webhook"
+	"github.com/photoshelf/photoshelf-storage/presentation/router"
+	"google.golang.org/grpc"
+	"net/http"
+	"time"
+)
+
+// Option changes the configuration the storage is built from.
+type Option func(configuration *application.Configuration) error
+
+// WithConfigurationFile reads the configuration file at path, as the -c flag does.
+// Options given after it override its values.
+func WithConfigurationFile(path string) Option {
+	return func(configuration *application.Configuration) error {
+		return configuration.Set(path)
+	}
+}
+
+// WithConfiguration lets f change any value of the configuration.
+func WithConfiguration(f func(configuration *application.Configuration)) Option {
+	return func(configuration *application.Configuration) error {
+		f(configuration)
+		return nil
+	}
+}
+
+// WithStorage stores photos in the storage of type at path.
+func WithStorage(storageType string, path string) Option {
+	return func(configuration *application.Configuration) error {
+		configuration.Storage.Type = storageType
+		configuration.Storage.Path = path
+		return nil
+	}
+}
+
+// WithMaxBytes bounds the bytes the memory storage holds.
+func WithMaxBytes(maxBytes int64) Option {
+	return func(configuration *application.Configuration) error {
+		configuration.Storage.MaxBytes = maxBytes
+		return nil
+	}
+}
+
+// WithEncryption encrypts photos with the key in keyFile.
+func WithEncryption(keyFile string) Option {
+	return func(configuration *application.Configuration) error {
+		configuration.Storage.Encryption.KeyFile = keyFile
+		return nil
+	}
+}
+
+// WithPlaintext reads photos stored before encryption was enabled, until they are re-encrypted.
+func WithPlaintext() Option {
+	return func(configuration *application.Configuration) error {
+		configuration.Storage.Encryption.AllowPlaintext = true
+		return nil
+	}
+}
+
+// WithScrub verifies rate photos per second every interval.
+func WithScrub(interval time.Duration, rate int) Option {
+	return func(configuration *application.Configuration) error {
+		configuration.Scrub.Interval = interval
+		configuration.Scrub.Rate = rate
+		return nil
+	}
+}
+
+// WithWebhook delivers events to the subscription.
+func WithWebhook(subscription webhook.Subscription) Option {
+	return func(configuration *application.Configuration) error {
+		configuration.Webhooks = append(configuration.Webhooks, subscription)
+		return nil
+	}
+}
+
+// Storage is an embedded photoshelf storage.
+// Unlike the server, it doesn't set up the process wide logging and tracing, leaving them to the host.
+type Storage struct {
+	app     *application.Application
+	handler *echo.Echo
+}
+
+// New builds a storage from the server defaults changed by opts, then opens it.
+func New(opts ...Option) (*Storage, error) {
+	configuration := application.DefaultConfiguration()
+	for _, opt := range opts {
+		if err := opt(configuration); err != nil {
+			return nil, err
+		}
+	}
+
+	app, err := application.New(configuration)
+	if err != nil {
+		return nil, err
+	}
+	handler, err := router.LoadEchoServer(app.Components())
+	if err != nil {
+		app.Close()
+		return nil, err
+	}
+	app.Health.SetServing(true)
+	return &Storage{app: app, handler: handler}, nil
+}
+
+// Handler serves the REST API with its health checks and metrics, as the server does.
+func (s *Storage) Handler() http.Handler {
+	return s.handler
+}
+
+// Register routes the REST API under the group, leaving its middleware to the host.
+func (s *Storage) Register(g *echo.Group) {
+	router.RegisterRoutes(g, s.app.Components())
+}
+
+// RegisterGrpc registers the photo and album services on the server.
+func (s *Storage) RegisterGrpc(server *grpc.Server) {
+	router.RegisterServices(server, s.app.Components())
+}
+
+// Photos returns the service managing photos.
+func (s *Storage) Photos() service.PhotoService {
+	return s.app.PhotoService
+}
+
+// Albums returns the service managing albums.
+func (s *Storage) Albums() service.AlbumService {
+	return s.app.AlbumService
+}
+
+// MetricsHandler serves the metrics of the storage in the Prometheus format.
+func (s *Storage) MetricsHandler() http.Handler {
+	return s.app.Metrics.Handler()
+}
+
+// Close stops the background tasks and closes the storage.
+func (s *Storage) Close() error {
+	s.app.Health.SetServing(false)
+	return s.app.Close()
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"github.com/labstack/echo"
+	"github.com/photoshelf/photoshelf-storage/application"
+	"github.com/photoshelf/photoshelf-storage/domain/model/photo"
+	"github.com/photoshelf/photoshelf-storage/presentation/protobuf"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("with invalid storage type, returns error", func(t *testing.T) {
+		_, err := New(WithStorage("unknown", ""))
+		assert.Error(t, err)
+	})
+
+	t.Run("with missing configuration file, returns error", func(t *testing.T) {
+		_, err := New(WithConfigurationFile("testdata/unknown.yml"))
+		assert.Error(t, err)
+	})
+}
+
+func TestStorage_Handler(t *testing.T) {
+	s := newMemoryStorage(t)
+	id := save(t, s, "test")
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/photos/"+id.Value(), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "test", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestStorage_Register(t *testing.T) {
+	s := newMemoryStorage(t)
+	id := save(t, s, "test")
+
+	e := echo.New()
+	g := e.Group("/api")
+	var intercepted bool
+	g.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			intercepted = true
+			return next(c)
+		}
+	})
+	s.Register(g)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/photos/"+id.Value(), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "test", rec.Body.String())
+	assert.True(t, intercepted)
+}
+
+func TestStorage_RegisterGrpc(t *testing.T) {
+	s := newMemoryStorage(t)
+	id := save(t, s, "test")
+
+	server := grpc.NewServer()
+	s.RegisterGrpc(server)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	found, err := protobuf.NewPhotoServiceClient(conn).Find(ctx, &protobuf.Id{Value: id.Value()})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []byte("test"), found.Image)
+	}
+}
+
+func TestStorage_MetricsHandler(t *testing.T) {
+	s, err := New(WithStorage("memory", ""), WithConfiguration(func(configuration *application.Configuration) {
+		configuration.Metrics.StatsInterval = 10 * time.Millisecond
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	save(t, s, "test")
+
+	assert.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		s.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body, _ := ioutil.ReadAll(rec.Body)
+		return strings.Contains(string(body), `photoshelf_repository_objects{backend="memory"} 1`)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func newMemoryStorage(t *testing.T) *Storage {
+	t.Helper()
+	s, err := New(WithStorage("memory", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func save(t *testing.T, s *Storage, image string) *photo.Identifier {
+	t.Helper()
+	id, err := s.Photos().Save(context.Background(), *photo.New([]byte(image)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}